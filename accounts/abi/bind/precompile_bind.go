@@ -0,0 +1,284 @@
+// (c) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package bind
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ir4tech/webb-evm/accounts/abi"
+)
+
+// allowListFunctions are the functions of IAllowList.sol, which are served by the allow list embedded in a
+// precompile rather than generated.
+var allowListFunctions = map[string]bool{
+	"setAdmin":            true,
+	"setManager":          true,
+	"setEnabled":          true,
+	"setNone":             true,
+	"setAdminUntil":       true,
+	"setManagerUntil":     true,
+	"setEnabledUntil":     true,
+	"readAllowList":       true,
+	"readAllowListExpiry": true,
+	"roleHolderCount":     true,
+	"roleHolderAt":        true,
+}
+
+// trailingBlankLines matches the blank lines before the closing brace of a block.
+var trailingBlankLines = regexp.MustCompile(`\n(?:[ \t]*\n)+([ \t]*})`)
+
+// PrecompileBind generates the skeleton of a stateful precompile named [typ] at [address] that implements the
+// functions of [abiJSON], along with a scaffold of its tests. The skeleton is generated into the precompile
+// package and the test scaffold into the core package, next to the tests of the other precompiles.
+// If [allowList] is true, the precompile embeds an allow list that the caller of any non-view function must
+// be enabled on, and the IAllowList functions in [abiJSON] are served by the allow list.
+func PrecompileBind(typ string, abiJSON string, address string, allowList bool, aliases map[string]string) (string, string, error) {
+	if typ == "" {
+		return "", "", errors.New("missing precompile type name")
+	}
+	if !common.IsHexAddress(address) {
+		return "", "", fmt.Errorf("invalid precompile address %q", address)
+	}
+	evmABI, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return "", "", err
+	}
+	if evmABI.HasReceive() {
+		return "", "", errors.New("stateful precompiles do not support receive functions")
+	}
+	// Strip any whitespace from the JSON ABI
+	strippedABI := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, abiJSON)
+
+	var (
+		typeName    = capitalise(typ)
+		funcs       = make(map[string]*tmplMethod)
+		structs     = make(map[string]*tmplStruct)
+		identifiers = make(map[string]bool)
+		fallback    *tmplMethod
+	)
+	for _, original := range evmABI.Methods {
+		if allowList && allowListFunctions[original.RawName] {
+			continue
+		}
+		normalized := original
+		normalized.Name = methodNormalizer[LangGo](alias(aliases, original.Name))
+		// Every function generates a handler, selector and helpers named after it, so names must not collide
+		// regardless of whether the function modifies state.
+		if identifiers[normalized.Name] {
+			return "", "", fmt.Errorf("duplicated identifier \"%s\"(normalized \"%s\"), use --alias for renaming", original.Name, normalized.Name)
+		}
+		identifiers[normalized.Name] = true
+		if normalized.Inputs, err = normalizePrecompileArgs(original.Name, original.Inputs, structs); err != nil {
+			return "", "", err
+		}
+		if normalized.Outputs, err = normalizePrecompileArgs(original.Name, original.Outputs, structs); err != nil {
+			return "", "", err
+		}
+		funcs[original.Name] = &tmplMethod{Original: original, Normalized: normalized, Structured: len(normalized.Outputs) > 1}
+	}
+	if evmABI.HasFallback() {
+		fallback = &tmplMethod{Original: evmABI.Fallback}
+	}
+	// Structs share the precompile package with every other precompile, so they are prefixed with the
+	// precompile type to avoid collisions.
+	for _, s := range structs {
+		if !strings.HasPrefix(s.Name, typeName) {
+			s.Name = typeName + s.Name
+		}
+	}
+
+	data := &tmplPrecompileData{
+		Type:      typeName,
+		Address:   common.HexToAddress(address).Hex(),
+		AllowList: allowList,
+		InputABI:  strings.Replace(strippedABI, "\"", "\\\"", -1),
+		Funcs:     funcs,
+		Fallback:  fallback,
+		Structs:   structs,
+	}
+	contract, err := renderPrecompileTemplate(tmplSourcePrecompileGo, data, "")
+	if err != nil {
+		return "", "", err
+	}
+	test, err := renderPrecompileTemplate(tmplSourcePrecompileTestGo, data, "precompile.")
+	if err != nil {
+		return "", "", err
+	}
+	return contract, test, nil
+}
+
+// normalizePrecompileArgs returns [args] of [method] with capitalised names, so that they can be used as the
+// fields of its input and output structs, and records the structs used by their types in [structs].
+func normalizePrecompileArgs(method string, args abi.Arguments, structs map[string]*tmplStruct) (abi.Arguments, error) {
+	var (
+		normalized = make(abi.Arguments, len(args))
+		names      = make(map[string]bool)
+	)
+	copy(normalized, args)
+	for i, arg := range normalized {
+		name := capitalise(arg.Name)
+		if name == "" {
+			name = fmt.Sprintf("Arg%d", i)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("duplicated argument \"%s\"(normalized \"%s\") of %s", arg.Name, name, method)
+		}
+		names[name] = true
+		normalized[i].Name = name
+		if hasStruct(arg.Type) {
+			bindStructTypeGo(arg.Type, structs)
+		}
+	}
+	return normalized, nil
+}
+
+// renderPrecompileTemplate renders [source] with [data] and formats the result, referring to the types generated
+// into the precompile package with [qualifier].
+func renderPrecompileTemplate(source string, data *tmplPrecompileData, qualifier string) (string, error) {
+	bindtype := func(kind abi.Type, structs map[string]*tmplStruct) string {
+		return bindPrecompileTypeGo(kind, structs, qualifier)
+	}
+	funcs := map[string]interface{}{
+		"bindtype":     bindtype,
+		"capitalise":   capitalise,
+		"decapitalise": decapitalise,
+		"paramname":    paramName,
+		"zerovalue": func(kind abi.Type, structs map[string]*tmplStruct) string {
+			return zeroValueGo(kind, structs, qualifier)
+		},
+	}
+	buffer := new(bytes.Buffer)
+	tmpl := template.Must(template.New("").Funcs(funcs).Parse(source))
+	if err := tmpl.Execute(buffer, data); err != nil {
+		return "", err
+	}
+	// Drop the blank lines the template actions leave at the end of blocks.
+	code, err := removeUnusedImports(trailingBlankLines.ReplaceAll(buffer.Bytes(), []byte("\n$1")))
+	if err != nil {
+		return "", fmt.Errorf("%v\n%s", err, buffer)
+	}
+	return string(code), nil
+}
+
+// bindPrecompileTypeGo converts solidity types to Go ones like bindTypeGo, referring to structs with [qualifier].
+func bindPrecompileTypeGo(kind abi.Type, structs map[string]*tmplStruct, qualifier string) string {
+	switch kind.T {
+	case abi.TupleTy:
+		return qualifier + structs[kind.TupleRawName+kind.String()].Name
+	case abi.ArrayTy:
+		return fmt.Sprintf("[%d]", kind.Size) + bindPrecompileTypeGo(*kind.Elem, structs, qualifier)
+	case abi.SliceTy:
+		return "[]" + bindPrecompileTypeGo(*kind.Elem, structs, qualifier)
+	default:
+		return bindBasicTypeGo(kind)
+	}
+}
+
+// zeroValueGo returns a Go expression of the zero value of [kind] that can be packed by the abi package.
+// Unlike the zero value of the Go type, big integers are non-nil.
+func zeroValueGo(kind abi.Type, structs map[string]*tmplStruct, qualifier string) string {
+	goType := bindPrecompileTypeGo(kind, structs, qualifier)
+	switch kind.T {
+	case abi.TupleTy:
+		s := structs[kind.TupleRawName+kind.String()]
+		fields := make([]string, 0, len(s.Fields))
+		for _, field := range s.Fields {
+			fields = append(fields, fmt.Sprintf("%s: %s", field.Name, zeroValueGo(field.SolKind, structs, qualifier)))
+		}
+		return fmt.Sprintf("%s{%s}", goType, strings.Join(fields, ", "))
+	case abi.ArrayTy:
+		elems := make([]string, kind.Size)
+		for i := range elems {
+			elems[i] = zeroValueGo(*kind.Elem, structs, qualifier)
+		}
+		return fmt.Sprintf("%s{%s}", goType, strings.Join(elems, ", "))
+	case abi.SliceTy, abi.BytesTy:
+		return "nil"
+	case abi.IntTy, abi.UintTy:
+		if goType == "*big.Int" {
+			return "new(big.Int)"
+		}
+		return "0"
+	case abi.BoolTy:
+		return "false"
+	case abi.StringTy:
+		return `""`
+	default:
+		return goType + "{}"
+	}
+}
+
+// paramName returns [name] decapitalised for use as a Go parameter, avoiding Go keywords.
+func paramName(name string) string {
+	param := decapitalise(name)
+	if token.Lookup(param).IsKeyword() {
+		return param + "Arg"
+	}
+	return param
+}
+
+// removeUnusedImports removes the imports of [src] that are never referred to, since the packages a
+// generated precompile needs depend on its functions, and formats the result.
+func removeUnusedImports(src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[string]bool)
+	ast.Inspect(file, func(node ast.Node) bool {
+		if sel, ok := node.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				used[ident.Name] = true
+			}
+		}
+		return true
+	})
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		specs := gen.Specs[:0]
+		for _, spec := range gen.Specs {
+			importSpec := spec.(*ast.ImportSpec)
+			path, err := strconv.Unquote(importSpec.Path.Value)
+			if err != nil {
+				return nil, err
+			}
+			name := path[strings.LastIndex(path, "/")+1:]
+			if importSpec.Name != nil {
+				name = importSpec.Name.Name
+			}
+			if used[name] || name == "_" {
+				specs = append(specs, spec)
+			}
+		}
+		gen.Specs = specs
+	}
+	file.Imports = nil
+	buffer := new(bytes.Buffer)
+	if err := format.Node(buffer, fset, file); err != nil {
+		return nil, err
+	}
+	// Format again to collapse the blank lines left behind by the removed imports.
+	return format.Source(buffer.Bytes())
+}
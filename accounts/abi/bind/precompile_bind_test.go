@@ -0,0 +1,157 @@
+// (c) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package bind
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var precompileBindTests = []struct {
+	name      string
+	abi       string
+	address   string
+	allowList bool
+	aliases   map[string]string
+	contains  []string
+	err       string
+}{
+	{
+		name: "PrecompileGreeter",
+		abi: `[
+			{"type":"function","name":"setAdmin","stateMutability":"nonpayable","inputs":[{"name":"addr","type":"address"}],"outputs":[]},
+			{"type":"function","name":"setEnabled","stateMutability":"nonpayable","inputs":[{"name":"addr","type":"address"}],"outputs":[]},
+			{"type":"function","name":"setNone","stateMutability":"nonpayable","inputs":[{"name":"addr","type":"address"}],"outputs":[]},
+			{"type":"function","name":"readAllowList","stateMutability":"view","inputs":[{"name":"addr","type":"address"}],"outputs":[{"name":"role","type":"uint256"}]},
+			{"type":"function","name":"sayHello","stateMutability":"view","inputs":[],"outputs":[{"name":"result","type":"string"}]},
+			{"type":"function","name":"setGreeting","stateMutability":"nonpayable","inputs":[{"name":"response","type":"string"},{"name":"","type":"uint256"}],"outputs":[]},
+			{"type":"function","name":"getGreetings","stateMutability":"view","inputs":[{"name":"type","type":"address"}],"outputs":[{"name":"greetings","type":"tuple[]","internalType":"struct Greeting[]","components":[{"name":"text","type":"string"},{"name":"count","type":"uint64"},{"name":"at","type":"uint256"}]},{"name":"total","type":"uint256"}]}
+		]`,
+		address:   "0x03000000000000000000000000000000000000f0",
+		allowList: true,
+		contains: []string{
+			"PrecompileGreeterConfigKey = \"precompileGreeterConfig\"",
+			"AllowListConfig",
+			"ErrCannotSetGreeting",
+			"type SetGreetingInput struct",
+			"type GetGreetingsOutput struct",
+			"type PrecompileGreeterGreeting struct",
+			"func PackGetGreetings(typeArg common.Address)",
+			"createAllowListFunctions(precompileAddr)",
+		},
+	},
+	{
+		name: "PrecompileCounter",
+		abi: `[
+			{"type":"fallback","stateMutability":"nonpayable"},
+			{"type":"function","name":"increment","stateMutability":"nonpayable","inputs":[{"name":"amounts","type":"uint256[2]"}],"outputs":[{"name":"","type":"uint256"}]},
+			{"type":"function","name":"get","stateMutability":"view","inputs":[],"outputs":[{"name":"count","type":"uint256"}]}
+		]`,
+		address: "0x03000000000000000000000000000000000000f1",
+		aliases: map[string]string{"get": "count"},
+		contains: []string{
+			"BlockTimestamp *big.Int",
+			"func PackCount()",
+			"func precompileCounterFallback(",
+			"newStatefulPrecompileWithFunctionSelectors(fallback, functions)",
+		},
+	},
+	{
+		name:    "PrecompileReceiver",
+		abi:     `[{"type":"receive","stateMutability":"payable"}]`,
+		address: "0x03000000000000000000000000000000000000f2",
+		err:     "stateful precompiles do not support receive functions",
+	},
+	{
+		name: "PrecompileDuplicate",
+		abi: `[
+			{"type":"function","name":"foo","stateMutability":"view","inputs":[],"outputs":[]},
+			{"type":"function","name":"bar","stateMutability":"view","inputs":[],"outputs":[]}
+		]`,
+		address: "0x03000000000000000000000000000000000000f3",
+		aliases: map[string]string{"bar": "foo"},
+		err:     "duplicated identifier",
+	},
+	{
+		name:    "PrecompileAddress",
+		abi:     `[]`,
+		address: "0x03",
+		err:     "invalid precompile address",
+	},
+}
+
+func TestPrecompileBind(t *testing.T) {
+	generated := make(map[string][2]string)
+	for _, tt := range precompileBindTests {
+		t.Run(tt.name, func(t *testing.T) {
+			contract, test, err := PrecompileBind(tt.name, tt.abi, tt.address, tt.allowList, tt.aliases)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to generate precompile: %v", err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(contract, want) {
+					t.Errorf("generated precompile does not contain %q", want)
+				}
+			}
+			if !strings.Contains(test, "func Test"+tt.name+"Run(t *testing.T)") {
+				t.Errorf("generated test scaffold does not contain Test%sRun", tt.name)
+			}
+			generated[tt.name] = [2]string{contract, test}
+		})
+	}
+	if testing.Short() {
+		t.Skip("skipping compilation of the generated precompiles in short mode")
+	}
+	// Skip the compilation if no Go command can be found
+	gocmd := runtime.GOROOT() + "/bin/go"
+	if !common.FileExist(gocmd) {
+		t.Skip("go sdk not found for testing")
+	}
+	// Overlay the generated precompiles onto the precompile and core packages, and run their test scaffolds,
+	// which pass against the unimplemented skeletons.
+	root, err := filepath.Abs(filepath.Join("..", "..", ".."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws := t.TempDir()
+	overlay := struct{ Replace map[string]string }{Replace: make(map[string]string)}
+	var names []string
+	for name, code := range generated {
+		names = append(names, name)
+		file := strings.ToLower(name)
+		for i, dst := range []string{filepath.Join(root, "precompile", file+".go"), filepath.Join(root, "core", file+"_test.go")} {
+			src := filepath.Join(ws, filepath.Base(dst))
+			if err := os.WriteFile(src, []byte(code[i]), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			overlay.Replace[dst] = src
+		}
+	}
+	overlayJSON, err := json.Marshal(overlay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	overlayFile := filepath.Join(ws, "overlay.json")
+	if err := os.WriteFile(overlayFile, overlayJSON, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(gocmd, "test", "-overlay", overlayFile, "-run", "^Test("+strings.Join(names, "|")+")Run$", "./core")
+	cmd.Dir = root
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to run the generated test scaffolds: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,501 @@
+// (c) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package bind
+
+// tmplPrecompileData is the data structure required to fill the precompile templates.
+type tmplPrecompileData struct {
+	Type      string                 // Type name of the precompile, used as the prefix of its exported identifiers
+	Address   string                 // Address of the precompile
+	AllowList bool                   // Whether the precompile embeds an allow list
+	InputABI  string                 // JSON ABI used as the input to generate the precompile from
+	Funcs     map[string]*tmplMethod // Functions of the precompile, excluding the allow list functions
+	Fallback  *tmplMethod            // Additional special fallback function
+	Structs   map[string]*tmplStruct // Struct type definitions used by the functions
+}
+
+// tmplSourcePrecompileGo is the Go source template of the generated stateful precompile skeleton.
+const tmplSourcePrecompileGo = `
+// Code generated by precompilegen.
+// This file is a skeleton of a stateful precompile: review every TODO, including the gas costs, before the
+// precompile is enabled on a network.
+
+package precompile
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ir4tech/webb-evm/accounts/abi"
+	"github.com/ir4tech/webb-evm/vmerrs"
+)
+
+{{$type := .Type}}
+{{$structs := .Structs}}
+{{$allowList := .AllowList}}
+
+// Gas costs of the functions of the {{.Type}} precompile.
+// TODO: charge for the storage slots each function actually reads and writes and for any events it emits.
+const (
+	{{range .Funcs}}{{.Normalized.Name}}GasCost uint64 = {{if .Original.IsConstant}}readGasCostPerSlot{{else}}writeGasCostPerSlot{{end}}
+	{{end}}{{if .Fallback}}{{.Type}}FallbackGasCost uint64 = writeGasCostPerSlot{{end}}
+)
+
+// {{.Type}}ConfigKey is the key of the config in the chain config and network upgrades.
+const {{.Type}}ConfigKey = "{{decapitalise .Type}}Config"
+
+// {{.Type}}RawABI is the solidity ABI of the {{.Type}} precompile.
+const {{.Type}}RawABI = "{{.InputABI}}"
+
+var (
+	_ StatefulPrecompileConfig = &{{.Type}}Config{}
+
+	// {{.Type}}Address is the address of the {{.Type}} precompile.
+	{{.Type}}Address = common.HexToAddress("{{.Address}}")
+
+	// {{.Type}}ABI is the parsed ABI of the {{.Type}} precompile used to unpack inputs and pack outputs.
+	{{.Type}}ABI = mustParseABI({{.Type}}RawABI)
+
+	// Singleton StatefulPrecompiledContract of the {{.Type}} precompile.
+	{{.Type}}Precompile StatefulPrecompiledContract = create{{.Type}}Precompile({{.Type}}Address)
+
+	{{range .Funcs}}{{decapitalise .Normalized.Name}}Signature = {{$type}}ABI.Methods["{{.Original.Name}}"].ID // {{.Original.Sig}}
+	{{end}}
+	{{if .AllowList}}{{range .Funcs}}{{if not .Original.IsConstant}}ErrCannot{{.Normalized.Name}} = errors.New("non-enabled cannot call {{.Original.RawName}}")
+	{{end}}{{end}}{{end}}
+)
+
+func init() {
+	if err := RegisterModule(Module{
+		ConfigKey: {{.Type}}ConfigKey,
+		Address:   {{.Type}}Address,
+		NewConfig: func() StatefulPrecompileConfig { return new({{.Type}}Config) },
+	}); err != nil {
+		panic(err)
+	}
+}
+
+{{range .Structs}}
+// {{.Name}} is the ABI representation of a solidity struct used by the {{$type}} precompile.
+type {{.Name}} struct {
+	{{range .Fields}}{{.Name}} {{bindtype .SolKind $structs}}
+	{{end}}
+}
+{{end}}
+
+{{if .AllowList}}
+// {{.Type}}Config wraps [AllowListConfig] and uses it to implement the StatefulPrecompileConfig
+// interface while adding in the {{.Type}} specific precompile address.
+type {{.Type}}Config struct {
+	AllowListConfig
+	// TODO: add the fields that configure the initial state of the precompile.
+}
+{{else}}
+// {{.Type}}Config implements the StatefulPrecompileConfig interface for the {{.Type}} precompile.
+// When used in a network upgrade, [Disabled] turns the precompile off at [BlockTimestamp] instead.
+type {{.Type}}Config struct {
+	BlockTimestamp *big.Int ` + "`json:\"blockTimestamp\"`" + `
+
+	Disabled bool ` + "`json:\"disable,omitempty\"`" + `
+	// TODO: add the fields that configure the initial state of the precompile.
+}
+
+// Timestamp returns the timestamp at which the {{.Type}} precompile should be enabled.
+func (c *{{.Type}}Config) Timestamp() *big.Int { return c.BlockTimestamp }
+
+// IsDisabled returns true if this config disables the precompile at [BlockTimestamp].
+func (c *{{.Type}}Config) IsDisabled() bool { return c.Disabled }
+{{end}}
+
+// Address returns the address of the {{.Type}} precompile.
+func (c *{{.Type}}Config) Address() common.Address {
+	return {{.Type}}Address
+}
+
+// Verify returns an error if [c] is invalid.
+func (c *{{.Type}}Config) Verify() error {
+	// TODO: verify the fields that configure the initial state of the precompile.
+	return nil
+}
+
+// Configure configures [state] with the initial state of the {{.Type}} precompile based on [c].
+func (c *{{.Type}}Config) Configure({{if .AllowList}}chainConfig{{else}}_{{end}} ChainConfig, state StateDB, {{if .AllowList}}blockContext{{else}}_{{end}} BlockContext) {
+	{{if .AllowList}}c.AllowListConfig.Configure(chainConfig, state, blockContext, {{.Type}}Address){{end}}
+	// TODO: set up the initial state of the precompile.
+}
+
+// Disable clears the {{if .AllowList}}roles and any other {{end}}state of the {{.Type}} precompile from [state].
+func (c *{{.Type}}Config) Disable(_ ChainConfig, state StateDB, _ BlockContext) {
+	clearPrecompileState(state, {{.Type}}Address)
+}
+
+// Contract returns the singleton stateful precompiled contract to be used for the {{.Type}} precompile.
+func (c *{{.Type}}Config) Contract() StatefulPrecompiledContract {
+	return {{.Type}}Precompile
+}
+
+{{if .AllowList}}
+// Get{{.Type}}Status returns the role of [address] for the {{.Type}} allow list.
+// [address] has no role if its role has expired by the block [timestamp].
+func Get{{.Type}}Status(stateDB StateDB, address common.Address, timestamp *big.Int) AllowListRole {
+	return getAllowListStatus(stateDB, {{.Type}}Address, address, timestamp)
+}
+
+// Set{{.Type}}Status sets the permissions of [address] to [role] for the
+// {{.Type}} allow list. assumes [role] has already been verified as valid.
+func Set{{.Type}}Status(stateDB StateDB, address common.Address, role AllowListRole) {
+	setAllowListRole(stateDB, {{.Type}}Address, address, role)
+}
+{{end}}
+
+{{range .Funcs}}
+{{$name := .Normalized.Name}}
+{{$method := .Original.Name}}
+{{$inputs := .Normalized.Inputs}}
+{{$outputs := .Normalized.Outputs}}
+{{if gt (len $inputs) 1}}
+// {{$name}}Input is the input of the {{.Original.RawName}} function.
+type {{$name}}Input struct {
+	{{range $inputs}}{{.Name}} {{bindtype .Type $structs}}
+	{{end}}
+}
+{{end}}
+{{if gt (len $outputs) 1}}
+// {{$name}}Output is the output of the {{.Original.RawName}} function.
+type {{$name}}Output struct {
+	{{range $outputs}}{{.Name}} {{bindtype .Type $structs}}
+	{{end}}
+}
+{{end}}
+
+// Pack{{$name}} packs {{if $inputs}}the input of {{.Original.RawName}}{{else}}the selector of {{.Original.RawName}}{{end}} into the input data to the {{$type}} precompile.
+func Pack{{$name}}({{if gt (len $inputs) 1}}input {{$name}}Input{{else}}{{range $inputs}}{{paramname .Name}} {{bindtype .Type $structs}}{{end}}{{end}}) ([]byte, error) {
+	return {{$type}}ABI.Pack("{{$method}}"{{if gt (len $inputs) 1}}{{range $inputs}}, input.{{.Name}}{{end}}{{else}}{{range $inputs}}, {{paramname .Name}}{{end}}{{end}})
+}
+
+{{if $inputs}}
+// Unpack{{$name}}Input attempts to unpack [input] into the arguments of {{.Original.RawName}}.
+// assumes that [input] does not include the selector (omits first 4 bytes in Pack{{$name}})
+func Unpack{{$name}}Input(input []byte) ({{if gt (len $inputs) 1}}{{$name}}Input{{else}}{{bindtype (index $inputs 0).Type $structs}}{{end}}, error) {
+	res, err := {{$type}}ABI.Methods["{{$method}}"].Inputs.Unpack(input)
+	if err != nil {
+		return {{if gt (len $inputs) 1}}{{$name}}Input{}{{else}}*new({{bindtype (index $inputs 0).Type $structs}}){{end}}, fmt.Errorf("invalid input for {{.Original.RawName}}: %w", err)
+	}
+	{{if gt (len $inputs) 1}}
+	inputStruct := {{$name}}Input{}
+	{{range $i, $input := $inputs}}inputStruct.{{.Name}} = *abi.ConvertType(res[{{$i}}], new({{bindtype .Type $structs}})).(*{{bindtype .Type $structs}})
+	{{end}}
+	return inputStruct, nil
+	{{else}}
+	unpacked := *abi.ConvertType(res[0], new({{bindtype (index $inputs 0).Type $structs}})).(*{{bindtype (index $inputs 0).Type $structs}})
+	return unpacked, nil
+	{{end}}
+}
+{{end}}
+
+{{if $outputs}}
+// Pack{{$name}}Output packs the output of {{.Original.RawName}} into the return data of the {{$type}} precompile.
+func Pack{{$name}}Output({{if gt (len $outputs) 1}}output {{$name}}Output{{else}}{{range $outputs}}{{paramname .Name}} {{bindtype .Type $structs}}{{end}}{{end}}) ([]byte, error) {
+	return {{$type}}ABI.Methods["{{$method}}"].Outputs.Pack({{if gt (len $outputs) 1}}{{range $i, $output := $outputs}}{{if $i}}, {{end}}output.{{.Name}}{{end}}{{else}}{{range $outputs}}{{paramname .Name}}{{end}}{{end}})
+}
+
+// Unpack{{$name}}Output attempts to unpack [output] returned by {{.Original.RawName}}.
+func Unpack{{$name}}Output(output []byte) ({{if gt (len $outputs) 1}}{{$name}}Output{{else}}{{bindtype (index $outputs 0).Type $structs}}{{end}}, error) {
+	res, err := {{$type}}ABI.Methods["{{$method}}"].Outputs.Unpack(output)
+	if err != nil {
+		return {{if gt (len $outputs) 1}}{{$name}}Output{}{{else}}*new({{bindtype (index $outputs 0).Type $structs}}){{end}}, fmt.Errorf("invalid output of {{.Original.RawName}}: %w", err)
+	}
+	{{if gt (len $outputs) 1}}
+	outputStruct := {{$name}}Output{}
+	{{range $i, $output := $outputs}}outputStruct.{{.Name}} = *abi.ConvertType(res[{{$i}}], new({{bindtype .Type $structs}})).(*{{bindtype .Type $structs}})
+	{{end}}
+	return outputStruct, nil
+	{{else}}
+	unpacked := *abi.ConvertType(res[0], new({{bindtype (index $outputs 0).Type $structs}})).(*{{bindtype (index $outputs 0).Type $structs}})
+	return unpacked, nil
+	{{end}}
+}
+{{end}}
+
+// {{decapitalise $name}} implements {{.Original.Sig}} of the {{$type}} precompile.
+// TODO: implement the function. The generated body only charges gas, unpacks the input{{if and $allowList (not .Original.IsConstant)}}, checks the allow list{{end}} and returns a zero output.
+func {{decapitalise $name}}(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, {{$name}}GasCost); err != nil {
+		return nil, 0, err
+	}
+	{{if not .Original.IsConstant}}
+	if readOnly {
+		return nil, remainingGas, vmerrs.ErrWriteProtection
+	}
+	{{end}}
+	{{if $inputs}}
+	inputStruct, err := Unpack{{$name}}Input(input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	_ = inputStruct // TODO: use the input of {{.Original.RawName}}.
+	{{end}}
+	{{if and $allowList (not .Original.IsConstant)}}
+	// Verify that the caller is in the allow list and therefore has the right to call {{.Original.RawName}}
+	callerStatus := getAllowListStatus(accessibleState.GetStateDB(), {{$type}}Address, caller, accessibleState.GetBlockContext().Timestamp())
+	if !callerStatus.IsEnabled() {
+		return revertWithReason(remainingGas, fmt.Errorf("%w: %s", ErrCannot{{$name}}, caller))
+	}
+	{{end}}
+	{{if $outputs}}
+	{{if gt (len $outputs) 1}}output := {{$name}}Output{ {{range $outputs}}{{.Name}}: {{zerovalue .Type $structs}}, {{end}} }{{else}}output := {{zerovalue (index $outputs 0).Type $structs}}{{end}}
+	packedOutput, err := Pack{{$name}}Output(output)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	return packedOutput, remainingGas, nil
+	{{else}}
+	// Return an empty output and the remaining gas
+	return []byte{}, remainingGas, nil
+	{{end}}
+}
+{{end}}
+
+{{if .Fallback}}
+// {{decapitalise .Type}}Fallback implements the fallback function of the {{.Type}} precompile, which is called
+// when there is no input.
+// TODO: implement the fallback function.
+func {{decapitalise .Type}}Fallback(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, {{.Type}}FallbackGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	if readOnly {
+		return nil, remainingGas, vmerrs.ErrWriteProtection
+	}
+
+	// Return an empty output and the remaining gas
+	return []byte{}, remainingGas, nil
+}
+{{end}}
+
+// create{{.Type}}Precompile returns a StatefulPrecompiledContract {{if .AllowList}}with R/W control of an allow list at [precompileAddr] and {{end}}the functions of the {{.Type}} precompile.
+func create{{.Type}}Precompile(precompileAddr common.Address) StatefulPrecompiledContract {
+	{{if .AllowList}}functions := createAllowListFunctions(precompileAddr){{else}}var functions []*statefulPrecompileFunction{{end}}
+	{{range .Funcs}}functions = append(functions, newStatefulPrecompileFunction({{decapitalise .Normalized.Name}}Signature, {{decapitalise .Normalized.Name}}))
+	{{end}}
+	{{if .Fallback}}
+	fallback := newStatefulPrecompileFunction(nil, {{decapitalise .Type}}Fallback)
+	return newStatefulPrecompileWithFunctionSelectors(fallback, functions)
+	{{else}}
+	// Construct the contract with no fallback function.
+	return newStatefulPrecompileWithFunctionSelectors(nil, functions)
+	{{end}}
+}
+`
+
+// tmplSourcePrecompileTestGo is the Go source template of the generated test scaffold of a stateful precompile.
+const tmplSourcePrecompileTestGo = `
+// Code generated by precompilegen.
+// This file is a scaffold of the tests of the {{.Type}} precompile: extend each case with the expected output
+// and state once the function is implemented.
+
+package core
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ir4tech/webb-evm/core/rawdb"
+	"github.com/ir4tech/webb-evm/core/state"
+	"github.com/ir4tech/webb-evm/params"
+	"github.com/ir4tech/webb-evm/precompile"
+	"github.com/ir4tech/webb-evm/vmerrs"
+	"github.com/stretchr/testify/assert"
+)
+
+{{$type := .Type}}
+{{$structs := .Structs}}
+{{$allowList := .AllowList}}
+
+// This test is added within the core package so that it can import all of the required code
+// without creating any import cycles
+func Test{{.Type}}Run(t *testing.T) {
+	type test struct {
+		caller         common.Address
+		precompileAddr common.Address
+		input          func() []byte
+		suppliedGas    uint64
+		readOnly       bool
+
+		expectedRes []byte
+		expectedErr string
+
+		assertState func(t *testing.T, state *state.StateDB)
+	}
+
+	// Every case runs against the same chain config and block, so gas costs that depend on the network upgrades
+	// active at the block are chosen once here.
+	chainConfig := params.TestChainConfig
+	blockContext := &mockBlockContext{blockNumber: common.Big0, timestamp: testTimestamp}
+
+	{{if .AllowList}}
+	adminAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+	allowAddr := common.HexToAddress("0xAb5801a7D398351b8bE11C439e05C5B3259aeC9B")
+	noRoleAddr := common.HexToAddress("0xF60C45c607D0f41687c94C314d300f483661E13a")
+
+	modifyAllowListGasCost := uint64(precompile.ModifyAllowListGasCost)
+	if !chainConfig.IsPrecompileUpgrade(blockContext.Timestamp()) {
+		modifyAllowListGasCost = precompile.LegacyModifyAllowListGasCost
+	}
+	{{else}}
+	allowAddr := common.HexToAddress("0xAb5801a7D398351b8bE11C439e05C5B3259aeC9B")
+	{{end}}
+
+	for name, test := range map[string]test{
+		{{- range .Funcs}}
+		{{- $name := .Normalized.Name}}
+		{{- $inputs := .Normalized.Inputs}}
+		{{- $outputs := .Normalized.Outputs}}
+		"{{.Original.RawName}} from allow address": {
+			caller:         allowAddr,
+			precompileAddr: precompile.{{$type}}Address,
+			input: func() []byte {
+				input, err := precompile.Pack{{$name}}({{if gt (len $inputs) 1}}precompile.{{$name}}Input{ {{range $inputs}}{{.Name}}: {{zerovalue .Type $structs}}, {{end}} }{{else}}{{range $inputs}}{{zerovalue .Type $structs}}{{end}}{{end}})
+				if err != nil {
+					panic(err)
+				}
+				return input
+			},
+			suppliedGas: precompile.{{$name}}GasCost,
+			readOnly:    false,
+			{{- if $outputs}}
+			expectedRes: func() []byte {
+				res, err := precompile.Pack{{$name}}Output({{if gt (len $outputs) 1}}precompile.{{$name}}Output{ {{range $outputs}}{{.Name}}: {{zerovalue .Type $structs}}, {{end}} }{{else}}{{zerovalue (index $outputs 0).Type $structs}}{{end}})
+				if err != nil {
+					panic(err)
+				}
+				return res
+			}(),
+			{{- else}}
+			expectedRes: []byte{},
+			{{- end}}
+			assertState: func(t *testing.T, state *state.StateDB) {
+				// TODO: assert the state after {{.Original.RawName}}.
+			},
+		},
+		"{{.Original.RawName}} with insufficient gas": {
+			caller:         allowAddr,
+			precompileAddr: precompile.{{$type}}Address,
+			input: func() []byte {
+				input, err := precompile.Pack{{$name}}({{if gt (len $inputs) 1}}precompile.{{$name}}Input{ {{range $inputs}}{{.Name}}: {{zerovalue .Type $structs}}, {{end}} }{{else}}{{range $inputs}}{{zerovalue .Type $structs}}{{end}}{{end}})
+				if err != nil {
+					panic(err)
+				}
+				return input
+			},
+			suppliedGas: precompile.{{$name}}GasCost - 1,
+			readOnly:    false,
+			expectedErr: vmerrs.ErrOutOfGas.Error(),
+		},
+		{{- if not .Original.IsConstant}}
+		"readOnly {{.Original.RawName}} fails": {
+			caller:         allowAddr,
+			precompileAddr: precompile.{{$type}}Address,
+			input: func() []byte {
+				input, err := precompile.Pack{{$name}}({{if gt (len $inputs) 1}}precompile.{{$name}}Input{ {{range $inputs}}{{.Name}}: {{zerovalue .Type $structs}}, {{end}} }{{else}}{{range $inputs}}{{zerovalue .Type $structs}}{{end}}{{end}})
+				if err != nil {
+					panic(err)
+				}
+				return input
+			},
+			suppliedGas: precompile.{{$name}}GasCost,
+			readOnly:    true,
+			expectedErr: vmerrs.ErrWriteProtection.Error(),
+		},
+		{{- if $allowList}}
+		"{{.Original.RawName}} from no role fails": {
+			caller:         noRoleAddr,
+			precompileAddr: precompile.{{$type}}Address,
+			input: func() []byte {
+				input, err := precompile.Pack{{$name}}({{if gt (len $inputs) 1}}precompile.{{$name}}Input{ {{range $inputs}}{{.Name}}: {{zerovalue .Type $structs}}, {{end}} }{{else}}{{range $inputs}}{{zerovalue .Type $structs}}{{end}}{{end}})
+				if err != nil {
+					panic(err)
+				}
+				return input
+			},
+			suppliedGas: precompile.{{$name}}GasCost,
+			readOnly:    false,
+			expectedErr: vmerrs.ErrExecutionReverted.Error(),
+		},
+		{{- end}}
+		{{- end}}
+		{{- end}}
+		{{- if .AllowList}}
+		"set allow role from admin": {
+			caller:         adminAddr,
+			precompileAddr: precompile.{{.Type}}Address,
+			input: func() []byte {
+				input, err := precompile.PackModifyAllowList(noRoleAddr, precompile.AllowListEnabled)
+				if err != nil {
+					panic(err)
+				}
+				return input
+			},
+			suppliedGas: modifyAllowListGasCost,
+			readOnly:    false,
+			expectedRes: []byte{},
+			assertState: func(t *testing.T, state *state.StateDB) {
+				res := precompile.Get{{.Type}}Status(state, noRoleAddr, blockContext.Timestamp())
+				assert.Equal(t, precompile.AllowListEnabled, res)
+			},
+		},
+		"set allow role from non-admin fails": {
+			caller:         allowAddr,
+			precompileAddr: precompile.{{.Type}}Address,
+			input: func() []byte {
+				input, err := precompile.PackModifyAllowList(noRoleAddr, precompile.AllowListEnabled)
+				if err != nil {
+					panic(err)
+				}
+				return input
+			},
+			suppliedGas: modifyAllowListGasCost,
+			readOnly:    false,
+			expectedErr: precompile.ErrCannotModifyAllowList.Error(),
+		},
+		{{- end}}
+	} {
+		t.Run(name, func(t *testing.T) {
+			db := rawdb.NewMemoryDatabase()
+			state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			{{- if .AllowList}}
+			// Set up the state so that each address has the expected permissions at the start.
+			precompile.Set{{.Type}}Status(state, adminAddr, precompile.AllowListAdmin)
+			precompile.Set{{.Type}}Status(state, allowAddr, precompile.AllowListEnabled)
+			precompile.Set{{.Type}}Status(state, noRoleAddr, precompile.AllowListNoRole)
+			{{- end}}
+			ret, remainingGas, err := precompile.{{.Type}}Precompile.Run(&mockAccessibleState{state: state, blockContext: blockContext, chainConfig: chainConfig}, test.caller, test.precompileAddr, test.input(), test.suppliedGas, test.readOnly)
+			if len(test.expectedErr) != 0 {
+				if err == nil {
+					assert.Failf(t, "run expectedly passed without error", "expected error %q", test.expectedErr)
+				} else {
+					assert.True(t, strings.Contains(err.Error(), test.expectedErr), "expected error (%s) to contain substring (%s)", err, test.expectedErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, uint64(0), remainingGas)
+			assert.Equal(t, test.expectedRes, ret)
+
+			test.assertState(t, state)
+		})
+	}
+}
+`
@@ -0,0 +1,144 @@
+// (c) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ir4tech/webb-evm/accounts/abi/bind"
+	"github.com/ir4tech/webb-evm/internal/flags"
+	"github.com/ir4tech/webb-evm/precompile"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	// Git SHA1 commit hash of the release (set via linker flags)
+	gitCommit = ""
+	gitDate   = ""
+
+	app *cli.App
+)
+
+var (
+	// Flags needed by precompilegen
+	abiFlag = &cli.StringFlag{
+		Name:  "abi",
+		Usage: "Path to the interface ABI json of the precompile, - for STDIN",
+	}
+	typeFlag = &cli.StringFlag{
+		Name:  "type",
+		Usage: "Type name of the precompile, used as the prefix of its exported identifiers",
+	}
+	addressFlag = &cli.StringFlag{
+		Name:  "address",
+		Usage: "Address of the precompile, which must be unused and within a range reserved for stateful precompiles",
+	}
+	allowListFlag = &cli.BoolFlag{
+		Name:  "allowlist",
+		Usage: "Embed an allow list that callers of non-view functions must be enabled on",
+	}
+	outFlag = &cli.StringFlag{
+		Name:  "out",
+		Usage: "Output file for the generated precompile (default = stdout)",
+	}
+	testOutFlag = &cli.StringFlag{
+		Name:  "test-out",
+		Usage: "Output file for the generated test scaffold, which belongs in the core package (default = not generated)",
+	}
+	aliasFlag = &cli.StringFlag{
+		Name:  "alias",
+		Usage: "Comma separated aliases for function renaming, e.g. original1=alias1, original2=alias2",
+	}
+)
+
+func init() {
+	app = flags.NewApp(gitCommit, gitDate, "stateful precompile generator")
+	app.Name = "precompilegen"
+	app.Flags = []cli.Flag{
+		abiFlag,
+		typeFlag,
+		addressFlag,
+		allowListFlag,
+		outFlag,
+		testOutFlag,
+		aliasFlag,
+	}
+	app.Action = precompilegen
+}
+
+func precompilegen(c *cli.Context) error {
+	if c.String(abiFlag.Name) == "" {
+		utils.Fatalf("No interface ABI specified (--abi)")
+	}
+	if c.String(typeFlag.Name) == "" {
+		utils.Fatalf("No precompile type specified (--type)")
+	}
+	address := c.String(addressFlag.Name)
+	if !common.IsHexAddress(address) {
+		utils.Fatalf("Invalid precompile address %q (--address)", address)
+	}
+	if !precompile.IsReservedAddress(common.HexToAddress(address)) {
+		utils.Fatalf("Precompile address %s is not in a range reserved for stateful precompiles", address)
+	}
+	if module, ok := precompile.GetRegisteredModuleByAddress(common.HexToAddress(address)); ok {
+		utils.Fatalf("Precompile address %s is already used by %s", address, module.ConfigKey)
+	}
+	// Load up the ABI
+	var (
+		abi []byte
+		err error
+	)
+	input := c.String(abiFlag.Name)
+	if input == "-" {
+		abi, err = io.ReadAll(os.Stdin)
+	} else {
+		abi, err = os.ReadFile(input)
+	}
+	if err != nil {
+		utils.Fatalf("Failed to read input ABI: %v", err)
+	}
+	// Extract all aliases from the flags
+	aliases := make(map[string]string)
+	if c.IsSet(aliasFlag.Name) {
+		re := regexp.MustCompile(`(?:(\w+)[:=](\w+))`)
+		submatches := re.FindAllStringSubmatch(c.String(aliasFlag.Name), -1)
+		for _, match := range submatches {
+			aliases[match[1]] = match[2]
+		}
+	}
+	// Generate the precompile and its test scaffold
+	code, test, err := bind.PrecompileBind(c.String(typeFlag.Name), string(abi), address, c.Bool(allowListFlag.Name), aliases)
+	if err != nil {
+		utils.Fatalf("Failed to generate precompile: %v", err)
+	}
+	if c.IsSet(testOutFlag.Name) {
+		if err := os.WriteFile(c.String(testOutFlag.Name), []byte(test), 0600); err != nil {
+			utils.Fatalf("Failed to write test scaffold: %v", err)
+		}
+	}
+	// Either flush it out to a file or display on the standard output
+	if !c.IsSet(outFlag.Name) {
+		fmt.Printf("%s\n", code)
+		return nil
+	}
+	if err := os.WriteFile(c.String(outFlag.Name), []byte(code), 0600); err != nil {
+		utils.Fatalf("Failed to write precompile: %v", err)
+	}
+	return nil
+}
+
+func main() {
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StreamHandler(os.Stderr, log.TerminalFormat(true))))
+
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
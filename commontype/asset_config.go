@@ -8,3 +8,13 @@ type Asset struct {
 	Owner    common.Address `json:"owner"`
 	Location string         `json:"location"`
 }
+
+// LocationRecord is an entry of the append-only location history of an asset.
+type LocationRecord struct {
+	Location string `json:"location"`
+	// Timestamp is the timestamp of the block in which the location was recorded
+	Timestamp uint64         `json:"timestamp"`
+	Updater   common.Address `json:"updater"`
+	// Evidence is an optional hash of off-chain evidence of the relocation, or the zero hash if none was given
+	Evidence common.Hash `json:"evidence"`
+}
@@ -12,6 +12,7 @@ contract AllowList is Ownable {
   uint256 constant STATUS_NONE = 0;
   uint256 constant STATUS_ENABLED = 1;
   uint256 constant STATUS_ADMIN = 2;
+  uint256 constant STATUS_MANAGER = 3;
 
   constructor(address precompileAddr) Ownable() {
     allowList = IAllowList(precompileAddr);
@@ -27,9 +28,14 @@ contract AllowList is Ownable {
     return result == STATUS_ADMIN;
   }
 
+  function isManager(address addr) public view returns (bool) {
+    uint256 result = allowList.readAllowList(addr);
+    return result == STATUS_MANAGER;
+  }
+
   function isEnabled(address addr) public view returns (bool) {
     uint256 result = allowList.readAllowList(addr);
-    // if address is ENABLED or ADMIN it can deploy
+    // if address is ENABLED, MANAGER or ADMIN it can deploy
     // in other words, if it's not NONE it can deploy.
     return result != STATUS_NONE;
   }
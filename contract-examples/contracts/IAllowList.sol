@@ -1,16 +1,45 @@
 //SPDX-License-Identifier: MIT
 pragma solidity ^0.8.0;
 
+// The manager role, role expiries, role holder enumeration and the RoleSet event are only available once the
+// precompileUpgradeTimestamp network upgrade is active.
 interface IAllowList {
+  // Role of [account] set to [role] by [sender], which expires at the block timestamp [expiresAt], or never if it is 0
+  event RoleSet(address indexed account, uint256 indexed role, address indexed sender, uint256 expiresAt);
+
   // Set [addr] to have the admin role over the minter list
   function setAdmin(address addr) external;
 
+  // Set [addr] to have the manager role over the minter list, which can enable and disable addresses
+  // that are not admins or managers
+  function setManager(address addr) external;
+
   // Set [addr] to be enabled on the minter list
   function setEnabled(address addr) external;
 
   // Set [addr] to have no role over the minter list
   function setNone(address addr) external;
 
-  // Read the status of [addr]
+  // Set [addr] to have the admin role until the block timestamp [expiresAt]
+  function setAdminUntil(address addr, uint256 expiresAt) external;
+
+  // Set [addr] to have the manager role until the block timestamp [expiresAt]
+  function setManagerUntil(address addr, uint256 expiresAt) external;
+
+  // Set [addr] to be enabled until the block timestamp [expiresAt]
+  function setEnabledUntil(address addr, uint256 expiresAt) external;
+
+  // Read the status of [addr], which is none once its role has expired
   function readAllowList(address addr) external view returns (uint256);
+
+  // Read the block timestamp at which the role of [addr] expires, or 0 if it never expires
+  function readAllowListExpiry(address addr) external view returns (uint256);
+
+  // Read the number of addresses that have been granted a role. Roles granted before the precompileUpgradeTimestamp
+  // network upgrade are only counted once they are set again.
+  function roleHolderCount() external view returns (uint256);
+
+  // Read the address at [index] of the addresses that have been granted a role, along with the role it was
+  // granted and the block timestamp at which it expires
+  function roleHolderAt(uint256 index) external view returns (address addr, uint256 role, uint256 expiresAt);
 }
@@ -1,6 +1,8 @@
 //SPDX-License-Identifier: MIT
 pragma solidity ^0.8.0;
 
+import "./IAllowList.sol";
+
 struct Asset {
     string id;
     string name;
@@ -8,11 +10,69 @@ struct Asset {
     string location;
 }
 
-interface IAsset {
+struct LocationRecord {
+    string location;
+    uint256 timestamp;
+    address updater;
+    bytes32 evidence;
+}
+
+// Only addresses enabled on the allow list may register assets, and admins may update any asset
+interface IAsset is IAllowList {
+    event AssetRegistered(address indexed owner, string assetId, string name);
+    event AssetRenamed(address indexed sender, string assetId, string name);
+    event AssetRelocated(address indexed sender, string assetId, string location);
+    event AssetMetadataSet(address indexed sender, string assetId, string key, string value);
+    // Emitted on transfers and, with [to] set to the zero address, when an asset is burned
+    event AssetTransferred(address indexed from, address indexed to, string assetId);
+    // Emitted when an asset is approved and, with [operator] set to the zero address, when its approval is cleared
+    // by a transfer or burn
+    event Approval(address indexed owner, address indexed operator, string assetId);
+    event ApprovalForAll(address indexed owner, address indexed operator, bool approved);
+
+    // Unbounded queries, charged for every asset returned
     function getAll() external view returns (Asset[] memory);
-    function registerAsset() external;
+    // Registers a new asset owned by the caller and returns its id
+    function registerAsset() external returns (string memory assetId);
+    // Registers a new asset owned by the caller under an id derived from the caller and [salt]. Reverts if an asset
+    // was already registered under the id, even if it has since been burned.
+    function registerAssetWithSalt(bytes32 salt) external returns (string memory assetId);
+    // Reverts if no asset is registered under [assetId]
     function getAsset(string memory assetId) external view returns (Asset memory);
-    function getAssetByAddress(address owner) external view returns (Asset memory);
+    function getAssetByAddress(address owner) external view returns (Asset[] memory);
+
+    // Enumeration in a deterministic order: assets are appended when registered and burning an asset moves the
+    // last asset into its place
+    function totalAssets() external view returns (uint256);
+    function assetByIndex(uint256 index) external view returns (Asset memory);
+    function assetsOfOwner(address owner, uint256 offset, uint256 limit) external view returns (Asset[] memory);
+    function balanceOf(address owner) external view returns (uint256);
     function updateLocation(string memory assetId, string memory location) external;
     function updateName(string memory assetId, string memory name) external;
-}
\ No newline at end of file
+
+    // Location history, appended to by every location update
+    function updateLocationWithEvidence(string memory assetId, string memory location, bytes32 evidence) external;
+    function getLocationHistory(string memory assetId, uint256 offset, uint256 limit) external view returns (LocationRecord[] memory);
+    function getLocationHistoryLength(string memory assetId) external view returns (uint256);
+
+    // Metadata restricted to the keys and value lengths declared by the metadata schema in the chain config.
+    // Values the schema no longer allows read as empty, and can always be cleared by setting them to empty.
+    function setMetadata(string memory assetId, string memory key, string memory value) external;
+    function getMetadata(string memory assetId, string memory key) external view returns (string memory);
+
+    // ERC-721 style ownership management. Transfers to contracts call IAssetReceiver.onAssetReceived on the
+    // recipient and revert unless it returns its selector.
+    function transferAsset(string memory assetId, address to) external;
+    function approve(string memory assetId, address operator) external;
+    function setApprovalForAll(address operator, bool approved) external;
+    function burnAsset(string memory assetId) external;
+    function getApproved(string memory assetId) external view returns (address);
+    function isApprovedForAll(address owner, address operator) external view returns (bool);
+}
+
+// Implemented by contracts that accept asset transfers
+interface IAssetReceiver {
+    // Called by the asset precompile after [assetId] is transferred to the contract by [operator] on behalf of
+    // [from]. Must return IAssetReceiver.onAssetReceived.selector to accept the transfer.
+    function onAssetReceived(address operator, address from, string memory assetId) external returns (bytes4);
+}
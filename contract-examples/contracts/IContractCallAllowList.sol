@@ -0,0 +1,23 @@
+//SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+import "./IAllowList.sol";
+
+// Restricts calls to functions of contracts, identified by the address of the contract and the selector of the
+// function, to the callers allowed for the function. Calls made by any other caller revert. Only admins of the
+// allow list may restrict functions, while admins and managers may allow and disallow callers.
+interface IContractCallAllowList is IAllowList {
+    event FunctionRestrictionSet(address indexed target, bytes4 indexed selector, bool restricted);
+    event FunctionCallerSet(address indexed target, bytes4 indexed selector, address indexed caller, bool allowed);
+
+    // Restrict calls to [selector] of [target] to the callers allowed for it
+    function restrictFunction(address target, bytes4 selector) external;
+    // Lift the restriction of [selector] of [target], keeping its allowed callers for when it is restricted again
+    function unrestrictFunction(address target, bytes4 selector) external;
+    // Allow or disallow [caller] to call [selector] of [target]
+    function setFunctionCaller(address target, bytes4 selector, address caller, bool allowed) external;
+
+    function isFunctionRestricted(address target, bytes4 selector) external view returns (bool);
+    // Returns whether [caller] may call [selector] of [target], which is true if the function is not restricted
+    function isFunctionCallAllowed(address target, bytes4 selector, address caller) external view returns (bool);
+}
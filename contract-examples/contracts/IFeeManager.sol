@@ -2,7 +2,39 @@
 pragma solidity ^0.8.0;
 import "./IAllowList.sol";
 
+// Scheduled fee configs, fee config bounds and the events are only available once the precompileUpgradeTimestamp
+// network upgrade is active.
 interface IFeeManager is IAllowList {
+  // Emitted when sender sets the fee config
+  event FeeConfigChanged(
+    address indexed sender,
+    uint256 gasLimit,
+    uint256 targetBlockRate,
+    uint256 minBaseFee,
+    uint256 targetGas,
+    uint256 baseFeeChangeDenominator,
+    uint256 minBlockGasCost,
+    uint256 maxBlockGasCost,
+    uint256 blockGasCostStep
+  );
+
+  // Emitted when sender schedules a fee config to take effect at effectiveAt
+  event FeeConfigScheduled(
+    address indexed sender,
+    uint256 effectiveAt,
+    uint256 gasLimit,
+    uint256 targetBlockRate,
+    uint256 minBaseFee,
+    uint256 targetGas,
+    uint256 baseFeeChangeDenominator,
+    uint256 minBlockGasCost,
+    uint256 maxBlockGasCost,
+    uint256 blockGasCostStep
+  );
+
+  // Emitted when sender cancels the scheduled fee config
+  event PendingFeeConfigCancelled(address indexed sender);
+
   // Set fee config fields to contract storage
   function setFeeConfig(
     uint256 gasLimit,
@@ -32,4 +64,37 @@ interface IFeeManager is IAllowList {
 
   // Get the last block number changed the fee config from the contract storage
   function getFeeConfigLastChangedAt() external view returns (uint256 blockNumber);
+
+  // Schedule fee config fields to be applied by the first block with a timestamp at or after effectiveAt,
+  // replacing any fee config scheduled before
+  function scheduleFeeConfig(
+    uint256 effectiveAt,
+    uint256 gasLimit,
+    uint256 targetBlockRate,
+    uint256 minBaseFee,
+    uint256 targetGas,
+    uint256 baseFeeChangeDenominator,
+    uint256 minBlockGasCost,
+    uint256 maxBlockGasCost,
+    uint256 blockGasCostStep
+  ) external;
+
+  // Cancel the scheduled fee config, if any
+  function cancelPendingFeeConfig() external;
+
+  // Get the scheduled fee config from the contract storage, where effectiveAt is 0 if none is scheduled
+  function getPendingFeeConfig()
+    external
+    view
+    returns (
+      uint256 effectiveAt,
+      uint256 gasLimit,
+      uint256 targetBlockRate,
+      uint256 minBaseFee,
+      uint256 targetGas,
+      uint256 baseFeeChangeDenominator,
+      uint256 minBlockGasCost,
+      uint256 maxBlockGasCost,
+      uint256 blockGasCostStep
+    );
 }
@@ -0,0 +1,25 @@
+//SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+import "./IAllowList.sol";
+
+// Fungible assets held natively by accounts alongside the native coin, identified by an arbitrary bytes32 id.
+// Only addresses enabled on the allow list may mint and burn, while any holder may transfer its own balance.
+interface INativeAsset is IAllowList {
+    // Emitted on transfers, with [from] set to the zero address on mints and [to] set to the zero address on burns
+    event NativeAssetTransferred(address indexed from, address indexed to, bytes32 indexed assetId, uint256 amount);
+
+    // Mint [amount] of [assetId] and send it to [to]
+    function mintNativeAsset(address to, bytes32 assetId, uint256 amount) external;
+    // Burn [amount] of [assetId] held by the caller
+    function burnNativeAsset(bytes32 assetId, uint256 amount) external;
+    // Transfer [amount] of [assetId] held by the caller to [to]
+    function transferNativeAsset(address to, bytes32 assetId, uint256 amount) external;
+    // Transfer [amount] of [assetId] held by the caller to [to] and then call [to] with [data] and the value sent
+    // along, returning the output of the call. The call is made by the precompile with the address of the caller
+    // appended to [data], so that [to] can authenticate the sender like an ERC-2771 trusted forwarder.
+    function transferNativeAssetAndCall(address to, bytes32 assetId, uint256 amount, bytes memory data) external payable returns (bytes memory);
+
+    function nativeAssetBalance(address account, bytes32 assetId) external view returns (uint256);
+    function nativeAssetTotalSupply(bytes32 assetId) external view returns (uint256);
+}
@@ -5,4 +5,16 @@ import "./IAllowList.sol";
 interface INativeMinter is IAllowList {
   // Mint [amount] number of native coins and send to [addr]
   function mintNativeCoin(address addr, uint256 amount) external;
+  // Burn [amount] number of native coins held by the caller.
+  // Burning, the supply counters and the max supply are only available once the precompileUpgradeTimestamp
+  // network upgrade is active.
+  function burnNativeCoin(uint256 amount) external;
+
+  // Native coins counted as minted, including genesis allocations if the minter is enabled in the genesis
+  function totalNativeCoinMinted() external view returns (uint256);
+  // Native coins counted as burned, including transaction fees sent to the blackhole address, up to the coins
+  // counted as minted
+  function totalNativeCoinBurned() external view returns (uint256);
+  // Cap on the minted coins less the burned coins, or zero if minting is not capped
+  function maxNativeCoinSupply() external view returns (uint256);
 }
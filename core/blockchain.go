@@ -104,10 +104,13 @@ const (
 )
 
 // cacheableFeeConfig encapsulates fee configuration itself and the block number that it has changed at,
-// in order to cache them together.
+// along with the pending fee configuration and the timestamp it takes effect at, in order to cache them together.
 type cacheableFeeConfig struct {
 	feeConfig     commontype.FeeConfig
 	lastChangedAt *big.Int
+
+	pendingFeeConfig   commontype.FeeConfig
+	pendingEffectiveAt *big.Int
 }
 
 // CacheConfig contains the configuration values for the trie caching/pruning
@@ -356,28 +356,59 @@ func (bc *BlockChain) SubscribeAcceptedTransactionEvent(ch chan<- NewTxsEvent) e
 func (bc *BlockChain) GetFeeConfigAt(parent *types.Header) (commontype.FeeConfig, *big.Int, error) {
 	config := bc.Config()
 	bigTime := new(big.Int).SetUint64(parent.Time)
-	if !config.IsFeeConfigManager(bigTime) {
+	if !config.IsPrecompileEnabled(precompile.FeeConfigManagerAddress, bigTime) {
 		return config.FeeConfig, common.Big0, nil
 	}
 
+	cached, err := bc.getCacheableFeeConfig(parent)
+	if err != nil {
+		return commontype.EmptyFeeConfig, nil, err
+	}
+	return cached.feeConfig, cached.lastChangedAt, nil
+}
+
+// GetPendingFeeConfigAt returns the fee configuration scheduled through the FeeConfigManager at [parent] and the
+// timestamp it takes effect at. The pending fee config is applied by the first block with a timestamp at or after
+// the returned timestamp, and governs the fees of the blocks built on top of that block.
+// Returns a nil timestamp if no fee config is scheduled or FeeConfigManager is not activated at [parent].
+func (bc *BlockChain) GetPendingFeeConfigAt(parent *types.Header) (commontype.FeeConfig, *big.Int, error) {
+	bigTime := new(big.Int).SetUint64(parent.Time)
+	if !bc.Config().IsPrecompileEnabled(precompile.FeeConfigManagerAddress, bigTime) {
+		return commontype.EmptyFeeConfig, nil, nil
+	}
+
+	cached, err := bc.getCacheableFeeConfig(parent)
+	if err != nil {
+		return commontype.EmptyFeeConfig, nil, err
+	}
+	return cached.pendingFeeConfig, cached.pendingEffectiveAt, nil
+}
+
+// getCacheableFeeConfig returns the current and pending fee configurations stored by the FeeConfigManager at
+// [parent], from the cache if possible.
+func (bc *BlockChain) getCacheableFeeConfig(parent *types.Header) (*cacheableFeeConfig, error) {
 	// try to return it from the cache
 	if cached, hit := bc.feeConfigCache.Get(parent.Root); hit {
 		cachedFeeConfig, ok := cached.(*cacheableFeeConfig)
 		if !ok {
-			return commontype.EmptyFeeConfig, nil, fmt.Errorf("expected type cacheableFeeConfig, got %T", cached)
+			return nil, fmt.Errorf("expected type cacheableFeeConfig, got %T", cached)
 		}
-		return cachedFeeConfig.feeConfig, cachedFeeConfig.lastChangedAt, nil
+		return cachedFeeConfig, nil
 	}
 
 	stateDB, err := bc.StateAt(parent.Root)
 	if err != nil {
-		return commontype.EmptyFeeConfig, nil, err
+		return nil, err
 	}
 
-	storedFeeConfig := precompile.GetStoredFeeConfig(stateDB)
-	lastChangedAt := precompile.GetFeeConfigLastChangedAt(stateDB)
-	cacheable := &cacheableFeeConfig{feeConfig: storedFeeConfig, lastChangedAt: lastChangedAt}
+	pendingFeeConfig, pendingEffectiveAt := precompile.GetPendingFeeConfig(stateDB)
+	cacheable := &cacheableFeeConfig{
+		feeConfig:          precompile.GetStoredFeeConfig(stateDB),
+		lastChangedAt:      precompile.GetFeeConfigLastChangedAt(stateDB),
+		pendingFeeConfig:   pendingFeeConfig,
+		pendingEffectiveAt: pendingEffectiveAt,
+	}
 	// add it to the cache
 	bc.feeConfigCache.Add(parent.Root, cacheable)
-	return storedFeeConfig, lastChangedAt, nil
+	return cacheable, nil
 }
@@ -216,6 +216,9 @@ func GenerateChain(config *params.ChainConfig, parent *types.Block, engine conse
 		b := &BlockGen{i: i, chain: blocks, parent: parent, statedb: statedb, config: config, engine: engine}
 		b.header = makeHeader(chainreader, config, parent, gap, statedb, b.engine)
 
+		// Configure any stateful precompiles that activate in this block
+		config.CheckConfigurePrecompiles(new(big.Int).SetUint64(parent.Time()), types.NewBlockWithHeader(b.header), statedb)
+
 		// Execute any user modifications to the block
 		if gen != nil {
 			gen(i, b)
@@ -46,6 +46,7 @@ import (
 	"github.com/ir4tech/webb-evm/core/types"
 	"github.com/ir4tech/webb-evm/ethdb"
 	"github.com/ir4tech/webb-evm/params"
+	"github.com/ir4tech/webb-evm/precompile"
 	"github.com/ir4tech/webb-evm/trie"
 )
 
@@ -175,9 +176,10 @@ func (e *GenesisMismatchError) Error() string {
 //     db has genesis    |  from DB           |  genesis (if compatible)
 //
 // The stored chain configuration will be updated if it is compatible (i.e. does not
-// specify a fork block below the local head block). In case of a conflict, the
-// error is a *params.ConfigCompatError and the new, unwritten config is returned.
-func SetupGenesisBlock(db ethdb.Database, genesis *Genesis) (*params.ChainConfig, error) {
+// specify a fork block or network upgrade below the last accepted block). In case of
+// a conflict, the error is a *params.ConfigCompatError and the new, unwritten config
+// is returned.
+func SetupGenesisBlock(db ethdb.Database, genesis *Genesis, lastAcceptedHash common.Hash) (*params.ChainConfig, error) {
 	if genesis == nil {
 		return nil, ErrNoGenesis
 	}
@@ -232,14 +234,18 @@ func SetupGenesisBlock(db ethdb.Database, genesis *Genesis) (*params.ChainConfig
 		return newcfg, nil
 	}
 
-	// Check config compatibility and write the config. Compatibility errors
-	// are returned to the caller unless we're already at block zero.
-	headBlock := rawdb.ReadHeadBlock(db)
-	if headBlock == nil {
-		return newcfg, fmt.Errorf("missing head block")
+	// Check config compatibility against the last accepted block and write the config.
+	// Compatibility errors are returned to the caller unless we're already at block zero.
+	lastAcceptedNumber := rawdb.ReadHeaderNumber(db, lastAcceptedHash)
+	if lastAcceptedNumber == nil {
+		return newcfg, fmt.Errorf("missing last accepted block number for %s", lastAcceptedHash)
 	}
-	height := headBlock.NumberU64()
-	timestamp := headBlock.Time()
+	lastAcceptedBlock := rawdb.ReadBlock(db, lastAcceptedHash, *lastAcceptedNumber)
+	if lastAcceptedBlock == nil {
+		return newcfg, fmt.Errorf("missing last accepted block %s", lastAcceptedHash)
+	}
+	height := lastAcceptedBlock.NumberU64()
+	timestamp := lastAcceptedBlock.Time()
 	compatErr := storedcfg.CheckCompatible(newcfg, height, timestamp)
 	if compatErr != nil && height != 0 && compatErr.RewindTo != 0 {
 		return newcfg, compatErr
@@ -258,6 +264,8 @@ func (g *Genesis) ToBlock(db ethdb.Database) *types.Block {
 	if err != nil {
 		panic(err)
 	}
+	// allocated records the accounts funded by the airdrop and the allocations
+	allocated := make(map[common.Address]struct{}, len(g.Alloc))
 	if g.AirdropHash != (common.Hash{}) {
 		t := time.Now()
 		h := common.BytesToHash(crypto.Keccak256(AirdropData))
@@ -270,6 +278,7 @@ func (g *Genesis) ToBlock(db ethdb.Database) *types.Block {
 		}
 		for _, alloc := range airdrop {
 			statedb.SetBalance(alloc.Address, g.AirdropAmount)
+			allocated[alloc.Address] = struct{}{}
 		}
 		log.Debug(
 			"applied airdrop allocation",
@@ -299,12 +308,23 @@ func (g *Genesis) ToBlock(db ethdb.Database) *types.Block {
 	// allocation
 	for addr, account := range g.Alloc {
 		statedb.SetBalance(addr, account.Balance)
+		allocated[addr] = struct{}{}
 		statedb.SetCode(addr, account.Code)
 		statedb.SetNonce(addr, account.Nonce)
 		for key, value := range account.Storage {
 			statedb.SetState(addr, key, value)
 		}
 	}
+	// Count the genesis allocations towards the native coins minted if the native minter is enabled in the genesis
+	// and the PrecompileUpgrade is active.
+	genesisTimestamp := new(big.Int).SetUint64(g.Timestamp)
+	if g.Config.IsPrecompileUpgrade(genesisTimestamp) && g.Config.IsPrecompileEnabled(precompile.ContractNativeMinterAddress, genesisTimestamp) {
+		supply := new(big.Int)
+		for addr := range allocated {
+			supply.Add(supply, statedb.GetBalance(addr))
+		}
+		precompile.AddNativeCoinMinted(statedb, supply)
+	}
 	root := statedb.IntermediateRoot(false)
 	head.Root = root
 
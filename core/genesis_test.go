@@ -28,15 +28,19 @@ package core
 
 import (
 	_ "embed"
+	"encoding/json"
 	"math/big"
 	"reflect"
 	"testing"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ir4tech/webb-evm/consensus/dummy"
+	"github.com/ir4tech/webb-evm/constants"
 	"github.com/ir4tech/webb-evm/core/rawdb"
 	"github.com/ir4tech/webb-evm/core/state"
+	"github.com/ir4tech/webb-evm/core/types"
 	"github.com/ir4tech/webb-evm/core/vm"
 	"github.com/ir4tech/webb-evm/ethdb"
 	"github.com/ir4tech/webb-evm/params"
@@ -45,7 +49,7 @@ import (
 )
 
 func setupGenesisBlock(db ethdb.Database, genesis *Genesis) (*params.ChainConfig, common.Hash, error) {
-	conf, err := SetupGenesisBlock(db, genesis)
+	conf, err := SetupGenesisBlock(db, genesis, rawdb.ReadHeadBlockHash(db))
 	stored := rawdb.ReadCanonicalHash(db, 0)
 	return conf, stored, err
 }
@@ -58,6 +62,64 @@ func TestGenesisBlockForTesting(t *testing.T) {
 	}
 }
 
+// preUpgradeGenesisJSON is the genesis of a chain that enables allow list precompiles at genesis, but not the
+// PrecompileUpgrade.
+const preUpgradeGenesisJSON = `{
+	"config": {
+		"chainId": 43214,
+		"homesteadBlock": 0,
+		"eip150Block": 0,
+		"eip155Block": 0,
+		"eip158Block": 0,
+		"byzantiumBlock": 0,
+		"constantinopleBlock": 0,
+		"petersburgBlock": 0,
+		"istanbulBlock": 0,
+		"muirGlacierBlock": 0,
+		"subnetEVMTimestamp": 0,
+		"feeConfig": {
+			"gasLimit": 8000000,
+			"minBaseFee": 25000000000,
+			"targetGas": 15000000,
+			"baseFeeChangeDenominator": 36,
+			"minBlockGasCost": 0,
+			"maxBlockGasCost": 1000000,
+			"targetBlockRate": 2,
+			"blockGasCostStep": 200000
+		},
+		"contractDeployerAllowListConfig": {"blockTimestamp": 0, "adminAddresses": ["0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC"]},
+		"contractNativeMinterConfig": {"blockTimestamp": 0, "adminAddresses": ["0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC", "0x0Fa8EA536Be85F32724D57A37758761B86416123"]},
+		"txAllowListConfig": {"blockTimestamp": 0, "adminAddresses": ["0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC"]},
+		"feeManagerConfig": {"blockTimestamp": 0, "adminAddresses": ["0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC"]}
+	},
+	"alloc": {
+		"8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC": {"balance": "0x52B7D2DCC80CD2E4000000"}
+	},
+	"gasLimit": "0x7A1200",
+	"timestamp": "0x0",
+	"difficulty": "0x0"
+}`
+
+// TestGenesisBeforePrecompileUpgrade checks that the genesis of a chain that does not activate the
+// PrecompileUpgrade is unchanged from before the upgrade, so that existing chains keep their genesis hash.
+func TestGenesisBeforePrecompileUpgrade(t *testing.T) {
+	genesisHash := common.HexToHash("0xabd19483e1ab30763b88e6df2a2c80c5f850a5ddb3cad126c34dcd704a985b1b")
+	genesis := new(Genesis)
+	if err := json.Unmarshal([]byte(preUpgradeGenesisJSON), genesis); err != nil {
+		t.Fatal(err)
+	}
+	if block := genesis.ToBlock(nil); block.Hash() != genesisHash {
+		t.Errorf("wrong genesis hash, got %v, want %v", block.Hash(), genesisHash)
+	}
+
+	// Activating the upgrade at genesis enumerates the admins and counts the allocations as minted, which changes
+	// the genesis.
+	genesis.Config.PrecompileUpgradeTimestamp = big.NewInt(0)
+	if block := genesis.ToBlock(nil); block.Hash() == genesisHash {
+		t.Errorf("expected the genesis hash to change when the PrecompileUpgrade is activated at genesis")
+	}
+}
+
 func TestSetupGenesis(t *testing.T) {
 	preSubnetConfig := *params.TestPreSubnetEVMConfig
 	preSubnetConfig.SubnetEVMTimestamp = big.NewInt(100)
@@ -183,16 +245,18 @@ func TestStatefulPrecompilesConfigure(t *testing.T) {
 		"allow list enabled in genesis": {
 			getConfig: func() *params.ChainConfig {
 				config := *params.TestChainConfig
-				config.ContractDeployerAllowListConfig = precompile.ContractDeployerAllowListConfig{
-					AllowListConfig: precompile.AllowListConfig{
-						BlockTimestamp:  big.NewInt(0),
-						AllowListAdmins: []common.Address{addr},
+				config.PrecompileConfigs = params.Precompiles{
+					precompile.ContractDeployerAllowListConfigKey: &precompile.ContractDeployerAllowListConfig{
+						AllowListConfig: precompile.AllowListConfig{
+							BlockTimestamp:  big.NewInt(0),
+							AllowListAdmins: []common.Address{addr},
+						},
 					},
 				}
 				return &config
 			},
 			assertState: func(t *testing.T, sdb *state.StateDB) {
-				assert.Equal(t, precompile.AllowListAdmin, precompile.GetContractDeployerAllowListStatus(sdb, addr), "unexpected allow list status for modified address")
+				assert.Equal(t, precompile.AllowListAdmin, precompile.GetContractDeployerAllowListStatus(sdb, addr, common.Big0), "unexpected allow list status for modified address")
 				assert.Equal(t, uint64(1), sdb.GetNonce(precompile.ContractDeployerAllowListAddress))
 			},
 		},
@@ -209,7 +273,7 @@ func TestStatefulPrecompilesConfigure(t *testing.T) {
 			}
 
 			db := rawdb.NewMemoryDatabase()
-			_, err := SetupGenesisBlock(db, genesis)
+			_, err := SetupGenesisBlock(db, genesis, common.Hash{})
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -225,3 +289,182 @@ func TestStatefulPrecompilesConfigure(t *testing.T) {
 		})
 	}
 }
+
+func TestPrecompileUpgrades(t *testing.T) {
+	admin := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+	newAdmin := common.HexToAddress("0x0Fa8EA536Be85F32724D57A37758761B86416123")
+
+	config := *params.TestChainConfig
+	config.UpgradeConfig = params.UpgradeConfig{
+		PrecompileUpgrades: []params.PrecompileUpgrade{
+			{
+				Config: &precompile.ContractNativeMinterConfig{
+					AllowListConfig: precompile.AllowListConfig{
+						BlockTimestamp:  big.NewInt(10),
+						AllowListAdmins: []common.Address{admin},
+					},
+				},
+			},
+			{
+				Config: &precompile.ContractNativeMinterConfig{
+					AllowListConfig: precompile.AllowListConfig{
+						BlockTimestamp:  big.NewInt(20),
+						AllowListAdmins: []common.Address{newAdmin},
+					},
+				},
+			},
+			{
+				Config: &precompile.ContractNativeMinterConfig{
+					AllowListConfig: precompile.AllowListConfig{
+						BlockTimestamp: big.NewInt(30),
+						Disable:        true,
+					},
+				},
+			},
+			{
+				Config: &precompile.ContractNativeMinterConfig{
+					AllowListConfig: precompile.AllowListConfig{
+						BlockTimestamp:  big.NewInt(40),
+						AllowListAdmins: []common.Address{newAdmin},
+					},
+				},
+			},
+		},
+	}
+	genesis := &Genesis{
+		Config:   &config,
+		Alloc:    GenesisAlloc{{1}: {Balance: big.NewInt(1)}},
+		GasLimit: config.FeeConfig.GasLimit.Uint64(),
+	}
+
+	db := rawdb.NewMemoryDatabase()
+	genesisBlock := genesis.MustCommit(db)
+	blockchain, err := NewBlockChain(db, DefaultCacheConfig, &config, dummy.NewFullFaker(), vm.Config{}, common.Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer blockchain.Stop()
+
+	// Blocks are produced at timestamps 10, 20, 30, 40 and 50.
+	blocks, _, err := GenerateChain(&config, genesisBlock, dummy.NewFullFaker(), db, 5, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := blockchain.InsertChain(blocks); err != nil {
+		t.Fatal(err)
+	}
+
+	genesisState, err := blockchain.StateAt(genesisBlock.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, genesisState.Exist(precompile.ContractNativeMinterAddress))
+	assert.False(t, config.IsPrecompileEnabled(precompile.ContractNativeMinterAddress, new(big.Int).SetUint64(genesisBlock.Time())))
+
+	for i, test := range []struct {
+		enabled      bool
+		adminRole    precompile.AllowListRole
+		newAdminRole precompile.AllowListRole
+	}{
+		// Enabled with [admin]
+		{enabled: true, adminRole: precompile.AllowListAdmin, newAdminRole: precompile.AllowListNoRole},
+		// Reconfigured with [newAdmin] on top of the existing roles
+		{enabled: true, adminRole: precompile.AllowListAdmin, newAdminRole: precompile.AllowListAdmin},
+		// Disabled, clearing all roles
+		{enabled: false, adminRole: precompile.AllowListNoRole, newAdminRole: precompile.AllowListNoRole},
+		// Re-enabled from a clean slate with [newAdmin] only
+		{enabled: true, adminRole: precompile.AllowListNoRole, newAdminRole: precompile.AllowListAdmin},
+		{enabled: true, adminRole: precompile.AllowListNoRole, newAdminRole: precompile.AllowListAdmin},
+	} {
+		block := blocks[i]
+		timestamp := new(big.Int).SetUint64(block.Time())
+		assert.Equal(t, test.enabled, config.IsPrecompileEnabled(precompile.ContractNativeMinterAddress, timestamp), "block %d", i)
+		_, ok := config.AvalancheRules(block.Number(), timestamp).Precompiles[precompile.ContractNativeMinterAddress]
+		assert.Equal(t, test.enabled, ok, "block %d", i)
+
+		statedb, err := blockchain.StateAt(block.Root())
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The precompile's account only exists, with its nonce and code set, while it is enabled.
+		assert.Equal(t, test.enabled, statedb.Exist(precompile.ContractNativeMinterAddress), "block %d", i)
+		assert.Equal(t, test.enabled, len(statedb.GetCode(precompile.ContractNativeMinterAddress)) > 0, "block %d", i)
+		assert.Equal(t, test.adminRole, precompile.GetContractNativeMinterStatus(statedb, admin, common.Big0), "block %d", i)
+		assert.Equal(t, test.newAdminRole, precompile.GetContractNativeMinterStatus(statedb, newAdmin, common.Big0), "block %d", i)
+	}
+
+	// The upgrades are persisted with the chain config, so restarting with the same
+	// upgrades is compatible while dropping upgrades that already took effect is not.
+	lastAccepted := blocks[len(blocks)-1].Hash()
+	if _, err := SetupGenesisBlock(db, genesis, lastAccepted); err != nil {
+		t.Fatal(err)
+	}
+	withoutUpgradesConfig := config
+	withoutUpgradesConfig.UpgradeConfig = params.UpgradeConfig{}
+	withoutUpgrades := *genesis
+	withoutUpgrades.Config = &withoutUpgradesConfig
+	_, err = SetupGenesisBlock(db, &withoutUpgrades, lastAccepted)
+	var compatErr *params.ConfigCompatError
+	assert.ErrorAs(t, err, &compatErr)
+}
+
+func TestNativeMinterSupplyCounters(t *testing.T) {
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	config := *params.TestChainConfig
+	config.PrecompileConfigs = params.Precompiles{
+		precompile.ContractNativeMinterConfigKey: &precompile.ContractNativeMinterConfig{
+			AllowListConfig: precompile.AllowListConfig{BlockTimestamp: big.NewInt(0)},
+			MaxSupply:       new(big.Int).Mul(big.NewInt(2), big.NewInt(params.Ether)),
+		},
+	}
+	genesis := &Genesis{
+		Config: &config,
+		Alloc: GenesisAlloc{
+			addr: {Balance: big.NewInt(params.Ether)},
+			{1}:  {Balance: big.NewInt(1)},
+		},
+		GasLimit: config.FeeConfig.GasLimit.Uint64(),
+	}
+
+	db := rawdb.NewMemoryDatabase()
+	genesisBlock := genesis.MustCommit(db)
+	blockchain, err := NewBlockChain(db, DefaultCacheConfig, &config, dummy.NewFullFaker(), vm.Config{}, common.Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer blockchain.Stop()
+
+	// The genesis allocations are counted as minted.
+	genesisState, err := blockchain.StateAt(genesisBlock.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, big.NewInt(params.Ether+1), precompile.GetNativeCoinMinted(genesisState))
+	assert.Equal(t, genesis.Config.PrecompileConfigs[precompile.ContractNativeMinterConfigKey].(*precompile.ContractNativeMinterConfig).MaxSupply, precompile.GetMaxNativeCoinSupply(genesisState))
+
+	// The fees of a block whose coinbase is the blackhole address are counted as burned.
+	signer := types.LatestSigner(&config)
+	blocks, _, err := GenerateChain(&config, genesisBlock, dummy.NewFullFaker(), db, 1, 10, func(i int, gen *BlockGen) {
+		gen.SetCoinbase(constants.BlackholeAddr)
+		tx, err := types.SignTx(types.NewTransaction(gen.TxNonce(addr), common.Address{2}, big.NewInt(1), params.TxGas, gen.BaseFee(), nil), signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gen.AddTx(tx)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := blockchain.InsertChain(blocks); err != nil {
+		t.Fatal(err)
+	}
+	statedb, err := blockchain.StateAt(blocks[0].Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(params.TxGas), blocks[0].BaseFee())
+	assert.Equal(t, fee, precompile.GetNativeCoinBurned(statedb))
+	assert.Equal(t, big.NewInt(params.Ether+1), precompile.GetNativeCoinMinted(statedb))
+}
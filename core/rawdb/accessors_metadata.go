@@ -69,12 +69,15 @@ func ReadChainConfig(db ethdb.KeyValueReader, hash common.Hash) *params.ChainCon
 	if len(data) == 0 {
 		return nil
 	}
-	var config params.ChainConfig
+	// Chain configs written before network upgrades were persisted simply decode
+	// without any upgrades.
+	var config params.ChainConfigWithUpgradesJSON
 	if err := json.Unmarshal(data, &config); err != nil {
 		log.Error("Invalid chain config JSON", "hash", hash, "err", err)
 		return nil
 	}
-	return &config
+	config.ChainConfig.UpgradeConfig = config.UpgradeConfig
+	return &config.ChainConfig
 }
 
 // WriteChainConfig writes the chain config settings to the database.
@@ -82,7 +85,7 @@ func WriteChainConfig(db ethdb.KeyValueWriter, hash common.Hash, cfg *params.Cha
 	if cfg == nil {
 		return
 	}
-	data, err := json.Marshal(cfg)
+	data, err := json.Marshal(params.ChainConfigWithUpgradesJSON{ChainConfig: *cfg, UpgradeConfig: cfg.UpgradeConfig})
 	if err != nil {
 		log.Crit("Failed to JSON encode chain config", "err", err)
 	}
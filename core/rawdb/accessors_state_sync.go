@@ -0,0 +1,86 @@
+// (c) 2021-2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package rawdb
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ir4tech/webb-evm/ethdb"
+)
+
+// ReadSyncRoot reads the root of the state trie being fetched by an
+// in-progress state sync. Returns the empty hash if no sync is in progress.
+func ReadSyncRoot(db ethdb.KeyValueReader) common.Hash {
+	data, _ := db.Get(syncRootKey)
+	if len(data) != common.HashLength {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// WriteSyncRoot stores the root of the state trie being fetched by state sync.
+func WriteSyncRoot(db ethdb.KeyValueWriter, root common.Hash) {
+	if err := db.Put(syncRootKey, root[:]); err != nil {
+		log.Crit("Failed to store sync root", "err", err)
+	}
+}
+
+// DeleteSyncRoot removes the root of the state trie being fetched by state sync.
+func DeleteSyncRoot(db ethdb.KeyValueWriter) {
+	if err := db.Delete(syncRootKey); err != nil {
+		log.Crit("Failed to remove sync root", "err", err)
+	}
+}
+
+// WriteSyncAccount stores an account trie leaf fetched by state sync, so the
+// account trie can be rebuilt from the fetched leaves after a restart.
+func WriteSyncAccount(db ethdb.KeyValueWriter, hash common.Hash, entry []byte) {
+	if err := db.Put(syncAccountKey(hash), entry); err != nil {
+		log.Crit("Failed to store sync account", "err", err)
+	}
+}
+
+// DeleteSyncAccount removes an account trie leaf fetched by state sync.
+func DeleteSyncAccount(db ethdb.KeyValueWriter, hash common.Hash) {
+	if err := db.Delete(syncAccountKey(hash)); err != nil {
+		log.Crit("Failed to delete sync account", "err", err)
+	}
+}
+
+// IterateSyncAccounts returns an iterator over the account trie leaves fetched
+// by state sync, in ascending key order. Keys returned by the iterator include
+// the sync account prefix, which can be stripped with [SyncAccountHash].
+func IterateSyncAccounts(db ethdb.Iteratee) ethdb.Iterator {
+	return db.NewIterator(syncAccountPrefix, nil)
+}
+
+// SyncAccountHash returns the account hash of a key returned by
+// [IterateSyncAccounts] and whether the key is a valid sync account key.
+func SyncAccountHash(key []byte) (common.Hash, bool) {
+	if len(key) != len(syncAccountPrefix)+common.HashLength {
+		return common.Hash{}, false
+	}
+	return common.BytesToHash(key[len(syncAccountPrefix):]), true
+}
+
+// ReadSyncSummary reads the summary of the block being synced by an
+// in-progress state sync. Returns nil if no sync is in progress.
+func ReadSyncSummary(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(syncSummaryKey)
+	return data
+}
+
+// WriteSyncSummary stores the summary of the block being synced by state sync.
+func WriteSyncSummary(db ethdb.KeyValueWriter, summary []byte) {
+	if err := db.Put(syncSummaryKey, summary); err != nil {
+		log.Crit("Failed to store sync summary", "err", err)
+	}
+}
+
+// DeleteSyncSummary removes the summary of the block being synced by state sync.
+func DeleteSyncSummary(db ethdb.KeyValueWriter) {
+	if err := db.Delete(syncSummaryKey); err != nil {
+		log.Crit("Failed to remove sync summary", "err", err)
+	}
+}
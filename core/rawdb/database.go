@@ -192,6 +192,7 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			for _, meta := range [][]byte{
 				databaseVersionKey, headHeaderKey, headBlockKey,
 				snapshotRootKey, snapshotGeneratorKey, uncleanShutdownKey,
+				syncRootKey, syncSummaryKey,
 			} {
 				if bytes.Equal(key, meta) {
 					metadata.Add(size)
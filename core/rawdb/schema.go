@@ -71,6 +71,12 @@ var (
 	// acceptorTipKey tracks the tip of the last accepted block that has been fully processed.
 	acceptorTipKey = []byte("AcceptorTipKey")
 
+	// syncRootKey tracks the root of the state trie being fetched by an in-progress state sync.
+	syncRootKey = []byte("SyncRoot")
+
+	// syncSummaryKey tracks the summary of the block being synced by an in-progress state sync.
+	syncSummaryKey = []byte("SyncSummary")
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix       = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	headerHashSuffix   = []byte("n") // headerPrefix + num (uint64 big endian) + headerHashSuffix -> hash
@@ -85,6 +91,8 @@ var (
 	SnapshotStoragePrefix = []byte("o") // SnapshotStoragePrefix + account hash + storage hash -> storage trie value
 	CodePrefix            = []byte("c") // CodePrefix + code hash -> account code
 
+	syncAccountPrefix = []byte("SyncAccount") // syncAccountPrefix + account hash -> account trie value fetched by state sync
+
 	preimagePrefix = []byte("secure-key-")      // preimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
 
@@ -150,6 +158,11 @@ func accountSnapshotKey(hash common.Hash) []byte {
 	return append(SnapshotAccountPrefix, hash.Bytes()...)
 }
 
+// syncAccountKey = syncAccountPrefix + hash
+func syncAccountKey(hash common.Hash) []byte {
+	return append(syncAccountPrefix, hash.Bytes()...)
+}
+
 // storageSnapshotKey = SnapshotStoragePrefix + account hash + storage hash
 func storageSnapshotKey(accountHash, storageHash common.Hash) []byte {
 	return append(append(SnapshotStoragePrefix, accountHash.Bytes()...), storageHash.Bytes()...)
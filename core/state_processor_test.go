@@ -326,10 +326,12 @@ func TestBadTxAllowListBlock(t *testing.T) {
 			MuirGlacierBlock:    big.NewInt(0),
 			SubnetEVMTimestamp:  big.NewInt(0),
 			FeeConfig:           params.DefaultFeeConfig,
-			TxAllowListConfig: precompile.TxAllowListConfig{
-				AllowListConfig: precompile.AllowListConfig{
-					BlockTimestamp:  big.NewInt(0),
-					AllowListAdmins: []common.Address{},
+			PrecompileConfigs: params.Precompiles{
+				precompile.TxAllowListConfigKey: &precompile.TxAllowListConfig{
+					AllowListConfig: precompile.AllowListConfig{
+						BlockTimestamp:  big.NewInt(0),
+						AllowListAdmins: []common.Address{},
+					},
 				},
 			},
 		}
@@ -34,6 +34,7 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ir4tech/webb-evm/constants"
 	"github.com/ir4tech/webb-evm/core/types"
 	"github.com/ir4tech/webb-evm/core/vm"
 	"github.com/ir4tech/webb-evm/params"
@@ -249,8 +250,8 @@ func (st *StateTransition) preCheck() error {
 		}
 
 		// Check that the sender is on the tx allow list if enabled
-		if st.evm.ChainConfig().IsTxAllowList(st.evm.Context.Time) {
-			txAllowListRole := precompile.GetTxAllowListStatus(st.state, st.msg.From())
+		if st.evm.ChainConfig().IsPrecompileEnabled(precompile.TxAllowListAddress, st.evm.Context.Time) {
+			txAllowListRole := precompile.GetTxAllowListStatus(st.state, st.msg.From(), st.evm.Context.Time)
 			if !txAllowListRole.IsEnabled() {
 				return fmt.Errorf("%w: %s", precompile.ErrSenderAddressNotAllowListed, st.msg.From())
 			}
@@ -336,7 +337,8 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	}
 
 	// Set up the initial access list.
-	if rules := st.evm.ChainConfig().AvalancheRules(st.evm.Context.BlockNumber, st.evm.Context.Time); rules.IsSubnetEVM {
+	rules := st.evm.ChainConfig().AvalancheRules(st.evm.Context.BlockNumber, st.evm.Context.Time)
+	if rules.IsSubnetEVM {
 		st.state.PrepareAccessList(msg.From(), msg.To(), vm.ActivePrecompiles(rules), msg.AccessList())
 	}
 	var (
@@ -351,7 +353,12 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 		ret, st.gas, vmerr = st.evm.Call(sender, st.to(), st.data, st.gas, st.value)
 	}
 	st.refundGas(subnetEVM)
-	st.state.AddBalance(st.evm.Context.Coinbase, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice))
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice)
+	st.state.AddBalance(st.evm.Context.Coinbase, fee)
+	// Fees sent to the blackhole address are burned, so count them towards the native coins burned.
+	if st.evm.Context.Coinbase == constants.BlackholeAddr && rules.IsPrecompileUpgrade && rules.IsPrecompileEnabled(precompile.ContractNativeMinterAddress) {
+		precompile.AddNativeCoinBurned(st.state, fee)
+	}
 
 	return &ExecutionResult{
 		UsedGas:    st.gasUsed(),
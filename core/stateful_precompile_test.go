@@ -4,15 +4,19 @@
 package core
 
 import (
+	"errors"
 	"math/big"
 	"strings"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ir4tech/webb-evm/accounts/abi"
 	"github.com/ir4tech/webb-evm/commontype"
 	"github.com/ir4tech/webb-evm/core/rawdb"
 	"github.com/ir4tech/webb-evm/core/state"
+	"github.com/ir4tech/webb-evm/core/types"
+	"github.com/ir4tech/webb-evm/params"
 	"github.com/ir4tech/webb-evm/precompile"
 	"github.com/ir4tech/webb-evm/vmerrs"
 	"github.com/stretchr/testify/assert"
@@ -33,6 +37,7 @@ var (
 	}
 
 	testBlockNumber = big.NewInt(7)
+	testTimestamp   = uint64(1_650_000_000)
 )
 
 type mockBlockContext struct {
@@ -46,12 +51,44 @@ func (mb *mockBlockContext) Timestamp() *big.Int { return new(big.Int).SetUint64
 type mockAccessibleState struct {
 	state        *state.StateDB
 	blockContext *mockBlockContext
+	chainConfig  *params.ChainConfig
+	origin       common.Address
 }
 
 func (m *mockAccessibleState) GetStateDB() precompile.StateDB { return m.state }
 
 func (m *mockAccessibleState) GetBlockContext() precompile.BlockContext { return m.blockContext }
 
+// GetChainConfig returns [params.TestChainConfig], which has every network upgrade activated, unless
+// [m.chainConfig] is set.
+func (m *mockAccessibleState) GetChainConfig() precompile.ChainConfig {
+	if m.chainConfig == nil {
+		return params.TestChainConfig
+	}
+	return m.chainConfig
+}
+
+func (m *mockAccessibleState) AddLog(addr common.Address, topics []common.Hash, data []byte) {
+	m.state.AddLog(&types.Log{Address: addr, Topics: topics, Data: data, BlockNumber: m.blockContext.blockNumber.Uint64()})
+}
+
+func (m *mockAccessibleState) Origin() common.Address { return m.origin }
+
+func (m *mockAccessibleState) Value() *big.Int { return new(big.Int) }
+
+// Call succeeds without executing anything when calling an account without code, like the EVM does, since the mock
+// cannot execute code.
+func (m *mockAccessibleState) Call(addr common.Address, input []byte, gas uint64, value *big.Int) ([]byte, uint64, error) {
+	if m.state.GetCodeSize(addr) > 0 {
+		return nil, 0, errors.New("mock accessible state cannot execute code")
+	}
+	return nil, gas, nil
+}
+
+func (m *mockAccessibleState) StaticCall(addr common.Address, input []byte, gas uint64) ([]byte, uint64, error) {
+	return m.Call(addr, input, gas, nil)
+}
+
 // This test is added within the core package so that it can import all of the required code
 // without creating any import cycles
 func TestContractDeployerAllowListRun(t *testing.T) {
@@ -86,10 +123,10 @@ func TestContractDeployerAllowListRun(t *testing.T) {
 			readOnly:    false,
 			expectedRes: []byte{},
 			assertState: func(t *testing.T, state *state.StateDB) {
-				res := precompile.GetContractDeployerAllowListStatus(state, adminAddr)
+				res := precompile.GetContractDeployerAllowListStatus(state, adminAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListAdmin, res)
 
-				res = precompile.GetContractDeployerAllowListStatus(state, noRoleAddr)
+				res = precompile.GetContractDeployerAllowListStatus(state, noRoleAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListAdmin, res)
 			},
 		},
@@ -107,10 +144,10 @@ func TestContractDeployerAllowListRun(t *testing.T) {
 			readOnly:    false,
 			expectedRes: []byte{},
 			assertState: func(t *testing.T, state *state.StateDB) {
-				res := precompile.GetContractDeployerAllowListStatus(state, adminAddr)
+				res := precompile.GetContractDeployerAllowListStatus(state, adminAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListAdmin, res)
 
-				res = precompile.GetContractDeployerAllowListStatus(state, noRoleAddr)
+				res = precompile.GetContractDeployerAllowListStatus(state, noRoleAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListEnabled, res)
 			},
 		},
@@ -128,7 +165,7 @@ func TestContractDeployerAllowListRun(t *testing.T) {
 			readOnly:    false,
 			expectedRes: []byte{},
 			assertState: func(t *testing.T, state *state.StateDB) {
-				res := precompile.GetContractDeployerAllowListStatus(state, adminAddr)
+				res := precompile.GetContractDeployerAllowListStatus(state, adminAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListNoRole, res)
 			},
 		},
@@ -212,7 +249,7 @@ func TestContractDeployerAllowListRun(t *testing.T) {
 			readOnly:    false,
 			expectedRes: common.Hash(precompile.AllowListNoRole).Bytes(),
 			assertState: func(t *testing.T, state *state.StateDB) {
-				res := precompile.GetContractDeployerAllowListStatus(state, noRoleAddr)
+				res := precompile.GetContractDeployerAllowListStatus(state, noRoleAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListNoRole, res)
 			},
 		},
@@ -226,7 +263,7 @@ func TestContractDeployerAllowListRun(t *testing.T) {
 			readOnly:    false,
 			expectedRes: common.Hash(precompile.AllowListNoRole).Bytes(),
 			assertState: func(t *testing.T, state *state.StateDB) {
-				res := precompile.GetContractDeployerAllowListStatus(state, adminAddr)
+				res := precompile.GetContractDeployerAllowListStatus(state, adminAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListAdmin, res)
 			},
 		},
@@ -240,7 +277,7 @@ func TestContractDeployerAllowListRun(t *testing.T) {
 			readOnly:    true,
 			expectedRes: common.Hash(precompile.AllowListNoRole).Bytes(),
 			assertState: func(t *testing.T, state *state.StateDB) {
-				res := precompile.GetContractDeployerAllowListStatus(state, adminAddr)
+				res := precompile.GetContractDeployerAllowListStatus(state, adminAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListAdmin, res)
 			},
 		},
@@ -320,10 +357,10 @@ func TestTxAllowListRun(t *testing.T) {
 			readOnly:    false,
 			expectedRes: []byte{},
 			assertState: func(t *testing.T, state *state.StateDB) {
-				res := precompile.GetTxAllowListStatus(state, adminAddr)
+				res := precompile.GetTxAllowListStatus(state, adminAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListAdmin, res)
 
-				res = precompile.GetTxAllowListStatus(state, noRoleAddr)
+				res = precompile.GetTxAllowListStatus(state, noRoleAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListAdmin, res)
 			},
 		},
@@ -341,10 +378,10 @@ func TestTxAllowListRun(t *testing.T) {
 			readOnly:    false,
 			expectedRes: []byte{},
 			assertState: func(t *testing.T, state *state.StateDB) {
-				res := precompile.GetTxAllowListStatus(state, adminAddr)
+				res := precompile.GetTxAllowListStatus(state, adminAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListAdmin, res)
 
-				res = precompile.GetTxAllowListStatus(state, noRoleAddr)
+				res = precompile.GetTxAllowListStatus(state, noRoleAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListEnabled, res)
 			},
 		},
@@ -362,7 +399,7 @@ func TestTxAllowListRun(t *testing.T) {
 			readOnly:    false,
 			expectedRes: []byte{},
 			assertState: func(t *testing.T, state *state.StateDB) {
-				res := precompile.GetTxAllowListStatus(state, adminAddr)
+				res := precompile.GetTxAllowListStatus(state, adminAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListNoRole, res)
 			},
 		},
@@ -446,7 +483,7 @@ func TestTxAllowListRun(t *testing.T) {
 			readOnly:    false,
 			expectedRes: common.Hash(precompile.AllowListNoRole).Bytes(),
 			assertState: func(t *testing.T, state *state.StateDB) {
-				res := precompile.GetTxAllowListStatus(state, noRoleAddr)
+				res := precompile.GetTxAllowListStatus(state, noRoleAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListNoRole, res)
 			},
 		},
@@ -460,7 +497,7 @@ func TestTxAllowListRun(t *testing.T) {
 			readOnly:    false,
 			expectedRes: common.Hash(precompile.AllowListNoRole).Bytes(),
 			assertState: func(t *testing.T, state *state.StateDB) {
-				res := precompile.GetTxAllowListStatus(state, adminAddr)
+				res := precompile.GetTxAllowListStatus(state, adminAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListAdmin, res)
 			},
 		},
@@ -474,7 +511,7 @@ func TestTxAllowListRun(t *testing.T) {
 			readOnly:    true,
 			expectedRes: common.Hash(precompile.AllowListNoRole).Bytes(),
 			assertState: func(t *testing.T, state *state.StateDB) {
-				res := precompile.GetTxAllowListStatus(state, adminAddr)
+				res := precompile.GetTxAllowListStatus(state, adminAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListAdmin, res)
 			},
 		},
@@ -521,6 +558,201 @@ func TestTxAllowListRun(t *testing.T) {
 	}
 }
 
+func TestAllowListRoles(t *testing.T) {
+	adminAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+	managerAddr := common.HexToAddress("0x0Fa8EA536Be85F32724D57A37758761B86416123")
+	allowAddr := common.HexToAddress("0xAb5801a7D398351b8bE11C439e05C5B3259aeC9B")
+	tempAddr := common.HexToAddress("0xF60C45c607D0f41687c94C314d300f483661E13a")
+
+	for name, contract := range map[common.Address]precompile.StatefulPrecompiledContract{
+		precompile.ContractDeployerAllowListAddress: precompile.ContractDeployerAllowListPrecompile,
+		precompile.TxAllowListAddress:               precompile.TxAllowListPrecompile,
+		precompile.ContractNativeMinterAddress:      precompile.ContractNativeMinterPrecompile,
+		precompile.FeeConfigManagerAddress:          precompile.FeeConfigManagerPrecompile,
+	} {
+		precompileAddr := name
+		contract := contract
+		t.Run(precompileAddr.Hex(), func(t *testing.T) {
+			db := rawdb.NewMemoryDatabase()
+			state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			config := &precompile.AllowListConfig{AllowListAdmins: []common.Address{adminAddr}}
+			blockContext := &mockBlockContext{blockNumber: testBlockNumber, timestamp: 100}
+			config.Configure(params.TestChainConfig, state, blockContext, precompileAddr)
+			accessibleState := &mockAccessibleState{state: state, blockContext: blockContext}
+			run := func(caller common.Address, input []byte) ([]byte, error) {
+				ret, _, err := contract.Run(accessibleState, caller, precompileAddr, input, precompile.ModifyAllowListGasCost, false)
+				return ret, err
+			}
+			modify := func(caller common.Address, address common.Address, role precompile.AllowListRole) error {
+				input, err := precompile.PackModifyAllowList(address, role)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = run(caller, input)
+				return err
+			}
+			modifyUntil := func(caller common.Address, address common.Address, role precompile.AllowListRole, expiresAt int64) error {
+				input, err := precompile.PackModifyAllowListUntil(address, role, big.NewInt(expiresAt))
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = run(caller, input)
+				return err
+			}
+			readRole := func(address common.Address) precompile.AllowListRole {
+				ret, err := run(address, precompile.PackReadAllowList(address))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return precompile.AllowListRole(common.BytesToHash(ret))
+			}
+			holders := func() map[common.Address]precompile.AllowListRole {
+				ret, err := run(adminAddr, precompile.PackRoleHolderCount())
+				if err != nil {
+					t.Fatal(err)
+				}
+				count := new(big.Int).SetBytes(ret).Uint64()
+				roles := make(map[common.Address]precompile.AllowListRole, count)
+				for i := uint64(0); i < count; i++ {
+					ret, err := run(adminAddr, precompile.PackRoleHolderAt(i))
+					if err != nil {
+						t.Fatal(err)
+					}
+					address, role, _, err := precompile.UnpackRoleHolderAtOutput(ret)
+					if err != nil {
+						t.Fatal(err)
+					}
+					roles[address] = role
+				}
+				if _, err := run(adminAddr, precompile.PackRoleHolderAt(count)); !errors.Is(err, precompile.ErrRoleHolderIndexOutOfRange) {
+					t.Fatalf("expected %v, got %v", precompile.ErrRoleHolderIndexOutOfRange, err)
+				}
+				return roles
+			}
+
+			// Only admins can grant the manager role, which can only move addresses between no role and enabled.
+			assert.ErrorIs(t, modify(managerAddr, managerAddr, precompile.AllowListManager), precompile.ErrCannotModifyAllowList)
+			assert.NoError(t, modify(adminAddr, managerAddr, precompile.AllowListManager))
+			assert.Equal(t, precompile.AllowListManager, readRole(managerAddr))
+			assert.NoError(t, modify(managerAddr, allowAddr, precompile.AllowListEnabled))
+			assert.ErrorIs(t, modify(managerAddr, allowAddr, precompile.AllowListAdmin), precompile.ErrCannotModifyAllowList)
+			assert.ErrorIs(t, modify(managerAddr, tempAddr, precompile.AllowListManager), precompile.ErrCannotModifyAllowList)
+			assert.ErrorIs(t, modify(managerAddr, adminAddr, precompile.AllowListNoRole), precompile.ErrCannotModifyAllowList)
+			assert.ErrorIs(t, modify(allowAddr, tempAddr, precompile.AllowListEnabled), precompile.ErrCannotModifyAllowList)
+
+			// Roles granted with an expiry are lost at the expiry.
+			assert.ErrorIs(t, modifyUntil(managerAddr, tempAddr, precompile.AllowListEnabled, 100), precompile.ErrInvalidRoleExpiry)
+			assert.NoError(t, modifyUntil(managerAddr, tempAddr, precompile.AllowListEnabled, 150))
+			assert.Equal(t, precompile.AllowListEnabled, readRole(tempAddr))
+			ret, err := run(tempAddr, precompile.PackReadAllowListExpiry(tempAddr))
+			assert.NoError(t, err)
+			assert.Equal(t, common.BigToHash(big.NewInt(150)).Bytes(), ret)
+			assert.Equal(t, map[common.Address]precompile.AllowListRole{
+				adminAddr:   precompile.AllowListAdmin,
+				managerAddr: precompile.AllowListManager,
+				allowAddr:   precompile.AllowListEnabled,
+				tempAddr:    precompile.AllowListEnabled,
+			}, holders())
+
+			blockContext.timestamp = 150
+			assert.Equal(t, precompile.AllowListNoRole, readRole(tempAddr))
+			assert.Equal(t, precompile.AllowListEnabled, readRole(allowAddr))
+			assert.ErrorIs(t, modify(tempAddr, tempAddr, precompile.AllowListEnabled), precompile.ErrCannotModifyAllowList)
+
+			// An expired admin can no longer modify the allow list.
+			assert.NoError(t, modifyUntil(adminAddr, tempAddr, precompile.AllowListAdmin, 200))
+			assert.NoError(t, modify(tempAddr, allowAddr, precompile.AllowListManager))
+			blockContext.timestamp = 200
+			assert.ErrorIs(t, modify(tempAddr, allowAddr, precompile.AllowListNoRole), precompile.ErrCannotModifyAllowList)
+
+			// Removing a role removes the address from the role holders.
+			assert.NoError(t, modify(adminAddr, managerAddr, precompile.AllowListNoRole))
+			assert.Equal(t, map[common.Address]precompile.AllowListRole{
+				adminAddr: precompile.AllowListAdmin,
+				allowAddr: precompile.AllowListManager,
+				tempAddr:  precompile.AllowListAdmin,
+			}, holders())
+
+			// Every change emits a RoleSet event.
+			logs := state.Logs()
+			if !assert.Len(t, logs, 6) {
+				return
+			}
+			event := precompile.AllowListABI.Events["RoleSet"]
+			log := logs[2]
+			assert.Equal(t, precompileAddr, log.Address)
+			assert.Equal(t, []common.Hash{event.ID, tempAddr.Hash(), common.Hash(precompile.AllowListEnabled), managerAddr.Hash()}, log.Topics)
+			assert.Equal(t, common.BigToHash(big.NewInt(150)).Bytes(), log.Data)
+		})
+	}
+}
+
+func TestAllowListBeforePrecompileUpgrade(t *testing.T) {
+	adminAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+	allowAddr := common.HexToAddress("0xAb5801a7D398351b8bE11C439e05C5B3259aeC9B")
+	precompileAddr := precompile.ContractDeployerAllowListAddress
+
+	db := rawdb.NewMemoryDatabase()
+	state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainConfig := *params.TestChainConfig
+	chainConfig.PrecompileUpgradeTimestamp = big.NewInt(200)
+	blockContext := &mockBlockContext{blockNumber: testBlockNumber, timestamp: 100}
+	config := &precompile.AllowListConfig{AllowListAdmins: []common.Address{adminAddr}}
+	config.Configure(&chainConfig, state, blockContext, precompileAddr)
+	accessibleState := &mockAccessibleState{state: state, blockContext: blockContext, chainConfig: &chainConfig}
+	run := func(caller common.Address, input []byte, suppliedGas uint64) ([]byte, uint64, error) {
+		return precompile.ContractDeployerAllowListPrecompile.Run(accessibleState, caller, precompileAddr, input, suppliedGas, false)
+	}
+	setEnabled, err := precompile.PackModifyAllowList(allowAddr, precompile.AllowListEnabled)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Before the upgrade, the allow list only reads and writes roles at the gas costs from before the upgrade, and
+	// does not emit events.
+	_, remainingGas, err := run(adminAddr, setEnabled, precompile.LegacyModifyAllowListGasCost)
+	assert.NoError(t, err)
+	assert.Zero(t, remainingGas)
+	ret, remainingGas, err := run(adminAddr, precompile.PackReadAllowList(allowAddr), precompile.LegacyReadAllowListGasCost)
+	assert.NoError(t, err)
+	assert.Zero(t, remainingGas)
+	assert.Equal(t, common.Hash(precompile.AllowListEnabled).Bytes(), ret)
+	assert.Empty(t, state.Logs())
+
+	// The functions added by the upgrade do not exist yet.
+	setManager, err := precompile.PackModifyAllowList(allowAddr, precompile.AllowListManager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setEnabledUntil, err := precompile.PackModifyAllowListUntil(allowAddr, precompile.AllowListEnabled, big.NewInt(300))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range [][]byte{setManager, setEnabledUntil, precompile.PackReadAllowListExpiry(allowAddr), precompile.PackRoleHolderCount(), precompile.PackRoleHolderAt(0)} {
+		_, _, err := run(adminAddr, input, precompile.ModifyAllowListGasCost)
+		assert.ErrorContains(t, err, "invalid function selector")
+	}
+
+	// Roles granted before the upgrade are only enumerated once they are set again.
+	blockContext.timestamp = 200
+	ret, _, err = run(adminAddr, precompile.PackRoleHolderCount(), precompile.ReadRoleHolderCountGasCost)
+	assert.NoError(t, err)
+	assert.Equal(t, common.Hash{}.Bytes(), ret)
+	_, remainingGas, err = run(adminAddr, setEnabled, precompile.ModifyAllowListGasCost)
+	assert.NoError(t, err)
+	assert.Zero(t, remainingGas)
+	ret, _, err = run(adminAddr, precompile.PackRoleHolderCount(), precompile.ReadRoleHolderCountGasCost)
+	assert.NoError(t, err)
+	assert.Equal(t, common.BigToHash(common.Big1).Bytes(), ret)
+	assert.Len(t, state.Logs(), 1)
+}
+
 func TestContractNativeMinterRun(t *testing.T) {
 	type test struct {
 		caller         common.Address
@@ -568,7 +800,7 @@ func TestContractNativeMinterRun(t *testing.T) {
 			readOnly:    false,
 			expectedRes: []byte{},
 			assertState: func(t *testing.T, state *state.StateDB) {
-				res := precompile.GetContractNativeMinterStatus(state, allowAddr)
+				res := precompile.GetContractNativeMinterStatus(state, allowAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListEnabled, res)
 
 				assert.Equal(t, common.Big1, state.GetBalance(allowAddr), "expected minted funds")
@@ -588,7 +820,7 @@ func TestContractNativeMinterRun(t *testing.T) {
 			readOnly:    false,
 			expectedRes: []byte{},
 			assertState: func(t *testing.T, state *state.StateDB) {
-				res := precompile.GetContractNativeMinterStatus(state, adminAddr)
+				res := precompile.GetContractNativeMinterStatus(state, adminAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListAdmin, res)
 
 				assert.Equal(t, common.Big1, state.GetBalance(adminAddr), "expected minted funds")
@@ -608,7 +840,7 @@ func TestContractNativeMinterRun(t *testing.T) {
 			readOnly:    false,
 			expectedRes: []byte{},
 			assertState: func(t *testing.T, state *state.StateDB) {
-				res := precompile.GetContractNativeMinterStatus(state, adminAddr)
+				res := precompile.GetContractNativeMinterStatus(state, adminAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListAdmin, res)
 
 				assert.Equal(t, math.MaxBig256, state.GetBalance(adminAddr), "expected minted funds")
@@ -716,10 +948,10 @@ func TestContractNativeMinterRun(t *testing.T) {
 			readOnly:    false,
 			expectedRes: []byte{},
 			assertState: func(t *testing.T, state *state.StateDB) {
-				res := precompile.GetContractNativeMinterStatus(state, adminAddr)
+				res := precompile.GetContractNativeMinterStatus(state, adminAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListAdmin, res)
 
-				res = precompile.GetContractNativeMinterStatus(state, noRoleAddr)
+				res = precompile.GetContractNativeMinterStatus(state, noRoleAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListEnabled, res)
 			},
 		},
@@ -771,6 +1003,129 @@ func TestContractNativeMinterRun(t *testing.T) {
 	}
 }
 
+func TestContractNativeMinterSupply(t *testing.T) {
+	adminAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+	noRoleAddr := common.HexToAddress("0xF60C45c607D0f41687c94C314d300f483661E13a")
+
+	db := rawdb.NewMemoryDatabase()
+	state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &precompile.ContractNativeMinterConfig{
+		AllowListConfig: precompile.AllowListConfig{AllowListAdmins: []common.Address{adminAddr}},
+		MaxSupply:       big.NewInt(10),
+	}
+	blockContext := &mockBlockContext{blockNumber: common.Big0}
+	config.Configure(params.TestChainConfig, state, blockContext)
+	accessibleState := &mockAccessibleState{state: state, blockContext: blockContext}
+	run := func(caller common.Address, input []byte, readOnly bool) ([]byte, error) {
+		ret, _, err := precompile.ContractNativeMinterPrecompile.Run(accessibleState, caller, precompile.ContractNativeMinterAddress, input, precompile.MintGasCost, readOnly)
+		return ret, err
+	}
+	mint := func(to common.Address, amount int64) error {
+		input, err := precompile.PackMintInput(to, big.NewInt(amount))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = run(adminAddr, input, false)
+		return err
+	}
+	assertCounter := func(signature string, expected int64) {
+		ret, err := run(noRoleAddr, precompile.CalculateFunctionSelector(signature), true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, common.BigToHash(big.NewInt(expected)).Bytes(), ret, signature)
+	}
+
+	assertCounter("maxNativeCoinSupply()", 10)
+	if err := mint(noRoleAddr, 8); err != nil {
+		t.Fatal(err)
+	}
+	// Minting beyond the max supply fails without minting anything.
+	if err := mint(noRoleAddr, 3); !errors.Is(err, precompile.ErrMaxSupplyExceeded) {
+		t.Fatalf("expected %v, got %v", precompile.ErrMaxSupplyExceeded, err)
+	}
+	assert.Equal(t, big.NewInt(8), state.GetBalance(noRoleAddr))
+
+	// Any caller may burn its own coins, which makes room for minting again.
+	if _, err := run(noRoleAddr, precompile.PackBurnInput(big.NewInt(3)), false); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, big.NewInt(5), state.GetBalance(noRoleAddr))
+	if err := mint(adminAddr, 5); err != nil {
+		t.Fatal(err)
+	}
+	assertCounter("totalNativeCoinMinted()", 13)
+	assertCounter("totalNativeCoinBurned()", 3)
+
+	if _, err := run(noRoleAddr, precompile.PackBurnInput(big.NewInt(6)), false); !errors.Is(err, precompile.ErrInsufficientBalanceBurn) {
+		t.Fatalf("expected %v, got %v", precompile.ErrInsufficientBalanceBurn, err)
+	}
+	if _, err := run(noRoleAddr, precompile.PackBurnInput(big.NewInt(1)), true); !errors.Is(err, vmerrs.ErrWriteProtection) {
+		t.Fatalf("expected %v, got %v", vmerrs.ErrWriteProtection, err)
+	}
+	assert.Equal(t, big.NewInt(5), state.GetBalance(noRoleAddr))
+	assertCounter("totalNativeCoinBurned()", 3)
+
+	// Burning coins that were never counted as minted only counts the burn up to the supply, so that it does not
+	// make room for minting beyond the max supply.
+	state.AddBalance(adminAddr, big.NewInt(100))
+	if _, err := run(adminAddr, precompile.PackBurnInput(big.NewInt(100)), false); err != nil {
+		t.Fatal(err)
+	}
+	assertCounter("totalNativeCoinBurned()", 13)
+	if err := mint(adminAddr, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := mint(adminAddr, 1); !errors.Is(err, precompile.ErrMaxSupplyExceeded) {
+		t.Fatalf("expected %v, got %v", precompile.ErrMaxSupplyExceeded, err)
+	}
+
+	// Reconfiguring the native minter without a max supply lifts the cap.
+	config.MaxSupply = nil
+	config.Configure(params.TestChainConfig, state, blockContext)
+	assertCounter("maxNativeCoinSupply()", 0)
+	if err := mint(adminAddr, 1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestContractNativeMinterBeforePrecompileUpgrade(t *testing.T) {
+	adminAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+
+	db := rawdb.NewMemoryDatabase()
+	state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainConfig := *params.TestChainConfig
+	chainConfig.PrecompileUpgradeTimestamp = nil
+	config := &precompile.ContractNativeMinterConfig{
+		AllowListConfig: precompile.AllowListConfig{AllowListAdmins: []common.Address{adminAddr}},
+		MaxSupply:       big.NewInt(10),
+	}
+	blockContext := &mockBlockContext{blockNumber: common.Big0}
+	config.Configure(&chainConfig, state, blockContext)
+	accessibleState := &mockAccessibleState{state: state, blockContext: blockContext, chainConfig: &chainConfig}
+	run := func(input []byte) error {
+		_, _, err := precompile.ContractNativeMinterPrecompile.Run(accessibleState, adminAddr, precompile.ContractNativeMinterAddress, input, precompile.MintGasCost, false)
+		return err
+	}
+
+	// Before the upgrade, minting is neither capped nor counted, and burning does not exist yet.
+	input, err := precompile.PackMintInput(adminAddr, big.NewInt(20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, run(input))
+	assert.Equal(t, big.NewInt(20), state.GetBalance(adminAddr))
+	assert.Zero(t, precompile.GetNativeCoinMinted(state).Sign())
+	assert.ErrorContains(t, run(precompile.PackBurnInput(big.NewInt(1))), "invalid function selector")
+	assert.Equal(t, big.NewInt(20), state.GetBalance(adminAddr))
+}
+
 func TestFeeConfigManagerRun(t *testing.T) {
 	type test struct {
 		caller         common.Address
@@ -790,6 +1145,26 @@ func TestFeeConfigManagerRun(t *testing.T) {
 	allowAddr := common.HexToAddress("0xAb5801a7D398351b8bE11C439e05C5B3259aeC9B")
 	noRoleAddr := common.HexToAddress("0xF60C45c607D0f41687c94C314d300f483661E13a")
 
+	// configureBounds bounds the gas limit from above and the base fee change denominator from below.
+	configureBounds := func(t *testing.T, state *state.StateDB) {
+		config := &precompile.FeeConfigManagerConfig{
+			MinFeeConfig: &commontype.FeeConfig{BaseFeeChangeDenominator: big.NewInt(10)},
+			MaxFeeConfig: &commontype.FeeConfig{GasLimit: big.NewInt(10_000_000)},
+		}
+		config.Configure(params.TestChainConfig, state, &mockBlockContext{blockNumber: testBlockNumber})
+	}
+	// schedule schedules [testFeeConfig] to take effect at timestamp 10.
+	schedule := func(t *testing.T, state *state.StateDB) {
+		input, err := precompile.PackScheduleFeeConfig(big.NewInt(10), testFeeConfig)
+		if err != nil {
+			panic(err)
+		}
+		accessibleState := &mockAccessibleState{state: state, blockContext: &mockBlockContext{blockNumber: testBlockNumber}}
+		if _, _, err := precompile.FeeConfigManagerPrecompile.Run(accessibleState, adminAddr, precompile.FeeConfigManagerAddress, input, precompile.ScheduleFeeConfigGasCost, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
 	for name, test := range map[string]test{
 		"set config from no role fails": {
 			caller:         noRoleAddr,
@@ -819,7 +1194,7 @@ func TestFeeConfigManagerRun(t *testing.T) {
 			readOnly:    false,
 			expectedRes: []byte{},
 			assertState: func(t *testing.T, state *state.StateDB) {
-				res := precompile.GetFeeConfigManagerStatus(state, allowAddr)
+				res := precompile.GetFeeConfigManagerStatus(state, allowAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListEnabled, res)
 
 				feeConfig := precompile.GetStoredFeeConfig(state)
@@ -843,7 +1218,7 @@ func TestFeeConfigManagerRun(t *testing.T) {
 			expectedRes: []byte{},
 			expectedErr: "cannot be greater than maxBlockGasCost",
 			assertState: func(t *testing.T, state *state.StateDB) {
-				res := precompile.GetFeeConfigManagerStatus(state, allowAddr)
+				res := precompile.GetFeeConfigManagerStatus(state, allowAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListEnabled, res)
 
 				feeConfig := precompile.GetStoredFeeConfig(state)
@@ -864,7 +1239,7 @@ func TestFeeConfigManagerRun(t *testing.T) {
 			readOnly:    false,
 			expectedRes: []byte{},
 			assertState: func(t *testing.T, state *state.StateDB) {
-				res := precompile.GetFeeConfigManagerStatus(state, adminAddr)
+				res := precompile.GetFeeConfigManagerStatus(state, adminAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListAdmin, res)
 
 				feeConfig := precompile.GetStoredFeeConfig(state)
@@ -977,6 +1352,192 @@ func TestFeeConfigManagerRun(t *testing.T) {
 			readOnly:    false,
 			expectedErr: vmerrs.ErrOutOfGas.Error(),
 		},
+		"set config above max bound fails": {
+			caller:         allowAddr,
+			precompileAddr: precompile.FeeConfigManagerAddress,
+			preCondition:   configureBounds,
+			input: func() []byte {
+				feeConfig := testFeeConfig
+				feeConfig.GasLimit = big.NewInt(20_000_000)
+				input, err := precompile.PackSetFeeConfig(feeConfig)
+				if err != nil {
+					panic(err)
+				}
+				return input
+			},
+			suppliedGas: precompile.SetFeeConfigGasCost,
+			readOnly:    false,
+			expectedErr: "gasLimit = 20000000 is greater than 10000000",
+		},
+		"set config within bounds": {
+			caller:         allowAddr,
+			precompileAddr: precompile.FeeConfigManagerAddress,
+			preCondition:   configureBounds,
+			input: func() []byte {
+				input, err := precompile.PackSetFeeConfig(testFeeConfig)
+				if err != nil {
+					panic(err)
+				}
+				return input
+			},
+			suppliedGas: precompile.SetFeeConfigGasCost,
+			readOnly:    false,
+			expectedRes: []byte{},
+			assertState: func(t *testing.T, state *state.StateDB) {
+				assert.Equal(t, testFeeConfig, precompile.GetStoredFeeConfig(state))
+				logs := state.Logs()
+				assert.Len(t, logs, 1)
+				event := precompile.FeeConfigManagerABI.Events["FeeConfigChanged"]
+				assert.Equal(t, []common.Hash{event.ID, allowAddr.Hash()}, logs[0].Topics)
+				fields, err := event.Inputs.NonIndexed().Unpack(logs[0].Data)
+				assert.NoError(t, err)
+				assert.Equal(t, testFeeConfig.GasLimit, fields[0])
+			},
+		},
+		"schedule config from allow address": {
+			caller:         allowAddr,
+			precompileAddr: precompile.FeeConfigManagerAddress,
+			input: func() []byte {
+				input, err := precompile.PackScheduleFeeConfig(big.NewInt(10), testFeeConfig)
+				if err != nil {
+					panic(err)
+				}
+				return input
+			},
+			suppliedGas: precompile.ScheduleFeeConfigGasCost,
+			readOnly:    false,
+			expectedRes: []byte{},
+			assertState: func(t *testing.T, state *state.StateDB) {
+				feeConfig, effectiveAt := precompile.GetPendingFeeConfig(state)
+				assert.Equal(t, testFeeConfig, feeConfig)
+				assert.EqualValues(t, big.NewInt(10), effectiveAt)
+				// The current fee config is unchanged until the pending fee config takes effect.
+				assert.NotEqual(t, testFeeConfig, precompile.GetStoredFeeConfig(state))
+				assert.Zero(t, precompile.GetFeeConfigLastChangedAt(state).Sign())
+				logs := state.Logs()
+				assert.Len(t, logs, 1)
+				assert.Equal(t, precompile.FeeConfigManagerABI.Events["FeeConfigScheduled"].ID, logs[0].Topics[0])
+			},
+		},
+		"schedule config from no role fails": {
+			caller:         noRoleAddr,
+			precompileAddr: precompile.FeeConfigManagerAddress,
+			input: func() []byte {
+				input, err := precompile.PackScheduleFeeConfig(big.NewInt(10), testFeeConfig)
+				if err != nil {
+					panic(err)
+				}
+				return input
+			},
+			suppliedGas: precompile.ScheduleFeeConfigGasCost,
+			readOnly:    false,
+			expectedErr: precompile.ErrCannotChangeFee.Error(),
+		},
+		"schedule config at current timestamp fails": {
+			caller:         allowAddr,
+			precompileAddr: precompile.FeeConfigManagerAddress,
+			input: func() []byte {
+				input, err := precompile.PackScheduleFeeConfig(common.Big0, testFeeConfig)
+				if err != nil {
+					panic(err)
+				}
+				return input
+			},
+			suppliedGas: precompile.ScheduleFeeConfigGasCost,
+			readOnly:    false,
+			expectedErr: precompile.ErrInvalidFeeConfigSchedule.Error(),
+		},
+		"schedule invalid config fails": {
+			caller:         allowAddr,
+			precompileAddr: precompile.FeeConfigManagerAddress,
+			input: func() []byte {
+				feeConfig := testFeeConfig
+				feeConfig.MinBlockGasCost = new(big.Int).Mul(feeConfig.MaxBlockGasCost, common.Big2)
+				input, err := precompile.PackScheduleFeeConfig(big.NewInt(10), feeConfig)
+				if err != nil {
+					panic(err)
+				}
+				return input
+			},
+			suppliedGas: precompile.ScheduleFeeConfigGasCost,
+			readOnly:    false,
+			expectedErr: "cannot be greater than maxBlockGasCost",
+		},
+		"schedule config below min bound fails": {
+			caller:         allowAddr,
+			precompileAddr: precompile.FeeConfigManagerAddress,
+			preCondition:   configureBounds,
+			input: func() []byte {
+				feeConfig := testFeeConfig
+				feeConfig.BaseFeeChangeDenominator = common.Big1
+				input, err := precompile.PackScheduleFeeConfig(big.NewInt(10), feeConfig)
+				if err != nil {
+					panic(err)
+				}
+				return input
+			},
+			suppliedGas: precompile.ScheduleFeeConfigGasCost,
+			readOnly:    false,
+			expectedErr: "baseFeeChangeDenominator = 1 is less than 10",
+		},
+		"readOnly scheduleFeeConfig with allow role fails": {
+			caller:         allowAddr,
+			precompileAddr: precompile.FeeConfigManagerAddress,
+			input: func() []byte {
+				input, err := precompile.PackScheduleFeeConfig(big.NewInt(10), testFeeConfig)
+				if err != nil {
+					panic(err)
+				}
+				return input
+			},
+			suppliedGas: precompile.ScheduleFeeConfigGasCost,
+			readOnly:    true,
+			expectedErr: vmerrs.ErrWriteProtection.Error(),
+		},
+		"cancel pending config from allow address": {
+			caller:         allowAddr,
+			precompileAddr: precompile.FeeConfigManagerAddress,
+			preCondition:   schedule,
+			input:          precompile.PackCancelPendingFeeConfigInput,
+			suppliedGas:    precompile.CancelPendingFeeConfigGasCost,
+			readOnly:       false,
+			expectedRes:    []byte{},
+			assertState: func(t *testing.T, state *state.StateDB) {
+				_, effectiveAt := precompile.GetPendingFeeConfig(state)
+				assert.Nil(t, effectiveAt)
+				logs := state.Logs()
+				assert.Len(t, logs, 2, "the scheduled and cancelled events")
+				assert.Equal(t, []common.Hash{precompile.FeeConfigManagerABI.Events["PendingFeeConfigCancelled"].ID, allowAddr.Hash()}, logs[1].Topics)
+			},
+		},
+		"cancel pending config from no role fails": {
+			caller:         noRoleAddr,
+			precompileAddr: precompile.FeeConfigManagerAddress,
+			preCondition:   schedule,
+			input:          precompile.PackCancelPendingFeeConfigInput,
+			suppliedGas:    precompile.CancelPendingFeeConfigGasCost,
+			readOnly:       false,
+			expectedErr:    precompile.ErrCannotChangeFee.Error(),
+		},
+		"get pending config from non-enabled address": {
+			caller:         noRoleAddr,
+			precompileAddr: precompile.FeeConfigManagerAddress,
+			preCondition:   schedule,
+			input:          precompile.PackGetPendingFeeConfigInput,
+			suppliedGas:    precompile.GetPendingFeeConfigGasCost,
+			readOnly:       true,
+			expectedRes:    precompile.PackPendingFeeConfig(big.NewInt(10), testFeeConfig),
+			assertState:    func(t *testing.T, state *state.StateDB) {},
+		},
+		"get pending config without pending config": {
+			caller:         noRoleAddr,
+			precompileAddr: precompile.FeeConfigManagerAddress,
+			input:          precompile.PackGetPendingFeeConfigInput,
+			suppliedGas:    precompile.GetPendingFeeConfigGasCost,
+			readOnly:       true,
+			expectedRes:    make([]byte, common.HashLength*9),
+			assertState:    func(t *testing.T, state *state.StateDB) {},
+		},
 		"set allow role from admin": {
 			caller:         adminAddr,
 			precompileAddr: precompile.FeeConfigManagerAddress,
@@ -991,10 +1552,10 @@ func TestFeeConfigManagerRun(t *testing.T) {
 			readOnly:    false,
 			expectedRes: []byte{},
 			assertState: func(t *testing.T, state *state.StateDB) {
-				res := precompile.GetFeeConfigManagerStatus(state, adminAddr)
+				res := precompile.GetFeeConfigManagerStatus(state, adminAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListAdmin, res)
 
-				res = precompile.GetFeeConfigManagerStatus(state, noRoleAddr)
+				res = precompile.GetFeeConfigManagerStatus(state, noRoleAddr, common.Big0)
 				assert.Equal(t, precompile.AllowListEnabled, res)
 			},
 		},
@@ -1050,3 +1611,1768 @@ func TestFeeConfigManagerRun(t *testing.T) {
 		})
 	}
 }
+
+func TestFeeConfigManagerSchedule(t *testing.T) {
+	adminAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+
+	db := rawdb.NewMemoryDatabase()
+	state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainConfig := *params.TestChainConfig
+	chainConfig.PrecompileConfigs = params.Precompiles{
+		precompile.FeeConfigManagerConfigKey: &precompile.FeeConfigManagerConfig{
+			AllowListConfig: precompile.AllowListConfig{BlockTimestamp: common.Big0, AllowListAdmins: []common.Address{adminAddr}},
+		},
+	}
+	chainConfig.CheckConfigurePrecompiles(nil, &mockBlockContext{blockNumber: common.Big0}, state)
+
+	input, err := precompile.PackScheduleFeeConfig(big.NewInt(20), testFeeConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessibleState := &mockAccessibleState{state: state, blockContext: &mockBlockContext{blockNumber: common.Big1, timestamp: 10}}
+	if _, _, err := precompile.FeeConfigManagerPrecompile.Run(accessibleState, adminAddr, precompile.FeeConfigManagerAddress, input, precompile.ScheduleFeeConfigGasCost, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// A block before the pending fee config takes effect keeps the current fee config.
+	chainConfig.CheckConfigurePrecompiles(big.NewInt(10), &mockBlockContext{blockNumber: common.Big2, timestamp: 19}, state)
+	assert.Equal(t, params.DefaultFeeConfig, precompile.GetStoredFeeConfig(state))
+	_, effectiveAt := precompile.GetPendingFeeConfig(state)
+	assert.EqualValues(t, big.NewInt(20), effectiveAt)
+
+	// The first block at or after the timestamp applies it and records itself as the block the fee config changed at.
+	chainConfig.CheckConfigurePrecompiles(big.NewInt(19), &mockBlockContext{blockNumber: common.Big3, timestamp: 25}, state)
+	assert.Equal(t, testFeeConfig, precompile.GetStoredFeeConfig(state))
+	assert.EqualValues(t, common.Big3, precompile.GetFeeConfigLastChangedAt(state))
+	_, effectiveAt = precompile.GetPendingFeeConfig(state)
+	assert.Nil(t, effectiveAt)
+}
+
+func TestFeeConfigManagerDropsPendingConfigOutOfBounds(t *testing.T) {
+	adminAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+
+	db := rawdb.NewMemoryDatabase()
+	state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainConfig := *params.TestChainConfig
+	chainConfig.PrecompileConfigs = params.Precompiles{
+		precompile.FeeConfigManagerConfigKey: &precompile.FeeConfigManagerConfig{
+			AllowListConfig: precompile.AllowListConfig{BlockTimestamp: common.Big0, AllowListAdmins: []common.Address{adminAddr}},
+		},
+	}
+	chainConfig.UpgradeConfig = params.UpgradeConfig{
+		PrecompileUpgrades: []params.PrecompileUpgrade{
+			{
+				Config: &precompile.FeeConfigManagerConfig{
+					AllowListConfig: precompile.AllowListConfig{BlockTimestamp: big.NewInt(15), AllowListAdmins: []common.Address{adminAddr}},
+					MaxFeeConfig:    &commontype.FeeConfig{GasLimit: new(big.Int).Sub(testFeeConfig.GasLimit, common.Big1)},
+				},
+			},
+		},
+	}
+	chainConfig.CheckConfigurePrecompiles(nil, &mockBlockContext{blockNumber: common.Big0}, state)
+
+	input, err := precompile.PackScheduleFeeConfig(big.NewInt(20), testFeeConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessibleState := &mockAccessibleState{state: state, blockContext: &mockBlockContext{blockNumber: common.Big1, timestamp: 10}}
+	if _, _, err := precompile.FeeConfigManagerPrecompile.Run(accessibleState, adminAddr, precompile.FeeConfigManagerAddress, input, precompile.ScheduleFeeConfigGasCost, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// The reconfiguration lowers the max gas limit below the gas limit of the pending fee config, which is dropped
+	// rather than applied once it takes effect.
+	chainConfig.CheckConfigurePrecompiles(big.NewInt(10), &mockBlockContext{blockNumber: common.Big2, timestamp: 15}, state)
+	chainConfig.CheckConfigurePrecompiles(big.NewInt(15), &mockBlockContext{blockNumber: common.Big3, timestamp: 25}, state)
+	assert.Equal(t, params.DefaultFeeConfig, precompile.GetStoredFeeConfig(state))
+	_, effectiveAt := precompile.GetPendingFeeConfig(state)
+	assert.Nil(t, effectiveAt)
+}
+
+func TestFeeConfigManagerBeforePrecompileUpgrade(t *testing.T) {
+	adminAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+
+	db := rawdb.NewMemoryDatabase()
+	state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainConfig := *params.TestChainConfig
+	chainConfig.PrecompileUpgradeTimestamp = nil
+	config := &precompile.FeeConfigManagerConfig{
+		AllowListConfig: precompile.AllowListConfig{AllowListAdmins: []common.Address{adminAddr}},
+		MaxFeeConfig:    &commontype.FeeConfig{GasLimit: common.Big1},
+	}
+	blockContext := &mockBlockContext{blockNumber: common.Big0}
+	config.Configure(&chainConfig, state, blockContext)
+	accessibleState := &mockAccessibleState{state: state, blockContext: blockContext, chainConfig: &chainConfig}
+	run := func(input []byte, suppliedGas uint64) (uint64, error) {
+		_, remainingGas, err := precompile.FeeConfigManagerPrecompile.Run(accessibleState, adminAddr, precompile.FeeConfigManagerAddress, input, suppliedGas, false)
+		return remainingGas, err
+	}
+
+	// Before the upgrade, setting the fee config costs as much as it did before and neither checks the bounds nor
+	// emits an event.
+	input, err := precompile.PackSetFeeConfig(testFeeConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remainingGas, err := run(input, precompile.LegacySetFeeConfigGasCost)
+	assert.NoError(t, err)
+	assert.Zero(t, remainingGas)
+	assert.Equal(t, testFeeConfig, precompile.GetStoredFeeConfig(state))
+	assert.Empty(t, state.Logs())
+
+	// Scheduling fee configs does not exist yet.
+	schedule, err := precompile.PackScheduleFeeConfig(big.NewInt(10), testFeeConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range [][]byte{schedule, precompile.PackCancelPendingFeeConfigInput(), precompile.PackGetPendingFeeConfigInput()} {
+		_, err := run(input, precompile.ScheduleFeeConfigGasCost)
+		assert.ErrorContains(t, err, "invalid function selector")
+	}
+	_, effectiveAt := precompile.GetPendingFeeConfig(state)
+	assert.Nil(t, effectiveAt)
+}
+
+func TestFeeConfigManagerReconfigure(t *testing.T) {
+	adminAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+
+	db := rawdb.NewMemoryDatabase()
+	state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	maxFeeConfig := commontype.FeeConfig{GasLimit: big.NewInt(30_000_000)}
+	chainConfig := *params.TestChainConfig
+	chainConfig.PrecompileConfigs = params.Precompiles{
+		precompile.FeeConfigManagerConfigKey: &precompile.FeeConfigManagerConfig{
+			AllowListConfig: precompile.AllowListConfig{BlockTimestamp: common.Big0, AllowListAdmins: []common.Address{adminAddr}},
+		},
+	}
+	chainConfig.UpgradeConfig = params.UpgradeConfig{
+		PrecompileUpgrades: []params.PrecompileUpgrade{
+			{
+				Config: &precompile.FeeConfigManagerConfig{
+					AllowListConfig: precompile.AllowListConfig{BlockTimestamp: big.NewInt(20), AllowListAdmins: []common.Address{adminAddr}},
+					MaxFeeConfig:    &maxFeeConfig,
+				},
+			},
+		},
+	}
+	chainConfig.CheckConfigurePrecompiles(nil, &mockBlockContext{blockNumber: common.Big0}, state)
+
+	input, err := precompile.PackSetFeeConfig(testFeeConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessibleState := &mockAccessibleState{state: state, blockContext: &mockBlockContext{blockNumber: common.Big1, timestamp: 10}}
+	if _, _, err := precompile.FeeConfigManagerPrecompile.Run(accessibleState, adminAddr, precompile.FeeConfigManagerAddress, input, precompile.SetFeeConfigGasCost, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reconfiguring the fee config manager applies the new bounds, but keeps the fee config set by the admin
+	// rather than resetting it to the fee config of the chain config.
+	chainConfig.CheckConfigurePrecompiles(big.NewInt(10), &mockBlockContext{blockNumber: common.Big2, timestamp: 20}, state)
+	assert.Equal(t, testFeeConfig, precompile.GetStoredFeeConfig(state))
+	assert.EqualValues(t, common.Big1, precompile.GetFeeConfigLastChangedAt(state))
+	_, maxBounds := precompile.GetFeeConfigBounds(state)
+	assert.EqualValues(t, maxFeeConfig.GasLimit, maxBounds.GasLimit)
+}
+
+func TestContractDeployerAssetRun(t *testing.T) {
+	ownerAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+	otherAddr := common.HexToAddress("0xF60C45c607D0f41687c94C314d300f483661E13a")
+	noRoleAddr := common.HexToAddress("0x0Fa8EA536Be85F32724D57A37758761B86416123")
+
+	type test struct {
+		caller      common.Address
+		input       func() []byte
+		suppliedGas uint64
+		readOnly    bool
+
+		expectedErr    string
+		expectedRevert string
+
+		assertState func(t *testing.T, state *state.StateDB)
+	}
+
+	for name, test := range map[string]test{
+		"register asset": {
+			caller:      ownerAddr,
+			input:       precompile.PackRegisterAsset,
+			suppliedGas: precompile.RegisterAssetGasCost,
+			readOnly:    false,
+			assertState: func(t *testing.T, state *state.StateDB) {
+				assert.Equal(t, uint64(2), precompile.GetStoredAssetCount(state))
+				assets := precompile.GetStoredAssetsByOwner(state, ownerAddr)
+				assert.Len(t, assets, 1)
+				assert.Equal(t, ownerAddr, assets[0].Owner)
+				assert.Len(t, precompile.GetStoredAssetsByOwner(state, otherAddr), 1)
+
+				asset, err := precompile.GetStoredAsset(state, assets[0].Id)
+				assert.NoError(t, err)
+				assert.Equal(t, assets[0], asset)
+			},
+		},
+		"register asset derives deterministic ids": {
+			caller:      ownerAddr,
+			input:       precompile.PackRegisterAsset,
+			suppliedGas: precompile.RegisterAssetGasCost,
+			readOnly:    false,
+			assertState: func(t *testing.T, state *state.StateDB) {
+				assert.Equal(t, uint64(1), precompile.GetAssetRegistrationNonce(state, ownerAddr))
+				_, err := precompile.GetStoredAsset(state, precompile.DeriveAssetId(ownerAddr, 0))
+				assert.NoError(t, err)
+			},
+		},
+		"register asset with salt": {
+			caller: ownerAddr,
+			input: func() []byte {
+				return precompile.PackRegisterAssetWithSalt(common.Hash{'s', 'a', 'l', 't'})
+			},
+			suppliedGas: precompile.RegisterAssetGasCost,
+			readOnly:    false,
+			assertState: func(t *testing.T, state *state.StateDB) {
+				asset, err := precompile.GetStoredAsset(state, precompile.DeriveAssetIdWithSalt(ownerAddr, common.Hash{'s', 'a', 'l', 't'}))
+				assert.NoError(t, err)
+				assert.Equal(t, ownerAddr, asset.Owner)
+				// Salted registrations do not consume the registration nonce.
+				assert.Equal(t, uint64(0), precompile.GetAssetRegistrationNonce(state, ownerAddr))
+			},
+		},
+		"register asset with reused salt reverts": {
+			caller: otherAddr,
+			input: func() []byte {
+				return precompile.PackRegisterAssetWithSalt(common.Hash{'u', 's', 'e', 'd'})
+			},
+			suppliedGas:    precompile.RegisterAssetGasCost,
+			readOnly:       false,
+			expectedErr:    vmerrs.ErrExecutionReverted.Error(),
+			expectedRevert: precompile.ErrAssetAlreadyExists.Error(),
+		},
+		"register asset by non-enabled reverts": {
+			caller:      noRoleAddr,
+			input:       precompile.PackRegisterAsset,
+			suppliedGas: precompile.RegisterAssetGasCost,
+			readOnly:    false,
+			expectedErr: vmerrs.ErrExecutionReverted.Error(),
+		},
+		"register asset with salt by non-enabled reverts": {
+			caller: noRoleAddr,
+			input: func() []byte {
+				return precompile.PackRegisterAssetWithSalt(common.Hash{'s', 'a', 'l', 't'})
+			},
+			suppliedGas: precompile.RegisterAssetGasCost,
+			readOnly:    false,
+			expectedErr: vmerrs.ErrExecutionReverted.Error(),
+		},
+		"register asset with readOnly enabled": {
+			caller:      ownerAddr,
+			input:       precompile.PackRegisterAsset,
+			suppliedGas: precompile.RegisterAssetGasCost,
+			readOnly:    true,
+			expectedErr: vmerrs.ErrWriteProtection.Error(),
+		},
+		"register asset out of gas": {
+			caller:      ownerAddr,
+			input:       precompile.PackRegisterAsset,
+			suppliedGas: precompile.RegisterAssetGasCost - 1,
+			readOnly:    false,
+			expectedErr: vmerrs.ErrOutOfGas.Error(),
+		},
+		"get missing asset reverts": {
+			caller: ownerAddr,
+			input: func() []byte {
+				return precompile.PackGetAsset(common.Hash{1})
+			},
+			suppliedGas:    precompile.GetAssetGasCost,
+			readOnly:       true,
+			expectedErr:    vmerrs.ErrExecutionReverted.Error(),
+			expectedRevert: precompile.ErrAssetNotFound.Error(),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			db := rawdb.NewMemoryDatabase()
+			state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			precompile.SetContractDeployerAssetStatus(state, ownerAddr, precompile.AllowListEnabled)
+			precompile.SetContractDeployerAssetStatus(state, otherAddr, precompile.AllowListEnabled)
+
+			// Register an asset with a known salt up front so that reusing it can be tested.
+			blockContext := &mockBlockContext{blockNumber: testBlockNumber}
+			if _, _, err := precompile.ContractDeployerAssetPrecompile.Run(&mockAccessibleState{state: state, blockContext: blockContext}, otherAddr, precompile.ContractDeployerAssetAddress, precompile.PackRegisterAssetWithSalt(common.Hash{'u', 's', 'e', 'd'}), precompile.RegisterAssetGasCost, false); err != nil {
+				t.Fatal(err)
+			}
+
+			ret, remainingGas, err := precompile.ContractDeployerAssetPrecompile.Run(&mockAccessibleState{state: state, blockContext: blockContext}, test.caller, precompile.ContractDeployerAssetAddress, test.input(), test.suppliedGas, test.readOnly)
+			if len(test.expectedErr) != 0 {
+				if err == nil {
+					assert.Failf(t, "run expectedly passed without error", "expected error %q", test.expectedErr)
+				} else {
+					assert.True(t, strings.Contains(err.Error(), test.expectedErr), "expected error (%s) to contain substring (%s)", err, test.expectedErr)
+				}
+				if len(test.expectedRevert) != 0 {
+					reason, err := abi.UnpackRevert(ret)
+					assert.NoError(t, err)
+					assert.Contains(t, reason, test.expectedRevert)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, uint64(0), remainingGas)
+
+			test.assertState(t, state)
+		})
+	}
+}
+
+func TestContractDeployerAssetRevert(t *testing.T) {
+	ownerAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+
+	db := rawdb.NewMemoryDatabase()
+	state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Mark the precompile as non-empty as is done by CheckConfigure.
+	state.SetNonce(precompile.ContractDeployerAssetAddress, 1)
+	precompile.SetContractDeployerAssetStatus(state, ownerAddr, precompile.AllowListEnabled)
+	emptyRoot := state.IntermediateRoot(true)
+
+	run := func() {
+		blockContext := &mockBlockContext{blockNumber: testBlockNumber}
+		if _, _, err := precompile.ContractDeployerAssetPrecompile.Run(&mockAccessibleState{state: state, blockContext: blockContext}, ownerAddr, precompile.ContractDeployerAssetAddress, precompile.PackRegisterAsset(), precompile.RegisterAssetGasCost, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Reverting the snapshot must drop the registration along with the rest of the tx.
+	snapshot := state.Snapshot()
+	run()
+	assert.Equal(t, uint64(1), precompile.GetStoredAssetCount(state))
+	state.RevertToSnapshot(snapshot)
+	assert.Equal(t, uint64(0), precompile.GetStoredAssetCount(state))
+	assert.Empty(t, precompile.GetStoredAssetsByOwner(state, ownerAddr))
+	assert.Equal(t, emptyRoot, state.IntermediateRoot(true))
+
+	// A registration that is kept must be part of the state root.
+	run()
+	assert.NotEqual(t, emptyRoot, state.IntermediateRoot(true))
+}
+
+func TestContractDeployerAssetABI(t *testing.T) {
+	ownerAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+
+	db := rawdb.NewMemoryDatabase()
+	state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	precompile.SetContractDeployerAssetStatus(state, ownerAddr, precompile.AllowListEnabled)
+	accessibleState := &mockAccessibleState{state: state, blockContext: &mockBlockContext{blockNumber: testBlockNumber, timestamp: testTimestamp}}
+	run := func(input []byte, readOnly bool) []byte {
+		ret, _, err := precompile.ContractDeployerAssetPrecompile.Run(accessibleState, ownerAddr, precompile.ContractDeployerAssetAddress, input, 100_000, readOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ret
+	}
+
+	assetId, err := precompile.UnpackAssetIdOutput(run(precompile.PackRegisterAsset(), false))
+	assert.NoError(t, err)
+	assert.Equal(t, precompile.DeriveAssetId(ownerAddr, 0), assetId)
+
+	asset, err := precompile.UnpackAssetOutput(run(precompile.PackGetAsset(assetId), true))
+	assert.NoError(t, err)
+	expected := commontype.Asset{Id: assetId, Name: "-", Owner: ownerAddr, Location: "-"}
+	assert.Equal(t, expected, asset)
+
+	assets, err := precompile.UnpackAssetsOutput(run(precompile.PackGetAssetByAddress(ownerAddr), true))
+	assert.NoError(t, err)
+	assert.Equal(t, []commontype.Asset{expected}, assets)
+
+	assets, err = precompile.UnpackAssetsOutput(run(precompile.PackGetAllAssets(), true))
+	assert.NoError(t, err)
+	assert.Equal(t, []commontype.Asset{expected}, assets)
+
+	// Malformed asset ids are rejected instead of being silently truncated.
+	input, err := precompile.AssetABI.Pack("getAsset", "not an id")
+	assert.NoError(t, err)
+	_, _, err = precompile.ContractDeployerAssetPrecompile.Run(accessibleState, ownerAddr, precompile.ContractDeployerAssetAddress, input, 100_000, true)
+	assert.ErrorIs(t, err, precompile.ErrInvalidAssetId)
+}
+
+func TestContractDeployerAssetUpdate(t *testing.T) {
+	ownerAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+	adminAddr := common.HexToAddress("0x0Fa8EA536Be85F32724D57A37758761B86416123")
+	otherAddr := common.HexToAddress("0xF60C45c607D0f41687c94C314d300f483661E13a")
+	assetId := precompile.DeriveAssetId(ownerAddr, 0)
+
+	type test struct {
+		caller      common.Address
+		input       []byte
+		suppliedGas uint64
+		readOnly    bool
+
+		expectedErr    error
+		expectedRevert string
+
+		assertState func(t *testing.T, state *state.StateDB)
+	}
+
+	for name, test := range map[string]test{
+		"update name by owner": {
+			caller:      ownerAddr,
+			input:       precompile.PackUpdateName(assetId, "pallet"),
+			suppliedGas: precompile.UpdateAssetBaseGasCost + 2*precompile.AssetWriteGasCostPerSlot,
+			assertState: func(t *testing.T, state *state.StateDB) {
+				asset, err := precompile.GetStoredAsset(state, assetId)
+				assert.NoError(t, err)
+				assert.Equal(t, "pallet", asset.Name)
+				assert.Equal(t, "-", asset.Location)
+			},
+		},
+		"update location by admin": {
+			caller:      adminAddr,
+			input:       precompile.PackUpdateLocation(assetId, "a warehouse location that spans more than one slot"),
+			suppliedGas: precompile.UpdateAssetBaseGasCost + 10*precompile.AssetWriteGasCostPerSlot, // 3 for the location and 7 for the history record
+			assertState: func(t *testing.T, state *state.StateDB) {
+				asset, err := precompile.GetStoredAsset(state, assetId)
+				assert.NoError(t, err)
+				assert.Equal(t, "a warehouse location that spans more than one slot", asset.Location)
+				assert.Equal(t, []commontype.LocationRecord{
+					{Location: "a warehouse location that spans more than one slot", Timestamp: testTimestamp, Updater: adminAddr},
+				}, precompile.GetStoredLocationHistory(state, assetId, 0, 10))
+			},
+		},
+		"update location out of gas for history": {
+			caller:      ownerAddr,
+			input:       precompile.PackUpdateLocation(assetId, "warehouse"),
+			suppliedGas: precompile.UpdateAssetBaseGasCost + 7*precompile.AssetWriteGasCostPerSlot - 1,
+			expectedErr: vmerrs.ErrOutOfGas,
+		},
+		"update name by non-owner reverts": {
+			caller:         otherAddr,
+			input:          precompile.PackUpdateName(assetId, "stolen"),
+			suppliedGas:    precompile.UpdateAssetBaseGasCost,
+			expectedErr:    vmerrs.ErrExecutionReverted,
+			expectedRevert: precompile.ErrCannotModifyAsset.Error(),
+		},
+		"update missing asset reverts": {
+			caller:         ownerAddr,
+			input:          precompile.PackUpdateLocation(common.Hash{1}, "nowhere"),
+			suppliedGas:    precompile.UpdateAssetBaseGasCost,
+			expectedErr:    vmerrs.ErrExecutionReverted,
+			expectedRevert: precompile.ErrAssetNotFound.Error(),
+		},
+		"update name with readOnly enabled": {
+			caller:      ownerAddr,
+			input:       precompile.PackUpdateName(assetId, "pallet"),
+			suppliedGas: precompile.UpdateAssetBaseGasCost,
+			readOnly:    true,
+			expectedErr: vmerrs.ErrWriteProtection,
+		},
+		"update name out of gas": {
+			caller:      ownerAddr,
+			input:       precompile.PackUpdateName(assetId, "pallet"),
+			suppliedGas: precompile.UpdateAssetBaseGasCost + 2*precompile.AssetWriteGasCostPerSlot - 1,
+			expectedErr: vmerrs.ErrOutOfGas,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			db := rawdb.NewMemoryDatabase()
+			state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			precompile.SetContractDeployerAssetStatus(state, adminAddr, precompile.AllowListAdmin)
+			precompile.SetContractDeployerAssetStatus(state, ownerAddr, precompile.AllowListEnabled)
+
+			accessibleState := &mockAccessibleState{state: state, blockContext: &mockBlockContext{blockNumber: testBlockNumber, timestamp: testTimestamp}}
+			if _, _, err := precompile.ContractDeployerAssetPrecompile.Run(accessibleState, ownerAddr, precompile.ContractDeployerAssetAddress, precompile.PackRegisterAsset(), precompile.RegisterAssetGasCost, false); err != nil {
+				t.Fatal(err)
+			}
+
+			ret, remainingGas, err := precompile.ContractDeployerAssetPrecompile.Run(accessibleState, test.caller, precompile.ContractDeployerAssetAddress, test.input, test.suppliedGas, test.readOnly)
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+				if len(test.expectedRevert) != 0 {
+					reason, err := abi.UnpackRevert(ret)
+					assert.NoError(t, err)
+					assert.Contains(t, reason, test.expectedRevert)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, uint64(0), remainingGas)
+
+			test.assertState(t, state)
+		})
+	}
+}
+
+func TestContractDeployerAssetOwnership(t *testing.T) {
+	ownerAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+	operatorAddr := common.HexToAddress("0x0Fa8EA536Be85F32724D57A37758761B86416123")
+	receiverAddr := common.HexToAddress("0xF60C45c607D0f41687c94C314d300f483661E13a")
+	firstId := precompile.DeriveAssetId(ownerAddr, 0)
+	secondId := precompile.DeriveAssetId(ownerAddr, 1)
+
+	type call struct {
+		caller common.Address
+		input  []byte
+	}
+	type test struct {
+		setup  []call // calls run before the tested call
+		caller common.Address
+		input  []byte
+
+		expectedRevert string
+
+		assertState func(t *testing.T, state *state.StateDB)
+	}
+
+	assetIds := func(assets []commontype.Asset) []common.Hash {
+		ids := make([]common.Hash, 0, len(assets))
+		for _, asset := range assets {
+			ids = append(ids, asset.Id)
+		}
+		return ids
+	}
+
+	for name, test := range map[string]test{
+		"transfer by owner": {
+			caller: ownerAddr,
+			input:  precompile.PackTransferAsset(firstId, receiverAddr),
+			assertState: func(t *testing.T, state *state.StateDB) {
+				asset, err := precompile.GetStoredAsset(state, firstId)
+				assert.NoError(t, err)
+				assert.Equal(t, receiverAddr, asset.Owner)
+				assert.Equal(t, []common.Hash{secondId}, assetIds(precompile.GetStoredAssetsByOwner(state, ownerAddr)))
+				assert.Equal(t, []common.Hash{firstId}, assetIds(precompile.GetStoredAssetsByOwner(state, receiverAddr)))
+				assert.Equal(t, uint64(2), precompile.GetStoredAssetCount(state))
+			},
+		},
+		"transfer by approved address clears approval": {
+			setup:  []call{{ownerAddr, precompile.PackApproveAsset(secondId, operatorAddr)}},
+			caller: operatorAddr,
+			input:  precompile.PackTransferAsset(secondId, receiverAddr),
+			assertState: func(t *testing.T, state *state.StateDB) {
+				asset, err := precompile.GetStoredAsset(state, secondId)
+				assert.NoError(t, err)
+				assert.Equal(t, receiverAddr, asset.Owner)
+				assert.Equal(t, common.Address{}, precompile.GetAssetApproval(state, secondId))
+				assert.Equal(t, []common.Hash{firstId}, assetIds(precompile.GetStoredAssetsByOwner(state, ownerAddr)))
+			},
+		},
+		"transfer by operator approved for all": {
+			setup:  []call{{ownerAddr, precompile.PackSetApprovalForAll(operatorAddr, true)}},
+			caller: operatorAddr,
+			input:  precompile.PackTransferAsset(firstId, operatorAddr),
+			assertState: func(t *testing.T, state *state.StateDB) {
+				assert.True(t, precompile.IsAssetOperatorApproved(state, ownerAddr, operatorAddr))
+				assert.Equal(t, []common.Hash{firstId}, assetIds(precompile.GetStoredAssetsByOwner(state, operatorAddr)))
+			},
+		},
+		"transfer after approval for all is revoked reverts": {
+			setup: []call{
+				{ownerAddr, precompile.PackSetApprovalForAll(operatorAddr, true)},
+				{ownerAddr, precompile.PackSetApprovalForAll(operatorAddr, false)},
+			},
+			caller:         operatorAddr,
+			input:          precompile.PackTransferAsset(firstId, operatorAddr),
+			expectedRevert: precompile.ErrCannotTransferAsset.Error(),
+		},
+		"transfer by stranger reverts": {
+			caller:         receiverAddr,
+			input:          precompile.PackTransferAsset(firstId, receiverAddr),
+			expectedRevert: precompile.ErrCannotTransferAsset.Error(),
+		},
+		"transfer to zero address reverts": {
+			caller:         ownerAddr,
+			input:          precompile.PackTransferAsset(firstId, common.Address{}),
+			expectedRevert: precompile.ErrInvalidAssetRecipient.Error(),
+		},
+		"approve by stranger reverts": {
+			caller:         receiverAddr,
+			input:          precompile.PackApproveAsset(firstId, receiverAddr),
+			expectedRevert: precompile.ErrCannotApproveAsset.Error(),
+		},
+		"burn by owner": {
+			setup:  []call{{ownerAddr, precompile.PackUpdateLocation(firstId, "a warehouse location that spans more than one slot")}},
+			caller: ownerAddr,
+			input:  precompile.PackBurnAsset(firstId),
+			assertState: func(t *testing.T, state *state.StateDB) {
+				_, err := precompile.GetStoredAsset(state, firstId)
+				assert.ErrorIs(t, err, precompile.ErrAssetNotFound)
+				assert.Equal(t, uint64(1), precompile.GetStoredAssetCount(state))
+				assert.Equal(t, []common.Hash{secondId}, assetIds(precompile.GetStoredAssets(state)))
+				assert.Equal(t, []common.Hash{secondId}, assetIds(precompile.GetStoredAssetsByOwner(state, ownerAddr)))
+
+				// Every slot of the burned asset must be cleared.
+				err = state.ForEachStorage(precompile.ContractDeployerAssetAddress, func(key, value common.Hash) bool {
+					assert.NotEqual(t, precompile.DeriveAssetId(ownerAddr, 0), value, "slot %s still references burned asset", key)
+					return true
+				})
+				assert.NoError(t, err)
+			},
+		},
+		"burn by stranger reverts": {
+			caller:         receiverAddr,
+			input:          precompile.PackBurnAsset(firstId),
+			expectedRevert: precompile.ErrCannotTransferAsset.Error(),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			db := rawdb.NewMemoryDatabase()
+			state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			precompile.SetContractDeployerAssetStatus(state, ownerAddr, precompile.AllowListEnabled)
+
+			accessibleState := &mockAccessibleState{state: state, blockContext: &mockBlockContext{blockNumber: testBlockNumber, timestamp: testTimestamp}}
+			run := func(caller common.Address, input []byte) ([]byte, error) {
+				ret, _, err := precompile.ContractDeployerAssetPrecompile.Run(accessibleState, caller, precompile.ContractDeployerAssetAddress, input, 1_000_000, false)
+				return ret, err
+			}
+			for _, input := range [][]byte{precompile.PackRegisterAsset(), precompile.PackRegisterAsset()} {
+				if _, err := run(ownerAddr, input); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, call := range test.setup {
+				if _, err := run(call.caller, call.input); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ret, err := run(test.caller, test.input)
+			if len(test.expectedRevert) != 0 {
+				assert.ErrorIs(t, err, vmerrs.ErrExecutionReverted)
+				reason, err := abi.UnpackRevert(ret)
+				assert.NoError(t, err)
+				assert.Contains(t, reason, test.expectedRevert)
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			test.assertState(t, state)
+		})
+	}
+}
+
+func TestContractDeployerAssetEvents(t *testing.T) {
+	ownerAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+	receiverAddr := common.HexToAddress("0xF60C45c607D0f41687c94C314d300f483661E13a")
+	operatorAddr := common.HexToAddress("0x0Fa8EA536Be85F32724D57A37758761B86416123")
+	assetId := precompile.DeriveAssetId(ownerAddr, 0)
+
+	db := rawdb.NewMemoryDatabase()
+	state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	precompile.SetContractDeployerAssetStatus(state, ownerAddr, precompile.AllowListEnabled)
+	accessibleState := &mockAccessibleState{state: state, blockContext: &mockBlockContext{blockNumber: testBlockNumber, timestamp: testTimestamp}}
+	run := func(caller common.Address, input []byte) {
+		if _, _, err := precompile.ContractDeployerAssetPrecompile.Run(accessibleState, caller, precompile.ContractDeployerAssetAddress, input, 1_000_000, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run(ownerAddr, precompile.PackRegisterAsset())
+	run(ownerAddr, precompile.PackUpdateName(assetId, "pallet"))
+	run(ownerAddr, precompile.PackUpdateLocation(assetId, "warehouse"))
+	run(ownerAddr, precompile.PackTransferAsset(assetId, receiverAddr))
+
+	// A reverted call must not leave its log behind.
+	snapshot := state.Snapshot()
+	run(receiverAddr, precompile.PackUpdateName(assetId, "reverted"))
+	state.RevertToSnapshot(snapshot)
+
+	// Approvals are reported, as is clearing them by a transfer or burn.
+	run(receiverAddr, precompile.PackApproveAsset(assetId, operatorAddr))
+	run(receiverAddr, precompile.PackSetApprovalForAll(operatorAddr, true))
+	// Clearing the approval is charged for its event.
+	_, remainingGas, err := precompile.ContractDeployerAssetPrecompile.Run(accessibleState, operatorAddr, precompile.ContractDeployerAssetAddress, precompile.PackTransferAsset(assetId, ownerAddr), precompile.TransferAssetGasCost+precompile.AssetEventGasCost, false)
+	assert.NoError(t, err)
+	assert.Zero(t, remainingGas)
+	run(ownerAddr, precompile.PackApproveAsset(assetId, operatorAddr))
+	run(ownerAddr, precompile.PackBurnAsset(assetId))
+
+	logs := state.Logs()
+	if !assert.Len(t, logs, 11) {
+		return
+	}
+	type expectedLog struct {
+		event  string
+		topics []common.Hash
+		data   []interface{}
+	}
+	for i, expected := range []expectedLog{
+		{event: "AssetRegistered", topics: []common.Hash{ownerAddr.Hash()}, data: []interface{}{assetId.Hex(), "-"}},
+		{event: "AssetRenamed", topics: []common.Hash{ownerAddr.Hash()}, data: []interface{}{assetId.Hex(), "pallet"}},
+		{event: "AssetRelocated", topics: []common.Hash{ownerAddr.Hash()}, data: []interface{}{assetId.Hex(), "warehouse"}},
+		{event: "AssetTransferred", topics: []common.Hash{ownerAddr.Hash(), receiverAddr.Hash()}, data: []interface{}{assetId.Hex()}},
+		{event: "Approval", topics: []common.Hash{receiverAddr.Hash(), operatorAddr.Hash()}, data: []interface{}{assetId.Hex()}},
+		{event: "ApprovalForAll", topics: []common.Hash{receiverAddr.Hash(), operatorAddr.Hash()}, data: []interface{}{true}},
+		{event: "Approval", topics: []common.Hash{receiverAddr.Hash(), {}}, data: []interface{}{assetId.Hex()}},
+		{event: "AssetTransferred", topics: []common.Hash{receiverAddr.Hash(), ownerAddr.Hash()}, data: []interface{}{assetId.Hex()}},
+		{event: "Approval", topics: []common.Hash{ownerAddr.Hash(), operatorAddr.Hash()}, data: []interface{}{assetId.Hex()}},
+		{event: "Approval", topics: []common.Hash{ownerAddr.Hash(), {}}, data: []interface{}{assetId.Hex()}},
+		{event: "AssetTransferred", topics: []common.Hash{ownerAddr.Hash(), {}}, data: []interface{}{assetId.Hex()}},
+	} {
+		log := logs[i]
+		event := precompile.AssetABI.Events[expected.event]
+		assert.Equal(t, precompile.ContractDeployerAssetAddress, log.Address)
+		assert.Equal(t, append([]common.Hash{event.ID}, expected.topics...), log.Topics)
+		data, err := event.Inputs.NonIndexed().Unpack(log.Data)
+		assert.NoError(t, err)
+		assert.Equal(t, expected.data, data)
+		assert.Equal(t, testBlockNumber.Uint64(), log.BlockNumber)
+	}
+}
+
+func TestContractDeployerAssetLocationHistory(t *testing.T) {
+	ownerAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+	adminAddr := common.HexToAddress("0x0Fa8EA536Be85F32724D57A37758761B86416123")
+	assetId := precompile.DeriveAssetId(ownerAddr, 0)
+	evidence := common.HexToHash("0x4f3c1a")
+
+	db := rawdb.NewMemoryDatabase()
+	state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	precompile.SetContractDeployerAssetStatus(state, adminAddr, precompile.AllowListAdmin)
+	precompile.SetContractDeployerAssetStatus(state, ownerAddr, precompile.AllowListEnabled)
+	blockContext := &mockBlockContext{blockNumber: testBlockNumber, timestamp: testTimestamp}
+	accessibleState := &mockAccessibleState{state: state, blockContext: blockContext}
+	run := func(caller common.Address, input []byte, suppliedGas uint64) ([]byte, uint64) {
+		ret, remainingGas, err := precompile.ContractDeployerAssetPrecompile.Run(accessibleState, caller, precompile.ContractDeployerAssetAddress, input, suppliedGas, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ret, remainingGas
+	}
+
+	run(ownerAddr, precompile.PackRegisterAsset(), 1_000_000)
+	run(ownerAddr, precompile.PackUpdateLocation(assetId, "warehouse"), 1_000_000)
+	blockContext.timestamp++
+	run(adminAddr, precompile.PackUpdateLocationWithEvidence(assetId, "a port location that spans more than one slot", evidence), 1_000_000)
+	blockContext.timestamp++
+	run(ownerAddr, precompile.PackUpdateLocation(assetId, "store"), 1_000_000)
+
+	expected := []commontype.LocationRecord{
+		{Location: "warehouse", Timestamp: testTimestamp, Updater: ownerAddr},
+		{Location: "a port location that spans more than one slot", Timestamp: testTimestamp + 1, Updater: adminAddr, Evidence: evidence},
+		{Location: "store", Timestamp: testTimestamp + 2, Updater: ownerAddr},
+	}
+
+	ret, _ := run(ownerAddr, precompile.PackGetLocationHistoryLength(assetId), precompile.GetAssetGasCost)
+	assert.Equal(t, common.BigToHash(big.NewInt(3)).Bytes(), ret)
+
+	for name, test := range map[string]struct {
+		offset, limit uint64
+		expected      []commontype.LocationRecord
+		slots         uint64
+	}{
+		"all":          {offset: 0, limit: 10, expected: expected, slots: 3*4 + 1 + 2 + 1},
+		"first page":   {offset: 0, limit: 2, expected: expected[:2], slots: 2*4 + 1 + 2},
+		"second page":  {offset: 2, limit: 2, expected: expected[2:], slots: 4 + 1},
+		"past the end": {offset: 3, limit: 2, expected: []commontype.LocationRecord{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			suppliedGas := precompile.GetLocationHistoryBaseGasCost + test.slots*precompile.LocationRecordReadGasCostPerSlot
+			ret, remainingGas := run(ownerAddr, precompile.PackGetLocationHistory(assetId, test.offset, test.limit), suppliedGas)
+			assert.Zero(t, remainingGas)
+			records, err := precompile.UnpackLocationHistoryOutput(ret)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, records)
+			assert.Equal(t, test.expected, precompile.GetStoredLocationHistory(state, assetId, test.offset, test.limit))
+
+			_, _, err = precompile.ContractDeployerAssetPrecompile.Run(accessibleState, ownerAddr, precompile.ContractDeployerAssetAddress, precompile.PackGetLocationHistory(assetId, test.offset, test.limit), suppliedGas-1, true)
+			assert.ErrorIs(t, err, vmerrs.ErrOutOfGas)
+		})
+	}
+
+	// The history of a burned asset remains available.
+	run(ownerAddr, precompile.PackBurnAsset(assetId), 1_000_000)
+	assert.Equal(t, expected, precompile.GetStoredLocationHistory(state, assetId, 0, 10))
+
+	// A burned id cannot be registered again, so its history is never inherited by another asset.
+	salt := common.HexToHash("0x5a17")
+	saltedId := precompile.DeriveAssetIdWithSalt(ownerAddr, salt)
+	run(ownerAddr, precompile.PackRegisterAssetWithSalt(salt), 1_000_000)
+	run(ownerAddr, precompile.PackUpdateLocation(saltedId, "warehouse"), 1_000_000)
+	run(ownerAddr, precompile.PackBurnAsset(saltedId), 1_000_000)
+	ret, remainingGas, err := precompile.ContractDeployerAssetPrecompile.Run(accessibleState, ownerAddr, precompile.ContractDeployerAssetAddress, precompile.PackRegisterAssetWithSalt(salt), 1_000_000, false)
+	assert.ErrorIs(t, err, vmerrs.ErrExecutionReverted)
+	reason, err := abi.UnpackRevert(ret)
+	assert.NoError(t, err)
+	assert.Contains(t, reason, precompile.ErrAssetBurned.Error())
+	// A revert leaves the caller the gas it did not use.
+	assert.Equal(t, uint64(1_000_000)-precompile.RegisterAssetGasCost, remainingGas)
+	assert.Len(t, precompile.GetStoredLocationHistory(state, saltedId, 0, 10), 1)
+}
+
+func TestContractDeployerAssetConfig(t *testing.T) {
+	ownerAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+	schema := precompile.AssetMetadataSchema{"serial": 16, "description": 64}
+	palletId := common.HexToHash("0x01")
+	crateId := common.HexToHash("0x02")
+
+	for name, test := range map[string]struct {
+		config      precompile.AssetConfig
+		expectedErr error
+	}{
+		"valid": {
+			config: precompile.AssetConfig{
+				InitialAssets: []precompile.AssetAllocation{
+					{Id: palletId, Owner: ownerAddr, Metadata: map[string]string{"serial": "A-1"}},
+				},
+				MetadataSchema: schema,
+			},
+		},
+		"zero id": {
+			config:      precompile.AssetConfig{InitialAssets: []precompile.AssetAllocation{{Owner: ownerAddr}}},
+			expectedErr: precompile.ErrInvalidAssetAllocation,
+		},
+		"duplicate id": {
+			config: precompile.AssetConfig{
+				InitialAssets: []precompile.AssetAllocation{{Id: palletId, Owner: ownerAddr}, {Id: palletId, Owner: ownerAddr}},
+			},
+			expectedErr: precompile.ErrInvalidAssetAllocation,
+		},
+		"zero owner": {
+			config:      precompile.AssetConfig{InitialAssets: []precompile.AssetAllocation{{Id: palletId}}},
+			expectedErr: precompile.ErrInvalidAssetAllocation,
+		},
+		"zero maximum length": {
+			config:      precompile.AssetConfig{MetadataSchema: precompile.AssetMetadataSchema{"serial": 0}},
+			expectedErr: precompile.ErrInvalidMetadataSchema,
+		},
+		"metadata key not in schema": {
+			config: precompile.AssetConfig{
+				InitialAssets:  []precompile.AssetAllocation{{Id: palletId, Owner: ownerAddr, Metadata: map[string]string{"color": "red"}}},
+				MetadataSchema: schema,
+			},
+			expectedErr: precompile.ErrMetadataKeyNotAllowed,
+		},
+		"metadata value too long": {
+			config: precompile.AssetConfig{
+				InitialAssets:  []precompile.AssetAllocation{{Id: palletId, Owner: ownerAddr, Metadata: map[string]string{"serial": "a serial number that is too long"}}},
+				MetadataSchema: schema,
+			},
+			expectedErr: precompile.ErrMetadataValueTooLong,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, test.config.Verify(), test.expectedErr)
+		})
+	}
+
+	db := rawdb.NewMemoryDatabase()
+	state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &precompile.ContractDeployerAssetConfig{AssetConfig: precompile.AssetConfig{
+		InitialAssets: []precompile.AssetAllocation{
+			{Id: palletId, Owner: ownerAddr, Name: "pallet", Location: "warehouse", Metadata: map[string]string{"serial": "A-1"}},
+			{Id: crateId, Owner: ownerAddr},
+		},
+		MetadataSchema: schema,
+	}}
+	config.Configure(params.TestChainConfig, state, &mockBlockContext{blockNumber: common.Big0})
+
+	assert.Equal(t, []commontype.Asset{
+		{Id: palletId, Name: "pallet", Owner: ownerAddr, Location: "warehouse"},
+		{Id: crateId, Name: "-", Owner: ownerAddr, Location: "-"},
+	}, precompile.GetStoredAssetsByOwner(state, ownerAddr))
+	assert.Equal(t, "A-1", precompile.GetAssetMetadata(state, palletId, "serial"))
+	assert.Equal(t, map[string]uint64(schema), precompile.GetMetadataSchema(state))
+	assert.Equal(t, []commontype.LocationRecord{{Location: "warehouse"}}, precompile.GetStoredLocationHistory(state, palletId, 0, 10))
+	assert.Zero(t, precompile.GetLocationHistoryLength(state, crateId))
+}
+
+func TestContractDeployerAssetReconfigure(t *testing.T) {
+	ownerAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+	receiverAddr := common.HexToAddress("0x0Fa8EA536Be85F32724D57A37758761B86416123")
+	palletId := common.HexToHash("0x01")
+
+	db := rawdb.NewMemoryDatabase()
+	state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assetConfig := precompile.AssetConfig{
+		InitialAssets:  []precompile.AssetAllocation{{Id: palletId, Owner: ownerAddr}},
+		MetadataSchema: precompile.AssetMetadataSchema{"serial": 16, "description": 64},
+	}
+	newSchema := precompile.AssetMetadataSchema{"description": 32}
+	chainConfig := *params.TestChainConfig
+	chainConfig.PrecompileConfigs = params.Precompiles{
+		precompile.ContractDeployerAssetConfigKey: &precompile.ContractDeployerAssetConfig{
+			AllowListConfig: precompile.AllowListConfig{BlockTimestamp: common.Big0},
+			AssetConfig:     assetConfig,
+		},
+	}
+	chainConfig.UpgradeConfig = params.UpgradeConfig{
+		PrecompileUpgrades: []params.PrecompileUpgrade{
+			{
+				Config: &precompile.ContractDeployerAssetConfig{
+					AllowListConfig: precompile.AllowListConfig{BlockTimestamp: big.NewInt(20)},
+					AssetConfig:     precompile.AssetConfig{InitialAssets: assetConfig.InitialAssets, MetadataSchema: newSchema},
+				},
+			},
+		},
+	}
+	chainConfig.CheckConfigurePrecompiles(nil, &mockBlockContext{blockNumber: common.Big0}, state)
+
+	accessibleState := &mockAccessibleState{state: state, blockContext: &mockBlockContext{blockNumber: common.Big1, timestamp: 10}}
+	if _, _, err := precompile.ContractDeployerAssetPrecompile.Run(accessibleState, ownerAddr, precompile.ContractDeployerAssetAddress, precompile.PackTransferAsset(palletId, receiverAddr), precompile.TransferAssetGasCost, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reconfiguring the asset precompile with the same initial assets replaces the metadata schema, but does
+	// not register the initial assets again.
+	chainConfig.CheckConfigurePrecompiles(big.NewInt(10), &mockBlockContext{blockNumber: common.Big2, timestamp: 20}, state)
+	asset, err := precompile.GetStoredAsset(state, palletId)
+	assert.NoError(t, err)
+	assert.Equal(t, receiverAddr, asset.Owner)
+	assert.Equal(t, uint64(1), precompile.GetStoredAssetCount(state))
+	assert.Equal(t, map[string]uint64(newSchema), precompile.GetMetadataSchema(state))
+
+	// Keys dropped from the schema can no longer be set.
+	accessibleState.blockContext = &mockBlockContext{blockNumber: common.Big3, timestamp: 30}
+	ret, _, err := precompile.ContractDeployerAssetPrecompile.Run(accessibleState, receiverAddr, precompile.ContractDeployerAssetAddress, precompile.PackSetMetadata(palletId, "serial", "A-1"), precompile.UpdateAssetBaseGasCost, false)
+	assert.ErrorIs(t, err, vmerrs.ErrExecutionReverted)
+	reason, err := abi.UnpackRevert(ret)
+	assert.NoError(t, err)
+	assert.Contains(t, reason, precompile.ErrMetadataKeyNotAllowed.Error())
+}
+
+func TestContractDeployerAssetBurnClearsDroppedMetadata(t *testing.T) {
+	ownerAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+	palletId := common.HexToHash("0x01")
+	description := "a pallet of forty two crates of oranges"
+
+	// burnedRoot returns the state root after setting metadata on an asset if [setMetadata] is true, dropping the
+	// serial and shortening the description below the length of the stored description, and burning the asset.
+	burnedRoot := func(t *testing.T, setMetadata bool) common.Hash {
+		db := rawdb.NewMemoryDatabase()
+		state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assetConfig := precompile.AssetConfig{
+			InitialAssets:  []precompile.AssetAllocation{{Id: palletId, Owner: ownerAddr}},
+			MetadataSchema: precompile.AssetMetadataSchema{"serial": 16, "description": 64},
+		}
+		chainConfig := *params.TestChainConfig
+		chainConfig.PrecompileConfigs = params.Precompiles{
+			precompile.ContractDeployerAssetConfigKey: &precompile.ContractDeployerAssetConfig{
+				AllowListConfig: precompile.AllowListConfig{BlockTimestamp: common.Big0},
+				AssetConfig:     assetConfig,
+			},
+		}
+		chainConfig.UpgradeConfig = params.UpgradeConfig{
+			PrecompileUpgrades: []params.PrecompileUpgrade{
+				{
+					Config: &precompile.ContractDeployerAssetConfig{
+						AllowListConfig: precompile.AllowListConfig{BlockTimestamp: big.NewInt(20)},
+						AssetConfig:     precompile.AssetConfig{MetadataSchema: precompile.AssetMetadataSchema{"description": 32}},
+					},
+				},
+			},
+		}
+		chainConfig.CheckConfigurePrecompiles(nil, &mockBlockContext{blockNumber: common.Big0}, state)
+
+		accessibleState := &mockAccessibleState{state: state, blockContext: &mockBlockContext{blockNumber: common.Big1, timestamp: 10}}
+		run := func(input []byte) {
+			if _, _, err := precompile.ContractDeployerAssetPrecompile.Run(accessibleState, ownerAddr, precompile.ContractDeployerAssetAddress, input, 1_000_000, false); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if setMetadata {
+			run(precompile.PackSetMetadata(palletId, "serial", "A-1"))
+			run(precompile.PackSetMetadata(palletId, "description", description))
+			assert.Equal(t, description, precompile.GetAssetMetadata(state, palletId, "description"))
+		}
+
+		chainConfig.CheckConfigurePrecompiles(big.NewInt(10), &mockBlockContext{blockNumber: common.Big2, timestamp: 20}, state)
+		accessibleState.blockContext = &mockBlockContext{blockNumber: common.Big3, timestamp: 30}
+		// Values the schema no longer allows are hidden, and can still be cleared.
+		assert.Empty(t, precompile.GetAssetMetadata(state, palletId, "serial"))
+		assert.Empty(t, precompile.GetAssetMetadata(state, palletId, "description"))
+		if setMetadata {
+			run(precompile.PackSetMetadata(palletId, "serial", ""))
+		}
+
+		run(precompile.PackBurnAsset(palletId))
+		return state.IntermediateRoot(true)
+	}
+
+	// Burning the asset leaves no slot behind for the metadata it held, including values the schema dropped.
+	assert.Equal(t, burnedRoot(t, false), burnedRoot(t, true))
+}
+
+func TestContractDeployerAssetMetadata(t *testing.T) {
+	ownerAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+	adminAddr := common.HexToAddress("0x0Fa8EA536Be85F32724D57A37758761B86416123")
+	otherAddr := common.HexToAddress("0xF60C45c607D0f41687c94C314d300f483661E13a")
+	assetId := common.HexToHash("0x01")
+
+	type test struct {
+		caller      common.Address
+		input       []byte
+		suppliedGas uint64
+
+		expectedRes    []byte
+		expectedErr    error
+		expectedRevert string
+
+		assertState func(t *testing.T, state *state.StateDB)
+	}
+
+	for name, test := range map[string]test{
+		"set metadata by owner": {
+			caller: ownerAddr,
+			input:  precompile.PackSetMetadata(assetId, "description", "a pallet of forty two crates of oranges"),
+			// both slots of the description, its length and adding it to the metadata keys of the asset
+			suppliedGas: precompile.UpdateAssetBaseGasCost + 6*precompile.AssetWriteGasCostPerSlot,
+			assertState: func(t *testing.T, state *state.StateDB) {
+				assert.Equal(t, "a pallet of forty two crates of oranges", precompile.GetAssetMetadata(state, assetId, "description"))
+			},
+		},
+		"overwrite metadata by admin": {
+			caller:      adminAddr,
+			input:       precompile.PackSetMetadata(assetId, "serial", "B-2"),
+			suppliedGas: precompile.UpdateAssetBaseGasCost + 2*precompile.AssetWriteGasCostPerSlot,
+			assertState: func(t *testing.T, state *state.StateDB) {
+				assert.Equal(t, "B-2", precompile.GetAssetMetadata(state, assetId, "serial"))
+			},
+		},
+		"set metadata by non-owner reverts": {
+			caller:         otherAddr,
+			input:          precompile.PackSetMetadata(assetId, "serial", "B-2"),
+			suppliedGas:    precompile.UpdateAssetBaseGasCost,
+			expectedErr:    vmerrs.ErrExecutionReverted,
+			expectedRevert: precompile.ErrCannotModifyAsset.Error(),
+		},
+		"set metadata key not in schema reverts": {
+			caller:         ownerAddr,
+			input:          precompile.PackSetMetadata(assetId, "color", "red"),
+			suppliedGas:    precompile.UpdateAssetBaseGasCost,
+			expectedErr:    vmerrs.ErrExecutionReverted,
+			expectedRevert: precompile.ErrMetadataKeyNotAllowed.Error(),
+		},
+		"set metadata value too long reverts": {
+			caller:         ownerAddr,
+			input:          precompile.PackSetMetadata(assetId, "serial", "a serial number that is too long"),
+			suppliedGas:    precompile.UpdateAssetBaseGasCost,
+			expectedErr:    vmerrs.ErrExecutionReverted,
+			expectedRevert: precompile.ErrMetadataValueTooLong.Error(),
+		},
+		"set metadata out of gas": {
+			caller:      ownerAddr,
+			input:       precompile.PackSetMetadata(assetId, "serial", "B-2"),
+			suppliedGas: precompile.UpdateAssetBaseGasCost + 2*precompile.AssetWriteGasCostPerSlot - 1,
+			expectedErr: vmerrs.ErrOutOfGas,
+		},
+		"get metadata": {
+			caller:      otherAddr,
+			input:       precompile.PackGetMetadata(assetId, "serial"),
+			suppliedGas: precompile.GetAssetGasCost,
+			expectedRes: func() []byte {
+				res, _ := precompile.AssetABI.Methods["getMetadata"].Outputs.Pack("A-1")
+				return res
+			}(),
+		},
+		"burn clears metadata": {
+			caller: ownerAddr,
+			input:  precompile.PackBurnAsset(assetId),
+			// name, location, both slots of the serial and the metadata keys of the asset
+			suppliedGas: precompile.BurnAssetBaseGasCost + 7*precompile.AssetWriteGasCostPerSlot,
+			assertState: func(t *testing.T, state *state.StateDB) {
+				assert.Empty(t, precompile.GetAssetMetadata(state, assetId, "serial"))
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			db := rawdb.NewMemoryDatabase()
+			state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			config := &precompile.ContractDeployerAssetConfig{AssetConfig: precompile.AssetConfig{
+				InitialAssets: []precompile.AssetAllocation{
+					{Id: assetId, Owner: ownerAddr, Metadata: map[string]string{"serial": "A-1"}},
+				},
+				MetadataSchema: precompile.AssetMetadataSchema{"serial": 16, "description": 64},
+			}}
+			config.Configure(params.TestChainConfig, state, &mockBlockContext{blockNumber: common.Big0})
+			precompile.SetContractDeployerAssetStatus(state, adminAddr, precompile.AllowListAdmin)
+
+			accessibleState := &mockAccessibleState{state: state, blockContext: &mockBlockContext{blockNumber: testBlockNumber, timestamp: testTimestamp}}
+			ret, remainingGas, err := precompile.ContractDeployerAssetPrecompile.Run(accessibleState, test.caller, precompile.ContractDeployerAssetAddress, test.input, test.suppliedGas, false)
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+				if test.expectedRevert != "" {
+					reason, err := abi.UnpackRevert(ret)
+					assert.NoError(t, err)
+					assert.Contains(t, reason, test.expectedRevert)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Zero(t, remainingGas)
+			if test.expectedRes != nil {
+				assert.Equal(t, test.expectedRes, ret)
+			}
+
+			if test.assertState != nil {
+				test.assertState(t, state)
+			}
+		})
+	}
+}
+
+func TestContractDeployerAssetAllowList(t *testing.T) {
+	adminAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+	registrarAddr := common.HexToAddress("0xF60C45c607D0f41687c94C314d300f483661E13a")
+
+	db := rawdb.NewMemoryDatabase()
+	state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &precompile.ContractDeployerAssetConfig{AllowListConfig: precompile.AllowListConfig{
+		BlockTimestamp:  common.Big0,
+		AllowListAdmins: []common.Address{adminAddr},
+	}}
+	config.Configure(params.TestChainConfig, state, &mockBlockContext{blockNumber: common.Big0})
+	assert.Equal(t, precompile.AllowListAdmin, precompile.GetContractDeployerAssetStatus(state, adminAddr, common.Big0))
+
+	accessibleState := &mockAccessibleState{state: state, blockContext: &mockBlockContext{blockNumber: testBlockNumber, timestamp: testTimestamp}}
+	run := func(caller common.Address, input []byte) ([]byte, error) {
+		ret, _, err := precompile.ContractDeployerAssetPrecompile.Run(accessibleState, caller, precompile.ContractDeployerAssetAddress, input, 1_000_000, false)
+		return ret, err
+	}
+	modify := func(caller common.Address, addr common.Address, role precompile.AllowListRole) error {
+		input, err := precompile.PackModifyAllowList(addr, role)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = run(caller, input)
+		return err
+	}
+
+	// Addresses without a role cannot register assets or modify the allow list.
+	_, err = run(registrarAddr, precompile.PackRegisterAsset())
+	assert.ErrorIs(t, err, vmerrs.ErrExecutionReverted)
+	assert.ErrorIs(t, modify(registrarAddr, registrarAddr, precompile.AllowListAdmin), precompile.ErrCannotModifyAllowList)
+
+	// Once enabled by an admin, an address can register assets.
+	assert.NoError(t, modify(adminAddr, registrarAddr, precompile.AllowListEnabled))
+	ret, err := run(registrarAddr, precompile.PackReadAllowList(registrarAddr))
+	assert.NoError(t, err)
+	assert.Equal(t, common.Hash(precompile.AllowListEnabled).Bytes(), ret)
+	ret, err = run(registrarAddr, precompile.PackRegisterAsset())
+	assert.NoError(t, err)
+	assetId, err := precompile.UnpackAssetIdOutput(ret)
+	assert.NoError(t, err)
+
+	// Enabled addresses cannot modify the allow list, but admins can force-update any asset.
+	assert.ErrorIs(t, modify(registrarAddr, registrarAddr, precompile.AllowListAdmin), precompile.ErrCannotModifyAllowList)
+	_, err = run(adminAddr, precompile.PackUpdateName(assetId, "seized"))
+	assert.NoError(t, err)
+	asset, err := precompile.GetStoredAsset(state, assetId)
+	assert.NoError(t, err)
+	assert.Equal(t, "seized", asset.Name)
+
+	// Revoking the role prevents further registrations.
+	assert.NoError(t, modify(adminAddr, registrarAddr, precompile.AllowListNoRole))
+	_, err = run(registrarAddr, precompile.PackRegisterAsset())
+	assert.ErrorIs(t, err, vmerrs.ErrExecutionReverted)
+	assert.Equal(t, uint64(1), precompile.GetStoredAssetCount(state))
+}
+
+func TestContractDeployerAssetEnumeration(t *testing.T) {
+	ownerAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+	otherAddr := common.HexToAddress("0xF60C45c607D0f41687c94C314d300f483661E13a")
+
+	db := rawdb.NewMemoryDatabase()
+	state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	precompile.SetContractDeployerAssetStatus(state, ownerAddr, precompile.AllowListEnabled)
+	precompile.SetContractDeployerAssetStatus(state, otherAddr, precompile.AllowListEnabled)
+	accessibleState := &mockAccessibleState{state: state, blockContext: &mockBlockContext{blockNumber: testBlockNumber, timestamp: testTimestamp}}
+	run := func(caller common.Address, input []byte, suppliedGas uint64) ([]byte, uint64, error) {
+		return precompile.ContractDeployerAssetPrecompile.Run(accessibleState, caller, precompile.ContractDeployerAssetAddress, input, suppliedGas, false)
+	}
+
+	// Register three assets for the owner and one for another address in between.
+	ids := make([]common.Hash, 0, 4)
+	for _, caller := range []common.Address{ownerAddr, otherAddr, ownerAddr, ownerAddr} {
+		ret, _, err := run(caller, precompile.PackRegisterAsset(), precompile.RegisterAssetGasCost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assetId, err := precompile.UnpackAssetIdOutput(ret)
+		assert.NoError(t, err)
+		ids = append(ids, assetId)
+	}
+	asset := func(id common.Hash, owner common.Address) commontype.Asset {
+		return commontype.Asset{Id: id, Name: "-", Owner: owner, Location: "-"}
+	}
+	// Every asset with the default name and location is read from 6 slots: its list entry, owner and both strings.
+	assetGas := uint64(6 * precompile.AssetReadGasCostPerSlot)
+
+	ret, _, err := run(ownerAddr, precompile.PackTotalAssets(), precompile.GetAssetGasCost)
+	assert.NoError(t, err)
+	assert.Equal(t, common.BigToHash(big.NewInt(4)).Bytes(), ret)
+
+	ret, _, err = run(ownerAddr, precompile.PackBalanceOf(ownerAddr), precompile.GetAssetGasCost)
+	assert.NoError(t, err)
+	assert.Equal(t, common.BigToHash(big.NewInt(3)).Bytes(), ret)
+
+	ret, remainingGas, err := run(ownerAddr, precompile.PackAssetByIndex(1), precompile.GetAssetGasCost+assetGas)
+	assert.NoError(t, err)
+	assert.Zero(t, remainingGas)
+	byIndex, err := precompile.UnpackAssetOutput(ret)
+	assert.NoError(t, err)
+	assert.Equal(t, asset(ids[1], otherAddr), byIndex)
+
+	ret, _, err = run(ownerAddr, precompile.PackAssetByIndex(4), precompile.GetAssetGasCost)
+	assert.ErrorIs(t, err, vmerrs.ErrExecutionReverted)
+	reason, err := abi.UnpackRevert(ret)
+	assert.NoError(t, err)
+	assert.Contains(t, reason, precompile.ErrAssetIndexOutOfRange.Error())
+
+	for name, test := range map[string]struct {
+		offset, limit uint64
+		expected      []commontype.Asset
+	}{
+		"all":          {offset: 0, limit: 10, expected: []commontype.Asset{asset(ids[0], ownerAddr), asset(ids[2], ownerAddr), asset(ids[3], ownerAddr)}},
+		"first page":   {offset: 0, limit: 2, expected: []commontype.Asset{asset(ids[0], ownerAddr), asset(ids[2], ownerAddr)}},
+		"second page":  {offset: 2, limit: 2, expected: []commontype.Asset{asset(ids[3], ownerAddr)}},
+		"past the end": {offset: 3, limit: 2, expected: []commontype.Asset{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			suppliedGas := precompile.GetAssetGasCost + uint64(len(test.expected))*assetGas
+			ret, remainingGas, err := run(ownerAddr, precompile.PackAssetsOfOwner(ownerAddr, test.offset, test.limit), suppliedGas)
+			assert.NoError(t, err)
+			assert.Zero(t, remainingGas)
+			assets, err := precompile.UnpackAssetsOutput(ret)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, assets)
+			assert.Equal(t, test.expected, precompile.GetStoredAssetsOfOwner(state, ownerAddr, test.offset, test.limit))
+
+			if len(test.expected) > 0 {
+				_, _, err = run(ownerAddr, precompile.PackAssetsOfOwner(ownerAddr, test.offset, test.limit), suppliedGas-1)
+				assert.ErrorIs(t, err, vmerrs.ErrOutOfGas)
+			}
+		})
+	}
+
+	// Unbounded queries are charged for every asset they return.
+	_, _, err = run(ownerAddr, precompile.PackGetAllAssets(), precompile.GetAssetGasCost+4*assetGas-1)
+	assert.ErrorIs(t, err, vmerrs.ErrOutOfGas)
+	_, remainingGas, err = run(ownerAddr, precompile.PackGetAllAssets(), precompile.GetAssetGasCost+4*assetGas)
+	assert.NoError(t, err)
+	assert.Zero(t, remainingGas)
+
+	// Burning an asset moves the last asset into its place.
+	if _, _, err := run(ownerAddr, precompile.PackBurnAsset(ids[0]), 1_000_000); err != nil {
+		t.Fatal(err)
+	}
+	byIndex, err = precompile.GetStoredAssetByIndex(state, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, asset(ids[3], ownerAddr), byIndex)
+	assert.Equal(t, uint64(2), precompile.GetStoredAssetBalance(state, ownerAddr))
+}
+
+func TestNativeAssetManagerRun(t *testing.T) {
+	adminAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+	holderAddr := common.HexToAddress("0xF60C45c607D0f41687c94C314d300f483661E13a")
+	otherAddr := common.HexToAddress("0x0Fa8EA536Be85F32724D57A37758761B86416123")
+	assetId := common.Hash{'g', 'r', 'a', 'i', 'n'}
+
+	type test struct {
+		caller      common.Address
+		input       []byte
+		suppliedGas uint64
+		readOnly    bool
+
+		expectedRes    []byte
+		expectedErr    error
+		expectedRevert string
+
+		assertState func(t *testing.T, state *state.StateDB)
+	}
+
+	for name, test := range map[string]test{
+		"mint by enabled": {
+			caller:      adminAddr,
+			input:       precompile.PackMintNativeAsset(otherAddr, assetId, big.NewInt(5)),
+			suppliedGas: precompile.MintNativeAssetGasCost,
+			expectedRes: []byte{},
+			assertState: func(t *testing.T, state *state.StateDB) {
+				assert.Equal(t, big.NewInt(5), precompile.GetNativeAssetBalance(state, otherAddr, assetId))
+				assert.Equal(t, big.NewInt(105), precompile.GetNativeAssetTotalSupply(state, assetId))
+				// Native assets are held alongside the native coin without affecting it.
+				assert.Equal(t, common.Big0, state.GetBalance(otherAddr))
+			},
+		},
+		"mint by no role reverts": {
+			caller:         holderAddr,
+			input:          precompile.PackMintNativeAsset(holderAddr, assetId, big.NewInt(5)),
+			suppliedGas:    precompile.MintNativeAssetGasCost,
+			expectedErr:    vmerrs.ErrExecutionReverted,
+			expectedRevert: precompile.ErrCannotMintNativeAsset.Error(),
+		},
+		"mint beyond max supply reverts": {
+			caller:         adminAddr,
+			input:          precompile.PackMintNativeAsset(otherAddr, assetId, math.MaxBig256),
+			suppliedGas:    precompile.MintNativeAssetGasCost,
+			expectedErr:    vmerrs.ErrExecutionReverted,
+			expectedRevert: precompile.ErrNativeAssetSupplyOverflow.Error(),
+		},
+		"mint with readOnly enabled": {
+			caller:      adminAddr,
+			input:       precompile.PackMintNativeAsset(otherAddr, assetId, big.NewInt(5)),
+			suppliedGas: precompile.MintNativeAssetGasCost,
+			readOnly:    true,
+			expectedErr: vmerrs.ErrWriteProtection,
+		},
+		"mint out of gas": {
+			caller:      adminAddr,
+			input:       precompile.PackMintNativeAsset(otherAddr, assetId, big.NewInt(5)),
+			suppliedGas: precompile.MintNativeAssetGasCost - 1,
+			expectedErr: vmerrs.ErrOutOfGas,
+		},
+		"burn by enabled": {
+			caller:      adminAddr,
+			input:       precompile.PackBurnNativeAsset(assetId, big.NewInt(10)),
+			suppliedGas: precompile.BurnNativeAssetGasCost,
+			expectedRes: []byte{},
+			assertState: func(t *testing.T, state *state.StateDB) {
+				assert.Equal(t, big.NewInt(40), precompile.GetNativeAssetBalance(state, adminAddr, assetId))
+				assert.Equal(t, big.NewInt(90), precompile.GetNativeAssetTotalSupply(state, assetId))
+			},
+		},
+		"burn by no role reverts": {
+			caller:         holderAddr,
+			input:          precompile.PackBurnNativeAsset(assetId, big.NewInt(10)),
+			suppliedGas:    precompile.BurnNativeAssetGasCost,
+			expectedErr:    vmerrs.ErrExecutionReverted,
+			expectedRevert: precompile.ErrCannotBurnNativeAsset.Error(),
+		},
+		"burn more than balance reverts": {
+			caller:         adminAddr,
+			input:          precompile.PackBurnNativeAsset(assetId, big.NewInt(51)),
+			suppliedGas:    precompile.BurnNativeAssetGasCost,
+			expectedErr:    vmerrs.ErrExecutionReverted,
+			expectedRevert: precompile.ErrInsufficientNativeAsset.Error(),
+		},
+		"transfer by holder": {
+			caller:      holderAddr,
+			input:       precompile.PackTransferNativeAsset(otherAddr, assetId, big.NewInt(20)),
+			suppliedGas: precompile.TransferNativeAssetGasCost,
+			expectedRes: []byte{},
+			assertState: func(t *testing.T, state *state.StateDB) {
+				assert.Equal(t, big.NewInt(30), precompile.GetNativeAssetBalance(state, holderAddr, assetId))
+				assert.Equal(t, big.NewInt(20), precompile.GetNativeAssetBalance(state, otherAddr, assetId))
+				assert.Equal(t, big.NewInt(100), precompile.GetNativeAssetTotalSupply(state, assetId))
+			},
+		},
+		"transfer to self": {
+			caller:      holderAddr,
+			input:       precompile.PackTransferNativeAsset(holderAddr, assetId, big.NewInt(20)),
+			suppliedGas: precompile.TransferNativeAssetGasCost,
+			expectedRes: []byte{},
+			assertState: func(t *testing.T, state *state.StateDB) {
+				assert.Equal(t, big.NewInt(50), precompile.GetNativeAssetBalance(state, holderAddr, assetId))
+			},
+		},
+		"transfer more than balance reverts": {
+			caller:         holderAddr,
+			input:          precompile.PackTransferNativeAsset(otherAddr, assetId, big.NewInt(51)),
+			suppliedGas:    precompile.TransferNativeAssetGasCost,
+			expectedErr:    vmerrs.ErrExecutionReverted,
+			expectedRevert: precompile.ErrInsufficientNativeAsset.Error(),
+		},
+		"transfer of another asset reverts": {
+			caller:         holderAddr,
+			input:          precompile.PackTransferNativeAsset(otherAddr, common.Hash{'o', 't', 'h', 'e', 'r'}, big.NewInt(1)),
+			suppliedGas:    precompile.TransferNativeAssetGasCost,
+			expectedErr:    vmerrs.ErrExecutionReverted,
+			expectedRevert: precompile.ErrInsufficientNativeAsset.Error(),
+		},
+		"transfer to zero address reverts": {
+			caller:         holderAddr,
+			input:          precompile.PackTransferNativeAsset(common.Address{}, assetId, big.NewInt(1)),
+			suppliedGas:    precompile.TransferNativeAssetGasCost,
+			expectedErr:    vmerrs.ErrExecutionReverted,
+			expectedRevert: precompile.ErrInvalidNativeAssetRecipient.Error(),
+		},
+		"transfer with readOnly enabled": {
+			caller:      holderAddr,
+			input:       precompile.PackTransferNativeAsset(otherAddr, assetId, big.NewInt(1)),
+			suppliedGas: precompile.TransferNativeAssetGasCost,
+			readOnly:    true,
+			expectedErr: vmerrs.ErrWriteProtection,
+		},
+		"transfer and call account without code": {
+			caller:      holderAddr,
+			input:       precompile.PackTransferNativeAssetAndCall(otherAddr, assetId, big.NewInt(20), []byte{1, 2, 3}),
+			suppliedGas: precompile.TransferNativeAssetAndCallGasCost,
+			expectedRes: common.FromHex("0x00000000000000000000000000000000000000000000000000000000000000200000000000000000000000000000000000000000000000000000000000000000"),
+			assertState: func(t *testing.T, state *state.StateDB) {
+				assert.Equal(t, big.NewInt(20), precompile.GetNativeAssetBalance(state, otherAddr, assetId))
+			},
+		},
+		"read balance": {
+			caller:      otherAddr,
+			input:       precompile.PackNativeAssetBalance(holderAddr, assetId),
+			suppliedGas: precompile.GetNativeAssetBalanceGasCost,
+			readOnly:    true,
+			expectedRes: common.BigToHash(big.NewInt(50)).Bytes(),
+		},
+		"read total supply": {
+			caller:      otherAddr,
+			input:       precompile.PackNativeAssetTotalSupply(assetId),
+			suppliedGas: precompile.GetNativeAssetBalanceGasCost,
+			readOnly:    true,
+			expectedRes: common.BigToHash(big.NewInt(100)).Bytes(),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			db := rawdb.NewMemoryDatabase()
+			state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			precompile.SetNativeAssetManagerStatus(state, adminAddr, precompile.AllowListEnabled)
+
+			// Mint the starting balances of the admin and the holder.
+			accessibleState := &mockAccessibleState{state: state, blockContext: &mockBlockContext{blockNumber: testBlockNumber}}
+			for _, addr := range []common.Address{adminAddr, holderAddr} {
+				if _, _, err := precompile.NativeAssetManagerPrecompile.Run(accessibleState, adminAddr, precompile.NativeAssetManagerAddress, precompile.PackMintNativeAsset(addr, assetId, big.NewInt(50)), precompile.MintNativeAssetGasCost, false); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ret, remainingGas, err := precompile.NativeAssetManagerPrecompile.Run(accessibleState, test.caller, precompile.NativeAssetManagerAddress, test.input, test.suppliedGas, test.readOnly)
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+				if len(test.expectedRevert) != 0 {
+					reason, err := abi.UnpackRevert(ret)
+					assert.NoError(t, err)
+					assert.Contains(t, reason, test.expectedRevert)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, uint64(0), remainingGas)
+			assert.Equal(t, test.expectedRes, ret)
+
+			if test.assertState != nil {
+				test.assertState(t, state)
+			}
+		})
+	}
+}
+
+func TestNativeAssetManagerEvents(t *testing.T) {
+	adminAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+	holderAddr := common.HexToAddress("0xF60C45c607D0f41687c94C314d300f483661E13a")
+	assetId := common.Hash{'g', 'r', 'a', 'i', 'n'}
+
+	db := rawdb.NewMemoryDatabase()
+	state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	precompile.SetNativeAssetManagerStatus(state, adminAddr, precompile.AllowListEnabled)
+	accessibleState := &mockAccessibleState{state: state, blockContext: &mockBlockContext{blockNumber: testBlockNumber}}
+	run := func(caller common.Address, input []byte) {
+		if _, _, err := precompile.NativeAssetManagerPrecompile.Run(accessibleState, caller, precompile.NativeAssetManagerAddress, input, 1_000_000, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run(adminAddr, precompile.PackMintNativeAsset(holderAddr, assetId, big.NewInt(3)))
+	run(holderAddr, precompile.PackTransferNativeAsset(adminAddr, assetId, big.NewInt(2)))
+	run(adminAddr, precompile.PackBurnNativeAsset(assetId, big.NewInt(1)))
+
+	logs := state.Logs()
+	if !assert.Len(t, logs, 3) {
+		return
+	}
+	event := precompile.NativeAssetABI.Events["NativeAssetTransferred"]
+	for i, expected := range []struct {
+		from, to common.Address
+		amount   int64
+	}{
+		{from: common.Address{}, to: holderAddr, amount: 3},
+		{from: holderAddr, to: adminAddr, amount: 2},
+		{from: adminAddr, to: common.Address{}, amount: 1},
+	} {
+		log := logs[i]
+		assert.Equal(t, precompile.NativeAssetManagerAddress, log.Address)
+		assert.Equal(t, []common.Hash{event.ID, expected.from.Hash(), expected.to.Hash(), assetId}, log.Topics)
+		assert.Equal(t, common.BigToHash(big.NewInt(expected.amount)).Bytes(), log.Data)
+	}
+}
+
+func TestContractCallAllowListRun(t *testing.T) {
+	adminAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+	managerAddr := common.HexToAddress("0xF60C45c607D0f41687c94C314d300f483661E13a")
+	otherAddr := common.HexToAddress("0x0Fa8EA536Be85F32724D57A37758761B86416123")
+	targetAddr := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	restricted := [4]byte{0xde, 0xad, 0xbe, 0xef}
+	unrestricted := [4]byte{0xca, 0xfe, 0xba, 0xbe}
+
+	type test struct {
+		caller      common.Address
+		input       []byte
+		suppliedGas uint64
+		readOnly    bool
+
+		expectedRes    []byte
+		expectedErr    error
+		expectedRevert string
+
+		assertState func(t *testing.T, state *state.StateDB)
+	}
+
+	for name, test := range map[string]test{
+		"restrict by admin": {
+			caller:      adminAddr,
+			input:       precompile.PackRestrictFunction(targetAddr, unrestricted),
+			suppliedGas: precompile.RestrictFunctionGasCost,
+			expectedRes: []byte{},
+			assertState: func(t *testing.T, state *state.StateDB) {
+				assert.True(t, precompile.IsFunctionRestricted(state, targetAddr, unrestricted[:]))
+				assert.False(t, precompile.IsFunctionCallAllowed(state, targetAddr, unrestricted[:], otherAddr))
+				_, err := precompile.CheckContractCall(state, otherAddr, targetAddr, unrestricted[:])
+				assert.ErrorIs(t, err, precompile.ErrContractCallNotAllowed)
+
+				logs := state.Logs()
+				event := precompile.ContractCallAllowListABI.Events["FunctionRestrictionSet"]
+				log := logs[len(logs)-1]
+				assert.Equal(t, []common.Hash{event.ID, targetAddr.Hash(), common.BytesToHash(common.RightPadBytes(unrestricted[:], common.HashLength))}, log.Topics)
+				assert.Equal(t, common.BigToHash(common.Big1).Bytes(), log.Data)
+			},
+		},
+		"restrict by manager reverts": {
+			caller:         managerAddr,
+			input:          precompile.PackRestrictFunction(targetAddr, unrestricted),
+			suppliedGas:    precompile.RestrictFunctionGasCost,
+			expectedErr:    vmerrs.ErrExecutionReverted,
+			expectedRevert: precompile.ErrCannotRestrictFunction.Error(),
+		},
+		"restrict with readOnly enabled": {
+			caller:      adminAddr,
+			input:       precompile.PackRestrictFunction(targetAddr, unrestricted),
+			suppliedGas: precompile.RestrictFunctionGasCost,
+			readOnly:    true,
+			expectedErr: vmerrs.ErrWriteProtection,
+		},
+		"restrict out of gas": {
+			caller:      adminAddr,
+			input:       precompile.PackRestrictFunction(targetAddr, unrestricted),
+			suppliedGas: precompile.RestrictFunctionGasCost - 1,
+			expectedErr: vmerrs.ErrOutOfGas,
+		},
+		"unrestrict by admin": {
+			caller:      adminAddr,
+			input:       precompile.PackUnrestrictFunction(targetAddr, restricted),
+			suppliedGas: precompile.RestrictFunctionGasCost,
+			expectedRes: []byte{},
+			assertState: func(t *testing.T, state *state.StateDB) {
+				assert.False(t, precompile.IsFunctionRestricted(state, targetAddr, restricted[:]))
+				assert.True(t, precompile.IsFunctionCallAllowed(state, targetAddr, restricted[:], otherAddr))
+			},
+		},
+		"set caller by manager": {
+			caller:      managerAddr,
+			input:       precompile.PackSetFunctionCaller(targetAddr, restricted, otherAddr, true),
+			suppliedGas: precompile.SetFunctionCallerGasCost,
+			expectedRes: []byte{},
+			assertState: func(t *testing.T, state *state.StateDB) {
+				assert.True(t, precompile.IsFunctionCallAllowed(state, targetAddr, restricted[:], otherAddr))
+				_, err := precompile.CheckContractCall(state, otherAddr, targetAddr, restricted[:])
+				assert.NoError(t, err)
+			},
+		},
+		"set caller by no role reverts": {
+			caller:         otherAddr,
+			input:          precompile.PackSetFunctionCaller(targetAddr, restricted, otherAddr, true),
+			suppliedGas:    precompile.SetFunctionCallerGasCost,
+			expectedErr:    vmerrs.ErrExecutionReverted,
+			expectedRevert: precompile.ErrCannotSetFunctionCaller.Error(),
+		},
+		"disallow caller by admin": {
+			caller:      adminAddr,
+			input:       precompile.PackSetFunctionCaller(targetAddr, restricted, managerAddr, false),
+			suppliedGas: precompile.SetFunctionCallerGasCost,
+			expectedRes: []byte{},
+			assertState: func(t *testing.T, state *state.StateDB) {
+				assert.False(t, precompile.IsFunctionCallAllowed(state, targetAddr, restricted[:], managerAddr))
+			},
+		},
+		"read restricted": {
+			caller:      otherAddr,
+			input:       precompile.PackIsFunctionRestricted(targetAddr, restricted),
+			suppliedGas: precompile.IsFunctionRestrictedGasCost,
+			readOnly:    true,
+			expectedRes: common.BigToHash(common.Big1).Bytes(),
+		},
+		"read call allowed": {
+			caller:      otherAddr,
+			input:       precompile.PackIsFunctionCallAllowed(targetAddr, restricted, managerAddr),
+			suppliedGas: precompile.IsFunctionCallAllowedGasCost,
+			readOnly:    true,
+			expectedRes: common.BigToHash(common.Big1).Bytes(),
+		},
+		"read call not allowed": {
+			caller:      otherAddr,
+			input:       precompile.PackIsFunctionCallAllowed(targetAddr, restricted, otherAddr),
+			suppliedGas: precompile.IsFunctionCallAllowedGasCost,
+			readOnly:    true,
+			expectedRes: common.Hash{}.Bytes(),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			db := rawdb.NewMemoryDatabase()
+			state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			precompile.SetContractCallAllowListStatus(state, adminAddr, precompile.AllowListAdmin)
+			precompile.SetContractCallAllowListStatus(state, managerAddr, precompile.AllowListManager)
+
+			// Restrict the function to the manager.
+			accessibleState := &mockAccessibleState{state: state, blockContext: &mockBlockContext{blockNumber: testBlockNumber}}
+			for _, input := range [][]byte{
+				precompile.PackRestrictFunction(targetAddr, restricted),
+				precompile.PackSetFunctionCaller(targetAddr, restricted, managerAddr, true),
+			} {
+				if _, _, err := precompile.ContractCallAllowListPrecompile.Run(accessibleState, adminAddr, precompile.ContractCallAllowListAddress, input, 1_000_000, false); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ret, remainingGas, err := precompile.ContractCallAllowListPrecompile.Run(accessibleState, test.caller, precompile.ContractCallAllowListAddress, test.input, test.suppliedGas, test.readOnly)
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+				if len(test.expectedRevert) != 0 {
+					reason, err := abi.UnpackRevert(ret)
+					assert.NoError(t, err)
+					assert.Contains(t, reason, test.expectedRevert)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, uint64(0), remainingGas)
+			assert.Equal(t, test.expectedRes, ret)
+
+			if test.assertState != nil {
+				test.assertState(t, state)
+			}
+		})
+	}
+}
+
+func TestContractCallAllowListReconfigure(t *testing.T) {
+	adminAddr := common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+	callerAddr := common.HexToAddress("0x0Fa8EA536Be85F32724D57A37758761B86416123")
+	newCallerAddr := common.HexToAddress("0xF60C45c607D0f41687c94C314d300f483661E13a")
+	targetAddr := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	removed := [4]byte{0xde, 0xad, 0xbe, 0xef}
+	kept := [4]byte{0xca, 0xfe, 0xba, 0xbe}
+	setByAdmin := [4]byte{0xf0, 0x0d, 0xf0, 0x0d}
+
+	db := rawdb.NewMemoryDatabase()
+	state, err := state.New(common.Hash{}, state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainConfig := *params.TestChainConfig
+	chainConfig.PrecompileConfigs = params.Precompiles{
+		precompile.ContractCallAllowListConfigKey: &precompile.ContractCallAllowListConfig{
+			AllowListConfig: precompile.AllowListConfig{BlockTimestamp: common.Big0, AllowListAdmins: []common.Address{adminAddr}},
+			Restrictions: []precompile.ContractCallRestriction{
+				{Contract: targetAddr, Selector: removed[:], Callers: []common.Address{callerAddr}},
+				{Contract: targetAddr, Selector: kept[:], Callers: []common.Address{callerAddr}},
+			},
+		},
+	}
+	chainConfig.UpgradeConfig = params.UpgradeConfig{
+		PrecompileUpgrades: []params.PrecompileUpgrade{
+			{
+				Config: &precompile.ContractCallAllowListConfig{
+					AllowListConfig: precompile.AllowListConfig{BlockTimestamp: big.NewInt(20), AllowListAdmins: []common.Address{adminAddr}},
+					Restrictions: []precompile.ContractCallRestriction{
+						{Contract: targetAddr, Selector: kept[:], Callers: []common.Address{newCallerAddr}},
+					},
+				},
+			},
+		},
+	}
+	chainConfig.CheckConfigurePrecompiles(nil, &mockBlockContext{blockNumber: common.Big0}, state)
+
+	accessibleState := &mockAccessibleState{state: state, blockContext: &mockBlockContext{blockNumber: common.Big1, timestamp: 10}}
+	if _, _, err := precompile.ContractCallAllowListPrecompile.Run(accessibleState, adminAddr, precompile.ContractCallAllowListAddress, precompile.PackRestrictFunction(targetAddr, setByAdmin), precompile.RestrictFunctionGasCost, false); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, precompile.IsFunctionRestricted(state, targetAddr, setByAdmin[:]))
+
+	// Reconfiguring the contract call allow list replaces the existing restrictions and allowed callers, including
+	// those set through the precompile, with the restrictions of the new config, while keeping the roles.
+	chainConfig.CheckConfigurePrecompiles(big.NewInt(10), &mockBlockContext{blockNumber: common.Big2, timestamp: 20}, state)
+	assert.False(t, precompile.IsFunctionRestricted(state, targetAddr, removed[:]))
+	assert.False(t, precompile.IsFunctionRestricted(state, targetAddr, setByAdmin[:]))
+	assert.True(t, precompile.IsFunctionRestricted(state, targetAddr, kept[:]))
+	assert.False(t, precompile.IsFunctionCallAllowed(state, targetAddr, kept[:], callerAddr))
+	assert.True(t, precompile.IsFunctionCallAllowed(state, targetAddr, kept[:], newCallerAddr))
+	assert.Equal(t, precompile.AllowListAdmin, precompile.GetContractCallAllowListStatus(state, adminAddr, common.Big0))
+}
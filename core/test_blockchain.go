@@ -1546,25 +1546,30 @@ func TestStatefulPrecompiles(t *testing.T, create func(db ethdb.Database, chainC
 	genesisBalance := new(big.Int).Mul(big.NewInt(1000000), big.NewInt(params.Ether))
 	config := *params.TestChainConfig
 	// Set all of the required config parameters
-	config.ContractDeployerAllowListConfig = precompile.ContractDeployerAllowListConfig{
-		AllowListConfig: precompile.AllowListConfig{
-			BlockTimestamp: big.NewInt(0),
-			AllowListAdmins: []common.Address{
-				addr1,
+	config.PrecompileConfigs = params.Precompiles{
+		precompile.ContractDeployerAllowListConfigKey: &precompile.ContractDeployerAllowListConfig{
+			AllowListConfig: precompile.AllowListConfig{
+				BlockTimestamp: big.NewInt(0),
+				AllowListAdmins: []common.Address{
+					addr1,
+				},
 			},
 		},
-	}
-	config.FeeManagerConfig = precompile.FeeConfigManagerConfig{
-		AllowListConfig: precompile.AllowListConfig{
-			BlockTimestamp: big.NewInt(0),
-			AllowListAdmins: []common.Address{
-				addr1,
+		precompile.FeeConfigManagerConfigKey: &precompile.FeeConfigManagerConfig{
+			AllowListConfig: precompile.AllowListConfig{
+				BlockTimestamp: big.NewInt(0),
+				AllowListAdmins: []common.Address{
+					addr1,
+				},
 			},
 		},
-	}
-	config.ContractDeployerAssetConfig = precompile.ContractDeployerAssetConfig{
-		AssetConfig: precompile.AssetConfig{
-			BlockTimestamp: big.NewInt(0),
+		precompile.ContractDeployerAssetConfigKey: &precompile.ContractDeployerAssetConfig{
+			AllowListConfig: precompile.AllowListConfig{
+				BlockTimestamp: big.NewInt(0),
+				AllowListAdmins: []common.Address{
+					addr1,
+				},
+			},
 		},
 	}
 	gspec := &Genesis{
@@ -1628,22 +1633,22 @@ func TestStatefulPrecompiles(t *testing.T, create func(db ethdb.Database, chainC
 				gen.AddTx(signedTx)
 			},
 			verifyState: func(sdb *state.StateDB) error {
-				res := precompile.GetContractDeployerAllowListStatus(sdb, addr1)
+				res := precompile.GetContractDeployerAllowListStatus(sdb, addr1, common.Big0)
 				if precompile.AllowListAdmin != res {
 					return fmt.Errorf("unexpected allow list status for addr1 %s, expected %s", res, precompile.AllowListAdmin)
 				}
-				res = precompile.GetContractDeployerAllowListStatus(sdb, addr2)
+				res = precompile.GetContractDeployerAllowListStatus(sdb, addr2, common.Big0)
 				if precompile.AllowListAdmin != res {
 					return fmt.Errorf("unexpected allow list status for addr2 %s, expected %s", res, precompile.AllowListAdmin)
 				}
 				return nil
 			},
 			verifyGenesis: func(sdb *state.StateDB) {
-				res := precompile.GetContractDeployerAllowListStatus(sdb, addr1)
+				res := precompile.GetContractDeployerAllowListStatus(sdb, addr1, common.Big0)
 				if precompile.AllowListAdmin != res {
 					t.Fatalf("unexpected allow list status for addr1 %s, expected %s", res, precompile.AllowListAdmin)
 				}
-				res = precompile.GetContractDeployerAllowListStatus(sdb, addr2)
+				res = precompile.GetContractDeployerAllowListStatus(sdb, addr2, common.Big0)
 				if precompile.AllowListNoRole != res {
 					t.Fatalf("unexpected allow list status for addr2 %s, expected %s", res, precompile.AllowListNoRole)
 				}
@@ -1674,7 +1679,7 @@ func TestStatefulPrecompiles(t *testing.T, create func(db ethdb.Database, chainC
 				gen.AddTx(signedTx)
 			},
 			verifyState: func(sdb *state.StateDB) error {
-				res := precompile.GetFeeConfigManagerStatus(sdb, addr1)
+				res := precompile.GetFeeConfigManagerStatus(sdb, addr1, common.Big0)
 				assert.Equal(precompile.AllowListAdmin, res)
 
 				storedConfig := precompile.GetStoredFeeConfig(sdb)
@@ -1686,7 +1691,7 @@ func TestStatefulPrecompiles(t *testing.T, create func(db ethdb.Database, chainC
 				return nil
 			},
 			verifyGenesis: func(sdb *state.StateDB) {
-				res := precompile.GetFeeConfigManagerStatus(sdb, addr1)
+				res := precompile.GetFeeConfigManagerStatus(sdb, addr1, common.Big0)
 				assert.Equal(precompile.AllowListAdmin, res)
 
 				feeConfig, _, err := blockchain.GetFeeConfigAt(blockchain.Genesis().Header())
@@ -1694,6 +1699,50 @@ func TestStatefulPrecompiles(t *testing.T, create func(db ethdb.Database, chainC
 				assert.EqualValues(config.FeeConfig, feeConfig)
 			},
 		},
+		"asset register": {
+			addTx: func(gen *BlockGen) {
+				feeCap := new(big.Int).Add(gen.BaseFee(), tip)
+				tx := types.NewTx(&types.DynamicFeeTx{
+					ChainID:   params.TestChainConfig.ChainID,
+					Nonce:     gen.TxNonce(addr1),
+					To:        &precompile.ContractDeployerAssetAddress,
+					Gas:       3_000_000,
+					Value:     common.Big0,
+					GasFeeCap: feeCap,
+					GasTipCap: tip,
+					Data:      precompile.PackRegisterAsset(),
+				})
+
+				signedTx, err := types.SignTx(tx, signer, key1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				gen.AddTx(signedTx)
+			},
+			verifyState: func(sdb *state.StateDB) error {
+				asset, err := precompile.GetStoredAsset(sdb, precompile.DeriveAssetId(addr1, 0))
+				if err != nil {
+					return err
+				}
+				assert.Equal(addr1, asset.Owner)
+
+				// The registration must be reported in the receipt of the registering transaction.
+				var found bool
+				for _, receipt := range blockchain.GetReceiptsByHash(blockchain.CurrentBlock().Hash()) {
+					for _, log := range receipt.Logs {
+						if log.Address == precompile.ContractDeployerAssetAddress && log.Topics[0] == precompile.AssetABI.Events["AssetRegistered"].ID {
+							found = true
+							assert.Equal(addr1.Hash(), log.Topics[1])
+						}
+					}
+				}
+				assert.True(found, "missing AssetRegistered log")
+				return nil
+			},
+			verifyGenesis: func(sdb *state.StateDB) {
+				assert.Equal(uint64(0), precompile.GetStoredAssetCount(sdb))
+			},
+		},
 	}
 
 	// Generate chain of blocks using [genDB] instead of [chainDB] to avoid writing
@@ -157,9 +157,11 @@ func init() {
 		PrecompileAllNativeAddresses[k] = struct{}{}
 	}
 
-	// Ensure that this package will panic during init if there is a conflict present with the declared
-	// precompile addresses.
-	for _, k := range precompile.UsedAddresses {
+	// Ensure that this package will panic during init if there is a conflict present with the registered
+	// precompile addresses. RegisterModule only accepts addresses in the reserved ranges, so this holds for
+	// modules registered later as well.
+	for _, module := range precompile.RegisteredModules() {
+		k := module.Address
 		if _, ok := PrecompileAllNativeAddresses[k]; ok {
 			panic(fmt.Errorf("precompile address collides with existing native address: %s", k))
 		}
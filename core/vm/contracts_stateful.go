@@ -4,10 +4,16 @@
 package vm
 
 import (
+	"math/big"
+
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ir4tech/webb-evm/params"
 	"github.com/ir4tech/webb-evm/precompile"
+	"github.com/ir4tech/webb-evm/vmerrs"
 )
 
+var _ precompile.PrecompileAccessibleState = &precompileAccessibleState{}
+
 // wrappedPrecompiledContract implements StatefulPrecompiledContract by wrapping stateless native precompiled contracts
 // in Ethereum.
 type wrappedPrecompiledContract struct {
@@ -29,3 +35,124 @@ func (w *wrappedPrecompiledContract) Run(accessibleState precompile.PrecompileAc
 func RunStatefulPrecompiledContract(precompile precompile.StatefulPrecompiledContract, accessibleState precompile.PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
 	return precompile.Run(accessibleState, caller, addr, input, suppliedGas, readOnly)
 }
+
+// precompileAccessibleState implements PrecompileAccessibleState for a single call to a stateful precompile.
+type precompileAccessibleState struct {
+	evm *EVM
+	// self is the address the precompile is executing as, which is the caller of any calls made by the precompile.
+	// It is the address of the precompile unless the precompile is called with CALLCODE or DELEGATECALL.
+	self     common.Address
+	value    *big.Int
+	readOnly bool
+}
+
+// newPrecompileAccessibleState returns the state accessible to a stateful precompile executing as [self] in a call
+// transferring [value].
+func (evm *EVM) newPrecompileAccessibleState(self common.Address, value *big.Int, readOnly bool) *precompileAccessibleState {
+	if value == nil {
+		value = new(big.Int)
+	}
+	return &precompileAccessibleState{
+		evm:      evm,
+		self:     self,
+		value:    value,
+		readOnly: readOnly,
+	}
+}
+
+// delegateValue returns the value of the call that delegated to a precompile from [caller], which a precompile
+// called with DELEGATECALL executes with.
+func delegateValue(caller ContractRef) *big.Int {
+	if contract, ok := caller.(*Contract); ok {
+		return contract.Value()
+	}
+	return nil
+}
+
+// GetStateDB returns the evm's StateDB
+func (s *precompileAccessibleState) GetStateDB() precompile.StateDB {
+	return s.evm.GetStateDB()
+}
+
+// GetBlockContext returns the evm's BlockContext
+func (s *precompileAccessibleState) GetBlockContext() precompile.BlockContext {
+	return s.evm.GetBlockContext()
+}
+
+// GetChainConfig returns the evm's ChainConfig
+func (s *precompileAccessibleState) GetChainConfig() precompile.ChainConfig {
+	return s.evm.GetChainConfig()
+}
+
+// AddLog adds a log emitted by the stateful precompile at [addr] to the current transaction
+func (s *precompileAccessibleState) AddLog(addr common.Address, topics []common.Hash, data []byte) {
+	s.evm.AddLog(addr, topics, data)
+}
+
+// Origin returns the sender of the current transaction
+func (s *precompileAccessibleState) Origin() common.Address {
+	return s.evm.Origin
+}
+
+// Value returns the value transferred by the call to the precompile
+func (s *precompileAccessibleState) Value() *big.Int {
+	return new(big.Int).Set(s.value)
+}
+
+// Call calls [addr] from [s.self], charging the same access and value transfer costs as the CALL opcode under
+// EIP-2929 against [gas]. The call is made one level deeper than the caller of the precompile, so that recursion
+// between precompiles and contracts is bounded by the call depth limit.
+func (s *precompileAccessibleState) Call(addr common.Address, input []byte, gas uint64, value *big.Int) (ret []byte, remainingGas uint64, err error) {
+	if value == nil {
+		value = new(big.Int)
+	}
+	if s.readOnly {
+		// Like the CALL opcode in a static context, only calls that do not transfer value are allowed and they
+		// are static.
+		if value.Sign() != 0 {
+			return nil, gas, vmerrs.ErrWriteProtection
+		}
+		return s.StaticCall(addr, input, gas)
+	}
+	cost := s.accessCost(addr)
+	if value.Sign() != 0 {
+		cost += params.CallValueTransferGas
+		if s.evm.StateDB.Empty(addr) {
+			cost += params.CallNewAccountGas
+		}
+	}
+	if gas < cost {
+		return nil, 0, vmerrs.ErrOutOfGas
+	}
+	gas -= cost
+	if value.Sign() != 0 {
+		gas += params.CallStipend
+	}
+
+	s.evm.depth++
+	defer func() { s.evm.depth-- }()
+	return s.evm.Call(AccountRef(s.self), addr, input, gas, value)
+}
+
+// StaticCall calls [addr] from [s.self] in a read only context, charging the same access cost as the STATICCALL
+// opcode under EIP-2929 against [gas].
+func (s *precompileAccessibleState) StaticCall(addr common.Address, input []byte, gas uint64) (ret []byte, remainingGas uint64, err error) {
+	cost := s.accessCost(addr)
+	if gas < cost {
+		return nil, 0, vmerrs.ErrOutOfGas
+	}
+	gas -= cost
+
+	s.evm.depth++
+	defer func() { s.evm.depth-- }()
+	return s.evm.StaticCall(AccountRef(s.self), addr, input, gas)
+}
+
+// accessCost adds [addr] to the access list and returns the cost of accessing it.
+func (s *precompileAccessibleState) accessCost(addr common.Address) uint64 {
+	if s.evm.StateDB.AddressInAccessList(addr) {
+		return params.WarmStorageReadCostEIP2929
+	}
+	s.evm.StateDB.AddAddressToAccessList(addr)
+	return params.ColdAccountAccessCostEIP2929
+}
@@ -0,0 +1,341 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package vm
+
+import (
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ir4tech/webb-evm/accounts/abi"
+	"github.com/ir4tech/webb-evm/core/rawdb"
+	"github.com/ir4tech/webb-evm/core/state"
+	"github.com/ir4tech/webb-evm/params"
+	"github.com/ir4tech/webb-evm/precompile"
+	"github.com/ir4tech/webb-evm/vmerrs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	assetAdmin = common.HexToAddress("0x8db97C7cEcE249c2b98bDC0226Cc4C2A57BF52FC")
+	assetOwner = common.HexToAddress("0xF60C45c607D0f41687c94C314d300f483661E13a")
+	testAsset  = common.HexToHash("0x01")
+)
+
+// newPrecompileTestEVM returns an EVM with the asset precompile and the native asset manager enabled, [testAsset]
+// owned by [assetOwner] and [assetAdmin] enabled to mint native assets.
+func newPrecompileTestEVM(t *testing.T) (*EVM, *state.StateDB) {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	require.NoError(t, err)
+
+	chainConfig := *params.TestChainConfig
+	config := &precompile.ContractDeployerAssetConfig{
+		AllowListConfig: precompile.AllowListConfig{BlockTimestamp: common.Big0, AllowListAdmins: []common.Address{assetAdmin}},
+		AssetConfig: precompile.AssetConfig{
+			InitialAssets: []precompile.AssetAllocation{{Id: testAsset, Owner: assetOwner}},
+		},
+	}
+	nativeAssetConfig := &precompile.NativeAssetManagerConfig{
+		AllowListConfig: precompile.AllowListConfig{BlockTimestamp: common.Big0, AllowListAdmins: []common.Address{assetAdmin}},
+	}
+	contractCallConfig := &precompile.ContractCallAllowListConfig{
+		AllowListConfig: precompile.AllowListConfig{BlockTimestamp: common.Big0, AllowListAdmins: []common.Address{assetAdmin}},
+	}
+	chainConfig.PrecompileConfigs = params.Precompiles{
+		precompile.ContractDeployerAssetConfigKey: config,
+		precompile.NativeAssetManagerConfigKey:    nativeAssetConfig,
+		precompile.ContractCallAllowListConfigKey: contractCallConfig,
+	}
+
+	blockCtx := BlockContext{
+		CanTransfer: func(db StateDB, addr common.Address, amount *big.Int) bool {
+			return db.GetBalance(addr).Cmp(amount) >= 0
+		},
+		Transfer: func(db StateDB, sender common.Address, recipient common.Address, amount *big.Int) {
+			db.SubBalance(sender, amount)
+			db.AddBalance(recipient, amount)
+		},
+		BlockNumber: common.Big0,
+		Time:        common.Big0,
+	}
+	precompile.Configure(&chainConfig, &blockCtx, config, statedb)
+	precompile.Configure(&chainConfig, &blockCtx, nativeAssetConfig, statedb)
+	precompile.Configure(&chainConfig, &blockCtx, contractCallConfig, statedb)
+	evm := NewEVM(blockCtx, TxContext{Origin: assetOwner}, statedb, &chainConfig, Config{})
+	return evm, statedb
+}
+
+// receiverCode returns the code of a contract that calls [callee] with [input] if [callee] is set, and then returns
+// [output] left aligned in a word, as solidity returns a bytes4.
+func receiverCode(callee common.Address, input []byte, output [4]byte) []byte {
+	code := []byte{
+		byte(PUSH4), output[0], output[1], output[2], output[3], byte(PUSH1), 0xe0, byte(SHL),
+		byte(PUSH1), 0, byte(MSTORE),
+	}
+	if callee != (common.Address{}) {
+		// Copy [input], which is appended after the code, to memory past the output and call [callee] with it.
+		call := []byte{byte(PUSH2), 0, 0, byte(PUSH2), 0, 0, byte(PUSH1), 0x20, byte(CODECOPY)}
+		call = append(call, byte(PUSH1), 0, byte(PUSH1), 0, byte(PUSH2), 0, 0, byte(PUSH1), 0x20, byte(PUSH1), 0, byte(PUSH20))
+		call = append(call, callee.Bytes()...)
+		call = append(call, byte(GAS), byte(CALL), byte(POP))
+		code = append(code, call...)
+	}
+	code = append(code, byte(PUSH1), 0x20, byte(PUSH1), 0, byte(RETURN))
+	if callee != (common.Address{}) {
+		// Fill in the size and offset of [input] for CODECOPY and the size of [input] for CALL.
+		binary.BigEndian.PutUint16(code[12:], uint16(len(input)))
+		binary.BigEndian.PutUint16(code[15:], uint16(len(code)))
+		binary.BigEndian.PutUint16(code[25:], uint16(len(input)))
+		code = append(code, input...)
+	}
+	return code
+}
+
+func TestAssetReceiverCallback(t *testing.T) {
+	var (
+		receiverAddr = common.HexToAddress("0x1000000000000000000000000000000000000001")
+		nextOwner    = common.HexToAddress("0x2000000000000000000000000000000000000002")
+		accept       [4]byte
+	)
+	copy(accept[:], precompile.AssetReceiverABI.Methods["onAssetReceived"].ID)
+
+	tests := map[string]struct {
+		code          []byte
+		expectedErr   error
+		expectedRes   []byte
+		expectedOwner common.Address
+	}{
+		"accepting receiver": {
+			code:          receiverCode(common.Address{}, nil, accept),
+			expectedOwner: receiverAddr,
+		},
+		"rejecting receiver": {
+			code:          receiverCode(common.Address{}, nil, [4]byte{0xde, 0xad, 0xbe, 0xef}),
+			expectedErr:   vmerrs.ErrExecutionReverted,
+			expectedOwner: assetOwner,
+		},
+		"non receiver": {
+			code:          []byte{byte(STOP)},
+			expectedErr:   vmerrs.ErrExecutionReverted,
+			expectedOwner: assetOwner,
+		},
+		"reverting receiver": {
+			code:          []byte{byte(PUSH1), 0x2a, byte(PUSH1), 0, byte(MSTORE), byte(PUSH1), 0x20, byte(PUSH1), 0, byte(REVERT)},
+			expectedErr:   vmerrs.ErrExecutionReverted,
+			expectedRes:   common.BigToHash(big.NewInt(0x2a)).Bytes(),
+			expectedOwner: assetOwner,
+		},
+		"reentrant receiver": {
+			code:          receiverCode(precompile.ContractDeployerAssetAddress, precompile.PackTransferAsset(testAsset, nextOwner), accept),
+			expectedOwner: nextOwner,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			evm, statedb := newPrecompileTestEVM(t)
+			statedb.SetCode(receiverAddr, test.code)
+
+			ret, _, err := evm.Call(AccountRef(assetOwner), precompile.ContractDeployerAssetAddress, precompile.PackTransferAsset(testAsset, receiverAddr), 1_000_000, common.Big0)
+			assert.ErrorIs(t, err, test.expectedErr)
+			if test.expectedRes != nil {
+				assert.Equal(t, test.expectedRes, ret)
+			}
+			asset, err := precompile.GetStoredAsset(statedb, testAsset)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedOwner, asset.Owner)
+		})
+	}
+}
+
+func TestPrecompileAccessibleStateCall(t *testing.T) {
+	var (
+		self       = common.HexToAddress("0x0300000000000000000000000000000000000042")
+		storer     = common.HexToAddress("0x1000000000000000000000000000000000000001")
+		recipient  = common.HexToAddress("0x2000000000000000000000000000000000000002")
+		storerCode = []byte{byte(PUSH1), 1, byte(PUSH1), 0, byte(SSTORE), byte(STOP)}
+	)
+
+	t.Run("origin and value", func(t *testing.T) {
+		evm, _ := newPrecompileTestEVM(t)
+		s := evm.newPrecompileAccessibleState(self, big.NewInt(7), false)
+		assert.Equal(t, assetOwner, s.Origin())
+		assert.Equal(t, big.NewInt(7), s.Value())
+		assert.Equal(t, common.Big0, evm.newPrecompileAccessibleState(self, nil, true).Value())
+	})
+
+	t.Run("access gas", func(t *testing.T) {
+		evm, statedb := newPrecompileTestEVM(t)
+		statedb.SetCode(storer, storerCode)
+		s := evm.newPrecompileAccessibleState(self, nil, false)
+
+		// The first access of an address is cold and later accesses are warm.
+		_, remainingGas, err := s.Call(storer, nil, 100_000, nil)
+		require.NoError(t, err)
+		assert.Less(t, remainingGas, 100_000-params.ColdAccountAccessCostEIP2929)
+		assert.Equal(t, common.BigToHash(common.Big1), statedb.GetState(storer, common.Hash{}))
+
+		_, remainingGas, err = s.StaticCall(recipient, nil, params.ColdAccountAccessCostEIP2929)
+		require.NoError(t, err)
+		assert.Zero(t, remainingGas)
+
+		_, remainingGas, err = s.StaticCall(recipient, nil, params.WarmStorageReadCostEIP2929)
+		require.NoError(t, err)
+		assert.Zero(t, remainingGas)
+		_, _, err = s.StaticCall(self, nil, params.ColdAccountAccessCostEIP2929-1)
+		assert.ErrorIs(t, err, vmerrs.ErrOutOfGas)
+	})
+
+	t.Run("value transfer", func(t *testing.T) {
+		evm, statedb := newPrecompileTestEVM(t)
+		statedb.AddBalance(self, common.Big1)
+		s := evm.newPrecompileAccessibleState(self, nil, false)
+
+		_, remainingGas, err := s.Call(recipient, nil, 100_000, common.Big1)
+		require.NoError(t, err)
+		// The stipend is returned since the recipient has no code.
+		assert.Equal(t, uint64(100_000)-params.ColdAccountAccessCostEIP2929-params.CallValueTransferGas-params.CallNewAccountGas+params.CallStipend, remainingGas)
+		assert.Equal(t, common.Big1, statedb.GetBalance(recipient))
+	})
+
+	t.Run("read only", func(t *testing.T) {
+		evm, statedb := newPrecompileTestEVM(t)
+		statedb.SetCode(storer, storerCode)
+		s := evm.newPrecompileAccessibleState(self, nil, true)
+
+		_, _, err := s.Call(recipient, nil, 100_000, common.Big1)
+		assert.ErrorIs(t, err, vmerrs.ErrWriteProtection)
+		_, _, err = s.Call(storer, nil, 100_000, nil)
+		assert.ErrorIs(t, err, vmerrs.ErrWriteProtection)
+		_, _, err = s.Call(precompile.ContractDeployerAssetAddress, precompile.PackTransferAsset(testAsset, recipient), 1_000_000, nil)
+		assert.ErrorIs(t, err, vmerrs.ErrWriteProtection)
+		assert.Equal(t, common.Hash{}, statedb.GetState(storer, common.Hash{}))
+	})
+
+	t.Run("read other precompile", func(t *testing.T) {
+		evm, _ := newPrecompileTestEVM(t)
+		s := evm.newPrecompileAccessibleState(self, nil, false)
+
+		ret, _, err := s.StaticCall(precompile.ContractDeployerAssetAddress, precompile.PackReadAllowList(assetAdmin), 100_000)
+		require.NoError(t, err)
+		assert.Equal(t, common.Hash(precompile.AllowListAdmin).Bytes(), ret)
+	})
+
+	t.Run("call depth", func(t *testing.T) {
+		evm, statedb := newPrecompileTestEVM(t)
+		statedb.SetCode(storer, storerCode)
+		s := evm.newPrecompileAccessibleState(self, nil, false)
+
+		evm.depth = int(params.CallCreateDepth)
+		_, _, err := s.Call(storer, nil, 100_000, nil)
+		assert.ErrorIs(t, err, vmerrs.ErrDepth)
+		assert.Equal(t, int(params.CallCreateDepth), evm.depth)
+	})
+}
+
+func TestContractCallAllowList(t *testing.T) {
+	var (
+		storer     = common.HexToAddress("0x1000000000000000000000000000000000000001")
+		allowed    = common.HexToAddress("0x2000000000000000000000000000000000000002")
+		other      = common.HexToAddress("0x3000000000000000000000000000000000000003")
+		storerCode = []byte{byte(PUSH1), 1, byte(PUSH1), 0, byte(SSTORE), byte(STOP)}
+		selector   = [4]byte{0xde, 0xad, 0xbe, 0xef}
+	)
+	evm, statedb := newPrecompileTestEVM(t)
+	statedb.SetCode(storer, storerCode)
+	statedb.AddAddressToAccessList(storer)
+
+	adminCall := func(input []byte) {
+		_, _, err := evm.Call(AccountRef(assetAdmin), precompile.ContractCallAllowListAddress, input, 1_000_000, common.Big0)
+		require.NoError(t, err)
+	}
+	adminCall(precompile.PackRestrictFunction(storer, selector))
+	adminCall(precompile.PackSetFunctionCaller(storer, selector, allowed, true))
+
+	// A caller that is not allowed is reverted with a reason and only charged for reading the generation, the
+	// restriction and the allowed caller like SLOADs. The generation is already warm from checking the admin's calls.
+	ret, remainingGas, err := evm.Call(AccountRef(other), storer, append(selector[:], 1), 100_000, common.Big0)
+	require.ErrorIs(t, err, vmerrs.ErrExecutionReverted)
+	assert.Equal(t, 100_000-params.WarmStorageReadCostEIP2929-2*params.ColdSloadCostEIP2929, remainingGas)
+	_, remainingGas, err = evm.Call(AccountRef(other), storer, selector[:], 100_000, common.Big0)
+	require.ErrorIs(t, err, vmerrs.ErrExecutionReverted)
+	assert.Equal(t, 100_000-3*params.WarmStorageReadCostEIP2929, remainingGas)
+	_, _, err = evm.Call(AccountRef(other), storer, selector[:], 3*params.WarmStorageReadCostEIP2929-1, common.Big0)
+	require.ErrorIs(t, err, vmerrs.ErrOutOfGas)
+	reason, err := abi.UnpackRevert(ret)
+	require.NoError(t, err)
+	assert.Contains(t, reason, precompile.ErrContractCallNotAllowed.Error())
+	assert.Equal(t, common.Hash{}, statedb.GetState(storer, common.Hash{}))
+
+	// Other functions and inputs shorter than a selector are not restricted.
+	_, _, err = evm.Call(AccountRef(other), storer, []byte{0xde, 0xad, 0xbe, 0xee}, 100_000, common.Big0)
+	require.NoError(t, err)
+	_, _, err = evm.Call(AccountRef(other), storer, selector[:3], 100_000, common.Big0)
+	require.NoError(t, err)
+
+	// An allowed caller may call the function, and anyone may once it is unrestricted.
+	statedb.SetState(storer, common.Hash{}, common.Hash{})
+	_, _, err = evm.Call(AccountRef(allowed), storer, selector[:], 100_000, common.Big0)
+	require.NoError(t, err)
+	assert.Equal(t, common.BigToHash(common.Big1), statedb.GetState(storer, common.Hash{}))
+
+	adminCall(precompile.PackUnrestrictFunction(storer, selector))
+	_, _, err = evm.Call(AccountRef(other), storer, selector[:], 100_000, common.Big0)
+	require.NoError(t, err)
+}
+
+func TestTransferNativeAssetAndCall(t *testing.T) {
+	var (
+		calleeAddr = common.HexToAddress("0x1000000000000000000000000000000000000001")
+		nativeId   = common.Hash{'g', 'r', 'a', 'i', 'n'}
+		// echoCode returns its calldata
+		echoCode = []byte{
+			byte(CALLDATASIZE), byte(PUSH1), 0, byte(PUSH1), 0, byte(CALLDATACOPY),
+			byte(CALLDATASIZE), byte(PUSH1), 0, byte(RETURN),
+		}
+		revertCode = []byte{byte(PUSH1), 0, byte(PUSH1), 0, byte(REVERT)}
+	)
+
+	tests := map[string]struct {
+		code            []byte
+		expectedErr     error
+		expectedBalance int64
+	}{
+		"call succeeds": {
+			code:            echoCode,
+			expectedBalance: 2,
+		},
+		"call reverts": {
+			code:        revertCode,
+			expectedErr: vmerrs.ErrExecutionReverted,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			evm, statedb := newPrecompileTestEVM(t)
+			statedb.SetCode(calleeAddr, test.code)
+			statedb.AddBalance(assetOwner, big.NewInt(10))
+			_, _, err := evm.Call(AccountRef(assetAdmin), precompile.NativeAssetManagerAddress, precompile.PackMintNativeAsset(assetOwner, nativeId, big.NewInt(5)), 1_000_000, common.Big0)
+			require.NoError(t, err)
+
+			input := precompile.PackTransferNativeAssetAndCall(calleeAddr, nativeId, big.NewInt(2), []byte{0xc0, 0xff, 0xee})
+			ret, _, err := evm.Call(AccountRef(assetOwner), precompile.NativeAssetManagerAddress, input, 1_000_000, big.NewInt(3))
+			assert.ErrorIs(t, err, test.expectedErr)
+			assert.Equal(t, test.expectedBalance, precompile.GetNativeAssetBalance(statedb, calleeAddr, nativeId).Int64())
+			assert.Equal(t, 5-test.expectedBalance, precompile.GetNativeAssetBalance(statedb, assetOwner, nativeId).Int64())
+			if test.expectedErr != nil {
+				assert.Equal(t, big.NewInt(10), statedb.GetBalance(assetOwner))
+				return
+			}
+
+			// The callee receives the data followed by the caller, and the value sent to the precompile.
+			output, err := precompile.UnpackTransferNativeAssetAndCallOutput(ret)
+			require.NoError(t, err)
+			assert.Equal(t, append([]byte{0xc0, 0xff, 0xee}, assetOwner.Bytes()...), output)
+			assert.Equal(t, big.NewInt(3), statedb.GetBalance(calleeAddr))
+			assert.Zero(t, statedb.GetBalance(precompile.NativeAssetManagerAddress).Sign())
+		})
+	}
+}
@@ -36,30 +36,26 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/holiman/uint256"
 	"github.com/ir4tech/webb-evm/constants"
+	"github.com/ir4tech/webb-evm/core/types"
 	"github.com/ir4tech/webb-evm/params"
 	"github.com/ir4tech/webb-evm/precompile"
 	"github.com/ir4tech/webb-evm/vmerrs"
 )
 
-var (
-	_ precompile.PrecompileAccessibleState = &EVM{}
-	_ precompile.BlockContext              = &BlockContext{}
-)
+var _ precompile.BlockContext = &BlockContext{}
 
 var prohibitedAddresses = map[common.Address]struct{}{
 	constants.BlackholeAddr: {},
 }
 
-func init() {
-	for _, addr := range precompile.UsedAddresses {
-		prohibitedAddresses[addr] = struct{}{}
-	}
-}
-
-// IsProhibited returns true if [addr] is in the prohibited list of addresses which should
-// not be allowed as an EOA or newly created contract address.
+// IsProhibited returns true if [addr] is in the prohibited list of addresses or is the address
+// of a registered stateful precompile, which should not be allowed as an EOA or newly created
+// contract address.
 func IsProhibited(addr common.Address) bool {
-	_, ok := prohibitedAddresses[addr]
+	if _, ok := prohibitedAddresses[addr]; ok {
+		return true
+	}
+	_, ok := precompile.GetRegisteredModuleByAddress(addr)
 	return ok
 }
 
@@ -152,7 +148,6 @@ type EVM struct {
 	TxContext
 	// StateDB gives access to the underlying state
 	StateDB StateDB
-	AssetDB AssetDB
 	// Depth is the current call stack
 	depth int
 
@@ -213,20 +208,49 @@ func (evm *EVM) GetStateDB() precompile.StateDB {
 	return evm.StateDB
 }
 
-func (evm *EVM) GetAssetDB() precompile.AssetDB {
-	return evm.AssetDB
-}
-
 // GetBlockContext returns the evm's BlockContext
 func (evm *EVM) GetBlockContext() precompile.BlockContext {
 	return &evm.Context
 }
 
+// GetChainConfig returns the evm's ChainConfig
+func (evm *EVM) GetChainConfig() precompile.ChainConfig {
+	return evm.chainConfig
+}
+
+// AddLog adds a log emitted by the stateful precompile at [addr] to the current transaction
+func (evm *EVM) AddLog(addr common.Address, topics []common.Hash, data []byte) {
+	evm.StateDB.AddLog(&types.Log{
+		Address: addr,
+		Topics:  topics,
+		Data:    data,
+		// This is a non-consensus field, but assigned here because
+		// core/state doesn't know the current block number.
+		BlockNumber: evm.Context.BlockNumber.Uint64(),
+	})
+}
+
 // Interpreter returns the current interpreter
 func (evm *EVM) Interpreter() *EVMInterpreter {
 	return evm.interpreter
 }
 
+// contractCallCheckGas returns the gas charged for reading [keys] of the contract call allow list when checking a
+// call. The reads are priced like SLOADs, so that checking calls to the same function again within a transaction
+// is cheap.
+func (evm *EVM) contractCallCheckGas(keys []common.Hash) uint64 {
+	var gas uint64
+	for _, key := range keys {
+		if _, slotOk := evm.StateDB.SlotInAccessList(precompile.ContractCallAllowListAddress, key); slotOk {
+			gas += params.WarmStorageReadCostEIP2929
+			continue
+		}
+		evm.StateDB.AddSlotToAccessList(precompile.ContractCallAllowListAddress, key)
+		gas += params.ColdSloadCostEIP2929
+	}
+	return gas
+}
+
 // Call executes the contract associated with the addr with the given input as
 // parameters. It also handles any necessary value transfer required and takes
 // the necessary steps to create accounts and reverses the state in case of an
@@ -243,6 +267,20 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 	if value.Sign() != 0 && !evm.Context.CanTransfer(evm.StateDB, caller.Address(), value) {
 		return nil, gas, vmerrs.ErrInsufficientBalance
 	}
+	// If the contract call allow list is enabled, check that [caller] may call the function of [addr] selected by
+	// [input]. Only calls are restricted, since delegate calls run the code of [addr] on the state of the caller
+	// and static calls cannot modify any state.
+	if evm.chainRules.IsPrecompileEnabled(precompile.ContractCallAllowListAddress) {
+		keys, err := precompile.CheckContractCall(evm.StateDB, caller.Address(), addr, input)
+		checkGas := evm.contractCallCheckGas(keys)
+		if gas < checkGas {
+			return nil, 0, vmerrs.ErrOutOfGas
+		}
+		gas -= checkGas
+		if err != nil {
+			return precompile.PackRevertReason(err), gas, vmerrs.ErrExecutionReverted
+		}
+	}
 	snapshot := evm.StateDB.Snapshot()
 	p, isPrecompile := evm.precompile(addr)
 
@@ -281,7 +319,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 	}
 
 	if isPrecompile {
-		ret, gas, err = RunStatefulPrecompiledContract(p, evm, caller.Address(), addr, input, gas, evm.interpreter.readOnly)
+		ret, gas, err = RunStatefulPrecompiledContract(p, evm.newPrecompileAccessibleState(addr, value, evm.interpreter.readOnly), caller.Address(), addr, input, gas, evm.interpreter.readOnly)
 	} else {
 		// Initialise a new contract and set the code that is to be used by the EVM.
 		// The contract is a scoped environment for this execution context only.
@@ -347,7 +385,7 @@ func (evm *EVM) CallCode(caller ContractRef, addr common.Address, input []byte,
 
 	// It is allowed to call precompiles, even via delegatecall
 	if p, isPrecompile := evm.precompile(addr); isPrecompile {
-		ret, gas, err = RunStatefulPrecompiledContract(p, evm, caller.Address(), addr, input, gas, evm.interpreter.readOnly)
+		ret, gas, err = RunStatefulPrecompiledContract(p, evm.newPrecompileAccessibleState(caller.Address(), value, evm.interpreter.readOnly), caller.Address(), addr, input, gas, evm.interpreter.readOnly)
 	} else {
 		addrCopy := addr
 		// Initialise a new contract and set the code that is to be used by the EVM.
@@ -388,7 +426,7 @@ func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []by
 
 	// It is allowed to call precompiles, even via delegatecall
 	if p, isPrecompile := evm.precompile(addr); isPrecompile {
-		ret, gas, err = RunStatefulPrecompiledContract(p, evm, caller.Address(), addr, input, gas, evm.interpreter.readOnly)
+		ret, gas, err = RunStatefulPrecompiledContract(p, evm.newPrecompileAccessibleState(caller.Address(), delegateValue(caller), evm.interpreter.readOnly), caller.Address(), addr, input, gas, evm.interpreter.readOnly)
 	} else {
 		addrCopy := addr
 		// Initialise a new contract and make initialise the delegate values
@@ -437,7 +475,7 @@ func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte
 	}
 
 	if p, isPrecompile := evm.precompile(addr); isPrecompile {
-		ret, gas, err = RunStatefulPrecompiledContract(p, evm, caller.Address(), addr, input, gas, true)
+		ret, gas, err = RunStatefulPrecompiledContract(p, evm.newPrecompileAccessibleState(addr, nil, true), caller.Address(), addr, input, gas, true)
 	} else {
 		// At this point, we use a copy of address. If we don't, the go compiler will
 		// leak the 'contract' to the outer scope, and make allocation for 'contract'
@@ -508,8 +546,8 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 		return nil, common.Address{}, 0, vmerrs.ErrContractAddressCollision
 	}
 	// If the allow list is enabled, check that [evm.TxContext.Origin] has permission to deploy a contract.
-	if evm.chainRules.IsContractDeployerAllowListEnabled {
-		allowListRole := precompile.GetContractDeployerAllowListStatus(evm.StateDB, evm.TxContext.Origin)
+	if evm.chainRules.IsPrecompileEnabled(precompile.ContractDeployerAllowListAddress) {
+		allowListRole := precompile.GetContractDeployerAllowListStatus(evm.StateDB, evm.TxContext.Origin, evm.Context.Time)
 		if !allowListRole.IsEnabled() {
 			return nil, common.Address{}, 0, fmt.Errorf("tx.origin %s is not authorized to deploy a contract", evm.TxContext.Origin)
 		}
@@ -30,7 +30,6 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ir4tech/webb-evm/commontype"
 	"github.com/ir4tech/webb-evm/core/types"
 )
 
@@ -87,15 +86,6 @@ type StateDB interface {
 	ForEachStorage(common.Address, func(common.Hash, common.Hash) bool) error
 }
 
-type AssetDB interface {
-	GetAll() []commontype.Asset
-	RegisterAsset(assetId common.Hash, owner common.Address, name string)
-	GetAsset(assetId common.Hash) (commontype.Asset, error)
-	GetAssetByOwner(owner common.Address) []commontype.Asset
-	UpdateLocation(assetId common.Hash, location string) error
-	UpdateName(assetId common.Hash, name string) error
-}
-
 // CallContext provides a basic interface for the EVM calling conventions. The EVM
 // depends on this context being implemented for doing subcalls and initialising new EVM contracts.
 type CallContext interface {
@@ -104,6 +104,10 @@ func (b *EthAPIBackend) GetFeeConfigAt(parent *types.Header) (commontype.FeeConf
 	return b.eth.blockchain.GetFeeConfigAt(parent)
 }
 
+func (b *EthAPIBackend) GetPendingFeeConfigAt(parent *types.Header) (commontype.FeeConfig, *big.Int, error) {
+	return b.eth.blockchain.GetPendingFeeConfigAt(parent)
+}
+
 func (b *EthAPIBackend) HeaderByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, error) {
 	if blockNr, ok := blockNrOrHash.Number(); ok {
 		return b.HeaderByNumber(ctx, blockNr)
@@ -44,6 +44,7 @@ import (
 	"github.com/ir4tech/webb-evm/core"
 	"github.com/ir4tech/webb-evm/core/types"
 	"github.com/ir4tech/webb-evm/params"
+	"github.com/ir4tech/webb-evm/precompile"
 	"github.com/ir4tech/webb-evm/rpc"
 )
 
@@ -95,6 +96,7 @@ type OracleBackend interface {
 	MinRequiredTip(ctx context.Context, header *types.Header) (*big.Int, error)
 	LastAcceptedBlock() *types.Block
 	GetFeeConfigAt(parent *types.Header) (commontype.FeeConfig, *big.Int, error)
+	GetPendingFeeConfigAt(parent *types.Header) (commontype.FeeConfig, *big.Int, error)
 }
 
 // Oracle recommends gas prices based on the content of recent
@@ -225,7 +227,27 @@ func (oracle *Oracle) EstimateBaseFee(ctx context.Context) (*big.Int, error) {
 	}
 
 	baseFee = math.BigMin(baseFee, nextBaseFee)
-	return baseFee, nil
+	return oracle.applyPendingMinBaseFee(ctx, baseFee), nil
+}
+
+// applyPendingMinBaseFee returns [baseFee] raised to the min base fee of the fee config scheduled through the fee
+// config manager, if a block produced at the current time would apply it. Since the pending fee config governs the
+// blocks built on top of that block, transactions priced now may be included after the fee change.
+func (oracle *Oracle) applyPendingMinBaseFee(ctx context.Context, baseFee *big.Int) *big.Int {
+	head, err := oracle.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		log.Warn("failed to fetch head for pending fee config", "err", err)
+		return baseFee
+	}
+	feeConfig, effectiveAt, err := oracle.backend.GetPendingFeeConfigAt(head)
+	if err != nil {
+		log.Warn("failed to get pending fee config", "err", err)
+		return baseFee
+	}
+	if effectiveAt == nil || !effectiveAt.IsUint64() || effectiveAt.Uint64() > oracle.clock.Unix() {
+		return baseFee
+	}
+	return math.BigMax(baseFee, feeConfig.MinBaseFee)
 }
 
 // estimateNextBaseFee calculates what the base fee should be on the next block if it
@@ -276,6 +298,7 @@ func (oracle *Oracle) SuggestPrice(ctx context.Context) (*big.Int, error) {
 	if nextBaseFee != nil {
 		baseFee = math.BigMin(baseFee, nextBaseFee)
 	}
+	baseFee = oracle.applyPendingMinBaseFee(ctx, baseFee)
 
 	return new(big.Int).Add(tip, baseFee), nil
 }
@@ -299,7 +322,7 @@ func (oracle *Oracle) suggestDynamicFees(ctx context.Context) (*big.Int, *big.In
 	}
 
 	var feeLastChangedAt *big.Int
-	if oracle.backend.ChainConfig().IsFeeConfigManager(new(big.Int).SetUint64(head.Time)) {
+	if oracle.backend.ChainConfig().IsPrecompileEnabled(precompile.FeeConfigManagerAddress, new(big.Int).SetUint64(head.Time)) {
 		_, feeLastChangedAt, err = oracle.backend.GetFeeConfigAt(head)
 		if err != nil {
 			return nil, nil, err
@@ -30,6 +30,7 @@ import (
 	"context"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -41,6 +42,7 @@ import (
 	"github.com/ir4tech/webb-evm/core/types"
 	"github.com/ir4tech/webb-evm/core/vm"
 	"github.com/ir4tech/webb-evm/params"
+	"github.com/ir4tech/webb-evm/precompile"
 	"github.com/ir4tech/webb-evm/rpc"
 )
 
@@ -95,6 +97,10 @@ func (b *testBackend) GetFeeConfigAt(parent *types.Header) (commontype.FeeConfig
 	return b.chain.GetFeeConfigAt(parent)
 }
 
+func (b *testBackend) GetPendingFeeConfigAt(parent *types.Header) (commontype.FeeConfig, *big.Int, error) {
+	return b.chain.GetPendingFeeConfigAt(parent)
+}
+
 func newTestBackendFakerEngine(t *testing.T, config *params.ChainConfig, numBlocks int, genBlocks func(i int, b *core.BlockGen)) *testBackend {
 	gspec := &core.Genesis{
 		Config: config,
@@ -467,3 +473,70 @@ func TestSuggestGasPricePreAP3(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestEstimateBaseFeePendingFeeConfig(t *testing.T) {
+	chainConfig := *params.TestChainConfig
+	chainConfig.PrecompileConfigs = params.Precompiles{
+		precompile.FeeConfigManagerConfigKey: &precompile.FeeConfigManagerConfig{
+			AllowListConfig: precompile.AllowListConfig{BlockTimestamp: common.Big0, AllowListAdmins: []common.Address{addr}},
+		},
+	}
+	pendingFeeConfig := params.DefaultFeeConfig
+	pendingFeeConfig.MinBaseFee = big.NewInt(1_000 * params.GWei)
+	effectiveAt := big.NewInt(1_000)
+
+	// Schedule the fee config in the first block, and pay a tip that covers the block gas cost in every block.
+	backend := newTestBackend(t, &chainConfig, 3, func(i int, b *core.BlockGen) {
+		to, gas, input := common.Address{}, params.TxGas, []byte{}
+		if i == 0 {
+			var err error
+			if input, err = precompile.PackScheduleFeeConfig(effectiveAt, pendingFeeConfig); err != nil {
+				t.Fatal(err)
+			}
+			to, gas = precompile.FeeConfigManagerAddress, 1_000_000
+		}
+		tx := types.NewTx(&types.LegacyTx{
+			Nonce:    b.TxNonce(addr),
+			To:       &to,
+			Gas:      gas,
+			GasPrice: big.NewInt(1_000 * params.GWei),
+			Data:     input,
+		})
+		tx, err := types.SignTx(tx, types.LatestSigner(&chainConfig), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.AddTx(tx)
+	})
+	feeConfig, pendingEffectiveAt, err := backend.GetPendingFeeConfigAt(backend.CurrentHeader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pendingEffectiveAt.Cmp(effectiveAt) != 0 || feeConfig.MinBaseFee.Cmp(pendingFeeConfig.MinBaseFee) != 0 {
+		t.Fatalf("Expected pending min base fee (%d) at (%d), got (%d) at (%d)", pendingFeeConfig.MinBaseFee, effectiveAt, feeConfig.MinBaseFee, pendingEffectiveAt)
+	}
+
+	oracle, err := NewOracle(backend, Config{Blocks: 20, Percentile: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Before the pending fee config takes effect, the estimate follows the current fee config.
+	oracle.clock.Set(time.Unix(effectiveAt.Int64()-1, 0))
+	baseFee, err := oracle.EstimateBaseFee(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if baseFee.Cmp(pendingFeeConfig.MinBaseFee) >= 0 {
+		t.Fatalf("Expected base fee (%d) below the pending min base fee (%d)", baseFee, pendingFeeConfig.MinBaseFee)
+	}
+
+	// Once a block produced now would apply the pending fee config, the estimate anticipates its min base fee.
+	oracle.clock.Set(time.Unix(effectiveAt.Int64(), 0))
+	baseFee, err = oracle.EstimateBaseFee(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if baseFee.Cmp(pendingFeeConfig.MinBaseFee) != 0 {
+		t.Fatalf("Expected base fee (%d), got base fee (%d)", pendingFeeConfig.MinBaseFee, baseFee)
+	}
+}
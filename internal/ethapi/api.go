@@ -52,6 +52,7 @@ import (
 	"github.com/ir4tech/webb-evm/core/vm"
 	"github.com/ir4tech/webb-evm/eth/tracers/logger"
 	"github.com/ir4tech/webb-evm/params"
+	"github.com/ir4tech/webb-evm/precompile"
 	"github.com/ir4tech/webb-evm/rpc"
 	"github.com/ir4tech/webb-evm/vmerrs"
 	"github.com/tyler-smith/go-bip39"
@@ -612,6 +613,10 @@ func (s *PublicBlockChainAPI) ChainId() (*hexutil.Big, error) {
 type FeeConfigResult struct {
 	FeeConfig     commontype.FeeConfig `json:"feeConfig"`
 	LastChangedAt *big.Int             `json:"lastChangedAt,omitempty"`
+	// PendingFeeConfig is the fee config scheduled through the fee config manager, which is applied by the first
+	// block with a timestamp at or after PendingEffectiveAt.
+	PendingFeeConfig   *commontype.FeeConfig `json:"pendingFeeConfig,omitempty"`
+	PendingEffectiveAt *big.Int              `json:"pendingEffectiveAt,omitempty"`
 }
 
 func (s *PublicBlockChainAPI) FeeConfig(ctx context.Context, blockNrOrHash *rpc.BlockNumberOrHash) (*FeeConfigResult, error) {
@@ -632,7 +637,70 @@ func (s *PublicBlockChainAPI) FeeConfig(ctx context.Context, blockNrOrHash *rpc.
 	if err != nil {
 		return nil, err
 	}
-	return &FeeConfigResult{FeeConfig: feeConfig, LastChangedAt: lastChangedAt}, nil
+	result := &FeeConfigResult{FeeConfig: feeConfig, LastChangedAt: lastChangedAt}
+	pendingFeeConfig, pendingEffectiveAt, err := s.b.GetPendingFeeConfigAt(header)
+	if err != nil {
+		return nil, err
+	}
+	if pendingEffectiveAt != nil {
+		result.PendingFeeConfig, result.PendingEffectiveAt = &pendingFeeConfig, pendingEffectiveAt
+	}
+	return result, nil
+}
+
+// NativeCoinSupplyResult is the count of native coins minted and burned kept by the native minter.
+type NativeCoinSupplyResult struct {
+	TotalMinted *hexutil.Big `json:"totalMinted"`
+	TotalBurned *hexutil.Big `json:"totalBurned"`
+	MaxSupply   *hexutil.Big `json:"maxSupply,omitempty"`
+}
+
+// GetNativeCoinSupply returns the native coins counted as minted and burned by the native minter, and its max supply
+// if minting is capped, in the state of the given block. It returns an error if the native minter is not enabled
+// at the block, since nothing is counted.
+func (s *PublicBlockChainAPI) GetNativeCoinSupply(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*NativeCoinSupplyResult, error) {
+	state, header, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	if !s.b.ChainConfig().IsPrecompileEnabled(precompile.ContractNativeMinterAddress, new(big.Int).SetUint64(header.Time)) {
+		return nil, fmt.Errorf("native minter is not enabled at block %d", header.Number)
+	}
+	result := &NativeCoinSupplyResult{
+		TotalMinted: (*hexutil.Big)(precompile.GetNativeCoinMinted(state)),
+		TotalBurned: (*hexutil.Big)(precompile.GetNativeCoinBurned(state)),
+	}
+	if maxSupply := precompile.GetMaxNativeCoinSupply(state); maxSupply.Sign() > 0 {
+		result.MaxSupply = (*hexutil.Big)(maxSupply)
+	}
+	return result, state.Error()
+}
+
+// maxAssetLocationHistoryPage is the maximum number of location records returned by a single
+// GetAssetLocationHistory call.
+const maxAssetLocationHistoryPage = 1024
+
+// GetAssetLocationHistory returns at most [limit] records of the location history of [assetId], oldest first,
+// starting at [offset] in the state of the given block.
+func (s *PublicBlockChainAPI) GetAssetLocationHistory(ctx context.Context, assetId common.Hash, offset hexutil.Uint64, limit hexutil.Uint64, blockNrOrHash rpc.BlockNumberOrHash) ([]commontype.LocationRecord, error) {
+	if limit > maxAssetLocationHistoryPage {
+		return nil, fmt.Errorf("limit %d exceeds maximum of %d", limit, maxAssetLocationHistoryPage)
+	}
+	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	return precompile.GetStoredLocationHistory(state, assetId, uint64(offset), uint64(limit)), state.Error()
+}
+
+// GetAssetBalance returns the balance of the native asset [assetId] held by [address] in the state of the given
+// block. Native assets are held alongside the native coin, whose balance is returned by GetBalance.
+func (s *PublicBlockChainAPI) GetAssetBalance(ctx context.Context, address common.Address, assetId common.Hash, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+	state, _, err := s.b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(precompile.GetNativeAssetBalance(state, address, assetId)), state.Error()
 }
 
 // BlockNumber returns the block number of the chain head.
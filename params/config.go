@@ -68,24 +68,25 @@ var (
 var (
 	// SubnetEVMDefaultChainConfig is the default configuration
 	SubnetEVMDefaultChainConfig = &ChainConfig{
-		ChainID:             SubnetEVMChainID,
-		HomesteadBlock:      big.NewInt(0),
-		EIP150Block:         big.NewInt(0),
-		EIP150Hash:          common.HexToHash("0x2086799aeebeae135c246c65021c82b4e15a2c451340993aacfd2751886514f0"),
-		EIP155Block:         big.NewInt(0),
-		EIP158Block:         big.NewInt(0),
-		ByzantiumBlock:      big.NewInt(0),
-		ConstantinopleBlock: big.NewInt(0),
-		PetersburgBlock:     big.NewInt(0),
-		IstanbulBlock:       big.NewInt(0),
-		MuirGlacierBlock:    big.NewInt(0),
-		SubnetEVMTimestamp:  big.NewInt(0),
-		FeeConfig:           DefaultFeeConfig,
-		AllowFeeRecipients:  false,
-	}
-
-	TestChainConfig        = &ChainConfig{big.NewInt(1), big.NewInt(0), big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), DefaultFeeConfig, false, precompile.ContractDeployerAllowListConfig{}, precompile.ContractNativeMinterConfig{}, precompile.TxAllowListConfig{}, precompile.FeeConfigManagerConfig{}, precompile.ContractDeployerAssetConfig{}}
-	TestPreSubnetEVMConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, DefaultFeeConfig, false, precompile.ContractDeployerAllowListConfig{}, precompile.ContractNativeMinterConfig{}, precompile.TxAllowListConfig{}, precompile.FeeConfigManagerConfig{}, precompile.ContractDeployerAssetConfig{}}
+		ChainID:                    SubnetEVMChainID,
+		HomesteadBlock:             big.NewInt(0),
+		EIP150Block:                big.NewInt(0),
+		EIP150Hash:                 common.HexToHash("0x2086799aeebeae135c246c65021c82b4e15a2c451340993aacfd2751886514f0"),
+		EIP155Block:                big.NewInt(0),
+		EIP158Block:                big.NewInt(0),
+		ByzantiumBlock:             big.NewInt(0),
+		ConstantinopleBlock:        big.NewInt(0),
+		PetersburgBlock:            big.NewInt(0),
+		IstanbulBlock:              big.NewInt(0),
+		MuirGlacierBlock:           big.NewInt(0),
+		SubnetEVMTimestamp:         big.NewInt(0),
+		PrecompileUpgradeTimestamp: big.NewInt(0),
+		FeeConfig:                  DefaultFeeConfig,
+		AllowFeeRecipients:         false,
+	}
+
+	TestChainConfig        = &ChainConfig{big.NewInt(1), big.NewInt(0), big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), DefaultFeeConfig, false, Precompiles{}, UpgradeConfig{}}
+	TestPreSubnetEVMConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, DefaultFeeConfig, false, Precompiles{}, UpgradeConfig{}}
 )
 
 // ChainConfig is the core config which determines the blockchain settings.
@@ -113,14 +114,25 @@ type ChainConfig struct {
 
 	SubnetEVMTimestamp *big.Int `json:"subnetEVMTimestamp,omitempty"` // A placeholder for the latest avalanche forks (nil = no fork, 0 = already activated)
 
+	// PrecompileUpgrade changes the state and gas costs of existing stateful precompiles: role expiries, the manager
+	// role, role holder enumeration and RoleSet events in allow lists, and counting the genesis allocations towards
+	// the coins minted by the native minter.
+	PrecompileUpgradeTimestamp *big.Int `json:"precompileUpgradeTimestamp,omitempty"` // (nil = no fork, 0 = already activated)
+
 	FeeConfig          commontype.FeeConfig `json:"feeConfig"`                    // Set the configuration for the dynamic fee algorithm
 	AllowFeeRecipients bool                 `json:"allowFeeRecipients,omitempty"` // Allows fees to be collected by block builders.
 
-	ContractDeployerAllowListConfig precompile.ContractDeployerAllowListConfig `json:"contractDeployerAllowListConfig,omitempty"` // Config for the contract deployer allow list precompile
-	ContractNativeMinterConfig      precompile.ContractNativeMinterConfig      `json:"contractNativeMinterConfig,omitempty"`      // Config for the native minter precompile
-	TxAllowListConfig               precompile.TxAllowListConfig               `json:"txAllowListConfig,omitempty"`               // Config for the tx allow list precompile
-	FeeManagerConfig                precompile.FeeConfigManagerConfig          `json:"feeManagerConfig,omitempty"`                // Config for the fee manager precompile
-	ContractDeployerAssetConfig     precompile.ContractDeployerAssetConfig     `json:"contractDeployerAssetConfig,omitempty"`     // Config for the asset contract deployer
+	PrecompileConfigs Precompiles `json:"-"` // Configs of the registered stateful precompiles, encoded under their config keys
+
+	UpgradeConfig UpgradeConfig `json:"-"` // Config specified in upgrade.json, not part of the genesis
+}
+
+// ChainConfigWithUpgradesJSON is the JSON encoding of a ChainConfig including its UpgradeConfig,
+// which is omitted from the genesis encoding. It is used to persist the chain config so that
+// network upgrades that have already taken effect can be checked for compatibility on restart.
+type ChainConfigWithUpgradesJSON struct {
+	ChainConfig
+	UpgradeConfig UpgradeConfig // Encoded under [upgradesKey]
 }
 
 // String implements the fmt.Stringer interface.
@@ -129,7 +141,11 @@ func (c *ChainConfig) String() string {
 	if err != nil {
 		feeBytes = []byte("cannot unmarshal FeeConfig")
 	}
-	return fmt.Sprintf("{ChainID: %v Homestead: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Petersburg: %v Istanbul: %v, Muir Glacier: %v, Subnet EVM: %v, FeeConfig: %v, AllowFeeRecipients: %v, ContractDeployerAllowListConfig: %v, ContractNativeMinterConfig: %v, TxAllowListConfig: %v, FeeManagerConfig: %v, ContractDeployerAssetConfig: %v, Engine: Dummy Consensus Engine}",
+	precompileBytes, err := json.Marshal(c.PrecompileConfigs)
+	if err != nil {
+		precompileBytes = []byte("cannot unmarshal PrecompileConfigs")
+	}
+	return fmt.Sprintf("{ChainID: %v Homestead: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Petersburg: %v Istanbul: %v, Muir Glacier: %v, Subnet EVM: %v, Precompile Upgrade: %v, FeeConfig: %v, AllowFeeRecipients: %v, PrecompileConfigs: %v, Engine: Dummy Consensus Engine}",
 		c.ChainID,
 		c.HomesteadBlock,
 		c.EIP150Block,
@@ -141,13 +157,10 @@ func (c *ChainConfig) String() string {
 		c.IstanbulBlock,
 		c.MuirGlacierBlock,
 		c.SubnetEVMTimestamp,
+		c.PrecompileUpgradeTimestamp,
 		string(feeBytes),
 		c.AllowFeeRecipients,
-		c.ContractDeployerAllowListConfig,
-		c.ContractNativeMinterConfig,
-		c.TxAllowListConfig,
-		c.FeeManagerConfig,
-		c.ContractDeployerAssetConfig,
+		string(precompileBytes),
 	)
 }
 
@@ -203,28 +216,15 @@ func (c *ChainConfig) IsSubnetEVM(blockTimestamp *big.Int) bool {
 	return utils.IsForked(c.SubnetEVMTimestamp, blockTimestamp)
 }
 
-// IsContractDeployerAllowList returns whether [blockTimestamp] is either equal to the ContractDeployerAllowList fork block timestamp or greater.
-func (c *ChainConfig) IsContractDeployerAllowList(blockTimestamp *big.Int) bool {
-	return utils.IsForked(c.ContractDeployerAllowListConfig.Timestamp(), blockTimestamp)
+// IsPrecompileUpgrade returns whether [blockTimestamp] is either equal to the PrecompileUpgrade fork block timestamp or greater.
+func (c *ChainConfig) IsPrecompileUpgrade(blockTimestamp *big.Int) bool {
+	return utils.IsForked(c.PrecompileUpgradeTimestamp, blockTimestamp)
 }
 
-// IsContractNativeMinter returns whether [blockTimestamp] is either equal to the NativeMinter fork block timestamp or greater.
-func (c *ChainConfig) IsContractNativeMinter(blockTimestamp *big.Int) bool {
-	return utils.IsForked(c.ContractNativeMinterConfig.Timestamp(), blockTimestamp)
-}
-
-// IsTxAllowList returns whether [blockTimestamp] is either equal to the TxAllowList fork block timestamp or greater.
-func (c *ChainConfig) IsTxAllowList(blockTimestamp *big.Int) bool {
-	return utils.IsForked(c.TxAllowListConfig.Timestamp(), blockTimestamp)
-}
-
-func (c *ChainConfig) IsContractDeployerAssetConfig(blockTimestamp *big.Int) bool {
-	return utils.IsForked(c.ContractDeployerAssetConfig.Timestamp(), blockTimestamp)
-}
-
-// IsFeeConfigManager returns whether [blockTimestamp] is either equal to the FeeConfigManager fork block timestamp or greater.
-func (c *ChainConfig) IsFeeConfigManager(blockTimestamp *big.Int) bool {
-	return utils.IsForked(c.FeeManagerConfig.Timestamp(), blockTimestamp)
+// IsPrecompileEnabled returns whether the stateful precompile at [address] is enabled at [blockTimestamp],
+// taking network upgrades into account.
+func (c *ChainConfig) IsPrecompileEnabled(address common.Address, blockTimestamp *big.Int) bool {
+	return c.getActivePrecompileConfig(address, blockTimestamp) != nil
 }
 
 // CheckCompatible checks whether scheduled fork transitions have been imported
@@ -252,6 +252,14 @@ func (c *ChainConfig) Verify() error {
 		return err
 	}
 
+	if err := c.verifyPrecompileConfigs(); err != nil {
+		return err
+	}
+
+	if err := c.verifyPrecompileUpgrades(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -308,6 +316,7 @@ func (c *ChainConfig) CheckConfigForkOrder() error {
 	lastFork = fork{}
 	for _, cur := range []fork{
 		{name: "subnetEVMTimestamp", block: c.SubnetEVMTimestamp},
+		{name: "precompileUpgradeTimestamp", block: c.PrecompileUpgradeTimestamp},
 	} {
 		if lastFork.name != "" {
 			// Next one must be higher number
@@ -370,29 +379,21 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, headHeight *big.Int,
 	if isForkIncompatible(c.SubnetEVMTimestamp, newcfg.SubnetEVMTimestamp, headTimestamp) {
 		return newCompatError("SubnetEVM fork block timestamp", c.SubnetEVMTimestamp, newcfg.SubnetEVMTimestamp)
 	}
-
-	// Check that the configuration of the optional AllowList stateful precompile is compatible.
-	if isForkIncompatible(c.ContractDeployerAllowListConfig.Timestamp(), newcfg.ContractDeployerAllowListConfig.Timestamp(), headTimestamp) {
-		return newCompatError("AllowList fork block timestamp", c.ContractDeployerAllowListConfig.Timestamp(), newcfg.ContractDeployerAllowListConfig.Timestamp())
-	}
-
-	// Check that the configuration of the optional ContractNativeMinter stateful precompile is compatible.
-	if isForkIncompatible(c.ContractNativeMinterConfig.Timestamp(), newcfg.ContractNativeMinterConfig.Timestamp(), headTimestamp) {
-		return newCompatError("ContractNativeMinter fork block timestamp", c.ContractNativeMinterConfig.Timestamp(), newcfg.ContractNativeMinterConfig.Timestamp())
-	}
-
-	// Check that the configuration of the optional TxAllowList stateful precompile is compatible.
-	if isForkIncompatible(c.TxAllowListConfig.Timestamp(), newcfg.TxAllowListConfig.Timestamp(), headTimestamp) {
-		return newCompatError("TxAllowList fork block timestamp", c.TxAllowListConfig.Timestamp(), newcfg.TxAllowListConfig.Timestamp())
+	if isForkIncompatible(c.PrecompileUpgradeTimestamp, newcfg.PrecompileUpgradeTimestamp, headTimestamp) {
+		return newCompatError("PrecompileUpgrade fork block timestamp", c.PrecompileUpgradeTimestamp, newcfg.PrecompileUpgradeTimestamp)
 	}
 
-	// Check that the configuration of the optional FeeManagerConfig stateful precompile is compatible.
-	if isForkIncompatible(c.FeeManagerConfig.Timestamp(), newcfg.FeeManagerConfig.Timestamp(), headTimestamp) {
-		return newCompatError("FeeManagerConfig fork block timestamp", c.FeeManagerConfig.Timestamp(), newcfg.FeeManagerConfig.Timestamp())
+	// Check that the configurations of the optional stateful precompiles are compatible.
+	for _, module := range precompile.RegisteredModules() {
+		storedTimestamp, newTimestamp := c.precompileTimestamp(module.ConfigKey), newcfg.precompileTimestamp(module.ConfigKey)
+		if isForkIncompatible(storedTimestamp, newTimestamp, headTimestamp) {
+			return newCompatError(fmt.Sprintf("%s fork block timestamp", module.ConfigKey), storedTimestamp, newTimestamp)
+		}
 	}
 
-	if isForkIncompatible(c.ContractDeployerAssetConfig.Timestamp(), newcfg.FeeManagerConfig.Timestamp(), headTimestamp) {
-		return newCompatError("AssetConfig fork block timestamp", c.ContractDeployerAssetConfig.Timestamp(), newcfg.ContractDeployerAssetConfig.Timestamp())
+	// Check that the precompile upgrades that have already taken effect are unchanged.
+	if err := c.checkPrecompileUpgradesCompatible(newcfg, headTimestamp); err != nil {
+		return err
 	}
 
 	// TODO verify that the fee config is fully compatible between [c] and [newcfg].
@@ -458,14 +459,8 @@ type Rules struct {
 	IsByzantium, IsConstantinople, IsPetersburg, IsIstanbul bool
 
 	// Rules for Avalanche releases
-	IsSubnetEVM bool
-
-	// Optional stateful precompile rules
-	IsContractDeployerAllowListEnabled bool
-	IsContractNativeMinterEnabled      bool
-	IsTxAllowListEnabled               bool
-	IsFeeConfigManagerEnabled          bool
-	IsContractDeployerAssetEnabled     bool
+	IsSubnetEVM         bool
+	IsPrecompileUpgrade bool
 
 	// Precompiles maps addresses to stateful precompiled contracts that are enabled
 	// for this rule set.
@@ -474,6 +469,12 @@ type Rules struct {
 	Precompiles map[common.Address]precompile.StatefulPrecompiledContract
 }
 
+// IsPrecompileEnabled returns whether the stateful precompile at [address] is enabled for this rule set.
+func (r *Rules) IsPrecompileEnabled(address common.Address) bool {
+	_, ok := r.Precompiles[address]
+	return ok
+}
+
 // Rules ensures c's ChainID is not nil.
 func (c *ChainConfig) rules(num *big.Int) Rules {
 	chainID := c.ChainID
@@ -499,57 +500,48 @@ func (c *ChainConfig) AvalancheRules(blockNum, blockTimestamp *big.Int) Rules {
 	rules := c.rules(blockNum)
 
 	rules.IsSubnetEVM = c.IsSubnetEVM(blockTimestamp)
-	rules.IsContractDeployerAllowListEnabled = c.IsContractDeployerAllowList(blockTimestamp)
-	rules.IsContractNativeMinterEnabled = c.IsContractNativeMinter(blockTimestamp)
-	rules.IsTxAllowListEnabled = c.IsTxAllowList(blockTimestamp)
-	rules.IsFeeConfigManagerEnabled = c.IsFeeConfigManager(blockTimestamp)
-	rules.IsContractDeployerAssetEnabled = c.IsContractDeployerAssetConfig(blockTimestamp)
+	rules.IsPrecompileUpgrade = c.IsPrecompileUpgrade(blockTimestamp)
 
 	// Initialize the stateful precompiles that should be enabled at [blockTimestamp].
 	rules.Precompiles = make(map[common.Address]precompile.StatefulPrecompiledContract)
-	for _, config := range c.enabledStatefulPrecompiles() {
-		if utils.IsForked(config.Timestamp(), blockTimestamp) {
-			rules.Precompiles[config.Address()] = config.Contract()
+	for _, module := range precompile.RegisteredModules() {
+		if config := c.getActivePrecompileConfig(module.Address, blockTimestamp); config != nil {
+			rules.Precompiles[module.Address] = config.Contract()
 		}
 	}
 
 	return rules
 }
 
-// enabledStatefulPrecompiles returns a list of stateful precompile configs in the order that they are enabled
-// by block timestamp.
+// enabledStatefulPrecompiles returns the genesis configs of the stateful precompiles that are enabled at some
+// timestamp, in the order of the registered modules.
 func (c *ChainConfig) enabledStatefulPrecompiles() []precompile.StatefulPrecompileConfig {
 	statefulPrecompileConfigs := make([]precompile.StatefulPrecompileConfig, 0)
-
-	if c.ContractDeployerAllowListConfig.Timestamp() != nil {
-		statefulPrecompileConfigs = append(statefulPrecompileConfigs, &c.ContractDeployerAllowListConfig)
-	}
-
-	if c.ContractNativeMinterConfig.Timestamp() != nil {
-		statefulPrecompileConfigs = append(statefulPrecompileConfigs, &c.ContractNativeMinterConfig)
-	}
-
-	if c.TxAllowListConfig.Timestamp() != nil {
-		statefulPrecompileConfigs = append(statefulPrecompileConfigs, &c.TxAllowListConfig)
-	}
-
-	if c.FeeManagerConfig.Timestamp() != nil {
-		statefulPrecompileConfigs = append(statefulPrecompileConfigs, &c.FeeManagerConfig)
-	}
-
-	if c.ContractDeployerAssetConfig.Timestamp() != nil {
-		statefulPrecompileConfigs = append(statefulPrecompileConfigs, &c.ContractDeployerAssetConfig)
+	for _, module := range precompile.RegisteredModules() {
+		if config := c.getPrecompileConfig(module.ConfigKey); config != nil && config.Timestamp() != nil {
+			statefulPrecompileConfigs = append(statefulPrecompileConfigs, config)
+		}
 	}
-
 	return statefulPrecompileConfigs
 }
 
-// CheckConfigurePrecompiles iterates over any stateful precompile configs that go into effect at some point and configures them
-// if they are activated between [parentTimestamp] and [currentTimestamp].
+// CheckConfigurePrecompiles iterates over the stateful precompile configs, including network upgrades, and configures
+// those that are activated or reconfigured between [parentTimestamp] and the timestamp of [blockContext]. Precompiles
+// disabled in that transition have their state cleared. A pending fee config that takes effect by the timestamp of
+// [blockContext] is applied.
 func (c *ChainConfig) CheckConfigurePrecompiles(parentTimestamp *big.Int, blockContext precompile.BlockContext, statedb precompile.StateDB) {
-	// Iterate the enabled stateful precompiles and configure them if needed
-	for _, config := range c.enabledStatefulPrecompiles() {
-		precompile.CheckConfigure(c, parentTimestamp, blockContext, config, statedb)
+	for _, module := range precompile.RegisteredModules() {
+		for _, config := range c.getActivatingPrecompileConfigs(module.Address, parentTimestamp, blockContext.Timestamp()) {
+			if config.IsDisabled() {
+				config.Disable(c, statedb, blockContext)
+			} else {
+				precompile.Configure(c, blockContext, config, statedb)
+			}
+		}
+	}
+	// Apply the fee config scheduled through the fee config manager once it takes effect.
+	if c.IsPrecompileEnabled(precompile.FeeConfigManagerAddress, blockContext.Timestamp()) {
+		precompile.ApplyPendingFeeConfig(statedb, blockContext)
 	}
 }
 
@@ -0,0 +1,152 @@
+// (c) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package params
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ir4tech/webb-evm/precompile"
+)
+
+// upgradesKey is the JSON key of the UpgradeConfig in the persisted chain config.
+const upgradesKey = "upgrades"
+
+// Precompiles maps the config keys of registered stateful precompile modules to their configs.
+type Precompiles map[string]precompile.StatefulPrecompileConfig
+
+// chainConfigJSON has the fields of ChainConfig without its JSON methods, so that the fixed
+// fields can be encoded with the standard library.
+type chainConfigJSON ChainConfig
+
+// MarshalJSON returns the JSON encoding of [c] with the config of each stateful precompile
+// encoded under its config key alongside the other fields.
+func (c ChainConfig) MarshalJSON() ([]byte, error) {
+	configBytes, err := json.Marshal((*chainConfigJSON)(&c))
+	if err != nil {
+		return nil, err
+	}
+	if len(c.PrecompileConfigs) == 0 {
+		return configBytes, nil
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(configBytes, &fields); err != nil {
+		return nil, err
+	}
+	for key, config := range c.PrecompileConfigs {
+		if config == nil {
+			continue
+		}
+		configBytes, err := json.Marshal(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s: %w", key, err)
+		}
+		fields[key] = configBytes
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON parses the JSON encoded [data] into [c], decoding the config of each registered
+// stateful precompile from its config key.
+func (c *ChainConfig) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*chainConfigJSON)(c)); err != nil {
+		return err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	c.PrecompileConfigs = make(Precompiles)
+	for _, module := range precompile.RegisteredModules() {
+		configBytes, ok := fields[module.ConfigKey]
+		if !ok {
+			continue
+		}
+		config := module.NewConfig()
+		if err := json.Unmarshal(configBytes, config); err != nil {
+			return fmt.Errorf("failed to unmarshal %s: %w", module.ConfigKey, err)
+		}
+		c.PrecompileConfigs[module.ConfigKey] = config
+	}
+	return nil
+}
+
+// MarshalJSON returns the JSON encoding of the chain config with its network upgrades under [upgradesKey].
+func (c ChainConfigWithUpgradesJSON) MarshalJSON() ([]byte, error) {
+	configBytes, err := json.Marshal(c.ChainConfig)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(configBytes, &fields); err != nil {
+		return nil, err
+	}
+	upgradeBytes, err := json.Marshal(c.UpgradeConfig)
+	if err != nil {
+		return nil, err
+	}
+	fields[upgradesKey] = upgradeBytes
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON parses a chain config and its network upgrades from the JSON encoded [data].
+func (c *ChainConfigWithUpgradesJSON) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &c.ChainConfig); err != nil {
+		return err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	c.UpgradeConfig = UpgradeConfig{}
+	if upgradeBytes, ok := fields[upgradesKey]; ok {
+		return json.Unmarshal(upgradeBytes, &c.UpgradeConfig)
+	}
+	return nil
+}
+
+// getPrecompileConfig returns the genesis config of the stateful precompile registered
+// with [configKey], or nil if it is not configured.
+func (c *ChainConfig) getPrecompileConfig(configKey string) precompile.StatefulPrecompileConfig {
+	return c.PrecompileConfigs[configKey]
+}
+
+// precompileTimestamp returns the timestamp at which the genesis config of the stateful
+// precompile registered with [configKey] enables it, or nil if it is never enabled.
+func (c *ChainConfig) precompileTimestamp(configKey string) *big.Int {
+	config := c.getPrecompileConfig(configKey)
+	if config == nil {
+		return nil
+	}
+	return config.Timestamp()
+}
+
+// verifyPrecompileConfigs checks that each genesis precompile config belongs to a registered
+// module and is valid.
+func (c *ChainConfig) verifyPrecompileConfigs() error {
+	keys := make([]string, 0, len(c.PrecompileConfigs))
+	for key := range c.PrecompileConfigs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		config := c.PrecompileConfigs[key]
+		if config == nil {
+			continue
+		}
+		module, ok := precompile.GetRegisteredModule(key)
+		if !ok {
+			return fmt.Errorf("unknown precompile config key %s", key)
+		}
+		if module.Address != config.Address() {
+			return fmt.Errorf("precompile config %s has address %s, expected %s", key, config.Address(), module.Address)
+		}
+		if err := config.Verify(); err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+// (c) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package params
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ir4tech/webb-evm/precompile"
+	"github.com/ir4tech/webb-evm/utils"
+)
+
+var (
+	errNoPrecompileUpgradeConfig       = errors.New("precompile upgrade must specify a config")
+	errMultiplePrecompileUpgradeConfig = errors.New("precompile upgrade must specify exactly one config")
+)
+
+// UpgradeConfig specifies the network upgrades that take effect after genesis. It is loaded from
+// the upgrade bytes passed to the VM (upgrade.json) rather than from the genesis.
+type UpgradeConfig struct {
+	// PrecompileUpgrades activate, reconfigure or disable stateful precompiles at a block timestamp.
+	// Upgrades of the same precompile must be listed in increasing timestamp order.
+	PrecompileUpgrades []PrecompileUpgrade `json:"precompileUpgrades,omitempty"`
+}
+
+// PrecompileUpgrade is a single network upgrade of a stateful precompile. It is encoded as an object
+// with exactly one registered precompile config key, such as {"txAllowListConfig": {...}}. A config
+// that disables the precompile turns it off at its timestamp, otherwise the config enables the
+// precompile, or replaces the active config if it is already enabled.
+type PrecompileUpgrade struct {
+	Config precompile.StatefulPrecompileConfig
+}
+
+// MarshalJSON returns the JSON encoding of [p], keyed by the config key of its precompile.
+func (p PrecompileUpgrade) MarshalJSON() ([]byte, error) {
+	if p.Config == nil {
+		return []byte("{}"), nil
+	}
+	module, ok := precompile.GetRegisteredModuleByAddress(p.Config.Address())
+	if !ok {
+		return nil, fmt.Errorf("no precompile module registered at %s", p.Config.Address())
+	}
+	return json.Marshal(map[string]precompile.StatefulPrecompileConfig{module.ConfigKey: p.Config})
+}
+
+// UnmarshalJSON parses the JSON encoded [data] into [p], decoding the config of the precompile
+// registered with its single key.
+func (p *PrecompileUpgrade) UnmarshalJSON(data []byte) error {
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	p.Config = nil
+	switch len(fields) {
+	case 0:
+		return nil
+	case 1:
+	default:
+		return errMultiplePrecompileUpgradeConfig
+	}
+	for key, configBytes := range fields {
+		module, ok := precompile.GetRegisteredModule(key)
+		if !ok {
+			return fmt.Errorf("unknown precompile config key %s", key)
+		}
+		config := module.NewConfig()
+		if err := json.Unmarshal(configBytes, config); err != nil {
+			return fmt.Errorf("failed to unmarshal %s: %w", key, err)
+		}
+		p.Config = config
+	}
+	return nil
+}
+
+// precompileUpgrades returns the configs of the upgrades of the precompile at [address], in the
+// order they are listed.
+// Assumes the upgrades have been verified.
+func (c *ChainConfig) precompileUpgrades(address common.Address) []precompile.StatefulPrecompileConfig {
+	var configs []precompile.StatefulPrecompileConfig
+	for _, upgrade := range c.UpgradeConfig.PrecompileUpgrades {
+		if upgrade.Config == nil || upgrade.Config.Address() != address {
+			continue
+		}
+		configs = append(configs, upgrade.Config)
+	}
+	return configs
+}
+
+// precompileConfigs returns every config of the precompile at [address] in the order they take
+// effect: the genesis config, if it is ever enabled, followed by its network upgrades.
+func (c *ChainConfig) precompileConfigs(address common.Address) []precompile.StatefulPrecompileConfig {
+	var configs []precompile.StatefulPrecompileConfig
+	for _, config := range c.enabledStatefulPrecompiles() {
+		if config.Address() == address {
+			configs = append(configs, config)
+		}
+	}
+	return append(configs, c.precompileUpgrades(address)...)
+}
+
+// getActivePrecompileConfig returns the config of the precompile at [address] that is in effect at
+// [blockTimestamp], or nil if the precompile is not enabled at [blockTimestamp].
+func (c *ChainConfig) getActivePrecompileConfig(address common.Address, blockTimestamp *big.Int) precompile.StatefulPrecompileConfig {
+	var active precompile.StatefulPrecompileConfig
+	for _, config := range c.precompileConfigs(address) {
+		if !utils.IsForked(config.Timestamp(), blockTimestamp) {
+			break
+		}
+		active = config
+	}
+	if active == nil || active.IsDisabled() {
+		return nil
+	}
+	return active
+}
+
+// getActivatingPrecompileConfigs returns the configs of the precompile at [address] that take
+// effect during the transition from [parentTimestamp] to [blockTimestamp]. A nil [parentTimestamp]
+// denotes genesis.
+func (c *ChainConfig) getActivatingPrecompileConfigs(address common.Address, parentTimestamp *big.Int, blockTimestamp *big.Int) []precompile.StatefulPrecompileConfig {
+	var configs []precompile.StatefulPrecompileConfig
+	for _, config := range c.precompileConfigs(address) {
+		if utils.IsForkTransition(config.Timestamp(), parentTimestamp, blockTimestamp) {
+			configs = append(configs, config)
+		}
+	}
+	return configs
+}
+
+// verifyPrecompileUpgrades checks that each precompile upgrade specifies a valid config of a
+// registered precompile with a timestamp, that the upgrades of each precompile are ordered after its genesis config and
+// each other, and that a precompile is only disabled while it is enabled.
+func (c *ChainConfig) verifyPrecompileUpgrades() error {
+	for i, upgrade := range c.UpgradeConfig.PrecompileUpgrades {
+		config := upgrade.Config
+		if config == nil {
+			return fmt.Errorf("invalid precompile upgrade at index %d: %w", i, errNoPrecompileUpgradeConfig)
+		}
+		if _, ok := precompile.GetRegisteredModuleByAddress(config.Address()); !ok {
+			return fmt.Errorf("invalid precompile upgrade at index %d: no precompile module registered at %s", i, config.Address())
+		}
+		if config.Timestamp() == nil {
+			return fmt.Errorf("invalid precompile upgrade at index %d: missing block timestamp", i)
+		}
+		if !config.IsDisabled() {
+			if err := config.Verify(); err != nil {
+				return fmt.Errorf("invalid precompile upgrade at index %d: %w", i, err)
+			}
+		}
+	}
+
+	for _, module := range precompile.RegisteredModules() {
+		var (
+			lastTimestamp *big.Int
+			enabled       bool
+		)
+		for _, config := range c.precompileConfigs(module.Address) {
+			if lastTimestamp != nil && config.Timestamp().Cmp(lastTimestamp) <= 0 {
+				return fmt.Errorf("precompile upgrade for %s at timestamp %v must be after timestamp %v", module.ConfigKey, config.Timestamp(), lastTimestamp)
+			}
+			if config.IsDisabled() && !enabled {
+				return fmt.Errorf("precompile upgrade for %s at timestamp %v disables a precompile that is not enabled", module.ConfigKey, config.Timestamp())
+			}
+			lastTimestamp = config.Timestamp()
+			enabled = !config.IsDisabled()
+		}
+	}
+	return nil
+}
+
+// checkPrecompileUpgradesCompatible checks that the precompile upgrades of [c] and [newcfg] that
+// have already taken effect at [headTimestamp] are identical.
+func (c *ChainConfig) checkPrecompileUpgradesCompatible(newcfg *ChainConfig, headTimestamp *big.Int) *ConfigCompatError {
+	for _, module := range precompile.RegisteredModules() {
+		var (
+			stored = c.precompileUpgrades(module.Address)
+			next   = newcfg.precompileUpgrades(module.Address)
+		)
+		for i := 0; i < len(stored) || i < len(next); i++ {
+			var storedConfig, newConfig precompile.StatefulPrecompileConfig
+			var storedTimestamp, newTimestamp *big.Int
+			if i < len(stored) {
+				storedConfig, storedTimestamp = stored[i], stored[i].Timestamp()
+			}
+			if i < len(next) {
+				newConfig, newTimestamp = next[i], next[i].Timestamp()
+			}
+			if !utils.IsForked(storedTimestamp, headTimestamp) && !utils.IsForked(newTimestamp, headTimestamp) {
+				break
+			}
+			if !precompileConfigsEqual(storedConfig, newConfig) {
+				return newCompatError(fmt.Sprintf("%s upgrade block timestamp", module.ConfigKey), storedTimestamp, newTimestamp)
+			}
+		}
+	}
+	return nil
+}
+
+// precompileConfigsEqual returns true if [a] and [b] are both nil or have the same encoding.
+// Configs are compared by encoding since they hold big.Int values whose internal
+// representation may differ for equal numbers.
+func precompileConfigsEqual(a, b precompile.StatefulPrecompileConfig) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	aBytes, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bBytes, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return a.Address() == b.Address() && string(aBytes) == string(bBytes)
+}
@@ -0,0 +1,308 @@
+// (c) 2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package params
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ir4tech/webb-evm/commontype"
+	"github.com/ir4tech/webb-evm/precompile"
+	"github.com/stretchr/testify/assert"
+)
+
+func txAllowListUpgrade(timestamp int64, disable bool, admins ...common.Address) PrecompileUpgrade {
+	return PrecompileUpgrade{
+		Config: &precompile.TxAllowListConfig{
+			AllowListConfig: precompile.AllowListConfig{
+				BlockTimestamp:  big.NewInt(timestamp),
+				AllowListAdmins: admins,
+				Disable:         disable,
+			},
+		},
+	}
+}
+
+func TestVerifyPrecompileUpgrades(t *testing.T) {
+	admin := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	tests := map[string]struct {
+		genesisTimestamp *big.Int
+		upgrades         []PrecompileUpgrade
+		expectedError    string
+	}{
+		"enable, reconfigure and disable": {
+			upgrades: []PrecompileUpgrade{
+				txAllowListUpgrade(1, false, admin),
+				txAllowListUpgrade(2, false),
+				txAllowListUpgrade(3, true),
+				txAllowListUpgrade(4, false, admin),
+			},
+		},
+		"disable genesis precompile": {
+			genesisTimestamp: big.NewInt(0),
+			upgrades:         []PrecompileUpgrade{txAllowListUpgrade(1, true)},
+		},
+		"missing config": {
+			upgrades:      []PrecompileUpgrade{{}},
+			expectedError: "precompile upgrade must specify a config",
+		},
+		"missing timestamp": {
+			upgrades:      []PrecompileUpgrade{{Config: &precompile.TxAllowListConfig{}}},
+			expectedError: "missing block timestamp",
+		},
+		"upgrade before genesis config": {
+			genesisTimestamp: big.NewInt(5),
+			upgrades:         []PrecompileUpgrade{txAllowListUpgrade(5, false)},
+			expectedError:    "must be after timestamp 5",
+		},
+		"upgrades out of order": {
+			upgrades: []PrecompileUpgrade{
+				txAllowListUpgrade(2, false),
+				txAllowListUpgrade(1, true),
+			},
+			expectedError: "must be after timestamp 2",
+		},
+		"disable before enable": {
+			upgrades:      []PrecompileUpgrade{txAllowListUpgrade(1, true)},
+			expectedError: "disables a precompile that is not enabled",
+		},
+		"disable twice": {
+			upgrades: []PrecompileUpgrade{
+				txAllowListUpgrade(1, false),
+				txAllowListUpgrade(2, true),
+				txAllowListUpgrade(3, true),
+			},
+			expectedError: "disables a precompile that is not enabled",
+		},
+		"invalid asset config": {
+			upgrades: []PrecompileUpgrade{{
+				Config: &precompile.ContractDeployerAssetConfig{
+					AllowListConfig: precompile.AllowListConfig{BlockTimestamp: big.NewInt(1)},
+					AssetConfig: precompile.AssetConfig{
+						InitialAssets: []precompile.AssetAllocation{{}},
+					},
+				},
+			}},
+			expectedError: "invalid initial asset",
+		},
+		"fee config min bound above max bound": {
+			upgrades: []PrecompileUpgrade{{
+				Config: &precompile.FeeConfigManagerConfig{
+					AllowListConfig: precompile.AllowListConfig{BlockTimestamp: big.NewInt(1)},
+					MinFeeConfig:    &commontype.FeeConfig{GasLimit: big.NewInt(20_000_000)},
+					MaxFeeConfig:    &commontype.FeeConfig{GasLimit: big.NewInt(10_000_000)},
+				},
+			}},
+			expectedError: "invalid fee config bounds",
+		},
+		"negative fee config bound": {
+			upgrades: []PrecompileUpgrade{{
+				Config: &precompile.FeeConfigManagerConfig{
+					AllowListConfig: precompile.AllowListConfig{BlockTimestamp: big.NewInt(1)},
+					MinFeeConfig:    &commontype.FeeConfig{MinBaseFee: big.NewInt(-1)},
+				},
+			}},
+			expectedError: "invalid fee config bounds",
+		},
+		"invalid contract call selector": {
+			upgrades: []PrecompileUpgrade{{
+				Config: &precompile.ContractCallAllowListConfig{
+					AllowListConfig: precompile.AllowListConfig{BlockTimestamp: big.NewInt(1)},
+					Restrictions:    []precompile.ContractCallRestriction{{Contract: admin, Selector: []byte{1, 2, 3}}},
+				},
+			}},
+			expectedError: "invalid contract call restriction",
+		},
+		"duplicate contract call restriction": {
+			upgrades: []PrecompileUpgrade{{
+				Config: &precompile.ContractCallAllowListConfig{
+					AllowListConfig: precompile.AllowListConfig{BlockTimestamp: big.NewInt(1)},
+					Restrictions: []precompile.ContractCallRestriction{
+						{Contract: admin, Selector: []byte{1, 2, 3, 4}},
+						{Contract: admin, Selector: []byte{1, 2, 3, 4}, Callers: []common.Address{admin}},
+					},
+				},
+			}},
+			expectedError: "duplicate selector",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := *TestChainConfig
+			config.PrecompileConfigs = Precompiles{
+				precompile.TxAllowListConfigKey: &precompile.TxAllowListConfig{
+					AllowListConfig: precompile.AllowListConfig{BlockTimestamp: test.genesisTimestamp},
+				},
+			}
+			config.UpgradeConfig = UpgradeConfig{PrecompileUpgrades: test.upgrades}
+
+			err := config.Verify()
+			if test.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, test.expectedError)
+			}
+		})
+	}
+}
+
+func TestGetActivePrecompileConfig(t *testing.T) {
+	admin := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	genesisConfig := &precompile.TxAllowListConfig{
+		AllowListConfig: precompile.AllowListConfig{BlockTimestamp: big.NewInt(0)},
+	}
+	config := *TestChainConfig
+	config.PrecompileConfigs = Precompiles{precompile.TxAllowListConfigKey: genesisConfig}
+	config.UpgradeConfig = UpgradeConfig{PrecompileUpgrades: []PrecompileUpgrade{
+		txAllowListUpgrade(10, true),
+		txAllowListUpgrade(20, false, admin),
+	}}
+
+	assert.Equal(t, genesisConfig, config.getActivePrecompileConfig(precompile.TxAllowListAddress, big.NewInt(9)))
+	assert.Nil(t, config.getActivePrecompileConfig(precompile.TxAllowListAddress, big.NewInt(10)))
+	assert.Nil(t, config.getActivePrecompileConfig(precompile.TxAllowListAddress, big.NewInt(19)))
+	assert.Equal(t, config.UpgradeConfig.PrecompileUpgrades[1].Config, config.getActivePrecompileConfig(precompile.TxAllowListAddress, big.NewInt(20)))
+	assert.Nil(t, config.getActivePrecompileConfig(precompile.ContractNativeMinterAddress, big.NewInt(20)))
+
+	assert.True(t, config.IsPrecompileEnabled(precompile.TxAllowListAddress, big.NewInt(5)))
+	assert.False(t, config.IsPrecompileEnabled(precompile.TxAllowListAddress, big.NewInt(15)))
+	assert.True(t, config.IsPrecompileEnabled(precompile.TxAllowListAddress, big.NewInt(25)))
+
+	_, enabled := config.AvalancheRules(common.Big0, big.NewInt(15)).Precompiles[precompile.TxAllowListAddress]
+	assert.False(t, enabled)
+
+	activating := config.getActivatingPrecompileConfigs(precompile.TxAllowListAddress, big.NewInt(5), big.NewInt(20))
+	assert.Len(t, activating, 2)
+	activating = config.getActivatingPrecompileConfigs(precompile.TxAllowListAddress, nil, big.NewInt(0))
+	assert.Equal(t, []precompile.StatefulPrecompileConfig{genesisConfig}, activating)
+}
+
+func TestCheckPrecompileUpgradesCompatible(t *testing.T) {
+	admin := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	tests := map[string]struct {
+		stored, new   []PrecompileUpgrade
+		headTimestamp uint64
+		wantErr       *ConfigCompatError
+	}{
+		"identical upgrades": {
+			stored:        []PrecompileUpgrade{txAllowListUpgrade(10, false)},
+			new:           []PrecompileUpgrade{txAllowListUpgrade(10, false)},
+			headTimestamp: 20,
+		},
+		"add future upgrade": {
+			stored:        []PrecompileUpgrade{txAllowListUpgrade(10, false)},
+			new:           []PrecompileUpgrade{txAllowListUpgrade(10, false), txAllowListUpgrade(30, true)},
+			headTimestamp: 20,
+		},
+		"reschedule future upgrade": {
+			stored:        []PrecompileUpgrade{txAllowListUpgrade(30, false)},
+			new:           []PrecompileUpgrade{txAllowListUpgrade(40, false)},
+			headTimestamp: 20,
+		},
+		"remove activated upgrade": {
+			stored:        []PrecompileUpgrade{txAllowListUpgrade(10, false)},
+			headTimestamp: 20,
+			wantErr: &ConfigCompatError{
+				What:         "txAllowListConfig upgrade block timestamp",
+				StoredConfig: big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
+		"reschedule activated upgrade": {
+			stored:        []PrecompileUpgrade{txAllowListUpgrade(10, false)},
+			new:           []PrecompileUpgrade{txAllowListUpgrade(15, false)},
+			headTimestamp: 20,
+			wantErr: &ConfigCompatError{
+				What:         "txAllowListConfig upgrade block timestamp",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(15),
+				RewindTo:     9,
+			},
+		},
+		"reconfigure activated upgrade": {
+			stored:        []PrecompileUpgrade{txAllowListUpgrade(10, false)},
+			new:           []PrecompileUpgrade{txAllowListUpgrade(10, false, admin)},
+			headTimestamp: 20,
+			wantErr: &ConfigCompatError{
+				What:         "txAllowListConfig upgrade block timestamp",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			stored, newcfg := *TestChainConfig, *TestChainConfig
+			stored.UpgradeConfig = UpgradeConfig{PrecompileUpgrades: test.stored}
+			newcfg.UpgradeConfig = UpgradeConfig{PrecompileUpgrades: test.new}
+
+			err := stored.CheckCompatible(&newcfg, 0, test.headTimestamp)
+			assert.Equal(t, test.wantErr, err)
+		})
+	}
+}
+
+func TestPrecompileUpgradeJSON(t *testing.T) {
+	admin := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	upgradeJSON := `{"precompileUpgrades":[{"txAllowListConfig":{"blockTimestamp":1,"adminAddresses":["0x0000000000000000000000000000000000000001"]}},{"txAllowListConfig":{"blockTimestamp":2,"adminAddresses":null,"disable":true}}]}`
+
+	var upgradeConfig UpgradeConfig
+	assert.NoError(t, json.Unmarshal([]byte(upgradeJSON), &upgradeConfig))
+	assert.Equal(t, []PrecompileUpgrade{txAllowListUpgrade(1, false, admin), txAllowListUpgrade(2, true)}, upgradeConfig.PrecompileUpgrades)
+
+	encoded, err := json.Marshal(upgradeConfig)
+	assert.NoError(t, err)
+	assert.JSONEq(t, upgradeJSON, string(encoded))
+
+	for name, test := range map[string]struct {
+		upgradeJSON   string
+		expectedError string
+	}{
+		"multiple configs": {
+			upgradeJSON:   `{"precompileUpgrades":[{"txAllowListConfig":{"blockTimestamp":1},"contractNativeMinterConfig":{"blockTimestamp":1}}]}`,
+			expectedError: "precompile upgrade must specify exactly one config",
+		},
+		"unknown config key": {
+			upgradeJSON:   `{"precompileUpgrades":[{"unknownConfig":{"blockTimestamp":1}}]}`,
+			expectedError: "unknown precompile config key unknownConfig",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var upgradeConfig UpgradeConfig
+			assert.ErrorContains(t, json.Unmarshal([]byte(test.upgradeJSON), &upgradeConfig), test.expectedError)
+		})
+	}
+}
+
+func TestChainConfigPrecompilesJSON(t *testing.T) {
+	admin := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	configJSON := `{"chainId":1,"feeConfig":{"gasLimit":8000000},"txAllowListConfig":{"blockTimestamp":0,"adminAddresses":["0x0000000000000000000000000000000000000001"]}}`
+
+	var config ChainConfig
+	assert.NoError(t, json.Unmarshal([]byte(configJSON), &config))
+	assert.Equal(t, Precompiles{
+		precompile.TxAllowListConfigKey: &precompile.TxAllowListConfig{
+			AllowListConfig: precompile.AllowListConfig{BlockTimestamp: big.NewInt(0), AllowListAdmins: []common.Address{admin}},
+		},
+	}, config.PrecompileConfigs)
+	assert.True(t, config.IsPrecompileEnabled(precompile.TxAllowListAddress, big.NewInt(0)))
+
+	encoded, err := json.Marshal(&config)
+	assert.NoError(t, err)
+	var decoded ChainConfig
+	assert.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, config, decoded)
+
+	// The persisted encoding includes the network upgrades, which the genesis encoding omits.
+	config.UpgradeConfig = UpgradeConfig{PrecompileUpgrades: []PrecompileUpgrade{txAllowListUpgrade(10, true)}}
+	encoded, err = json.Marshal(ChainConfigWithUpgradesJSON{ChainConfig: config, UpgradeConfig: config.UpgradeConfig})
+	assert.NoError(t, err)
+	var persisted ChainConfigWithUpgradesJSON
+	assert.NoError(t, json.Unmarshal(encoded, &persisted))
+	assert.Equal(t, config.UpgradeConfig, persisted.UpgradeConfig)
+	assert.Equal(t, config.PrecompileConfigs, persisted.ChainConfig.PrecompileConfigs)
+}
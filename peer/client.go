@@ -19,12 +19,13 @@ var (
 
 // Client defines ability to send request / response through the Network
 type Client interface {
-	// RequestAny synchronously sends request to the first connected peer that matches the specified minVersion in
-	// random order.
+	// RequestAny synchronously sends request to a connected peer that matches the specified minVersion,
+	// preferring well-behaved peers.
 	// A peer is considered a match if its version is greater than or equal to the specified minVersion
+	// Returns the response bytes and the nodeID of the peer that sent them.
 	// Returns errNoPeersMatchingVersion if no peer could be found matching specified version
 	// and errRequestFailed if the request should be retried.
-	RequestAny(minVersion *version.Application, request []byte) ([]byte, error)
+	RequestAny(minVersion *version.Application, request []byte) ([]byte, ids.NodeID, error)
 
 	// Request synchronously sends request to the selected nodeID
 	// Returns response bytes
@@ -33,6 +34,10 @@ type Client interface {
 
 	// Gossip sends given gossip message to peers
 	Gossip(gossip []byte) error
+
+	// TrackInvalidResponse reports that [nodeID] sent a response that failed verification
+	// so that subsequent requests avoid it.
+	TrackInvalidResponse(nodeID ids.NodeID)
 }
 
 // client implements Client interface
@@ -41,19 +46,20 @@ type client struct {
 	network Network
 }
 
-// RequestAny synchronously sends request to the first connected peer that matches the specified minVersion in
-// random order and blocks until it receives a response or the request could not be sent or times out.
-// Returns the response bytes from the peer.
-func (c *client) RequestAny(minVersion *version.Application, request []byte) ([]byte, error) {
+// RequestAny synchronously sends request to a connected peer that matches the specified minVersion
+// and blocks until it receives a response or the request could not be sent or times out.
+// Returns the response bytes from the peer and its nodeID.
+func (c *client) RequestAny(minVersion *version.Application, request []byte) ([]byte, ids.NodeID, error) {
 	waitingHandler := newWaitingResponseHandler()
-	if err := c.network.RequestAny(minVersion, request, waitingHandler); err != nil {
-		return nil, err
+	nodeID, err := c.network.RequestAny(minVersion, request, waitingHandler)
+	if err != nil {
+		return nil, nodeID, err
 	}
 	response := <-waitingHandler.responseChan
 	if waitingHandler.failed {
-		return nil, errRequestFailed
+		return nil, nodeID, errRequestFailed
 	}
-	return response, nil
+	return response, nodeID, nil
 }
 
 // Request synchronously sends [request] message to specified [nodeID]
@@ -74,6 +80,10 @@ func (c *client) Gossip(gossip []byte) error {
 	return c.network.Gossip(gossip)
 }
 
+func (c *client) TrackInvalidResponse(nodeID ids.NodeID) {
+	c.network.TrackInvalidResponse(nodeID)
+}
+
 // NewClient returns Client for a given network
 func NewClient(network Network) Client {
 	return &client{
@@ -37,11 +37,12 @@ type Network interface {
 	validators.Connector
 	common.AppHandler
 
-	// RequestAny synchronously sends request to the first connected peer that matches the specified minVersion in
-	// random order.
+	// RequestAny sends request to a connected peer that matches the specified minVersion, preferring peers
+	// with a good track record of responding and passing over benched peers.
 	// A peer is considered a match if its version is greater than or equal to the specified minVersion
-	// Returns an error if the request could not be sent to a peer with the desired [minVersion].
-	RequestAny(minVersion *version.Application, message []byte, handler message.ResponseHandler) error
+	// Returns the nodeID of the selected peer, or an error if the request could not be sent to a peer
+	// with the desired [minVersion].
+	RequestAny(minVersion *version.Application, message []byte, handler message.ResponseHandler) (ids.NodeID, error)
 
 	// Request sends message to given nodeID, notifying handler when there's a response or timeout
 	Request(nodeID ids.NodeID, message []byte, handler message.ResponseHandler) error
@@ -62,6 +63,19 @@ type Network interface {
 
 	// Size returns the size of the network in number of connected peers
 	Size() uint32
+
+	// TrackInvalidResponse lowers the score of [nodeID] and benches it after it sent
+	// a response that failed verification.
+	TrackInvalidResponse(nodeID ids.NodeID)
+
+	// PeerScores returns the reputation of every peer the network has sent requests to
+	PeerScores() []PeerScore
+}
+
+// outstandingRequest is a request awaiting a response
+type outstandingRequest struct {
+	handler message.ResponseHandler
+	sentAt  time.Time
 }
 
 // network is an implementation of Network that processes message requests for
@@ -70,7 +84,7 @@ type network struct {
 	lock                          sync.RWMutex                        // lock for mutating state of this Network struct
 	self                          ids.NodeID                          // NodeID of this node
 	requestIDGen                  uint32                              // requestID counter used to track outbound requests
-	outstandingResponseHandlerMap map[uint32]message.ResponseHandler  // maps avalanchego requestID => response handler
+	outstandingResponseHandlerMap map[uint32]outstandingRequest       // maps avalanchego requestID => response handler
 	activeRequests                *semaphore.Weighted                 // controls maximum number of active outbound requests
 	appSender                     common.AppSender                    // avalanchego AppSender for sending messages
 	codec                         codec.Manager                       // Codec used for parsing messages
@@ -78,6 +92,7 @@ type network struct {
 	gossipHandler                 message.GossipHandler               // maps gossip type => handler
 	peers                         map[ids.NodeID]*version.Application // maps nodeID => version.Version
 	stats                         stats.RequestHandlerStats           // Provide request handler metrics
+	peerTracker                   *peerTracker                        // tracks peer reputation for request routing
 }
 
 func NewNetwork(appSender common.AppSender, codec codec.Manager, self ids.NodeID, maxActiveRequests int64) Network {
@@ -85,39 +100,43 @@ func NewNetwork(appSender common.AppSender, codec codec.Manager, self ids.NodeID
 		appSender:                     appSender,
 		codec:                         codec,
 		self:                          self,
-		outstandingResponseHandlerMap: make(map[uint32]message.ResponseHandler),
+		outstandingResponseHandlerMap: make(map[uint32]outstandingRequest),
 		peers:                         make(map[ids.NodeID]*version.Application),
 		activeRequests:                semaphore.NewWeighted(maxActiveRequests),
 		gossipHandler:                 message.NoopMempoolGossipHandler{},
 		stats:                         stats.NewRequestHandlerStats(),
+		peerTracker:                   newPeerTracker(),
 	}
 }
 
-// RequestAny sends given request to the first connected peer that matches the specified minVersion
+// RequestAny sends given request to a connected peer that matches the specified minVersion
 // A peer is considered a match if its version is greater than or equal to the specified minVersion
 // If minVersion is nil, then the request will be sent to any peer regardless of their version
-// Returns a non-nil error if we were not able to send a request to a peer with >= [minVersion]
-// or we fail to send a request to the selected peer.
-func (n *network) RequestAny(minVersion *version.Application, request []byte, handler message.ResponseHandler) error {
+// Among matching peers, the peer tracker picks one that is not benched and has a good score.
+// Returns the nodeID the request was sent to, or a non-nil error if we were not able to send
+// a request to a peer with >= [minVersion] or we fail to send a request to the selected peer.
+func (n *network) RequestAny(minVersion *version.Application, request []byte, handler message.ResponseHandler) (ids.NodeID, error) {
 	// Take a slot from total [activeRequests] and block until a slot becomes available.
 	if err := n.activeRequests.Acquire(context.Background(), 1); err != nil {
-		return errAcquiringSemaphore
+		return ids.EmptyNodeID, errAcquiringSemaphore
 	}
 
 	n.lock.Lock()
 	defer n.lock.Unlock()
 
+	candidates := make([]ids.NodeID, 0, len(n.peers))
 	for nodeID, nodeVersion := range n.peers {
-		// map iteration is sufficiently random to avoid hitting same peer so here
-		// we get a random peerID key that we compare minimum version that
-		// we have
 		if minVersion == nil || nodeVersion.Compare(minVersion) >= 0 {
-			return n.request(nodeID, request, handler)
+			candidates = append(candidates, nodeID)
 		}
 	}
 
+	if nodeID, ok := n.peerTracker.SelectPeer(candidates); ok {
+		return nodeID, n.request(nodeID, request, handler)
+	}
+
 	n.activeRequests.Release(1)
-	return fmt.Errorf("no peers found matching version %s out of %d peers", minVersion, len(n.peers))
+	return ids.EmptyNodeID, fmt.Errorf("no peers found matching version %s out of %d peers", minVersion, len(n.peers))
 }
 
 // Request sends request message bytes to specified nodeID, notifying the responseHandler on response or failure
@@ -150,7 +169,7 @@ func (n *network) request(nodeID ids.NodeID, request []byte, responseHandler mes
 	requestID := n.requestIDGen
 	n.requestIDGen++
 
-	n.outstandingResponseHandlerMap[requestID] = responseHandler
+	n.outstandingResponseHandlerMap[requestID] = outstandingRequest{handler: responseHandler, sentAt: time.Now()}
 
 	nodeIDs := ids.NewNodeIDSet(1)
 	nodeIDs.Add(nodeID)
@@ -228,20 +247,23 @@ func (n *network) AppResponse(nodeID ids.NodeID, requestID uint32, response []by
 
 	log.Debug("received AppResponse from peer", "nodeID", nodeID, "requestID", requestID)
 
-	handler, exists := n.getRequestHandler(requestID)
+	outstanding, exists := n.getOutstandingRequest(requestID)
 	if !exists {
 		// Should never happen since the engine should be managing outstanding requests
 		log.Error("received response to unknown request", "nodeID", nodeID, "requestID", requestID, "responseLen", len(response))
 		return nil
 	}
 
-	return handler.OnResponse(nodeID, requestID, response)
+	n.peerTracker.TrackResponse(nodeID, time.Since(outstanding.sentAt))
+	return outstanding.handler.OnResponse(nodeID, requestID, response)
 }
 
 // AppRequestFailed can be called by the avalanchego -> VM in following cases:
 // - node is benched
 // - failed to send message to [nodeID] due to a network issue
 // - timeout
+// Failures count towards benching [nodeID], so request handlers answer requests they cannot serve, such as
+// a state sync request for a missing trie root, with an empty response rather than dropping them.
 // error returned by this function is expected to be treated as fatal by the engine
 // returns error only when the response handler returns an error
 func (n *network) AppRequestFailed(nodeID ids.NodeID, requestID uint32) error {
@@ -249,28 +271,29 @@ func (n *network) AppRequestFailed(nodeID ids.NodeID, requestID uint32) error {
 	defer n.lock.Unlock()
 	log.Debug("received AppRequestFailed from peer", "nodeID", nodeID, "requestID", requestID)
 
-	handler, exists := n.getRequestHandler(requestID)
+	outstanding, exists := n.getOutstandingRequest(requestID)
 	if !exists {
 		// Should never happen since the engine should be managing outstanding requests
 		log.Error("received request failed to unknown request", "nodeID", nodeID, "requestID", requestID)
 		return nil
 	}
 
-	return handler.OnFailure(nodeID, requestID)
+	n.peerTracker.TrackFailure(nodeID)
+	return outstanding.handler.OnFailure(nodeID, requestID)
 }
 
-// getRequestHandler fetches the handler for [requestID] and marks the request with [requestID] as having been fulfilled.
+// getOutstandingRequest fetches the request with [requestID] and marks it as having been fulfilled.
 // This is called by either [AppResponse] or [AppRequestFailed].
 // assumes that the write lock is held.
-func (n *network) getRequestHandler(requestID uint32) (message.ResponseHandler, bool) {
-	handler, exists := n.outstandingResponseHandlerMap[requestID]
+func (n *network) getOutstandingRequest(requestID uint32) (outstandingRequest, bool) {
+	outstanding, exists := n.outstandingResponseHandlerMap[requestID]
 	if !exists {
-		return nil, false
+		return outstandingRequest{}, false
 	}
 	// mark message as processed, release activeRequests slot
 	delete(n.outstandingResponseHandlerMap, requestID)
 	n.activeRequests.Release(1)
-	return handler, true
+	return outstanding, true
 }
 
 // Gossip sends given gossip message to peers
@@ -318,6 +341,7 @@ func (n *network) Connected(nodeID ids.NodeID, nodeVersion *version.Application)
 	}
 
 	n.peers[nodeID] = nodeVersion
+	n.peerTracker.Connected(nodeID)
 	return nil
 }
 
@@ -335,6 +359,7 @@ func (n *network) Disconnected(nodeID ids.NodeID) error {
 	}
 
 	delete(n.peers, nodeID)
+	n.peerTracker.Disconnected(nodeID)
 	return nil
 }
 
@@ -367,3 +392,18 @@ func (n *network) Size() uint32 {
 
 	return uint32(len(n.peers))
 }
+
+func (n *network) TrackInvalidResponse(nodeID ids.NodeID) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	log.Debug("peer sent invalid response", "nodeID", nodeID)
+	n.peerTracker.TrackInvalidResponse(nodeID)
+}
+
+func (n *network) PeerScores() []PeerScore {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	return n.peerTracker.Scores()
+}
@@ -103,7 +103,7 @@ func TestRequestAnyRequestsRoutingAndResponse(t *testing.T) {
 			defer wg.Done()
 			requestBytes, err := message.RequestToBytes(codecManager, requestMessage)
 			assert.NoError(t, err)
-			responseBytes, err := client.RequestAny(defaultPeerVersion, requestBytes)
+			responseBytes, _, err := client.RequestAny(defaultPeerVersion, requestBytes)
 			assert.NoError(t, err)
 			assert.NotNil(t, responseBytes)
 
@@ -253,7 +253,7 @@ func TestRequestMinVersion(t *testing.T) {
 	}))
 
 	// ensure version does not match
-	responseBytes, err := client.RequestAny(&version.Application{
+	responseBytes, _, err := client.RequestAny(&version.Application{
 		Major: 2,
 		Minor: 0,
 		Patch: 0,
@@ -262,12 +262,13 @@ func TestRequestMinVersion(t *testing.T) {
 	assert.Nil(t, responseBytes)
 
 	// ensure version matches and the request goes through
-	responseBytes, err = client.RequestAny(&version.Application{
+	responseBytes, respondingNodeID, err := client.RequestAny(&version.Application{
 		Major: 1,
 		Minor: 0,
 		Patch: 0,
 	}, requestBytes)
 	assert.NoError(t, err)
+	assert.Equal(t, nodeID, respondingNodeID)
 
 	var response TestMessage
 	if _, err = codecManager.Unmarshal(responseBytes, &response); err != nil {
@@ -276,6 +277,124 @@ func TestRequestMinVersion(t *testing.T) {
 	assert.Equal(t, "this is a response", response.Message)
 }
 
+func TestRequestAnyAvoidsBadPeers(t *testing.T) {
+	codecManager := buildCodec(t, TestMessage{})
+	failingNodeID, invalidNodeID, goodNodeID := ids.GenerateTestNodeID(), ids.GenerateTestNodeID(), ids.GenerateTestNodeID()
+
+	var net Network
+	sender := testAppSender{
+		sendAppRequestFn: func(nodes ids.NodeIDSet, reqID uint32, _ []byte) error {
+			nodeID, _ := nodes.Pop()
+			go func() {
+				if nodeID == failingNodeID {
+					assert.NoError(t, net.AppRequestFailed(nodeID, reqID))
+					return
+				}
+				responseBytes, err := codecManager.Marshal(message.Version, TestMessage{Message: "this is a response"})
+				if err != nil {
+					panic(err)
+				}
+				assert.NoError(t, net.AppResponse(nodeID, reqID, responseBytes))
+			}()
+			return nil
+		},
+	}
+
+	net = NewNetwork(sender, codecManager, ids.EmptyNodeID, 1)
+	client := NewClient(net)
+	requestBytes, err := message.RequestToBytes(codecManager, TestMessage{Message: "this is a request"})
+	assert.NoError(t, err)
+	// bench [failingNodeID] while it is the only peer, so it is always selected
+	assert.NoError(t, net.Connected(failingNodeID, defaultPeerVersion))
+	for i := 0; i < maxConsecutiveFailures; i++ {
+		_, nodeID, err := client.RequestAny(defaultPeerVersion, requestBytes)
+		assert.ErrorIs(t, err, errRequestFailed)
+		assert.Equal(t, failingNodeID, nodeID)
+	}
+
+	assert.NoError(t, net.Connected(invalidNodeID, defaultPeerVersion))
+	assert.NoError(t, net.Connected(goodNodeID, defaultPeerVersion))
+	client.TrackInvalidResponse(invalidNodeID)
+
+	for i := 0; i < 10; i++ {
+		responseBytes, nodeID, err := client.RequestAny(defaultPeerVersion, requestBytes)
+		assert.NoError(t, err)
+		assert.NotNil(t, responseBytes)
+		assert.Equal(t, goodNodeID, nodeID)
+	}
+
+	scores := make(map[ids.NodeID]PeerScore)
+	for _, score := range net.PeerScores() {
+		scores[score.NodeID] = score
+	}
+	assert.True(t, scores[failingNodeID].Benched)
+	assert.EqualValues(t, maxConsecutiveFailures, scores[failingNodeID].Failures)
+	assert.True(t, scores[invalidNodeID].Benched)
+	assert.EqualValues(t, 1, scores[invalidNodeID].InvalidResponses)
+	assert.False(t, scores[goodNodeID].Benched)
+	assert.EqualValues(t, 10, scores[goodNodeID].Responses)
+}
+
+func TestRequestAnyRecoversBenchedPeer(t *testing.T) {
+	codecManager := buildCodec(t, TestMessage{})
+	recoveringNodeID, benchedNodeID := ids.GenerateTestNodeID(), ids.GenerateTestNodeID()
+
+	var net Network
+	timingOut := true
+	sender := testAppSender{
+		sendAppRequestFn: func(nodes ids.NodeIDSet, reqID uint32, _ []byte) error {
+			nodeID, _ := nodes.Pop()
+			failed := timingOut
+			go func() {
+				if failed {
+					assert.NoError(t, net.AppRequestFailed(nodeID, reqID))
+					return
+				}
+				responseBytes, err := codecManager.Marshal(message.Version, TestMessage{Message: "this is a response"})
+				if err != nil {
+					panic(err)
+				}
+				assert.NoError(t, net.AppResponse(nodeID, reqID, responseBytes))
+			}()
+			return nil
+		},
+	}
+
+	net = NewNetwork(sender, codecManager, ids.EmptyNodeID, 1)
+	client := NewClient(net)
+	requestBytes, err := message.RequestToBytes(codecManager, TestMessage{Message: "this is a request"})
+	assert.NoError(t, err)
+	// bench [recoveringNodeID] by timing out while it is the only peer
+	assert.NoError(t, net.Connected(recoveringNodeID, defaultPeerVersion))
+	for i := 0; i < maxConsecutiveFailures; i++ {
+		_, _, err := client.RequestAny(defaultPeerVersion, requestBytes)
+		assert.ErrorIs(t, err, errRequestFailed)
+	}
+	assert.NoError(t, net.Connected(benchedNodeID, defaultPeerVersion))
+	client.TrackInvalidResponse(benchedNodeID)
+	scores := make(map[ids.NodeID]PeerScore)
+	for _, score := range net.PeerScores() {
+		scores[score.NodeID] = score
+	}
+	assert.True(t, scores[recoveringNodeID].Benched)
+
+	// once its bench has expired, the peer is preferred over a peer that is
+	// still benched, and serves requests again
+	timingOut = false
+	net.(*network).peerTracker.peers[recoveringNodeID].benchedUntil = time.Now().Add(-benchDuration)
+	for i := 0; i < 10; i++ {
+		_, nodeID, err := client.RequestAny(defaultPeerVersion, requestBytes)
+		assert.NoError(t, err)
+		assert.Equal(t, recoveringNodeID, nodeID)
+	}
+	for _, score := range net.PeerScores() {
+		scores[score.NodeID] = score
+	}
+	assert.False(t, scores[recoveringNodeID].Benched)
+	assert.EqualValues(t, 10, scores[recoveringNodeID].Responses)
+	assert.True(t, scores[benchedNodeID].Benched)
+}
+
 func TestOnRequestHonoursDeadline(t *testing.T) {
 	var net Network
 	responded := false
@@ -0,0 +1,239 @@
+// (c) 2019-2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package peer
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ir4tech/webb-evm/metrics"
+)
+
+const (
+	// scoreAlpha is the weight given to the latest sample when updating a peer's
+	// exponentially weighted response time and reliability.
+	scoreAlpha = 0.1
+
+	// maxConsecutiveFailures is the number of failed requests in a row after
+	// which a peer is benched.
+	maxConsecutiveFailures = 3
+
+	// benchDuration is how long a benched peer is passed over by RequestAny.
+	benchDuration = time.Minute
+)
+
+// PeerScore is a snapshot of the reputation tracked for a peer.
+type PeerScore struct {
+	NodeID           ids.NodeID    `json:"nodeID"`
+	Score            float64       `json:"score"`
+	ResponseTime     time.Duration `json:"responseTime"`
+	Responses        uint64        `json:"responses"`
+	Failures         uint64        `json:"failures"`
+	InvalidResponses uint64        `json:"invalidResponses"`
+	Benched          bool          `json:"benched"`
+}
+
+// peerInfo holds the reputation of a single peer.
+type peerInfo struct {
+	responseTime        time.Duration // exponentially weighted response time
+	reliability         float64       // exponentially weighted share of requests answered with a valid response
+	responses           uint64
+	failures            uint64
+	invalidResponses    uint64
+	consecutiveFailures int
+	benchedUntil        time.Time
+	connected           bool
+}
+
+// score ranks the peer for request routing. It is in (0, 1], favouring reliable
+// peers and, between equally reliable peers, the faster one.
+// New peers start with a perfect score so that they are tried.
+func (p *peerInfo) score() float64 {
+	return p.reliability / (1 + p.responseTime.Seconds())
+}
+
+func (p *peerInfo) benched(now time.Time) bool {
+	return now.Before(p.benchedUntil)
+}
+
+// peerTracker tracks the response times, failures and invalid responses of peers
+// and uses them to pick which peer a request is routed to.
+// The reputation of a benched peer is kept across disconnects until its bench
+// expires so that a peer cannot clear its bench by reconnecting. Other peers are
+// forgotten when they disconnect.
+// peerTracker is not thread safe and relies on the network lock.
+type peerTracker struct {
+	peers     map[ids.NodeID]*peerInfo
+	nextPrune time.Time // earliest time disconnected peers are swept again
+
+	responseTime     metrics.Timer
+	failures         metrics.Counter
+	invalidResponses metrics.Counter
+	benchings        metrics.Counter
+}
+
+func newPeerTracker() *peerTracker {
+	return &peerTracker{
+		peers:            make(map[ids.NodeID]*peerInfo),
+		responseTime:     metrics.GetOrRegisterTimer("net_peer_response_time", nil),
+		failures:         metrics.GetOrRegisterCounter("net_peer_request_failed", nil),
+		invalidResponses: metrics.GetOrRegisterCounter("net_peer_invalid_response", nil),
+		benchings:        metrics.GetOrRegisterCounter("net_peer_benched", nil),
+	}
+}
+
+func (p *peerTracker) getOrCreate(nodeID ids.NodeID) *peerInfo {
+	info, exists := p.peers[nodeID]
+	if !exists {
+		info = &peerInfo{reliability: 1}
+		p.peers[nodeID] = info
+	}
+	return info
+}
+
+// Connected records that [nodeID] connected.
+func (p *peerTracker) Connected(nodeID ids.NodeID) {
+	p.getOrCreate(nodeID).connected = true
+	p.prune(time.Now())
+}
+
+// Disconnected records that [nodeID] disconnected. The peer is forgotten unless
+// it is benched, in which case it is kept until its bench expires.
+func (p *peerTracker) Disconnected(nodeID ids.NodeID) {
+	now := time.Now()
+	if info, exists := p.peers[nodeID]; exists {
+		if info.benched(now) {
+			info.connected = false
+		} else {
+			delete(p.peers, nodeID)
+		}
+	}
+	p.prune(now)
+}
+
+// prune removes the disconnected peers whose bench has expired, at most once per
+// [benchDuration]. Peers that are tracked while disconnected, e.g. because a
+// request to them timed out after they disconnected, are removed as well.
+func (p *peerTracker) prune(now time.Time) {
+	if now.Before(p.nextPrune) {
+		return
+	}
+	p.nextPrune = now.Add(benchDuration)
+	for nodeID, info := range p.peers {
+		if !info.connected && !info.benched(now) {
+			delete(p.peers, nodeID)
+		}
+	}
+}
+
+// TrackResponse records that [nodeID] responded to a request after [responseTime].
+func (p *peerTracker) TrackResponse(nodeID ids.NodeID, responseTime time.Duration) {
+	info := p.getOrCreate(nodeID)
+	info.responses++
+	info.consecutiveFailures = 0
+	info.reliability += scoreAlpha * (1 - info.reliability)
+	if info.responses == 1 {
+		info.responseTime = responseTime
+	} else {
+		info.responseTime += time.Duration(scoreAlpha * float64(responseTime-info.responseTime))
+	}
+	p.responseTime.Update(responseTime)
+}
+
+// TrackFailure records that a request to [nodeID] failed or timed out, benching
+// the peer after [maxConsecutiveFailures] failures in a row.
+func (p *peerTracker) TrackFailure(nodeID ids.NodeID) {
+	info := p.getOrCreate(nodeID)
+	info.failures++
+	info.consecutiveFailures++
+	info.reliability -= scoreAlpha * info.reliability
+	p.failures.Inc(1)
+	if info.consecutiveFailures >= maxConsecutiveFailures {
+		p.bench(nodeID, info)
+	}
+}
+
+// TrackInvalidResponse records that [nodeID] sent a response that failed
+// verification. Since this is never the result of a network issue, the peer is
+// benched immediately.
+func (p *peerTracker) TrackInvalidResponse(nodeID ids.NodeID) {
+	info := p.getOrCreate(nodeID)
+	info.invalidResponses++
+	info.reliability = 0
+	p.invalidResponses.Inc(1)
+	p.bench(nodeID, info)
+}
+
+func (p *peerTracker) bench(nodeID ids.NodeID, info *peerInfo) {
+	log.Debug("benching peer", "nodeID", nodeID, "failures", info.failures, "invalidResponses", info.invalidResponses, "duration", benchDuration)
+	info.consecutiveFailures = 0
+	info.benchedUntil = time.Now().Add(benchDuration)
+	p.benchings.Inc(1)
+}
+
+// SelectPeer returns the better scoring of two peers drawn at random from
+// [candidates], skipping benched peers unless every candidate is benched.
+// Sampling two peers rather than always taking the best one spreads concurrent
+// requests over the well-behaved peers instead of piling them onto one.
+// Returns false if [candidates] is empty.
+func (p *peerTracker) SelectPeer(candidates []ids.NodeID) (ids.NodeID, bool) {
+	now := time.Now()
+	available := make([]ids.NodeID, 0, len(candidates))
+	for _, nodeID := range candidates {
+		if info, exists := p.peers[nodeID]; exists && info.benched(now) {
+			continue
+		}
+		available = append(available, nodeID)
+	}
+	if len(available) == 0 {
+		// Rather than failing the request, fall back to the benched peers.
+		available = candidates
+	}
+
+	switch len(available) {
+	case 0:
+		return ids.EmptyNodeID, false
+	case 1:
+		return available[0], true
+	}
+
+	i := rand.Intn(len(available))
+	j := rand.Intn(len(available) - 1)
+	if j >= i {
+		j++
+	}
+	if p.score(available[j]) > p.score(available[i]) {
+		return available[j], true
+	}
+	return available[i], true
+}
+
+func (p *peerTracker) score(nodeID ids.NodeID) float64 {
+	info, exists := p.peers[nodeID]
+	if !exists {
+		return 1
+	}
+	return info.score()
+}
+
+// Scores returns the reputation of every tracked peer.
+func (p *peerTracker) Scores() []PeerScore {
+	now := time.Now()
+	scores := make([]PeerScore, 0, len(p.peers))
+	for nodeID, info := range p.peers {
+		scores = append(scores, PeerScore{
+			NodeID:           nodeID,
+			Score:            info.score(),
+			ResponseTime:     info.responseTime,
+			Responses:        info.responses,
+			Failures:         info.failures,
+			InvalidResponses: info.invalidResponses,
+			Benched:          info.benched(now),
+		})
+	}
+	return scores
+}
@@ -0,0 +1,120 @@
+// (c) 2019-2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package peer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeerTrackerScores(t *testing.T) {
+	tracker := newPeerTracker()
+	fast, slow, flaky := ids.GenerateTestNodeID(), ids.GenerateTestNodeID(), ids.GenerateTestNodeID()
+
+	for i := 0; i < 10; i++ {
+		tracker.TrackResponse(fast, 10*time.Millisecond)
+		tracker.TrackResponse(slow, 2*time.Second)
+		tracker.TrackResponse(flaky, 10*time.Millisecond)
+		tracker.TrackFailure(flaky)
+	}
+
+	assert.Greater(t, tracker.score(fast), tracker.score(slow))
+	assert.Greater(t, tracker.score(fast), tracker.score(flaky))
+	assert.Equal(t, 1.0, tracker.score(ids.GenerateTestNodeID()), "untracked peers should have a perfect score")
+
+	scores := make(map[ids.NodeID]PeerScore)
+	for _, score := range tracker.Scores() {
+		scores[score.NodeID] = score
+	}
+	assert.Len(t, scores, 3)
+	assert.EqualValues(t, 10, scores[flaky].Responses)
+	assert.EqualValues(t, 10, scores[flaky].Failures)
+	assert.False(t, scores[flaky].Benched, "peer should only be benched after consecutive failures")
+	assert.Equal(t, 10*time.Millisecond, scores[fast].ResponseTime)
+}
+
+func TestPeerTrackerBenching(t *testing.T) {
+	tracker := newPeerTracker()
+	failing, invalid, good := ids.GenerateTestNodeID(), ids.GenerateTestNodeID(), ids.GenerateTestNodeID()
+
+	for i := 0; i < maxConsecutiveFailures; i++ {
+		tracker.TrackFailure(failing)
+	}
+	tracker.TrackResponse(invalid, time.Millisecond)
+	tracker.TrackInvalidResponse(invalid)
+
+	now := time.Now()
+	assert.True(t, tracker.peers[failing].benched(now))
+	assert.True(t, tracker.peers[invalid].benched(now))
+
+	candidates := []ids.NodeID{failing, invalid, good}
+	for i := 0; i < 100; i++ {
+		nodeID, ok := tracker.SelectPeer(candidates)
+		assert.True(t, ok)
+		assert.Equal(t, good, nodeID, "benched peers should not be selected")
+	}
+
+	// benched peers are used when no other peer is available
+	nodeID, ok := tracker.SelectPeer([]ids.NodeID{failing})
+	assert.True(t, ok)
+	assert.Equal(t, failing, nodeID)
+
+	// once the bench expires the peer is preferred over benched peers again
+	tracker.peers[failing].benchedUntil = now
+	for i := 0; i < 100; i++ {
+		nodeID, _ := tracker.SelectPeer([]ids.NodeID{failing, invalid})
+		assert.Equal(t, failing, nodeID)
+	}
+
+	_, ok = tracker.SelectPeer(nil)
+	assert.False(t, ok)
+}
+
+func TestPeerTrackerPrefersBetterPeer(t *testing.T) {
+	tracker := newPeerTracker()
+	good, bad := ids.GenerateTestNodeID(), ids.GenerateTestNodeID()
+	tracker.TrackResponse(good, time.Millisecond)
+	tracker.TrackResponse(bad, 5*time.Second)
+
+	// with two candidates both are always sampled, so the better peer wins
+	for i := 0; i < 100; i++ {
+		nodeID, ok := tracker.SelectPeer([]ids.NodeID{bad, good})
+		assert.True(t, ok)
+		assert.Equal(t, good, nodeID)
+	}
+}
+
+func TestPeerTrackerPrunesDisconnectedPeers(t *testing.T) {
+	tracker := newPeerTracker()
+	good, benched, stale := ids.GenerateTestNodeID(), ids.GenerateTestNodeID(), ids.GenerateTestNodeID()
+	for _, nodeID := range []ids.NodeID{good, benched} {
+		tracker.Connected(nodeID)
+		tracker.TrackResponse(nodeID, time.Millisecond)
+	}
+	tracker.TrackInvalidResponse(benched)
+
+	// a peer that is not benched is forgotten as soon as it disconnects
+	tracker.Disconnected(good)
+	assert.NotContains(t, tracker.peers, good)
+
+	// a benched peer keeps its bench across a reconnect
+	tracker.Disconnected(benched)
+	assert.Contains(t, tracker.peers, benched)
+	tracker.Connected(benched)
+	assert.True(t, tracker.peers[benched].benched(time.Now()))
+
+	// once its bench expires, a disconnected peer is removed by the next sweep,
+	// as is a peer that was only tracked after it disconnected
+	tracker.Disconnected(benched)
+	tracker.TrackFailure(stale)
+	now := time.Now()
+	tracker.peers[benched].benchedUntil = now
+	tracker.prune(now)
+	assert.Contains(t, tracker.peers, benched, "peers should be swept at most once per bench duration")
+	tracker.prune(now.Add(benchDuration))
+	assert.Empty(t, tracker.peers)
+}
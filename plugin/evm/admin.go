@@ -10,6 +10,7 @@ import (
 	"github.com/ava-labs/avalanchego/api"
 	"github.com/ava-labs/avalanchego/utils/profiler"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ir4tech/webb-evm/peer"
 )
 
 // Admin is the API service for admin API calls
@@ -79,3 +80,15 @@ func (p *Admin) GetVMConfig(r *http.Request, args *struct{}, reply *ConfigReply)
 	reply.Config = &p.vm.config
 	return nil
 }
+
+type PeerScoresReply struct {
+	Peers []peer.PeerScore `json:"peers"`
+}
+
+// GetPeerScores returns the reputation the VM tracks for each peer it has sent requests to
+func (p *Admin) GetPeerScores(r *http.Request, args *struct{}, reply *PeerScoresReply) error {
+	log.Info("Admin: GetPeerScores called")
+
+	reply.Peers = p.vm.Network.PeerScores()
+	return nil
+}
@@ -33,10 +33,13 @@ const (
 	defaultPriorityRegossipFrequency              = 1 * time.Second
 	defaultPriorityRegossipMaxTxs                 = 32
 	defaultPriorityRegossipTxsPerAddress          = 16
+	defaultTxPullFrequency                        = 5 * time.Second
 	defaultOfflinePruningBloomFilterSize   uint64 = 512 // Default size (MB) for the offline pruner to use
 	defaultLogLevel                               = "info"
 	defaultMaxOutboundActiveRequests              = 8
 	defaultPopulateMissingTriesParallelism        = 1024
+	defaultStateSyncMinBlocks                     = 300_000 // Minimum number of blocks a state summary must be ahead of the last accepted block to sync to it
+	defaultStateSyncWorkers                       = 8
 )
 
 var defaultEnabledAPIs = []string{
@@ -112,6 +115,7 @@ type Config struct {
 	PriorityRegossipMaxTxs        int              `json:"priority-regossip-max-txs"`
 	PriorityRegossipTxsPerAddress int              `json:"priority-regossip-txs-per-address"`
 	PriorityRegossipAddresses     []common.Address `json:"priority-regossip-addresses"`
+	TxPullFrequency               Duration         `json:"tx-pull-frequency"` // How often to pull missing txs from a peer (0 disables pulling)
 
 	// Log level
 	LogLevel string `json:"log-level"`
@@ -126,6 +130,11 @@ type Config struct {
 
 	// VM2VM network
 	MaxOutboundActiveRequests int64 `json:"max-outbound-active-requests"`
+
+	// Sync settings
+	StateSyncEnabled   bool   `json:"state-sync-enabled"`    // If enabled, a new node syncs to a recent state summary instead of bootstrapping from genesis
+	StateSyncMinBlocks uint64 `json:"state-sync-min-blocks"` // Minimum number of blocks a summary must be ahead of the last accepted block to sync to it
+	StateSyncWorkers   int    `json:"state-sync-workers"`    // Number of storage tries and contract codes fetched concurrently during state sync
 }
 
 // EthAPIs returns an array of strings representing the Eth APIs that should be enabled
@@ -158,10 +167,13 @@ func (c *Config) SetDefaults() {
 	c.PriorityRegossipFrequency.Duration = defaultPriorityRegossipFrequency
 	c.PriorityRegossipMaxTxs = defaultPriorityRegossipMaxTxs
 	c.PriorityRegossipTxsPerAddress = defaultPriorityRegossipTxsPerAddress
+	c.TxPullFrequency.Duration = defaultTxPullFrequency
 	c.OfflinePruningBloomFilterSize = defaultOfflinePruningBloomFilterSize
 	c.LogLevel = defaultLogLevel
 	c.MaxOutboundActiveRequests = defaultMaxOutboundActiveRequests
 	c.PopulateMissingTriesParallelism = defaultPopulateMissingTriesParallelism
+	c.StateSyncMinBlocks = defaultStateSyncMinBlocks
+	c.StateSyncWorkers = defaultStateSyncWorkers
 }
 
 func (d *Duration) UnmarshalJSON(data []byte) (err error) {
@@ -186,6 +198,16 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("cannot run offline pruning while pruning is disabled")
 	}
 
+	// Peers answer TxsPullRequests sent more often than txsPullMinInterval with no txs.
+	if c.TxPullFrequency.Duration != 0 && c.TxPullFrequency.Duration < txsPullMinInterval {
+		return fmt.Errorf("tx pull frequency (%s) must be 0 or at least %s", c.TxPullFrequency.Duration, txsPullMinInterval)
+	}
+
+	// State summaries are served every CommitInterval blocks, so syncing to them requires a non-zero commit interval.
+	if c.StateSyncEnabled && c.CommitInterval == 0 {
+		return fmt.Errorf("cannot use commit interval of 0 with state sync enabled")
+	}
+
 	// If pruning is enabled, the commit interval must be non-zero so the node commits state tries every CommitInterval blocks.
 	if c.Pruning && c.CommitInterval == 0 {
 		return fmt.Errorf("cannot use commit interval of 0 with pruning enabled")
@@ -11,7 +11,11 @@ import (
 	"github.com/ava-labs/avalanchego/ids"
 )
 
-var _ GossipHandler = NoopMempoolGossipHandler{}
+var (
+	_ GossipHandler         = NoopMempoolGossipHandler{}
+	_ TxsPullRequestHandler = NoopTxsPullRequestHandler{}
+	_ RequestHandler        = &requestHandler{}
+)
 
 // GossipHandler handles incoming gossip messages
 type GossipHandler interface {
@@ -31,11 +35,44 @@ func (NoopMempoolGossipHandler) HandleTxs(nodeID ids.NodeID, _ TxsGossip) error
 // on this struct.
 // Also see GossipHandler for implementation style.
 type RequestHandler interface {
+	SyncRequestHandler
+	TxsPullRequestHandler
+}
+
+// SyncRequestHandler handles the requests used by state sync
+type SyncRequestHandler interface {
 	HandleTrieLeafsRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, leafsRequest LeafsRequest) ([]byte, error)
 	HandleBlockRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, request BlockRequest) ([]byte, error)
 	HandleCodeRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, codeRequest CodeRequest) ([]byte, error)
 }
 
+// TxsPullRequestHandler handles requests for the pending transactions a peer is missing
+type TxsPullRequestHandler interface {
+	HandleTxsPullRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, request TxsPullRequest) ([]byte, error)
+}
+
+type NoopTxsPullRequestHandler struct{}
+
+func (NoopTxsPullRequestHandler) HandleTxsPullRequest(_ context.Context, nodeID ids.NodeID, requestID uint32, _ TxsPullRequest) ([]byte, error) {
+	log.Debug("dropping unexpected TxsPullRequest", "peerID", nodeID, "requestID", requestID)
+	return nil, nil
+}
+
+// requestHandler routes state sync requests and txs pull requests to their handlers
+type requestHandler struct {
+	SyncRequestHandler
+	TxsPullRequestHandler
+}
+
+// NewRequestHandler returns a RequestHandler that passes state sync requests to [syncHandler]
+// and txs pull requests to [txsPullHandler]
+func NewRequestHandler(syncHandler SyncRequestHandler, txsPullHandler TxsPullRequestHandler) RequestHandler {
+	return &requestHandler{
+		SyncRequestHandler:    syncHandler,
+		TxsPullRequestHandler: txsPullHandler,
+	}
+}
+
 // ResponseHandler handles response for a sent request
 // Only one of OnResponse or OnFailure is called for a given requestID, not both
 type ResponseHandler interface {
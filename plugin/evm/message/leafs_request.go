@@ -15,17 +15,20 @@ var _ Request = LeafsRequest{}
 
 // LeafsRequest is a request to receive trie leaves at specified Root within Start and End byte range
 // Limit outlines maximum number of leaves to returns starting at Start
+// Account is the hash of an account whose storage trie is at Root, or empty if Root is an account trie.
+// It lets the handler serve storage leaves from the snapshot, which is keyed by account.
 type LeafsRequest struct {
-	Root  common.Hash `serialize:"true"`
-	Start []byte      `serialize:"true"`
-	End   []byte      `serialize:"true"`
-	Limit uint16      `serialize:"true"`
+	Root    common.Hash `serialize:"true"`
+	Account common.Hash `serialize:"true"`
+	Start   []byte      `serialize:"true"`
+	End     []byte      `serialize:"true"`
+	Limit   uint16      `serialize:"true"`
 }
 
 func (l LeafsRequest) String() string {
 	return fmt.Sprintf(
-		"LeafsRequest(Root=%s, Start=%s, End %s, Limit=%d)",
-		l.Root, common.Bytes2Hex(l.Start), common.Bytes2Hex(l.End), l.Limit,
+		"LeafsRequest(Root=%s, Account=%s, Start=%s, End %s, Limit=%d)",
+		l.Root, l.Account, common.Bytes2Hex(l.Start), common.Bytes2Hex(l.End), l.Limit,
 	)
 }
 
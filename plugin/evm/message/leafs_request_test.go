@@ -29,13 +29,14 @@ func TestMarshalLeafsRequest(t *testing.T) {
 	assert.NoError(t, err)
 
 	leafsRequest := LeafsRequest{
-		Root:  common.BytesToHash([]byte("im ROOTing for ya")),
-		Start: startBytes,
-		End:   endBytes,
-		Limit: 1024,
+		Root:    common.BytesToHash([]byte("im ROOTing for ya")),
+		Account: common.BytesToHash([]byte("account hash")),
+		Start:   startBytes,
+		End:     endBytes,
+		Limit:   1024,
 	}
 
-	base64LeafsRequest := "AAAAAAAAAAAAAAAAAAAAAABpbSBST09UaW5nIGZvciB5YQAAACBS/fwHIYJlTxY/Xw+aYh1ylWbHTRADfE17uwQH0eLGSQAAACCBhVrYaB0NhtHpHgAWeTnLZpTSxCKs0gigByk5SH9pmQQA"
+	base64LeafsRequest := "AAAAAAAAAAAAAAAAAAAAAABpbSBST09UaW5nIGZvciB5YQAAAAAAAAAAAAAAAAAAAAAAAAAAYWNjb3VudCBoYXNoAAAAIFL9/AchgmVPFj9fD5piHXKVZsdNEAN8TXu7BAfR4sZJAAAAIIGFWthoHQ2G0ekeABZ5OctmlNLEIqzSCKAHKTlIf2mZBAA="
 
 	codec, err := BuildCodec()
 	assert.NoError(t, err)
@@ -48,6 +49,7 @@ func TestMarshalLeafsRequest(t *testing.T) {
 	_, err = codec.Unmarshal(leafsRequestBytes, &l)
 	assert.NoError(t, err)
 	assert.Equal(t, leafsRequest.Root, l.Root)
+	assert.Equal(t, leafsRequest.Account, l.Account)
 	assert.Equal(t, leafsRequest.Start, l.Start)
 	assert.Equal(t, leafsRequest.End, l.End)
 	assert.Equal(t, leafsRequest.Limit, l.Limit)
@@ -0,0 +1,35 @@
+// (c) 2021-2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package message
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ava-labs/avalanchego/ids"
+)
+
+var _ Request = TxsPullRequest{}
+
+// TxsPullRequest is a request for the pending transactions the requester is missing
+// Filter is a serialized bloom filter of the hashes of the transactions in the requester's mempool
+type TxsPullRequest struct {
+	Filter []byte `serialize:"true"`
+}
+
+func (t TxsPullRequest) String() string {
+	return fmt.Sprintf("TxsPullRequest(FilterLen=%d)", len(t.Filter))
+}
+
+func (t TxsPullRequest) Handle(ctx context.Context, nodeID ids.NodeID, requestID uint32, handler RequestHandler) ([]byte, error) {
+	return handler.HandleTxsPullRequest(ctx, nodeID, requestID, t)
+}
+
+// TxsPullResponse is a response to a TxsPullRequest
+// Txs is the RLP encoding of pending transactions whose hashes are not in TxsPullRequest.Filter,
+// encoded the same way as in TxsGossip.
+// handler: evm.TxsPullHandler
+type TxsPullResponse struct {
+	Txs []byte `serialize:"true"`
+}
@@ -0,0 +1,55 @@
+// (c) 2021-2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package message
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMarshalTxsPullRequest asserts that the structure or serialization logic hasn't changed, primarily to
+// ensure compatibility with the network.
+func TestMarshalTxsPullRequest(t *testing.T) {
+	txsPullRequest := TxsPullRequest{
+		Filter: []byte("some filter bits"),
+	}
+
+	base64TxsPullRequest := "AAAAAAAQc29tZSBmaWx0ZXIgYml0cw=="
+
+	codec, err := BuildCodec()
+	assert.NoError(t, err)
+
+	txsPullRequestBytes, err := codec.Marshal(Version, txsPullRequest)
+	assert.NoError(t, err)
+	assert.Equal(t, base64TxsPullRequest, base64.StdEncoding.EncodeToString(txsPullRequestBytes))
+
+	var r TxsPullRequest
+	_, err = codec.Unmarshal(txsPullRequestBytes, &r)
+	assert.NoError(t, err)
+	assert.Equal(t, txsPullRequest.Filter, r.Filter)
+}
+
+// TestMarshalTxsPullResponse asserts that the structure or serialization logic hasn't changed, primarily to
+// ensure compatibility with the network.
+func TestMarshalTxsPullResponse(t *testing.T) {
+	txsPullResponse := TxsPullResponse{
+		Txs: []byte("some rlp encoded txs"),
+	}
+
+	base64TxsPullResponse := "AAAAAAAUc29tZSBybHAgZW5jb2RlZCB0eHM="
+
+	codec, err := BuildCodec()
+	assert.NoError(t, err)
+
+	txsPullResponseBytes, err := codec.Marshal(Version, txsPullResponse)
+	assert.NoError(t, err)
+	assert.Equal(t, base64TxsPullResponse, base64.StdEncoding.EncodeToString(txsPullResponseBytes))
+
+	var r TxsPullResponse
+	_, err = codec.Unmarshal(txsPullResponseBytes, &r)
+	assert.NoError(t, err)
+	assert.Equal(t, txsPullResponse.Txs, r.Txs)
+}
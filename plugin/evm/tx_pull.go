@@ -0,0 +1,332 @@
+// (c) 2019-2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ava-labs/avalanchego/cache"
+	"github.com/ava-labs/avalanchego/codec"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	bloomfilter "github.com/holiman/bloomfilter/v2"
+
+	"github.com/ir4tech/webb-evm/core"
+	"github.com/ir4tech/webb-evm/core/types"
+	"github.com/ir4tech/webb-evm/peer"
+	"github.com/ir4tech/webb-evm/plugin/evm/message"
+)
+
+const (
+	// txsFilterFalsePositiveRate is the false positive rate of the bloom filter
+	// sent in a TxsPullRequest. A tx wrongly reported as known is only missed for
+	// one pull, since every filter is built with new random keys.
+	txsFilterFalsePositiveRate = 0.01
+
+	// txsFilterMinSize is the minimum number of txs a filter is sized for, so
+	// that txs added while the filter is built barely raise its false positive
+	// rate when the mempool is small.
+	txsFilterMinSize = 1024
+
+	// txsFilterHeaderLen is the length of the header of a serialized filter: a
+	// 12 byte magic followed by the number of keys, entries and bits.
+	txsFilterHeaderLen = 12 + 3*8
+
+	// txsPullMinInterval is the minimum time between two TxsPullRequests served
+	// to the same peer. Requests arriving sooner are answered with no txs.
+	txsPullMinInterval = time.Second
+
+	// txsPullRateLimitCacheSize is the number of peers whose last served
+	// TxsPullRequest is remembered for rate limiting.
+	txsPullRateLimitCacheSize = 1024
+)
+
+var (
+	errInvalidTxsFilter = errors.New("invalid txs filter")
+	errTxPullerShutdown = errors.New("tx puller shut down")
+)
+
+// txHashKey returns the key of [hash] in a txs filter. Since tx hashes are
+// already uniformly distributed, any 8 of their bytes will do.
+func txHashKey(hash common.Hash) uint64 {
+	return binary.BigEndian.Uint64(hash[:8])
+}
+
+// buildTxsFilter returns a bloom filter of the hashes of the pending and queued
+// txs in [txPool].
+func buildTxsFilter(txPool *core.TxPool) (*bloomfilter.Filter, error) {
+	pending, queued := txPool.Stats()
+	size := uint64(pending + queued)
+	if size < txsFilterMinSize {
+		size = txsFilterMinSize
+	}
+	filter, err := bloomfilter.NewOptimal(size, txsFilterFalsePositiveRate)
+	if err != nil {
+		return nil, err
+	}
+	txPool.IterateHashes(func(hash common.Hash) bool {
+		filter.AddHash(txHashKey(hash))
+		return true
+	})
+	return filter, nil
+}
+
+// parseTxsFilter unmarshals a filter built by buildTxsFilter.
+// Since unmarshalling allocates as many keys and bits as the header of
+// [filterBytes] claims, the header is checked against the length of
+// [filterBytes] first.
+func parseTxsFilter(filterBytes []byte) (*bloomfilter.Filter, error) {
+	if len(filterBytes) < txsFilterHeaderLen {
+		return nil, fmt.Errorf("%w: length %d shorter than header", errInvalidTxsFilter, len(filterBytes))
+	}
+	var (
+		numKeys  = binary.LittleEndian.Uint64(filterBytes[txsFilterHeaderLen-24:])
+		numBits  = binary.LittleEndian.Uint64(filterBytes[txsFilterHeaderLen-8:])
+		maxWords = uint64(len(filterBytes)) / 8
+	)
+	if numKeys > maxWords || numBits/64 > maxWords ||
+		txsFilterHeaderLen+8*(numKeys+(numBits+63)/64)+sha512.Size384 != uint64(len(filterBytes)) {
+		return nil, fmt.Errorf("%w: %d keys and %d bits do not match length %d", errInvalidTxsFilter, numKeys, numBits, len(filterBytes))
+	}
+
+	filter := new(bloomfilter.Filter)
+	if err := filter.UnmarshalBinary(filterBytes); err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidTxsFilter, err)
+	}
+	return filter, nil
+}
+
+// TxsPullHandler serves TxsPullRequests with the pending txs that are missing
+// from the requester's filter, serving each peer at most once every
+// [txsPullMinInterval]. Rate limited requests are answered with no txs rather
+// than dropped, so that a requester whose pulls arrive slightly early, for
+// instance because of network delay, does not see them fail.
+type TxsPullHandler struct {
+	txPool     *core.TxPool
+	codec      codec.Manager
+	remoteOnly bool
+
+	lock       sync.Mutex
+	lastServed *cache.LRU // maps nodeID => time its last request was served
+}
+
+func NewTxsPullHandler(vm *VM) *TxsPullHandler {
+	return &TxsPullHandler{
+		txPool:     vm.chain.GetTxPool(),
+		codec:      vm.networkCodec,
+		remoteOnly: vm.config.RemoteGossipOnlyEnabled,
+		lastServed: &cache.LRU{Size: txsPullRateLimitCacheSize},
+	}
+}
+
+func (h *TxsPullHandler) HandleTxsPullRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, request message.TxsPullRequest) ([]byte, error) {
+	if !h.allow(nodeID) {
+		log.Debug("serving rate limited TxsPullRequest with no txs", "peerID", nodeID, "requestID", requestID)
+		return h.marshalResponse(nodeID, requestID, make([]*types.Transaction, 0))
+	}
+
+	filter, err := parseTxsFilter(request.Filter)
+	if err != nil {
+		log.Debug("dropping TxsPullRequest with invalid filter", "peerID", nodeID, "requestID", requestID, "err", err)
+		return nil, nil
+	}
+
+	var (
+		txs  = make([]*types.Transaction, 0)
+		size = common.StorageSize(0)
+	)
+accounts:
+	for _, accountTxs := range h.txPool.Pending(true) {
+		if err := ctx.Err(); err != nil {
+			log.Debug("context err set before TxsPullRequest was served", "peerID", nodeID, "requestID", requestID, "err", err)
+			return nil, nil
+		}
+		for _, tx := range accountTxs {
+			txHash := tx.Hash()
+			if filter.ContainsHash(txHashKey(txHash)) {
+				continue
+			}
+			if h.remoteOnly && h.txPool.HasLocal(txHash) {
+				continue
+			}
+			if size+tx.Size() > message.TxMsgSoftCapSize {
+				break accounts
+			}
+			txs = append(txs, tx)
+			size += tx.Size()
+		}
+	}
+
+	log.Trace("serving TxsPullRequest", "peerID", nodeID, "requestID", requestID, "len(txs)", len(txs))
+	return h.marshalResponse(nodeID, requestID, txs)
+}
+
+// marshalResponse returns a TxsPullResponse with [txs], or nil if it cannot be
+// marshalled.
+func (h *TxsPullHandler) marshalResponse(nodeID ids.NodeID, requestID uint32, txs []*types.Transaction) ([]byte, error) {
+	txBytes, err := rlp.EncodeToBytes(txs)
+	if err != nil {
+		log.Warn("failed to encode pulled txs", "peerID", nodeID, "requestID", requestID, "err", err)
+		return nil, nil
+	}
+	responseBytes, err := h.codec.Marshal(message.Version, message.TxsPullResponse{Txs: txBytes})
+	if err != nil {
+		log.Warn("failed to marshal TxsPullResponse", "peerID", nodeID, "requestID", requestID, "err", err)
+		return nil, nil
+	}
+	return responseBytes, nil
+}
+
+// allow returns true and records the request if [nodeID] was not served in
+// the last [txsPullMinInterval].
+func (h *TxsPullHandler) allow(nodeID ids.NodeID) bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	now := time.Now()
+	if lastServed, ok := h.lastServed.Get(nodeID); ok && now.Sub(lastServed.(time.Time)) < txsPullMinInterval {
+		return false
+	}
+	h.lastServed.Put(nodeID, now)
+	return true
+}
+
+// txPuller periodically sends a filter of the mempool to a peer and adds the
+// txs the peer responds with to the mempool. This lets a node catch up on txs
+// it missed, for instance because they were pushed before it connected.
+type txPuller struct {
+	ctx                  *snow.Context
+	gossipActivationTime time.Time
+	frequency            time.Duration
+
+	client       peer.Client
+	txPool       *core.TxPool
+	codec        codec.Manager
+	shutdownChan chan struct{}
+	shutdownWg   *sync.WaitGroup
+}
+
+// startTxPuller starts pulling txs from peers every TxPullFrequency, unless
+// pulling is disabled.
+func (vm *VM) startTxPuller() {
+	if vm.config.TxPullFrequency.Duration == 0 {
+		return
+	}
+	puller := &txPuller{
+		ctx:                  vm.ctx,
+		gossipActivationTime: time.Unix(vm.chainConfig.SubnetEVMTimestamp.Int64(), 0),
+		frequency:            vm.config.TxPullFrequency.Duration,
+		client:               vm.client,
+		txPool:               vm.chain.GetTxPool(),
+		codec:                vm.networkCodec,
+		shutdownChan:         vm.shutdownChan,
+		shutdownWg:           &vm.shutdownWg,
+	}
+	puller.awaitTxsPull()
+}
+
+// awaitTxsPull pulls txs every [frequency] until shutdown.
+func (p *txPuller) awaitTxsPull() {
+	p.shutdownWg.Add(1)
+	go p.ctx.Log.RecoverAndPanic(func() {
+		defer p.shutdownWg.Done()
+
+		ticker := time.NewTicker(p.frequency)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if time.Now().Before(p.gossipActivationTime) {
+					continue
+				}
+				if received, err := p.pullTxs(); err != nil {
+					log.Debug("failed to pull eth transactions", "err", err)
+				} else {
+					log.Trace("pulled eth transactions", "len(txs)", received)
+				}
+			case <-p.shutdownChan:
+				return
+			}
+		}
+	})
+}
+
+// pullTxs sends a filter of the mempool to a peer and adds the txs it responds
+// with to the mempool. Returns the number of txs received.
+func (p *txPuller) pullTxs() (int, error) {
+	filter, err := buildTxsFilter(p.txPool)
+	if err != nil {
+		return 0, err
+	}
+	filterBytes, err := filter.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	requestBytes, err := message.RequestToBytes(p.codec, message.TxsPullRequest{Filter: filterBytes})
+	if err != nil {
+		return 0, err
+	}
+
+	responseBytes, nodeID, err := p.requestAny(requestBytes)
+	if err != nil {
+		return 0, err
+	}
+
+	var response message.TxsPullResponse
+	if _, err := p.codec.Unmarshal(responseBytes, &response); err != nil {
+		p.client.TrackInvalidResponse(nodeID)
+		return 0, fmt.Errorf("failed to unmarshal TxsPullResponse from %s: %w", nodeID, err)
+	}
+	txs := make([]*types.Transaction, 0)
+	if err := rlp.DecodeBytes(response.Txs, &txs); err != nil {
+		p.client.TrackInvalidResponse(nodeID)
+		return 0, fmt.Errorf("failed to decode txs pulled from %s: %w", nodeID, err)
+	}
+
+	errs := p.txPool.AddRemotes(txs)
+	for i, err := range errs {
+		if err != nil {
+			log.Trace(
+				"failed to add pulled tx to mempool",
+				"err", err,
+				"tx", txs[i].Hash(),
+			)
+		}
+	}
+	return len(txs), nil
+}
+
+// requestAny sends [request] to a peer and waits for its response, returning
+// early if the puller is shut down first. The response to a request that is
+// abandoned this way is dropped once it arrives or the request times out.
+func (p *txPuller) requestAny(request []byte) ([]byte, ids.NodeID, error) {
+	type result struct {
+		response []byte
+		nodeID   ids.NodeID
+		err      error
+	}
+	results := make(chan result, 1)
+	go func() {
+		response, nodeID, err := p.client.RequestAny(nil, request)
+		results <- result{response: response, nodeID: nodeID, err: err}
+	}()
+
+	select {
+	case r := <-results:
+		return r.response, r.nodeID, r.err
+	case <-p.shutdownChan:
+		return nil, ids.EmptyNodeID, errTxPullerShutdown
+	}
+}
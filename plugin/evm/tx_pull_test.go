@@ -0,0 +1,282 @@
+// (c) 2019-2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package evm
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ava-labs/avalanchego/codec"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow"
+	"github.com/ava-labs/avalanchego/version"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	bloomfilter "github.com/holiman/bloomfilter/v2"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ir4tech/webb-evm/core/types"
+	"github.com/ir4tech/webb-evm/params"
+	"github.com/ir4tech/webb-evm/peer"
+	"github.com/ir4tech/webb-evm/plugin/evm/message"
+)
+
+var _ peer.Client = &testTxsPullClient{}
+
+// testTxsPullClient serves requests with a local [handler], reporting them as
+// served by [nodeID].
+type testTxsPullClient struct {
+	codec          codec.Manager
+	handler        message.TxsPullRequestHandler
+	nodeID         ids.NodeID
+	invalidReports []ids.NodeID
+}
+
+func (c *testTxsPullClient) RequestAny(_ *version.Application, requestBytes []byte) ([]byte, ids.NodeID, error) {
+	response, err := c.Request(c.nodeID, requestBytes)
+	return response, c.nodeID, err
+}
+
+func (c *testTxsPullClient) Request(nodeID ids.NodeID, requestBytes []byte) ([]byte, error) {
+	request, err := message.BytesToRequest(c.codec, requestBytes)
+	if err != nil {
+		return nil, err
+	}
+	response, err := request.Handle(context.Background(), nodeID, 0, message.NewRequestHandler(nil, c.handler))
+	if err == nil && response == nil {
+		err = errors.New("request dropped")
+	}
+	return response, err
+}
+
+func (c *testTxsPullClient) Gossip([]byte) error { return nil }
+
+func (c *testTxsPullClient) TrackInvalidResponse(nodeID ids.NodeID) {
+	c.invalidReports = append(c.invalidReports, nodeID)
+}
+
+func TestParseTxsFilter(t *testing.T) {
+	filter, err := bloomfilter.NewOptimal(txsFilterMinSize, txsFilterFalsePositiveRate)
+	assert.NoError(t, err)
+	hash := common.HexToHash("0x1234")
+	filter.AddHash(txHashKey(hash))
+	filterBytes, err := filter.MarshalBinary()
+	assert.NoError(t, err)
+
+	parsed, err := parseTxsFilter(filterBytes)
+	assert.NoError(t, err)
+	assert.True(t, parsed.ContainsHash(txHashKey(hash)))
+
+	_, err = parseTxsFilter(filterBytes[:txsFilterHeaderLen-1])
+	assert.ErrorIs(t, err, errInvalidTxsFilter)
+	_, err = parseTxsFilter(filterBytes[:len(filterBytes)-1])
+	assert.ErrorIs(t, err, errInvalidTxsFilter)
+
+	// a header claiming far more bits than are sent must be rejected before
+	// the bits are allocated
+	inflated := common.CopyBytes(filterBytes)
+	binary.LittleEndian.PutUint64(inflated[txsFilterHeaderLen-8:], 1<<60)
+	_, err = parseTxsFilter(inflated)
+	assert.ErrorIs(t, err, errInvalidTxsFilter)
+
+	// a corrupted filter fails the checksum
+	corrupted := common.CopyBytes(filterBytes)
+	corrupted[len(corrupted)-1] ^= 0xff
+	_, err = parseTxsFilter(corrupted)
+	assert.ErrorIs(t, err, errInvalidTxsFilter)
+}
+
+func TestTxsPullHandler(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	cfgJson, err := fundAddressByGenesis([]common.Address{crypto.PubkeyToAddress(key.PublicKey)})
+	assert.NoError(t, err)
+
+	_, vm, _, _ := GenesisVM(t, true, cfgJson, "", "")
+	defer func() {
+		assert.NoError(t, vm.Shutdown())
+	}()
+	txPool := vm.chain.GetTxPool()
+	txPool.SetGasPrice(common.Big1)
+	txPool.SetMinFee(common.Big0)
+
+	txs := getValidTxs(key, 10, big.NewInt(226*params.GWei))
+	for _, err := range txPool.AddRemotesSync(txs) {
+		assert.NoError(t, err)
+	}
+
+	// the requester already knows the first 4 txs. Use a filter with a
+	// negligible false positive rate so the test is deterministic.
+	filter, err := bloomfilter.NewOptimal(txsFilterMinSize, 1e-9)
+	assert.NoError(t, err)
+	for _, tx := range txs[:4] {
+		filter.AddHash(txHashKey(tx.Hash()))
+	}
+	filterBytes, err := filter.MarshalBinary()
+	assert.NoError(t, err)
+	request := message.TxsPullRequest{Filter: filterBytes}
+
+	handler := NewTxsPullHandler(vm)
+	nodeID := ids.GenerateTestNodeID()
+	responseBytes, err := handler.HandleTxsPullRequest(context.Background(), nodeID, 1, request)
+	assert.NoError(t, err)
+	var response message.TxsPullResponse
+	_, err = vm.networkCodec.Unmarshal(responseBytes, &response)
+	assert.NoError(t, err)
+	pulledTxs := make([]*types.Transaction, 0)
+	assert.NoError(t, rlp.DecodeBytes(response.Txs, &pulledTxs))
+	expectedHashes := make([]common.Hash, 0, 6)
+	for _, tx := range txs[4:] {
+		expectedHashes = append(expectedHashes, tx.Hash())
+	}
+	pulledHashes := make([]common.Hash, 0, len(pulledTxs))
+	for _, tx := range pulledTxs {
+		pulledHashes = append(pulledHashes, tx.Hash())
+	}
+	assert.ElementsMatch(t, expectedHashes, pulledHashes)
+
+	// a second request from the same peer is rate limited and answered with no
+	// txs, but other peers are served
+	responseBytes, err = handler.HandleTxsPullRequest(context.Background(), nodeID, 2, request)
+	assert.NoError(t, err)
+	_, err = vm.networkCodec.Unmarshal(responseBytes, &response)
+	assert.NoError(t, err)
+	assert.NoError(t, rlp.DecodeBytes(response.Txs, &pulledTxs))
+	assert.Empty(t, pulledTxs)
+	responseBytes, err = handler.HandleTxsPullRequest(context.Background(), ids.GenerateTestNodeID(), 3, request)
+	assert.NoError(t, err)
+	assert.NotNil(t, responseBytes)
+
+	// requests with an invalid filter are dropped
+	responseBytes, err = handler.HandleTxsPullRequest(context.Background(), ids.GenerateTestNodeID(), 4, message.TxsPullRequest{Filter: []byte("not a filter")})
+	assert.NoError(t, err)
+	assert.Nil(t, responseBytes)
+}
+
+func TestTxPullerPullsMissingTxs(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	cfgJson, err := fundAddressByGenesis([]common.Address{crypto.PubkeyToAddress(key.PublicKey)})
+	assert.NoError(t, err)
+
+	_, serverVM, _, _ := GenesisVM(t, true, cfgJson, "", "")
+	defer func() {
+		assert.NoError(t, serverVM.Shutdown())
+	}()
+	_, clientVM, _, _ := GenesisVM(t, true, cfgJson, "", "")
+	defer func() {
+		assert.NoError(t, clientVM.Shutdown())
+	}()
+	for _, vm := range []*VM{serverVM, clientVM} {
+		vm.chain.GetTxPool().SetGasPrice(common.Big1)
+		vm.chain.GetTxPool().SetMinFee(common.Big0)
+	}
+
+	txs := getValidTxs(key, 5, big.NewInt(226*params.GWei))
+	for _, err := range serverVM.chain.GetTxPool().AddRemotesSync(txs) {
+		assert.NoError(t, err)
+	}
+	for _, err := range clientVM.chain.GetTxPool().AddRemotesSync(txs[:2]) {
+		assert.NoError(t, err)
+	}
+
+	client := &testTxsPullClient{
+		codec:   serverVM.networkCodec,
+		handler: NewTxsPullHandler(serverVM),
+		nodeID:  ids.GenerateTestNodeID(),
+	}
+	puller := &txPuller{
+		client: client,
+		txPool: clientVM.chain.GetTxPool(),
+		codec:  clientVM.networkCodec,
+	}
+	received, err := puller.pullTxs()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, received)
+	for _, tx := range txs {
+		assert.True(t, clientVM.chain.GetTxPool().Has(tx.Hash()), "tx %s should be in the mempool", tx.Hash())
+	}
+	assert.Empty(t, client.invalidReports)
+
+	// a peer responding with txs that cannot be decoded is reported
+	client.handler = invalidTxsPullHandler{codec: serverVM.networkCodec}
+	_, err = puller.pullTxs()
+	assert.Error(t, err)
+	assert.Equal(t, []ids.NodeID{client.nodeID}, client.invalidReports)
+}
+
+// invalidTxsPullHandler responds to every TxsPullRequest with undecodable txs
+type invalidTxsPullHandler struct {
+	codec codec.Manager
+}
+
+func (h invalidTxsPullHandler) HandleTxsPullRequest(context.Context, ids.NodeID, uint32, message.TxsPullRequest) ([]byte, error) {
+	return h.codec.Marshal(message.Version, message.TxsPullResponse{Txs: []byte("not txs")})
+}
+
+func TestTxPullerStopsOnShutdown(t *testing.T) {
+	_, vm, _, _ := GenesisVM(t, true, genesisJSONSubnetEVM, "", "")
+	defer func() {
+		assert.NoError(t, vm.Shutdown())
+	}()
+
+	handler := &blockingTxsPullHandler{
+		received: make(chan struct{}, 1),
+		unblock:  make(chan struct{}),
+	}
+	defer close(handler.unblock)
+	var shutdownWg sync.WaitGroup
+	puller := &txPuller{
+		ctx:       snow.DefaultContextTest(),
+		frequency: time.Millisecond,
+		client: &testTxsPullClient{
+			codec:   vm.networkCodec,
+			handler: handler,
+			nodeID:  ids.GenerateTestNodeID(),
+		},
+		txPool:       vm.chain.GetTxPool(),
+		codec:        vm.networkCodec,
+		shutdownChan: make(chan struct{}),
+		shutdownWg:   &shutdownWg,
+	}
+	puller.awaitTxsPull()
+	<-handler.received
+
+	// the puller stops while its request is still outstanding
+	close(puller.shutdownChan)
+	stopped := make(chan struct{})
+	go func() {
+		shutdownWg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("tx puller did not stop on shutdown")
+	}
+}
+
+// blockingTxsPullHandler never responds to a TxsPullRequest before [unblock] is
+// closed, signalling on [received] when a request arrives.
+type blockingTxsPullHandler struct {
+	received chan struct{}
+	unblock  chan struct{}
+}
+
+func (h *blockingTxsPullHandler) HandleTxsPullRequest(context.Context, ids.NodeID, uint32, message.TxsPullRequest) ([]byte, error) {
+	select {
+	case h.received <- struct{}{}:
+	default:
+	}
+	<-h.unblock
+	return nil, nil
+}
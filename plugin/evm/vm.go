@@ -28,6 +28,10 @@ import (
 	"github.com/ir4tech/webb-evm/params"
 	"github.com/ir4tech/webb-evm/peer"
 	"github.com/ir4tech/webb-evm/plugin/evm/message"
+	"github.com/ir4tech/webb-evm/precompile"
+	"github.com/ir4tech/webb-evm/statesync"
+	"github.com/ir4tech/webb-evm/statesync/handlers"
+	handlerstats "github.com/ir4tech/webb-evm/statesync/handlers/stats"
 
 	"github.com/prometheus/client_golang/prometheus"
 
@@ -68,6 +72,7 @@ import (
 var (
 	_ block.ChainVM              = &VM{}
 	_ block.HeightIndexedChainVM = &VM{}
+	_ block.StateSyncableVM      = &VM{}
 )
 
 const (
@@ -150,6 +155,9 @@ type VM struct {
 	builder *blockBuilder
 
 	gossiper Gossiper
+	// [txPullerOnce] starts the tx puller the first time the VM enters
+	// normal operation.
+	txPullerOnce sync.Once
 
 	clock mockable.Clock
 
@@ -163,6 +171,10 @@ type VM struct {
 	client       peer.Client
 	networkCodec codec.Manager
 
+	// [stateSyncClient] serves state summaries to peers and syncs this node
+	// to a summary accepted by the engine
+	stateSyncClient *statesync.StateSyncClient
+
 	// Metrics
 	multiGatherer avalanchegoMetrics.MultiGatherer
 
@@ -279,6 +291,16 @@ func (vm *VM) Initialize(
 		g.Config.FeeConfig = params.DefaultFeeConfig
 	}
 
+	// Load the network upgrades from upgrade.json if provided. They are verified along
+	// with the rest of the chain config when the genesis is set up.
+	if len(upgradeBytes) > 0 {
+		var upgradeConfig params.UpgradeConfig
+		if err := json.Unmarshal(upgradeBytes, &upgradeConfig); err != nil {
+			return fmt.Errorf("failed to parse upgrade bytes: %w", err)
+		}
+		g.Config.UpgradeConfig = upgradeConfig
+	}
+
 	ethConfig := ethconfig.NewDefaultConfig()
 	ethConfig.Genesis = g
 	ethConfig.NetworkId = g.Config.ChainID.Uint64()
@@ -356,6 +378,8 @@ func (vm *VM) Initialize(
 	if err := vm.initializeChain(lastAcceptedHash, ethConfig); err != nil {
 		return err
 	}
+	vm.initRequestHandling()
+	vm.initializeStateSync()
 
 	go vm.ctx.Log.RecoverAndPanic(vm.startContinuousProfiler)
 
@@ -435,16 +459,115 @@ func (vm *VM) initChainState(lastAcceptedBlock *types.Block) error {
 	return vm.multiGatherer.Register(chainStateMetricsPrefix, chainStateRegisterer)
 }
 
+// initRequestHandling serves state sync requests and, if gossip is
+// supported, txs pull requests from peers.
+// Note: assumes the chain has been initialized.
+func (vm *VM) initRequestHandling() {
+	var handlerStats handlerstats.HandlerStats
+	if metrics.Enabled {
+		handlerStats = handlerstats.NewHandlerStats()
+	} else {
+		handlerStats = handlerstats.NewNoopHandlerStats()
+	}
+	blockChain := vm.chain.BlockChain()
+	syncHandler := handlers.NewSyncHandler(
+		handlers.NewLeafsRequestHandler(blockChain, blockChain, handlerStats, vm.networkCodec),
+		handlers.NewBlockRequestHandler(blockChain.GetBlock, vm.networkCodec, handlerStats),
+		handlers.NewCodeRequestHandler(vm.chaindb, handlerStats, vm.networkCodec),
+	)
+
+	var txsPullHandler message.TxsPullRequestHandler = message.NoopTxsPullRequestHandler{}
+	if vm.chainConfig.SubnetEVMTimestamp != nil {
+		txsPullHandler = NewTxsPullHandler(vm)
+	}
+	vm.Network.SetRequestHandler(message.NewRequestHandler(syncHandler, txsPullHandler))
+}
+
+// initializeStateSync creates the client used to sync this node to a state summary.
+// Note: assumes the chain has been initialized.
+func (vm *VM) initializeStateSync() {
+	blockChain := vm.chain.BlockChain()
+	vm.stateSyncClient = statesync.NewStateSyncClient(&statesync.StateSyncClientConfig{
+		Enabled:              vm.config.StateSyncEnabled,
+		MinBlocks:            vm.config.StateSyncMinBlocks,
+		SyncableInterval:     vm.config.CommitInterval,
+		NumWorkers:           vm.config.StateSyncWorkers,
+		Chain:                blockChain,
+		ChainDB:              vm.chaindb,
+		Client:               statesync.NewClient(vm.client, vm.networkCodec, nil),
+		Codec:                vm.networkCodec,
+		SetLastAcceptedBlock: vm.setLastAcceptedBlock,
+		ToEngine:             vm.toEngine,
+	})
+}
+
+// setLastAcceptedBlock marks [lastAcceptedBlock], whose state was fetched by
+// state sync, as the last accepted block.
+func (vm *VM) setLastAcceptedBlock(lastAcceptedBlock *types.Block) error {
+	block := &Block{
+		id:       ids.ID(lastAcceptedBlock.Hash()),
+		ethBlock: lastAcceptedBlock,
+		vm:       vm,
+		status:   choices.Accepted,
+	}
+	if err := vm.State.SetLastAcceptedBlock(block); err != nil {
+		return err
+	}
+	if err := vm.acceptedBlockDB.Put(lastAcceptedKey, block.id[:]); err != nil {
+		return fmt.Errorf("failed to put %s as the last accepted block: %w", block.ID(), err)
+	}
+	return vm.db.Commit()
+}
+
+// StateSyncEnabled implements the block.StateSyncableVM interface
+func (vm *VM) StateSyncEnabled() (bool, error) {
+	return vm.stateSyncClient.StateSyncEnabled()
+}
+
+// GetOngoingSyncStateSummary implements the block.StateSyncableVM interface
+func (vm *VM) GetOngoingSyncStateSummary() (block.StateSummary, error) {
+	return vm.stateSyncClient.GetOngoingSyncStateSummary()
+}
+
+// GetLastStateSummary implements the block.StateSyncableVM interface
+func (vm *VM) GetLastStateSummary() (block.StateSummary, error) {
+	return vm.stateSyncClient.GetLastStateSummary()
+}
+
+// ParseStateSummary implements the block.StateSyncableVM interface
+func (vm *VM) ParseStateSummary(summaryBytes []byte) (block.StateSummary, error) {
+	return vm.stateSyncClient.ParseStateSummary(summaryBytes)
+}
+
+// GetStateSummary implements the block.StateSyncableVM interface
+func (vm *VM) GetStateSummary(summaryHeight uint64) (block.StateSummary, error) {
+	return vm.stateSyncClient.GetStateSummary(summaryHeight)
+}
+
 func (vm *VM) initGossipHandling() {
 	if vm.chainConfig.SubnetEVMTimestamp != nil {
 		vm.Network.SetGossipHandler(NewGossipHandler(vm))
+		vm.txPullerOnce.Do(vm.startTxPuller)
 	}
 }
 
 func (vm *VM) SetState(state snow.State) error {
 	switch state {
+	case snow.StateSyncing:
+		vm.bootstrapped = false
+		return nil
 	case snow.Bootstrapping:
 		vm.bootstrapped = false
+		// Bootstrapping starts after a state sync completes. A sync that failed
+		// before resetting the chain left it at the last accepted block, so
+		// bootstrapping falls back to processing blocks from there. The summary
+		// of the failed sync is kept so that it can be resumed after a restart.
+		if err := vm.stateSyncClient.Error(); err != nil {
+			if errors.Is(err, statesync.ErrChainReset) {
+				return err
+			}
+			log.Warn("state sync failed, bootstrapping from the last accepted block", "lastAccepted", vm.chain.LastAcceptedBlock().Hash(), "err", err)
+		}
 		return nil
 	case snow.NormalOp:
 		vm.initGossipHandling()
@@ -462,8 +585,11 @@ func (vm *VM) Shutdown() error {
 	}
 
 	close(vm.shutdownChan)
-	vm.chain.Stop()
+	vm.stateSyncClient.Shutdown()
+	// Wait for the background goroutines before stopping the chain, since
+	// some of them, such as the tx puller, add txs to the mempool.
 	vm.shutdownWg.Wait()
+	vm.chain.Stop()
 	return nil
 }
 
@@ -689,7 +815,7 @@ func (vm *VM) currentRules() params.Rules {
 // follows the ruleset defined by [rules]
 func (vm *VM) getBlockValidator(rules params.Rules) BlockValidator {
 	if rules.IsSubnetEVM {
-		return blockValidatorSubnetEVM{feeConfigManagerEnabled: rules.IsFeeConfigManagerEnabled}
+		return blockValidatorSubnetEVM{feeConfigManagerEnabled: rules.IsPrecompileEnabled(precompile.FeeConfigManagerAddress)}
 	}
 
 	return legacyBlockValidator
@@ -27,6 +27,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/ava-labs/avalanchego/api/keystore"
+	"github.com/ava-labs/avalanchego/database"
 	"github.com/ava-labs/avalanchego/database/manager"
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ava-labs/avalanchego/snow"
@@ -44,6 +45,7 @@ import (
 	"github.com/ir4tech/webb-evm/core/types"
 	"github.com/ir4tech/webb-evm/eth"
 	"github.com/ir4tech/webb-evm/params"
+	"github.com/ir4tech/webb-evm/plugin/evm/message"
 	"github.com/ir4tech/webb-evm/rpc"
 
 	accountKeystore "github.com/ir4tech/webb-evm/accounts/keystore"
@@ -241,6 +243,32 @@ func TestVMNilConfig(t *testing.T) {
 	assert.NoError(t, vm.Shutdown())
 }
 
+func TestVMUpgradeBytes(t *testing.T) {
+	upgradeJSON := `{"precompileUpgrades":[{"txAllowListConfig":{"blockTimestamp":100,"adminAddresses":["0x0000000000000000000000000000000000000001"]}},{"txAllowListConfig":{"blockTimestamp":200,"disable":true}}]}`
+	_, vm, _, _ := GenesisVM(t, false, genesisJSONSubnetEVM, "", upgradeJSON)
+
+	upgrades := vm.chainConfig.UpgradeConfig.PrecompileUpgrades
+	assert.Len(t, upgrades, 2)
+	assert.False(t, vm.chainConfig.IsPrecompileEnabled(precompile.TxAllowListAddress, big.NewInt(99)))
+	assert.True(t, vm.chainConfig.IsPrecompileEnabled(precompile.TxAllowListAddress, big.NewInt(100)))
+	assert.False(t, vm.chainConfig.IsPrecompileEnabled(precompile.TxAllowListAddress, big.NewInt(200)))
+	assert.NoError(t, vm.Shutdown())
+}
+
+func TestVMInvalidUpgradeBytes(t *testing.T) {
+	for name, upgradeJSON := range map[string]string{
+		"malformed json":        `{"precompileUpgrades":`,
+		"disable before enable": `{"precompileUpgrades":[{"txAllowListConfig":{"blockTimestamp":100,"disable":true}}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			vm := &VM{}
+			ctx, dbManager, genesisBytes, issuer := setupGenesis(t, genesisJSONSubnetEVM)
+			err := vm.Initialize(ctx, dbManager, genesisBytes, []byte(upgradeJSON), nil, issuer, []*engCommon.Fx{}, &engCommon.SenderTest{T: t})
+			assert.Error(t, err)
+		})
+	}
+}
+
 func TestVMContinuosProfiler(t *testing.T) {
 	profilerDir := t.TempDir()
 	profilerFrequency := 500 * time.Millisecond
@@ -2159,7 +2187,7 @@ func TestBuildAllowListActivationBlock(t *testing.T) {
 	if err := genesis.UnmarshalJSON([]byte(genesisJSONSubnetEVM)); err != nil {
 		t.Fatal(err)
 	}
-	genesis.Config.ContractDeployerAllowListConfig = precompile.ContractDeployerAllowListConfig{
+	genesis.Config.PrecompileConfigs[precompile.ContractDeployerAllowListConfigKey] = &precompile.ContractDeployerAllowListConfig{
 		AllowListConfig: precompile.AllowListConfig{
 			BlockTimestamp:  big.NewInt(time.Now().Unix()),
 			AllowListAdmins: testEthAddrs,
@@ -2184,7 +2212,7 @@ func TestBuildAllowListActivationBlock(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	role := precompile.GetContractDeployerAllowListStatus(genesisState, testEthAddrs[0])
+	role := precompile.GetContractDeployerAllowListStatus(genesisState, testEthAddrs[0], common.Big0)
 	if role != precompile.AllowListNoRole {
 		t.Fatalf("Expected allow list status to be set to no role: %s, but found: %s", precompile.AllowListNoRole, role)
 	}
@@ -2236,7 +2264,7 @@ func TestBuildAllowListActivationBlock(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	role = precompile.GetContractDeployerAllowListStatus(blkState, testEthAddrs[0])
+	role = precompile.GetContractDeployerAllowListStatus(blkState, testEthAddrs[0], common.Big0)
 	if role != precompile.AllowListAdmin {
 		t.Fatalf("Expected allow list status to be set to Admin: %s, but found: %s", precompile.AllowListAdmin, role)
 	}
@@ -2250,7 +2278,7 @@ func TestTxAllowListSuccessfulTx(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	genesis.Config.TxAllowListConfig = precompile.TxAllowListConfig{
+	genesis.Config.PrecompileConfigs[precompile.TxAllowListConfigKey] = &precompile.TxAllowListConfig{
 		AllowListConfig: precompile.AllowListConfig{
 			BlockTimestamp:  big.NewInt(0),
 			AllowListAdmins: testEthAddrs[0:1],
@@ -2277,11 +2305,11 @@ func TestTxAllowListSuccessfulTx(t *testing.T) {
 	}
 
 	// Check that address 0 is whitelisted and address 1 is not
-	role := precompile.GetTxAllowListStatus(genesisState, testEthAddrs[0])
+	role := precompile.GetTxAllowListStatus(genesisState, testEthAddrs[0], common.Big0)
 	if role != precompile.AllowListAdmin {
 		t.Fatalf("Expected allow list status to be set to no role: %s, but found: %s", precompile.AllowListAdmin, role)
 	}
-	role = precompile.GetTxAllowListStatus(genesisState, testEthAddrs[1])
+	role = precompile.GetTxAllowListStatus(genesisState, testEthAddrs[1], common.Big0)
 	if role != precompile.AllowListNoRole {
 		t.Fatalf("Expected allow list status to be set to no role: %s, but found: %s", precompile.AllowListNoRole, role)
 	}
@@ -2352,7 +2380,7 @@ func TestFeeManagerChangeFee(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	genesis.Config.FeeManagerConfig = precompile.FeeConfigManagerConfig{
+	genesis.Config.PrecompileConfigs[precompile.FeeConfigManagerConfigKey] = &precompile.FeeConfigManagerConfig{
 		AllowListConfig: precompile.AllowListConfig{
 			BlockTimestamp:  big.NewInt(0),
 			AllowListAdmins: testEthAddrs[0:1],
@@ -2395,11 +2423,11 @@ func TestFeeManagerChangeFee(t *testing.T) {
 	}
 
 	// Check that address 0 is whitelisted and address 1 is not
-	role := precompile.GetFeeConfigManagerStatus(genesisState, testEthAddrs[0])
+	role := precompile.GetFeeConfigManagerStatus(genesisState, testEthAddrs[0], common.Big0)
 	if role != precompile.AllowListAdmin {
 		t.Fatalf("Expected fee manager list status to be set to no role: %s, but found: %s", precompile.FeeConfigManagerAddress, role)
 	}
-	role = precompile.GetFeeConfigManagerStatus(genesisState, testEthAddrs[1])
+	role = precompile.GetFeeConfigManagerStatus(genesisState, testEthAddrs[1], common.Big0)
 	if role != precompile.AllowListNoRole {
 		t.Fatalf("Expected fee manager list status to be set to no role: %s, but found: %s", precompile.FeeConfigManagerAddress, role)
 	}
@@ -2494,3 +2522,45 @@ func TestFeeManagerChangeFee(t *testing.T) {
 	err = vm.chain.GetTxPool().AddRemote(signedTx2)
 	assert.ErrorIs(t, err, core.ErrUnderpriced)
 }
+
+func TestStateSyncSummaries(t *testing.T) {
+	_, vm, _, _ := GenesisVM(t, true, genesisJSONSubnetEVM, `{"state-sync-enabled":true}`, "")
+	defer func() {
+		if err := vm.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	enabled, err := vm.StateSyncEnabled()
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+
+	// Only the genesis block is accepted, so there is no summary to serve.
+	_, err = vm.GetLastStateSummary()
+	assert.ErrorIs(t, err, database.ErrNotFound)
+	_, err = vm.GetStateSummary(vm.config.CommitInterval)
+	assert.ErrorIs(t, err, database.ErrNotFound)
+	_, err = vm.GetOngoingSyncStateSummary()
+	assert.ErrorIs(t, err, database.ErrNotFound)
+
+	summaryBytes, err := vm.networkCodec.Marshal(message.Version, &message.SyncableBlock{
+		BlockNumber: vm.config.CommitInterval,
+		BlockRoot:   common.HexToHash("0x01"),
+		BlockHash:   common.HexToHash("0x02"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err := vm.ParseStateSummary(summaryBytes)
+	assert.NoError(t, err)
+	assert.Equal(t, vm.config.CommitInterval, summary.Height())
+	assert.Equal(t, summaryBytes, summary.Bytes())
+
+	// The summary is fewer than StateSyncMinBlocks ahead of the last accepted
+	// block, so the VM skips state sync.
+	accepted, err := summary.Accept()
+	assert.NoError(t, err)
+	assert.False(t, accepted)
+	_, err = vm.GetOngoingSyncStateSummary()
+	assert.ErrorIs(t, err, database.ErrNotFound)
+}
@@ -4,56 +4,109 @@
 package precompile
 
 import (
+	_ "embed"
 	"errors"
 	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ir4tech/webb-evm/accounts/abi"
 	"github.com/ir4tech/webb-evm/vmerrs"
 )
 
 // Enum constants for valid AllowListRole
 type AllowListRole common.Hash
 
+// Storage layout of the allow list. The role of every address is stored at the hash of the address, while its
+// expiry and its position in the enumeration of role holders are stored at keys derived with storageKey.
+// The prefixes of these keys start above the prefixes used by the precompiles that embed an allow list, so that
+// their keys never collide.
+// Role expiries and the enumeration of role holders are only written once the PrecompileUpgrade is active. Roles
+// granted before the upgrade never expire and are only enumerated once they are set again after the upgrade.
+const (
+	allowListRoleExpiryPrefix byte = iota + 0x80
+	allowListRoleHolderPrefix
+	allowListRoleHolderIndexPrefix
+)
+
+// AllowListRawABI is the solidity ABI of the allow list as declared in contract-examples/contracts/IAllowList.sol.
+//
+//go:embed allow_list.abi
+var AllowListRawABI string
+
 var (
 	AllowListNoRole  AllowListRole = AllowListRole(common.BigToHash(big.NewInt(0))) // No role assigned - this is equivalent to common.Hash{} and deletes the key from the DB when set
 	AllowListEnabled AllowListRole = AllowListRole(common.BigToHash(big.NewInt(1))) // Deployers are allowed to create new contracts
 	AllowListAdmin   AllowListRole = AllowListRole(common.BigToHash(big.NewInt(2))) // Admin - allowed to modify both the admin and deployer list as well as deploy contracts
+	AllowListManager AllowListRole = AllowListRole(common.BigToHash(big.NewInt(3))) // Manager - allowed to enable and disable addresses that are not admins or managers as well as deploy contracts
+
+	// AllowListABI is the parsed ABI of the allow list used to pack its events.
+	AllowListABI = mustParseABI(AllowListRawABI)
 
 	// AllowList function signatures
-	setAdminSignature      = CalculateFunctionSelector("setAdmin(address)")
-	setEnabledSignature    = CalculateFunctionSelector("setEnabled(address)")
-	setNoneSignature       = CalculateFunctionSelector("setNone(address)")
-	readAllowListSignature = CalculateFunctionSelector("readAllowList(address)")
+	setAdminSignature            = CalculateFunctionSelector("setAdmin(address)")
+	setManagerSignature          = CalculateFunctionSelector("setManager(address)")
+	setEnabledSignature          = CalculateFunctionSelector("setEnabled(address)")
+	setNoneSignature             = CalculateFunctionSelector("setNone(address)")
+	setAdminUntilSignature       = CalculateFunctionSelector("setAdminUntil(address,uint256)")
+	setManagerUntilSignature     = CalculateFunctionSelector("setManagerUntil(address,uint256)")
+	setEnabledUntilSignature     = CalculateFunctionSelector("setEnabledUntil(address,uint256)")
+	readAllowListSignature       = CalculateFunctionSelector("readAllowList(address)")
+	readAllowListExpirySignature = CalculateFunctionSelector("readAllowListExpiry(address)")
+	roleHolderCountSignature     = CalculateFunctionSelector("roleHolderCount()")
+	roleHolderAtSignature        = CalculateFunctionSelector("roleHolderAt(uint256)")
 	// Error returned when an invalid write is attempted
 	ErrCannotModifyAllowList = errors.New("non-admin cannot modify allow list")
+	// Error returned when a role is granted with an expiry that is not in the future
+	ErrInvalidRoleExpiry = errors.New("role expiry must be after the current block timestamp")
+	// Error returned when reading a role holder past the end of the enumeration
+	ErrRoleHolderIndexOutOfRange = errors.New("role holder index out of range")
+
+	// roleHolderCountKey is the storage key of the number of enumerated role holders.
+	roleHolderCountKey = common.Hash{'r', 'h'}
 
-	allowListInputLen = common.HashLength
+	allowListInputLen      = common.HashLength
+	allowListUntilInputLen = common.HashLength + common.HashLength
 )
 
 // AllowListConfig specifies the configuration of the allow list.
 // Specifies the block timestamp at which it goes into effect as well as the initial set of allow list admins.
+// When used in a network upgrade, [Disable] turns the precompile off at [BlockTimestamp] instead.
 type AllowListConfig struct {
 	BlockTimestamp *big.Int `json:"blockTimestamp"`
 
 	AllowListAdmins []common.Address `json:"adminAddresses"`
+
+	Disable bool `json:"disable,omitempty"`
 }
 
 // Timestamp returns the timestamp at which the allow list should be enabled
 func (c *AllowListConfig) Timestamp() *big.Int { return c.BlockTimestamp }
 
+// IsDisabled returns true if this config disables the precompile at [BlockTimestamp]
+func (c *AllowListConfig) IsDisabled() bool { return c.Disable }
+
+// Verify returns nil since any set of admins is a valid allow list config.
+func (c *AllowListConfig) Verify() error { return nil }
+
 // Configure initializes the address space of [precompileAddr] by initializing the role of each of
-// the addresses in [AllowListAdmins].
-func (c *AllowListConfig) Configure(state StateDB, precompileAddr common.Address) {
+// the addresses in [AllowListAdmins]. The admins are only enumerated as role holders if the PrecompileUpgrade
+// is active at [blockContext], so that the state configured before the upgrade is unchanged.
+func (c *AllowListConfig) Configure(chainConfig ChainConfig, state StateDB, blockContext BlockContext, precompileAddr common.Address) {
+	upgraded := chainConfig.IsPrecompileUpgrade(blockContext.Timestamp())
 	for _, adminAddr := range c.AllowListAdmins {
-		setAllowListRole(state, precompileAddr, adminAddr, AllowListAdmin)
+		if upgraded {
+			setAllowListRole(state, precompileAddr, adminAddr, AllowListAdmin)
+		} else {
+			storeAllowListRole(state, precompileAddr, adminAddr, AllowListAdmin)
+		}
 	}
 }
 
 // Valid returns true iff [s] represents a valid role.
 func (s AllowListRole) Valid() bool {
 	switch s {
-	case AllowListNoRole, AllowListEnabled, AllowListAdmin:
+	case AllowListNoRole, AllowListEnabled, AllowListAdmin, AllowListManager:
 		return true
 	default:
 		return false
@@ -80,34 +133,156 @@ func (s AllowListRole) IsAdmin() bool {
 	}
 }
 
+// IsManager returns true if [s] indicates the permission to enable and disable addresses that are not admins or managers.
+func (s AllowListRole) IsManager() bool {
+	switch s {
+	case AllowListManager:
+		return true
+	default:
+		return false
+	}
+}
+
 // IsEnabled returns true if [s] indicates that it has permission to access the resource.
 func (s AllowListRole) IsEnabled() bool {
 	switch s {
-	case AllowListAdmin, AllowListEnabled:
+	case AllowListAdmin, AllowListManager, AllowListEnabled:
 		return true
 	default:
 		return false
 	}
 }
 
+// CanModify returns true if [s] indicates the permission to change the role of an address from [from] to [to].
+// Admins may make any change, while managers may only move addresses between no role and enabled.
+func (s AllowListRole) CanModify(from AllowListRole, to AllowListRole) bool {
+	switch {
+	case s.IsAdmin():
+		return true
+	case s.IsManager():
+		return (from.IsNoRole() || from == AllowListEnabled) && (to.IsNoRole() || to == AllowListEnabled)
+	default:
+		return false
+	}
+}
+
 // getAllowListStatus returns the allow list role of [address] for the precompile
-// at [precompileAddr]
-func getAllowListStatus(state StateDB, precompileAddr common.Address, address common.Address) AllowListRole {
+// at [precompileAddr] at the block [timestamp], which is no role once the role has expired.
+func getAllowListStatus(state StateDB, precompileAddr common.Address, address common.Address, timestamp *big.Int) AllowListRole {
 	// Generate the state key for [address]
 	addressKey := address.Hash()
-	return AllowListRole(state.GetState(precompileAddr, addressKey))
+	role := AllowListRole(state.GetState(precompileAddr, addressKey))
+	if role.IsNoRole() {
+		return role
+	}
+	if expiry := getAllowListRoleExpiry(state, precompileAddr, address); expiry.Sign() != 0 && timestamp.Cmp(expiry) >= 0 {
+		return AllowListNoRole
+	}
+	return role
+}
+
+// getAllowListRoleExpiry returns the block timestamp at which the role of [address] for the precompile at
+// [precompileAddr] expires, or zero if it never expires.
+func getAllowListRoleExpiry(state StateDB, precompileAddr common.Address, address common.Address) *big.Int {
+	return state.GetState(precompileAddr, storageKey(allowListRoleExpiryPrefix, address.Hash().Bytes())).Big()
 }
 
 // setAllowListRole sets the permissions of [address] to [role] for the precompile
-// at [precompileAddr].
+// at [precompileAddr], without an expiry.
 // assumes [role] has already been verified as valid.
 func setAllowListRole(stateDB StateDB, precompileAddr, address common.Address, role AllowListRole) {
+	setAllowListRoleUntil(stateDB, precompileAddr, address, role, common.Big0)
+}
+
+// setAllowListRoleUntil sets the permissions of [address] to [role] for the precompile at [precompileAddr] until
+// the block timestamp [expiresAt], or without an expiry if [expiresAt] is zero, and keeps the enumeration of role
+// holders up to date.
+// assumes [role] has already been verified as valid.
+func setAllowListRoleUntil(stateDB StateDB, precompileAddr, address common.Address, role AllowListRole, expiresAt *big.Int) {
+	storeAllowListRole(stateDB, precompileAddr, address, role)
+	addressKey := address.Hash()
+	if role.IsNoRole() {
+		expiresAt = common.Big0
+	}
+	stateDB.SetState(precompileAddr, storageKey(allowListRoleExpiryPrefix, addressKey.Bytes()), common.BigToHash(expiresAt))
+
+	if role.IsNoRole() {
+		removeRoleHolder(stateDB, precompileAddr, address)
+	} else {
+		addRoleHolder(stateDB, precompileAddr, address)
+	}
+}
+
+// storeAllowListRole sets the permissions of [address] to [role] for the precompile at [precompileAddr] without
+// touching its expiry or the enumeration of role holders, as roles are set before the PrecompileUpgrade.
+// assumes [role] has already been verified as valid.
+func storeAllowListRole(stateDB StateDB, precompileAddr, address common.Address, role AllowListRole) {
 	// Generate the state key for [address]
 	addressKey := address.Hash()
 	// Assign [role] to the address
 	stateDB.SetState(precompileAddr, addressKey, common.Hash(role))
 }
 
+// getRoleHolderCount returns the number of enumerated role holders of the precompile at [precompileAddr].
+func getRoleHolderCount(stateDB StateDB, precompileAddr common.Address) uint64 {
+	return stateDB.GetState(precompileAddr, roleHolderCountKey).Big().Uint64()
+}
+
+// getRoleHolderAt returns the role holder of the precompile at [precompileAddr] at [index] of the enumeration.
+// assumes [index] is less than the number of role holders.
+func getRoleHolderAt(stateDB StateDB, precompileAddr common.Address, index uint64) common.Address {
+	return common.BytesToAddress(stateDB.GetState(precompileAddr, storageKey(allowListRoleHolderPrefix, indexBytes(index))).Bytes())
+}
+
+// addRoleHolder appends [address] to the enumeration of role holders of the precompile at [precompileAddr],
+// unless it is already enumerated. The position of every holder is stored offset by one, so that zero
+// indicates an address that is not enumerated.
+func addRoleHolder(stateDB StateDB, precompileAddr, address common.Address) {
+	positionKey := storageKey(allowListRoleHolderIndexPrefix, address.Hash().Bytes())
+	if stateDB.GetState(precompileAddr, positionKey) != (common.Hash{}) {
+		return
+	}
+	count := getRoleHolderCount(stateDB, precompileAddr)
+	stateDB.SetState(precompileAddr, storageKey(allowListRoleHolderPrefix, indexBytes(count)), address.Hash())
+	stateDB.SetState(precompileAddr, positionKey, common.BytesToHash(indexBytes(count+1)))
+	stateDB.SetState(precompileAddr, roleHolderCountKey, common.BytesToHash(indexBytes(count+1)))
+}
+
+// removeRoleHolder removes [address] from the enumeration of role holders of the precompile at [precompileAddr]
+// by moving the last holder into its position, if it is enumerated.
+func removeRoleHolder(stateDB StateDB, precompileAddr, address common.Address) {
+	positionKey := storageKey(allowListRoleHolderIndexPrefix, address.Hash().Bytes())
+	position := stateDB.GetState(precompileAddr, positionKey).Big().Uint64()
+	if position == 0 {
+		return
+	}
+	index, last := position-1, getRoleHolderCount(stateDB, precompileAddr)-1
+	if index != last {
+		lastHolder := getRoleHolderAt(stateDB, precompileAddr, last)
+		stateDB.SetState(precompileAddr, storageKey(allowListRoleHolderPrefix, indexBytes(index)), lastHolder.Hash())
+		stateDB.SetState(precompileAddr, storageKey(allowListRoleHolderIndexPrefix, lastHolder.Hash().Bytes()), common.BytesToHash(indexBytes(position)))
+	}
+	stateDB.SetState(precompileAddr, storageKey(allowListRoleHolderPrefix, indexBytes(last)), common.Hash{})
+	stateDB.SetState(precompileAddr, positionKey, common.Hash{})
+	stateDB.SetState(precompileAddr, roleHolderCountKey, common.BytesToHash(indexBytes(last)))
+}
+
+// emitRoleSet adds a RoleSet log of [sender] setting the role of [account] to [role] until [expiresAt] to the
+// logs of the precompile at [precompileAddr].
+func emitRoleSet(accessibleState PrecompileAccessibleState, precompileAddr common.Address, account common.Address, role AllowListRole, sender common.Address, expiresAt *big.Int) error {
+	event := AllowListABI.Events["RoleSet"]
+	topics, err := abi.MakeTopics([]interface{}{account}, []interface{}{common.Hash(role).Big()}, []interface{}{sender})
+	if err != nil {
+		return err
+	}
+	data, err := event.Inputs.NonIndexed().Pack(expiresAt)
+	if err != nil {
+		return err
+	}
+	accessibleState.AddLog(precompileAddr, []common.Hash{event.ID, topics[0][0], topics[1][0], topics[2][0]}, data)
+	return nil
+}
+
 // PackModifyAllowList packs [address] and [role] into the appropriate arguments for modifying the allow list.
 // Note: [role] is not packed in the input value returned, but is instead used as a selector for the function
 // selector that should be encoded in the input.
@@ -118,6 +293,8 @@ func PackModifyAllowList(address common.Address, role AllowListRole) ([]byte, er
 	switch role {
 	case AllowListAdmin:
 		input = append(input, setAdminSignature...)
+	case AllowListManager:
+		input = append(input, setManagerSignature...)
 	case AllowListEnabled:
 		input = append(input, setEnabledSignature...)
 	case AllowListNoRole:
@@ -130,6 +307,29 @@ func PackModifyAllowList(address common.Address, role AllowListRole) ([]byte, er
 	return input, nil
 }
 
+// PackModifyAllowListUntil packs [address], [role] and [expiresAt] into the appropriate arguments for granting
+// [role] to [address] until the block timestamp [expiresAt].
+// Note: like PackModifyAllowList, [role] selects the function selector that is encoded in the input.
+// Assumes that [expiresAt] can be represented by 32 bytes.
+func PackModifyAllowListUntil(address common.Address, role AllowListRole, expiresAt *big.Int) ([]byte, error) {
+	var selector []byte
+	switch role {
+	case AllowListAdmin:
+		selector = setAdminUntilSignature
+	case AllowListManager:
+		selector = setManagerUntilSignature
+	case AllowListEnabled:
+		selector = setEnabledUntilSignature
+	default:
+		return nil, fmt.Errorf("cannot pack modify list input with expiry for role: %s", role)
+	}
+
+	// function selector (4 bytes) + hash for address + hash for expiry
+	input := make([]byte, selectorLen+allowListUntilInputLen)
+	packOrderedHashesWithSelector(input, selector, []common.Hash{address.Hash(), common.BigToHash(expiresAt)})
+	return input, nil
+}
+
 // PackReadAllowList packs [address] into the input data to the read allow list function
 func PackReadAllowList(address common.Address) []byte {
 	input := make([]byte, 0, selectorLen+common.HashLength)
@@ -138,11 +338,78 @@ func PackReadAllowList(address common.Address) []byte {
 	return input
 }
 
+// PackReadAllowListExpiry packs [address] into the input data to the read allow list expiry function
+func PackReadAllowListExpiry(address common.Address) []byte {
+	input := make([]byte, 0, selectorLen+common.HashLength)
+	input = append(input, readAllowListExpirySignature...)
+	input = append(input, address.Hash().Bytes()...)
+	return input
+}
+
+// PackRoleHolderCount returns the input data to the role holder count function
+func PackRoleHolderCount() []byte {
+	return append([]byte{}, roleHolderCountSignature...)
+}
+
+// PackRoleHolderAt packs [index] into the input data to the role holder at function
+func PackRoleHolderAt(index uint64) []byte {
+	input := make([]byte, 0, selectorLen+common.HashLength)
+	input = append(input, roleHolderAtSignature...)
+	input = append(input, indexBytes(index)...)
+	return input
+}
+
+// UnpackRoleHolderAtOutput unpacks the output of the role holder at function into the address, the role it was
+// granted and the block timestamp at which the role expires.
+func UnpackRoleHolderAtOutput(output []byte) (common.Address, AllowListRole, *big.Int, error) {
+	if len(output) != 3*common.HashLength {
+		return common.Address{}, AllowListNoRole, nil, fmt.Errorf("invalid output length for role holder: %d", len(output))
+	}
+	address := common.BytesToAddress(returnPackedHash(output, 0))
+	role := AllowListRole(common.BytesToHash(returnPackedHash(output, 1)))
+	expiresAt := new(big.Int).SetBytes(returnPackedHash(output, 2))
+	return address, role, expiresAt, nil
+}
+
+// modifyAllowList sets the role of [modifyAddress] to [role] until [expiresAt] on behalf of [callerAddr] for the
+// precompile at [precompileAddr], after verifying that the caller has the permission to do so, and emits a RoleSet event.
+// Before the PrecompileUpgrade, only the role is set and no event is emitted.
+func modifyAllowList(evm PrecompileAccessibleState, precompileAddr, callerAddr, modifyAddress common.Address, role AllowListRole, expiresAt *big.Int, remainingGas uint64) ([]byte, uint64, error) {
+	stateDB := evm.GetStateDB()
+	timestamp := evm.GetBlockContext().Timestamp()
+
+	// Verify that the caller is in the allow list and therefore has the right to modify it
+	callerStatus := getAllowListStatus(stateDB, precompileAddr, callerAddr, timestamp)
+	currentStatus := getAllowListStatus(stateDB, precompileAddr, modifyAddress, timestamp)
+	if !callerStatus.CanModify(currentStatus, role) {
+		return nil, remainingGas, fmt.Errorf("%w: %s", ErrCannotModifyAllowList, callerAddr)
+	}
+	if expiresAt.Sign() != 0 && expiresAt.Cmp(timestamp) <= 0 {
+		return nil, remainingGas, fmt.Errorf("%w: %s", ErrInvalidRoleExpiry, expiresAt)
+	}
+
+	if !isPrecompileUpgrade(evm) {
+		storeAllowListRole(stateDB, precompileAddr, modifyAddress, role)
+		return []byte{}, remainingGas, nil
+	}
+
+	setAllowListRoleUntil(stateDB, precompileAddr, modifyAddress, role, expiresAt)
+	if err := emitRoleSet(evm, precompileAddr, modifyAddress, role, callerAddr, expiresAt); err != nil {
+		return nil, remainingGas, err
+	}
+	// Return an empty output and the remaining gas
+	return []byte{}, remainingGas, nil
+}
+
 // createAllowListRoleSetter returns an execution function for setting the allow list status of the input address argument to [role].
 // This execution function is speciifc to [precompileAddr].
 func createAllowListRoleSetter(precompileAddr common.Address, role AllowListRole) RunStatefulPrecompileFunc {
 	return func(evm PrecompileAccessibleState, callerAddr, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
-		if remainingGas, err = deductGas(suppliedGas, ModifyAllowListGasCost); err != nil {
+		gasCost := uint64(ModifyAllowListGasCost)
+		if !isPrecompileUpgrade(evm) {
+			gasCost = LegacyModifyAllowListGasCost
+		}
+		if remainingGas, err = deductGas(suppliedGas, gasCost); err != nil {
 			return nil, 0, err
 		}
 
@@ -156,26 +423,44 @@ func createAllowListRoleSetter(precompileAddr common.Address, role AllowListRole
 			return nil, remainingGas, vmerrs.ErrWriteProtection
 		}
 
-		stateDB := evm.GetStateDB()
+		return modifyAllowList(evm, precompileAddr, callerAddr, modifyAddress, role, common.Big0, remainingGas)
+	}
+}
+
+// createAllowListRoleSetterUntil returns an execution function for setting the allow list status of the input address
+// argument to [role] until the block timestamp given as the second argument.
+// This execution function is specific to [precompileAddr].
+func createAllowListRoleSetterUntil(precompileAddr common.Address, role AllowListRole) RunStatefulPrecompileFunc {
+	return func(evm PrecompileAccessibleState, callerAddr, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+		if remainingGas, err = deductGas(suppliedGas, ModifyAllowListGasCost); err != nil {
+			return nil, 0, err
+		}
 
-		// Verify that the caller is in the allow list and therefore has the right to modify it
-		callerStatus := getAllowListStatus(stateDB, precompileAddr, callerAddr)
-		if !callerStatus.IsAdmin() {
-			return nil, remainingGas, fmt.Errorf("%w: %s", ErrCannotModifyAllowList, callerAddr)
+		if len(input) != allowListUntilInputLen {
+			return nil, remainingGas, fmt.Errorf("invalid input length for modifying allow list with expiry: %d", len(input))
 		}
 
-		setAllowListRole(stateDB, precompileAddr, modifyAddress, role)
-		// Return an empty output and the remaining gas
-		return []byte{}, remainingGas, nil
+		modifyAddress := common.BytesToAddress(returnPackedHash(input, 0))
+		expiresAt := new(big.Int).SetBytes(returnPackedHash(input, 1))
+
+		if readOnly {
+			return nil, remainingGas, vmerrs.ErrWriteProtection
+		}
+
+		return modifyAllowList(evm, precompileAddr, callerAddr, modifyAddress, role, expiresAt, remainingGas)
 	}
 }
 
 // createReadAllowList returns an execution function that reads the allow list for the given [precompileAddr].
 // The execution function parses the input into a single address and returns the 32 byte hash that specifies the
-// designated role of that address
+// designated role of that address at the current block timestamp
 func createReadAllowList(precompileAddr common.Address) RunStatefulPrecompileFunc {
 	return func(evm PrecompileAccessibleState, callerAddr common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
-		if remainingGas, err = deductGas(suppliedGas, ReadAllowListGasCost); err != nil {
+		gasCost := uint64(ReadAllowListGasCost)
+		if !isPrecompileUpgrade(evm) {
+			gasCost = LegacyReadAllowListGasCost
+		}
+		if remainingGas, err = deductGas(suppliedGas, gasCost); err != nil {
 			return nil, 0, err
 		}
 
@@ -184,12 +469,76 @@ func createReadAllowList(precompileAddr common.Address) RunStatefulPrecompileFun
 		}
 
 		readAddress := common.BytesToAddress(input)
-		role := getAllowListStatus(evm.GetStateDB(), precompileAddr, readAddress)
+		role := getAllowListStatus(evm.GetStateDB(), precompileAddr, readAddress, evm.GetBlockContext().Timestamp())
 		roleBytes := common.Hash(role).Bytes()
 		return roleBytes, remainingGas, nil
 	}
 }
 
+// createReadAllowListExpiry returns an execution function that reads the block timestamp at which the role of the
+// input address expires for the given [precompileAddr], which is zero if the role never expires.
+func createReadAllowListExpiry(precompileAddr common.Address) RunStatefulPrecompileFunc {
+	return func(evm PrecompileAccessibleState, callerAddr common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+		if remainingGas, err = deductGas(suppliedGas, ReadAllowListExpiryGasCost); err != nil {
+			return nil, 0, err
+		}
+
+		if len(input) != allowListInputLen {
+			return nil, remainingGas, fmt.Errorf("invalid input length for read allow list expiry: %d", len(input))
+		}
+
+		readAddress := common.BytesToAddress(input)
+		expiry := getAllowListRoleExpiry(evm.GetStateDB(), precompileAddr, readAddress)
+		return common.BigToHash(expiry).Bytes(), remainingGas, nil
+	}
+}
+
+// createReadRoleHolderCount returns an execution function that reads the number of role holders of the given [precompileAddr].
+func createReadRoleHolderCount(precompileAddr common.Address) RunStatefulPrecompileFunc {
+	return func(evm PrecompileAccessibleState, callerAddr common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+		if remainingGas, err = deductGas(suppliedGas, ReadRoleHolderCountGasCost); err != nil {
+			return nil, 0, err
+		}
+
+		if len(input) != 0 {
+			return nil, remainingGas, fmt.Errorf("invalid input length for read role holder count: %d", len(input))
+		}
+
+		return evm.GetStateDB().GetState(precompileAddr, roleHolderCountKey).Bytes(), remainingGas, nil
+	}
+}
+
+// createReadRoleHolderAt returns an execution function that reads the role holder of the given [precompileAddr] at the
+// input index, and returns its address, the role it was granted and the block timestamp at which the role expires.
+// Role holders stay enumerated after their role expires until their role is set again. Roles granted before the
+// PrecompileUpgrade are not enumerated until they are set again.
+func createReadRoleHolderAt(precompileAddr common.Address) RunStatefulPrecompileFunc {
+	return func(evm PrecompileAccessibleState, callerAddr common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+		if remainingGas, err = deductGas(suppliedGas, ReadRoleHolderAtGasCost); err != nil {
+			return nil, 0, err
+		}
+
+		if len(input) != common.HashLength {
+			return nil, remainingGas, fmt.Errorf("invalid input length for read role holder: %d", len(input))
+		}
+
+		stateDB := evm.GetStateDB()
+		index := new(big.Int).SetBytes(input)
+		if count := getRoleHolderCount(stateDB, precompileAddr); !index.IsUint64() || index.Uint64() >= count {
+			return nil, remainingGas, fmt.Errorf("%w: %s", ErrRoleHolderIndexOutOfRange, index)
+		}
+		holder := getRoleHolderAt(stateDB, precompileAddr, index.Uint64())
+
+		output := make([]byte, 3*common.HashLength)
+		packOrderedHashes(output, []common.Hash{
+			holder.Hash(),
+			stateDB.GetState(precompileAddr, holder.Hash()),
+			common.BigToHash(getAllowListRoleExpiry(stateDB, precompileAddr, holder)),
+		})
+		return output, remainingGas, nil
+	}
+}
+
 // createAllowListPrecompile returns a StatefulPrecompiledContract with R/W control of an allow list at [precompileAddr]
 func createAllowListPrecompile(precompileAddr common.Address) StatefulPrecompiledContract {
 	// Construct the contract with no fallback function.
@@ -198,11 +547,20 @@ func createAllowListPrecompile(precompileAddr common.Address) StatefulPrecompile
 	return contract
 }
 
+// createAllowListFunctions returns the functions of an allow list at [precompileAddr]. The manager role, role expiries
+// and role holder enumeration only exist once the PrecompileUpgrade is active.
 func createAllowListFunctions(precompileAddr common.Address) []*statefulPrecompileFunction {
 	setAdmin := newStatefulPrecompileFunction(setAdminSignature, createAllowListRoleSetter(precompileAddr, AllowListAdmin))
+	setManager := newUpgradeStatefulPrecompileFunction(setManagerSignature, createAllowListRoleSetter(precompileAddr, AllowListManager))
 	setEnabled := newStatefulPrecompileFunction(setEnabledSignature, createAllowListRoleSetter(precompileAddr, AllowListEnabled))
 	setNone := newStatefulPrecompileFunction(setNoneSignature, createAllowListRoleSetter(precompileAddr, AllowListNoRole))
+	setAdminUntil := newUpgradeStatefulPrecompileFunction(setAdminUntilSignature, createAllowListRoleSetterUntil(precompileAddr, AllowListAdmin))
+	setManagerUntil := newUpgradeStatefulPrecompileFunction(setManagerUntilSignature, createAllowListRoleSetterUntil(precompileAddr, AllowListManager))
+	setEnabledUntil := newUpgradeStatefulPrecompileFunction(setEnabledUntilSignature, createAllowListRoleSetterUntil(precompileAddr, AllowListEnabled))
 	read := newStatefulPrecompileFunction(readAllowListSignature, createReadAllowList(precompileAddr))
+	readExpiry := newUpgradeStatefulPrecompileFunction(readAllowListExpirySignature, createReadAllowListExpiry(precompileAddr))
+	holderCount := newUpgradeStatefulPrecompileFunction(roleHolderCountSignature, createReadRoleHolderCount(precompileAddr))
+	holderAt := newUpgradeStatefulPrecompileFunction(roleHolderAtSignature, createReadRoleHolderAt(precompileAddr))
 
-	return []*statefulPrecompileFunction{setAdmin, setEnabled, setNone, read}
+	return []*statefulPrecompileFunction{setAdmin, setManager, setEnabled, setNone, setAdminUntil, setManagerUntil, setEnabledUntil, read, readExpiry, holderCount, holderAt}
 }
@@ -0,0 +1,123 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package precompile
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ir4tech/webb-evm/commontype"
+)
+
+var (
+	ErrInvalidAssetAllocation = errors.New("invalid initial asset")
+	ErrInvalidMetadataSchema  = errors.New("invalid asset metadata schema")
+)
+
+// AssetConfig specifies the assets registered when the asset precompile activates and the schema that metadata
+// attached to assets must follow.
+type AssetConfig struct {
+	// InitialAssets are registered when the precompile activates. Reconfigurations do not register them again.
+	InitialAssets []AssetAllocation `json:"initialAssets,omitempty"`
+	// MetadataSchema declares the metadata keys that may be set on assets. No metadata may be set without a schema.
+	MetadataSchema AssetMetadataSchema `json:"metadataSchema,omitempty"`
+}
+
+// AssetAllocation is an asset registered when the asset precompile activates.
+type AssetAllocation struct {
+	Id       common.Hash       `json:"id"`
+	Owner    common.Address    `json:"owner"`
+	Name     string            `json:"name,omitempty"`
+	Location string            `json:"location,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// AssetMetadataSchema maps every metadata key that may be set on an asset to the maximum length in bytes of its value.
+type AssetMetadataSchema map[string]uint64
+
+// Verify returns an error if the initial assets are malformed or their metadata does not follow the metadata schema.
+func (c *AssetConfig) Verify() error {
+	for key, maxLength := range c.MetadataSchema {
+		if len(key) == 0 {
+			return fmt.Errorf("%w: empty key", ErrInvalidMetadataSchema)
+		}
+		if maxLength == 0 {
+			return fmt.Errorf("%w: zero maximum length for key %q", ErrInvalidMetadataSchema, key)
+		}
+	}
+
+	ids := make(map[common.Hash]struct{}, len(c.InitialAssets))
+	for _, allocation := range c.InitialAssets {
+		if allocation.Id == (common.Hash{}) {
+			return fmt.Errorf("%w: zero id", ErrInvalidAssetAllocation)
+		}
+		if _, ok := ids[allocation.Id]; ok {
+			return fmt.Errorf("%w: duplicate id %s", ErrInvalidAssetAllocation, allocation.Id)
+		}
+		ids[allocation.Id] = struct{}{}
+		if allocation.Owner == (common.Address{}) {
+			return fmt.Errorf("%w: zero owner for %s", ErrInvalidAssetAllocation, allocation.Id)
+		}
+		for key, value := range allocation.Metadata {
+			if err := c.MetadataSchema.check(key, value); err != nil {
+				return fmt.Errorf("%w: %s", err, allocation.Id)
+			}
+		}
+	}
+	return nil
+}
+
+// check returns an error if [schema] does not allow [value] to be set for [key].
+func (schema AssetMetadataSchema) check(key string, value string) error {
+	maxLength, ok := schema[key]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrMetadataKeyNotAllowed, key)
+	}
+	if uint64(len(value)) > maxLength {
+		return fmt.Errorf("%w: %q is limited to %d bytes", ErrMetadataValueTooLong, key, maxLength)
+	}
+	return nil
+}
+
+// Configure writes the metadata schema and the initial assets of [c] to the storage of the asset precompile at
+// [precompileAddr]. The initial assets are only registered when the precompile activates, so a reconfiguration
+// only replaces the metadata schema. An initial asset configured with a location starts its location history with
+// that location, recorded at the timestamp of [blockContext].
+// Assumes that [c] has been verified. Metadata that the schema does not allow is skipped rather than failing the
+// block that activates the precompile.
+func (c *AssetConfig) Configure(state StateDB, blockContext BlockContext, precompileAddr common.Address) {
+	storeMetadataSchema(state, c.MetadataSchema)
+
+	if isConfigured(state, precompileAddr) {
+		return
+	}
+	for _, allocation := range c.InitialAssets {
+		asset := commontype.Asset{
+			Id:       allocation.Id,
+			Name:     allocation.Name,
+			Owner:    allocation.Owner,
+			Location: allocation.Location,
+		}
+		if asset.Name == "" {
+			asset.Name = defaultAssetName
+		}
+		if asset.Location == "" {
+			asset.Location = defaultAssetLocation
+		}
+		storeNewAsset(state, asset)
+		if allocation.Location != "" {
+			appendLocationRecord(state, asset.Id, commontype.LocationRecord{
+				Location:  asset.Location,
+				Timestamp: blockContext.Timestamp().Uint64(),
+			})
+		}
+		for key, value := range allocation.Metadata {
+			if err := storeAssetMetadata(state, asset.Id, key, value); err != nil {
+				log.Warn("skipping initial asset metadata", "assetId", asset.Id, "key", key, "err", err)
+			}
+		}
+	}
+}
@@ -1,159 +1,1260 @@
 package precompile
 
 import (
+	"bytes"
+	_ "embed"
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/log"
-	"github.com/google/uuid"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ir4tech/webb-evm/accounts/abi"
 	"github.com/ir4tech/webb-evm/commontype"
-	"math/big"
+	"github.com/ir4tech/webb-evm/vmerrs"
 )
 
 type AssetLocation string
 
+// AssetRawABI is the solidity ABI of the asset precompile as declared in contract-examples/contracts/IAsset.sol.
+//
+//go:embed asset.abi
+var AssetRawABI string
+
+// AssetReceiverRawABI is the solidity ABI of IAssetReceiver as declared in contract-examples/contracts/IAsset.sol,
+// which contracts implement to accept asset transfers.
+//
+//go:embed asset_receiver.abi
+var AssetReceiverRawABI string
+
 var (
 	DefaultAsset = commontype.Asset{}
 
-	registerAssetSignature     = CalculateFunctionSelector("registerAsset()")
-	getAssetSignature          = CalculateFunctionSelector("getAsset(string)")
-	getAllAssetsSignature      = CalculateFunctionSelector("getAll()")
-	getAssetByAddressSignature = CalculateFunctionSelector("getAssetByAddress(address)")
-	updateLocationSignature    = CalculateFunctionSelector("updateLocation(string,string)")
-	updateNameSignature        = CalculateFunctionSelector("updateName(string,string)")
+	// AssetABI is the parsed ABI of the asset precompile used to unpack inputs and pack outputs.
+	AssetABI = mustParseABI(AssetRawABI)
+	// AssetReceiverABI is the parsed ABI of IAssetReceiver used to call back into the recipients of transfers.
+	AssetReceiverABI = mustParseABI(AssetReceiverRawABI)
+
+	registerAssetSignature              = AssetABI.Methods["registerAsset"].ID
+	registerAssetWithSaltSignature      = AssetABI.Methods["registerAssetWithSalt"].ID
+	getAssetSignature                   = AssetABI.Methods["getAsset"].ID
+	getAllAssetsSignature               = AssetABI.Methods["getAll"].ID
+	getAssetByAddressSignature          = AssetABI.Methods["getAssetByAddress"].ID
+	updateLocationSignature             = AssetABI.Methods["updateLocation"].ID
+	updateNameSignature                 = AssetABI.Methods["updateName"].ID
+	transferAssetSignature              = AssetABI.Methods["transferAsset"].ID
+	approveAssetSignature               = AssetABI.Methods["approve"].ID
+	setApprovalForAllSignature          = AssetABI.Methods["setApprovalForAll"].ID
+	burnAssetSignature                  = AssetABI.Methods["burnAsset"].ID
+	getApprovedSignature                = AssetABI.Methods["getApproved"].ID
+	isApprovedForAllSignature           = AssetABI.Methods["isApprovedForAll"].ID
+	updateLocationWithEvidenceSignature = AssetABI.Methods["updateLocationWithEvidence"].ID
+	getLocationHistorySignature         = AssetABI.Methods["getLocationHistory"].ID
+	getLocationHistoryLengthSignature   = AssetABI.Methods["getLocationHistoryLength"].ID
+	setMetadataSignature                = AssetABI.Methods["setMetadata"].ID
+	getMetadataSignature                = AssetABI.Methods["getMetadata"].ID
+	totalAssetsSignature                = AssetABI.Methods["totalAssets"].ID
+	assetByIndexSignature               = AssetABI.Methods["assetByIndex"].ID
+	assetsOfOwnerSignature              = AssetABI.Methods["assetsOfOwner"].ID
+	balanceOfSignature                  = AssetABI.Methods["balanceOf"].ID
+	onAssetReceivedSignature            = AssetReceiverABI.Methods["onAssetReceived"].ID
 
-	getAssetByIdInputLen      = common.HashLength
-	getAssetByAddressInputLen = common.AddressLength
+	ErrInvalidAssetId        = errors.New("invalid asset id")
+	ErrCannotRegisterAsset   = errors.New("non-enabled cannot register asset")
+	ErrCannotModifyAsset     = errors.New("non-owner cannot modify asset")
+	ErrCannotTransferAsset   = errors.New("caller is not owner nor approved")
+	ErrCannotApproveAsset    = errors.New("caller is not owner nor approved for all")
+	ErrInvalidAssetRecipient = errors.New("cannot transfer asset to the zero address")
+	ErrApprovalToOwner       = errors.New("cannot approve the current owner")
+	ErrMetadataKeyNotAllowed = errors.New("metadata key not allowed by schema")
+	ErrMetadataValueTooLong  = errors.New("metadata value exceeds maximum length")
+	ErrAssetNotReceived      = errors.New("transfer to non asset receiver")
 )
 
-type AssetConfig struct {
-	BlockTimestamp *big.Int `json:"blockTimestamp"`
+// assetOutput is the ABI representation of the solidity struct Asset declared in IAsset.sol.
+type assetOutput struct {
+	Id       string
+	Name     string
+	Owner    common.Address
+	Location string
+}
+
+// mustParseABI parses [rawABI] and panics if it is invalid.
+func mustParseABI(rawABI string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(rawABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// locationRecordOutput is the ABI representation of the solidity struct LocationRecord declared in IAsset.sol.
+type locationRecordOutput struct {
+	Location  string
+	Timestamp *big.Int
+	Updater   common.Address
+	Evidence  [32]byte
+}
+
+func toLocationRecordOutput(record commontype.LocationRecord) locationRecordOutput {
+	return locationRecordOutput{
+		Location:  record.Location,
+		Timestamp: new(big.Int).SetUint64(record.Timestamp),
+		Updater:   record.Updater,
+		Evidence:  record.Evidence,
+	}
+}
+
+// toAssetOutput converts [asset] to its ABI representation.
+func toAssetOutput(asset commontype.Asset) assetOutput {
+	return assetOutput{
+		Id:       asset.Id.Hex(),
+		Name:     asset.Name,
+		Owner:    asset.Owner,
+		Location: asset.Location,
+	}
+}
+
+// fromAssetOutput converts the ABI representation of an asset back to a commontype.Asset.
+func fromAssetOutput(output assetOutput) (commontype.Asset, error) {
+	assetId, err := ParseAssetId(output.Id)
+	if err != nil {
+		return commontype.Asset{}, err
+	}
+	return commontype.Asset{
+		Id:       assetId,
+		Name:     output.Name,
+		Owner:    output.Owner,
+		Location: output.Location,
+	}, nil
+}
+
+// ParseAssetId parses the 0x prefixed hex string [assetId] used in the ABI into an asset id.
+func ParseAssetId(assetId string) (common.Hash, error) {
+	b, err := hexutil.Decode(assetId)
+	if err != nil || len(b) != common.HashLength {
+		return common.Hash{}, fmt.Errorf("%w: %q", ErrInvalidAssetId, assetId)
+	}
+	return common.BytesToHash(b), nil
+}
+
+// unpackAssetInput unpacks [input] into the arguments of [method] of the asset precompile.
+// assumes that [input] does not include the selector.
+func unpackAssetInput(method string, input []byte) ([]interface{}, error) {
+	args, err := AssetABI.Methods[method].Inputs.Unpack(input)
+	if err != nil {
+		return nil, fmt.Errorf("invalid input for %s: %w", method, err)
+	}
+	return args, nil
+}
+
+// packAssetOutput packs [outputs] as the return values of [method] of the asset precompile.
+func packAssetOutput(method string, outputs ...interface{}) ([]byte, error) {
+	return AssetABI.Methods[method].Outputs.Pack(outputs...)
 }
 
-func (asset *AssetConfig) Timestamp() *big.Int { return asset.BlockTimestamp }
+// emitAssetEvent adds a log of the event [name] of the asset precompile with [args] given in the order the event
+// inputs are declared. Indexed arguments are converted to topics and the remaining arguments are packed as data.
+func emitAssetEvent(accessibleState PrecompileAccessibleState, name string, args ...interface{}) error {
+	event := AssetABI.Events[name]
+	topics := []common.Hash{event.ID}
+	data := make([]interface{}, 0, len(args))
+	for i, input := range event.Inputs {
+		if !input.Indexed {
+			data = append(data, args[i])
+			continue
+		}
+		topic, err := abi.MakeTopics([]interface{}{args[i]})
+		if err != nil {
+			return err
+		}
+		topics = append(topics, topic[0][0])
+	}
+	packed, err := event.Inputs.NonIndexed().Pack(data...)
+	if err != nil {
+		return err
+	}
+	accessibleState.AddLog(ContractDeployerAssetAddress, topics, packed)
+	return nil
+}
 
-func (asset *AssetConfig) Configure(_ StateDB, _ common.Address) {
+// emitApprovalCleared emits an Approval event of the zero address for [asset] if it has an approval that is about to
+// be cleared by a transfer or burn, charging [AssetEventGasCost] for the event from [suppliedGas].
+func emitApprovalCleared(accessibleState PrecompileAccessibleState, asset commontype.Asset, suppliedGas uint64) (uint64, error) {
+	if GetAssetApproval(accessibleState.GetStateDB(), asset.Id) == (common.Address{}) {
+		return suppliedGas, nil
+	}
+	remainingGas, err := deductGas(suppliedGas, AssetEventGasCost)
+	if err != nil {
+		return 0, err
+	}
+	return remainingGas, emitAssetEvent(accessibleState, "Approval", asset.Owner, common.Address{}, asset.Id.Hex())
+}
 
+// DeriveAssetId returns the id of the asset registered by [caller] when its registration nonce is [nonce].
+// The id only depends on consensus inputs so that every validator derives the same id when re-executing a block.
+func DeriveAssetId(caller common.Address, nonce uint64) common.Hash {
+	return crypto.Keccak256Hash(caller.Bytes(), indexBytes(nonce))
 }
 
-func getAll(assetDB AssetDB) []commontype.Asset {
-	return assetDB.GetAll()
+// DeriveAssetIdWithSalt returns the id of the asset registered by [caller] with [salt]. Similar to CREATE2,
+// this allows the id to be known before the registering transaction is issued.
+func DeriveAssetIdWithSalt(caller common.Address, salt common.Hash) common.Hash {
+	return crypto.Keccak256Hash([]byte{0xff}, caller.Bytes(), salt.Bytes())
 }
 
-func registerAsset(assetDB AssetDB, owner common.Address, name string) {
-	assetId := common.BytesToHash([]byte(uuid.New().String()))
-	assetDB.RegisterAsset(assetId, owner, name)
+// registerAsset registers a new asset owned by [owner] under [assetId].
+func registerAsset(stateDB StateDB, assetId common.Hash, owner common.Address, name string) error {
+	if assetExists(stateDB, assetId) {
+		return fmt.Errorf("%w: %s", ErrAssetAlreadyExists, assetId)
+	}
+	if isAssetBurned(stateDB, assetId) {
+		return fmt.Errorf("%w: %s", ErrAssetBurned, assetId)
+	}
+	storeNewAsset(stateDB, commontype.Asset{
+		Id:       assetId,
+		Name:     name,
+		Owner:    owner,
+		Location: defaultAssetLocation,
+	})
+	return nil
 }
 
-func getAsset(assetDB AssetDB, assetId common.Hash) (commontype.Asset, error) {
-	asset, err := assetDB.GetAsset(assetId)
+func getAsset(stateDB StateDB, assetId common.Hash) (commontype.Asset, error) {
+	asset, err := GetStoredAsset(stateDB, assetId)
 	if err != nil {
 		return DefaultAsset, err
 	}
 	return asset, nil
 }
 
-func getAssetByAddress(assetDB AssetDB, address common.Address) []commontype.Asset {
-	return assetDB.GetAssetByOwner(address)
+// updateLocation overwrites the location of [assetId] and appends it to the location history of the asset along
+// with the current block timestamp, [caller] and [evidence].
+func updateLocation(evm PrecompileAccessibleState, caller common.Address, assetId common.Hash, location string, evidence common.Hash) error {
+	stateDB := evm.GetStateDB()
+	if err := storeAssetLocation(stateDB, assetId, location); err != nil {
+		return err
+	}
+	appendLocationRecord(stateDB, assetId, commontype.LocationRecord{
+		Location:  location,
+		Timestamp: evm.GetBlockContext().Timestamp().Uint64(),
+		Updater:   caller,
+		Evidence:  evidence,
+	})
+	return nil
 }
 
-func updateLocation(assetDB AssetDB, assetId common.Hash, location string) {
-	assetDB.UpdateLocation(assetId, location)
+func updateName(evm PrecompileAccessibleState, _ common.Address, assetId common.Hash, name string, _ common.Hash) error {
+	return storeAssetName(evm.GetStateDB(), assetId, name)
 }
 
-func updateName(assetDB AssetDB, stateDB StateDB, precompileAddr common.Address, assetId common.Hash, name string) {
-	assetDB.UpdateName(assetId, name)
+// assetFieldStore writes [value] to a field of [assetId] on behalf of [caller]. [evidence] is the optional hash
+// of off-chain evidence given as the third input of the method, or the zero hash.
+type assetFieldStore func(evm PrecompileAccessibleState, caller common.Address, assetId common.Hash, value string, evidence common.Hash) error
+
+// nameSlots returns the number of storage slots written by updateName.
+func nameSlots(stateDB StateDB, assetId common.Hash, name string) uint64 {
+	return stringSlotsTouched(stateDB, ContractDeployerAssetAddress, storageKey(assetNamePrefix, assetId.Bytes()), name)
+}
+
+// locationSlots returns the number of storage slots written by updateLocation.
+func locationSlots(stateDB StateDB, assetId common.Hash, location string) uint64 {
+	return stringSlotsTouched(stateDB, ContractDeployerAssetAddress, storageKey(assetLocationPrefix, assetId.Bytes()), location) + locationRecordSlots(location)
 }
 
 func createAssetPrecompile(precompileAddr common.Address) StatefulPrecompiledContract {
 	// Construct the contract with no fallback function.
-	allowListFuncs := createAssetFunctions(precompileAddr)
-	contract := newStatefulPrecompileWithFunctionSelectors(nil, allowListFuncs)
+	assetFuncs := createAssetFunctions(precompileAddr)
+	contract := newStatefulPrecompileWithFunctionSelectors(nil, assetFuncs)
 	return contract
 }
 
 func createAssetFunctions(precompileAddr common.Address) []*statefulPrecompileFunction {
 	registerAsset := newStatefulPrecompileFunction(registerAssetSignature, createRegisterAsset(precompileAddr))
+	registerAssetWithSalt := newStatefulPrecompileFunction(registerAssetWithSaltSignature, createRegisterAssetWithSalt(precompileAddr))
 	getAsset := newStatefulPrecompileFunction(getAssetSignature, createGetAsset(precompileAddr))
 	getAllAssets := newStatefulPrecompileFunction(getAllAssetsSignature, createGetAllAssets(precompileAddr))
 	getAssetByAddress := newStatefulPrecompileFunction(getAssetByAddressSignature, createGetAssetByAddress(precompileAddr))
-	updateLocation := newStatefulPrecompileFunction(updateLocationSignature, createGetAsset(precompileAddr)) // TODO: needs the correct create function
-	updateName := newStatefulPrecompileFunction(updateNameSignature, createGetAsset(precompileAddr))         // TODO: needs the correct create function
-	return []*statefulPrecompileFunction{registerAsset, getAsset, getAllAssets, getAssetByAddress, updateLocation, updateName}
+	updateLocationWithEvidence := newStatefulPrecompileFunction(updateLocationWithEvidenceSignature, createAssetFieldSetter(precompileAddr, "updateLocationWithEvidence", "AssetRelocated", locationSlots, updateLocation))
+	updateLocation := newStatefulPrecompileFunction(updateLocationSignature, createAssetFieldSetter(precompileAddr, "updateLocation", "AssetRelocated", locationSlots, updateLocation))
+	updateName := newStatefulPrecompileFunction(updateNameSignature, createAssetFieldSetter(precompileAddr, "updateName", "AssetRenamed", nameSlots, updateName))
+	transferAsset := newStatefulPrecompileFunction(transferAssetSignature, transferAsset)
+	approveAsset := newStatefulPrecompileFunction(approveAssetSignature, approveAsset)
+	setApprovalForAll := newStatefulPrecompileFunction(setApprovalForAllSignature, setApprovalForAll)
+	burnAsset := newStatefulPrecompileFunction(burnAssetSignature, burnAsset)
+	getApproved := newStatefulPrecompileFunction(getApprovedSignature, getApproved)
+	isApprovedForAll := newStatefulPrecompileFunction(isApprovedForAllSignature, isApprovedForAll)
+	getLocationHistory := newStatefulPrecompileFunction(getLocationHistorySignature, getLocationHistory)
+	getLocationHistoryLength := newStatefulPrecompileFunction(getLocationHistoryLengthSignature, getLocationHistoryLength)
+	setMetadata := newStatefulPrecompileFunction(setMetadataSignature, createSetMetadata(precompileAddr))
+	getMetadata := newStatefulPrecompileFunction(getMetadataSignature, getMetadata)
+	totalAssets := newStatefulPrecompileFunction(totalAssetsSignature, totalAssets)
+	assetByIndex := newStatefulPrecompileFunction(assetByIndexSignature, assetByIndex)
+	assetsOfOwner := newStatefulPrecompileFunction(assetsOfOwnerSignature, assetsOfOwner)
+	balanceOf := newStatefulPrecompileFunction(balanceOfSignature, balanceOf)
+	allowListFuncs := createAllowListFunctions(precompileAddr)
+	return append(allowListFuncs,
+		registerAsset, registerAssetWithSalt, getAsset, getAllAssets, getAssetByAddress, updateLocation, updateName,
+		transferAsset, approveAsset, setApprovalForAll, burnAsset, getApproved, isApprovedForAll,
+		updateLocationWithEvidence, getLocationHistory, getLocationHistoryLength, setMetadata, getMetadata,
+		totalAssets, assetByIndex, assetsOfOwner, balanceOf,
+	)
 }
 
+// createRegisterAsset returns an execution function that registers a new asset owned by the caller. The id of the
+// asset is derived from the caller and its registration nonce, which is incremented afterwards, and is returned as output.
+// Only addresses enabled on the allow list at [precompileAddr] may register assets.
 func createRegisterAsset(precompileAddr common.Address) RunStatefulPrecompileFunc {
 	return func(evm PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
 		if remainingGas, err = deductGas(suppliedGas, RegisterAssetGasCost); err != nil {
 			return nil, 0, err
 		}
 
-		assetDB := evm.GetAssetDB()
+		if readOnly {
+			return nil, remainingGas, vmerrs.ErrWriteProtection
+		}
+
+		stateDB := evm.GetStateDB()
+		// Verify that the caller is in the allow list and therefore has the right to register assets
+		if !getAllowListStatus(stateDB, precompileAddr, caller, evm.GetBlockContext().Timestamp()).IsEnabled() {
+			return revertWithReason(remainingGas, fmt.Errorf("%w: %s", ErrCannotRegisterAsset, caller))
+		}
+
+		nonce := GetAssetRegistrationNonce(stateDB, caller)
+		assetId := DeriveAssetId(caller, nonce)
+		if err := registerAsset(stateDB, assetId, caller, defaultAssetName); err != nil {
+			return revertWithReason(remainingGas, err)
+		}
+		setAssetRegistrationNonce(stateDB, caller, nonce+1)
+
+		if err := emitAssetEvent(evm, "AssetRegistered", caller, assetId.Hex(), defaultAssetName); err != nil {
+			return nil, remainingGas, err
+		}
+
+		// Return the id of the new asset and the remaining gas
+		output, err := packAssetOutput("registerAsset", assetId.Hex())
+		if err != nil {
+			return nil, remainingGas, err
+		}
+		return output, remainingGas, nil
+	}
+}
+
+// createRegisterAssetWithSalt returns an execution function that registers a new asset owned by the caller under
+// the id derived from the caller and the salt given as input. Registering the same salt twice reverts.
+// Only addresses enabled on the allow list at [precompileAddr] may register assets.
+func createRegisterAssetWithSalt(precompileAddr common.Address) RunStatefulPrecompileFunc {
+	return func(evm PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+		if remainingGas, err = deductGas(suppliedGas, RegisterAssetGasCost); err != nil {
+			return nil, 0, err
+		}
+
+		args, err := unpackAssetInput("registerAssetWithSalt", input)
+		if err != nil {
+			return nil, remainingGas, err
+		}
+		salt := common.Hash(args[0].([32]byte))
+
+		if readOnly {
+			return nil, remainingGas, vmerrs.ErrWriteProtection
+		}
+
+		stateDB := evm.GetStateDB()
+		// Verify that the caller is in the allow list and therefore has the right to register assets
+		if !getAllowListStatus(stateDB, precompileAddr, caller, evm.GetBlockContext().Timestamp()).IsEnabled() {
+			return revertWithReason(remainingGas, fmt.Errorf("%w: %s", ErrCannotRegisterAsset, caller))
+		}
+
+		assetId := DeriveAssetIdWithSalt(caller, salt)
+		if err := registerAsset(stateDB, assetId, caller, defaultAssetName); err != nil {
+			return revertWithReason(remainingGas, err)
+		}
+
+		if err := emitAssetEvent(evm, "AssetRegistered", caller, assetId.Hex(), defaultAssetName); err != nil {
+			return nil, remainingGas, err
+		}
+
+		// Return the id of the new asset and the remaining gas
+		output, err := packAssetOutput("registerAssetWithSalt", assetId.Hex())
+		if err != nil {
+			return nil, remainingGas, err
+		}
+		return output, remainingGas, nil
+	}
+}
+
+// createAssetFieldSetter returns an execution function for [method] that overwrites a string field of an asset
+// using [store] and emits [event]. The input is unpacked into the asset id, the new value and an optional evidence hash.
+// Only the owner of the asset or an admin of the allow list at [precompileAddr] may modify it, and write gas is
+// charged for every storage slot returned by [slots]. Failures after the input is parsed revert with a reason.
+func createAssetFieldSetter(precompileAddr common.Address, method string, event string, slots func(StateDB, common.Hash, string) uint64, store assetFieldStore) RunStatefulPrecompileFunc {
+	return func(evm PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+		if remainingGas, err = deductGas(suppliedGas, UpdateAssetBaseGasCost); err != nil {
+			return nil, 0, err
+		}
+
+		if readOnly {
+			return nil, remainingGas, vmerrs.ErrWriteProtection
+		}
+
+		args, err := unpackAssetInput(method, input)
+		if err != nil {
+			return nil, remainingGas, err
+		}
+		assetId, err := ParseAssetId(args[0].(string))
+		if err != nil {
+			return nil, remainingGas, err
+		}
+		value := args[1].(string)
+		var evidence common.Hash
+		if len(args) > 2 {
+			evidence = args[2].([32]byte)
+		}
+
+		stateDB := evm.GetStateDB()
+		asset, err := GetStoredAsset(stateDB, assetId)
+		if err != nil {
+			return revertWithReason(remainingGas, fmt.Errorf("%w: %s", err, assetId))
+		}
+		// Verify that the caller owns the asset or is an admin and therefore has the right to modify it
+		if caller != asset.Owner && !getAllowListStatus(stateDB, precompileAddr, caller, evm.GetBlockContext().Timestamp()).IsAdmin() {
+			return revertWithReason(remainingGas, fmt.Errorf("%w: %s", ErrCannotModifyAsset, caller))
+		}
+
+		if remainingGas, err = deductGas(remainingGas, slots(stateDB, assetId, value)*AssetWriteGasCostPerSlot); err != nil {
+			return nil, 0, err
+		}
+
+		if err := store(evm, caller, assetId, value, evidence); err != nil {
+			return revertWithReason(remainingGas, err)
+		}
+
+		if err := emitAssetEvent(evm, event, caller, assetId.Hex(), value); err != nil {
+			return nil, remainingGas, err
+		}
 
-		registerAsset(assetDB, caller, "-")
 		// Return an empty output and the remaining gas
 		return []byte{}, remainingGas, nil
 	}
 }
 
-func createGetAsset(_ common.Address) RunStatefulPrecompileFunc {
+// createSetMetadata returns an execution function that sets a metadata key of an asset to a new value. The key and
+// the length of the value are checked against the metadata schema. Only the owner of the asset or an admin of the
+// allow list at [precompileAddr] may set its metadata. Failures after the input is parsed revert with a reason.
+func createSetMetadata(precompileAddr common.Address) RunStatefulPrecompileFunc {
 	return func(evm PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
-		if remainingGas, err = deductGas(suppliedGas, GetAssetGasCost); err != nil {
+		if remainingGas, err = deductGas(suppliedGas, UpdateAssetBaseGasCost); err != nil {
 			return nil, 0, err
 		}
 
-		if len(input) != getAssetByIdInputLen {
-			return nil, remainingGas, fmt.Errorf("invalid input length for getting an asset: %d", len(input))
+		if readOnly {
+			return nil, remainingGas, vmerrs.ErrWriteProtection
 		}
 
-		assetDB := evm.GetAssetDB()
-		assetId := common.BytesToHash(input)
+		args, err := unpackAssetInput("setMetadata", input)
+		if err != nil {
+			return nil, remainingGas, err
+		}
+		assetId, err := ParseAssetId(args[0].(string))
+		if err != nil {
+			return nil, remainingGas, err
+		}
+		key, value := args[1].(string), args[2].(string)
 
-		asset, err := getAsset(assetDB, assetId)
+		stateDB := evm.GetStateDB()
+		asset, err := GetStoredAsset(stateDB, assetId)
 		if err != nil {
-			log.Info("precompile: not found asset: ", assetId)
-			return nil, remainingGas, fmt.Errorf("error: %d", err)
+			return revertWithReason(remainingGas, fmt.Errorf("%w: %s", err, assetId))
+		}
+		if caller != asset.Owner && !getAllowListStatus(stateDB, precompileAddr, caller, evm.GetBlockContext().Timestamp()).IsAdmin() {
+			return revertWithReason(remainingGas, fmt.Errorf("%w: %s", ErrCannotModifyAsset, caller))
 		}
+		if err := checkMetadata(stateDB, key, value); err != nil {
+			return revertWithReason(remainingGas, err)
+		}
+
+		slots := assetMetadataSlotsTouched(stateDB, assetId, key, value)
+		if remainingGas, err = deductGas(remainingGas, slots*AssetWriteGasCostPerSlot); err != nil {
+			return nil, 0, err
+		}
+
+		if err := storeAssetMetadata(stateDB, assetId, key, value); err != nil {
+			return revertWithReason(remainingGas, err)
+		}
+
+		if err := emitAssetEvent(evm, "AssetMetadataSet", caller, assetId.Hex(), key, value); err != nil {
+			return nil, remainingGas, err
+		}
+
+		// Return an empty output and the remaining gas
+		return []byte{}, remainingGas, nil
+	}
+}
+
+// getMetadata returns the value of the metadata key given as input of the asset given as input.
+func getMetadata(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, GetAssetGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	args, err := unpackAssetInput("getMetadata", input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	assetId, err := ParseAssetId(args[0].(string))
+	if err != nil {
+		return nil, remainingGas, err
+	}
+
+	output, err := packAssetOutput("getMetadata", GetAssetMetadata(accessibleState.GetStateDB(), assetId, args[1].(string)))
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	return output, remainingGas, nil
+}
+
+// isApprovedOrOwner returns true if [spender] owns [asset], is approved to transfer it or has been approved by the
+// owner to manage all of its assets.
+func isApprovedOrOwner(stateDB StateDB, asset commontype.Asset, spender common.Address) bool {
+	return spender == asset.Owner || GetAssetApproval(stateDB, asset.Id) == spender || IsAssetOperatorApproved(stateDB, asset.Owner, spender)
+}
+
+// transferAsset transfers the asset given as input to a new owner. The caller must own the asset or be approved
+// to transfer it. Any approval of the asset is cleared by the transfer, which is reported by an Approval event of
+// the zero address.
+func transferAsset(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, TransferAssetGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	if readOnly {
+		return nil, remainingGas, vmerrs.ErrWriteProtection
+	}
+
+	args, err := unpackAssetInput("transferAsset", input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	assetId, err := ParseAssetId(args[0].(string))
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	to := args[1].(common.Address)
+
+	if to == (common.Address{}) {
+		return revertWithReason(remainingGas, ErrInvalidAssetRecipient)
+	}
+
+	stateDB := accessibleState.GetStateDB()
+	asset, err := GetStoredAsset(stateDB, assetId)
+	if err != nil {
+		return revertWithReason(remainingGas, fmt.Errorf("%w: %s", err, assetId))
+	}
+	if !isApprovedOrOwner(stateDB, asset, caller) {
+		return revertWithReason(remainingGas, fmt.Errorf("%w: %s", ErrCannotTransferAsset, caller))
+	}
+	if remainingGas, err = emitApprovalCleared(accessibleState, asset, remainingGas); err != nil {
+		return nil, remainingGas, err
+	}
+
+	if err := transferStoredAsset(stateDB, assetId, to); err != nil {
+		return revertWithReason(remainingGas, err)
+	}
+
+	if err := emitAssetEvent(accessibleState, "AssetTransferred", asset.Owner, to, assetId.Hex()); err != nil {
+		return nil, remainingGas, err
+	}
+
+	// Transfers to contracts must be accepted by the recipient. The transfer is already recorded, so the recipient
+	// observes itself as the owner and may transfer the asset onwards.
+	if stateDB.GetCodeSize(to) > 0 {
+		return callOnAssetReceived(accessibleState, caller, asset.Owner, to, assetId, remainingGas)
+	}
+
+	// Return an empty output and the remaining gas
+	return []byte{}, remainingGas, nil
+}
+
+// callOnAssetReceived calls IAssetReceiver.onAssetReceived on [to] after [assetId] is transferred to it by [operator]
+// on behalf of [from], forwarding all but one 64th of [suppliedGas] like the CALL opcode. The call must return the
+// selector of onAssetReceived, otherwise the transfer reverts with the revert reason of the recipient if it has one.
+func callOnAssetReceived(accessibleState PrecompileAccessibleState, operator common.Address, from common.Address, to common.Address, assetId common.Hash, suppliedGas uint64) (ret []byte, remainingGas uint64, err error) {
+	input, err := AssetReceiverABI.Pack("onAssetReceived", operator, from, assetId.Hex())
+	if err != nil {
+		return nil, suppliedGas, err
+	}
+	gas := callGas(suppliedGas)
+	ret, leftOverGas, err := accessibleState.Call(to, input, gas, common.Big0)
+	remainingGas = suppliedGas - gas + leftOverGas
+	if err != nil {
+		if err == vmerrs.ErrExecutionReverted && len(ret) > 0 {
+			return ret, remainingGas, err
+		}
+		return revertWithReason(remainingGas, fmt.Errorf("%w: %s", ErrAssetNotReceived, to))
+	}
+	var selector [selectorLen]byte
+	if res, err := AssetReceiverABI.Unpack("onAssetReceived", ret); err == nil {
+		selector = res[0].([selectorLen]byte)
+	}
+	if !bytes.Equal(selector[:], onAssetReceivedSignature) {
+		return revertWithReason(remainingGas, fmt.Errorf("%w: %s", ErrAssetNotReceived, to))
+	}
+	return []byte{}, remainingGas, nil
+}
+
+// approveAsset approves the operator given as input to transfer the asset given as input. The caller must own the
+// asset or be approved by the owner to manage all of its assets. Approving the zero address clears the approval.
+func approveAsset(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, ApproveAssetGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	if readOnly {
+		return nil, remainingGas, vmerrs.ErrWriteProtection
+	}
 
-		assetBytes := []byte(fmt.Sprintf("%v", asset))
+	args, err := unpackAssetInput("approve", input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	assetId, err := ParseAssetId(args[0].(string))
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	operator := args[1].(common.Address)
 
-		log.Info("precompile: found asset = ", asset)
-		log.Info("precompile: asset to bytes = ", assetBytes)
+	stateDB := accessibleState.GetStateDB()
+	asset, err := GetStoredAsset(stateDB, assetId)
+	if err != nil {
+		return revertWithReason(remainingGas, fmt.Errorf("%w: %s", err, assetId))
+	}
+	if operator == asset.Owner {
+		return revertWithReason(remainingGas, ErrApprovalToOwner)
+	}
+	if caller != asset.Owner && !IsAssetOperatorApproved(stateDB, asset.Owner, caller) {
+		return revertWithReason(remainingGas, fmt.Errorf("%w: %s", ErrCannotApproveAsset, caller))
+	}
 
-		return assetBytes, remainingGas, nil
+	setAssetApproval(stateDB, assetId, operator)
+	if err := emitAssetEvent(accessibleState, "Approval", asset.Owner, operator, assetId.Hex()); err != nil {
+		return nil, remainingGas, err
 	}
+
+	// Return an empty output and the remaining gas
+	return []byte{}, remainingGas, nil
 }
 
+// setApprovalForAll sets whether the operator given as input may manage all of the assets of the caller.
+func setApprovalForAll(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, SetApprovalForAllGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	if readOnly {
+		return nil, remainingGas, vmerrs.ErrWriteProtection
+	}
+
+	args, err := unpackAssetInput("setApprovalForAll", input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	operator := args[0].(common.Address)
+	approved := args[1].(bool)
+
+	if operator == caller {
+		return revertWithReason(remainingGas, ErrApprovalToOwner)
+	}
+
+	setAssetOperatorApproval(accessibleState.GetStateDB(), caller, operator, approved)
+	if err := emitAssetEvent(accessibleState, "ApprovalForAll", caller, operator, approved); err != nil {
+		return nil, remainingGas, err
+	}
+
+	// Return an empty output and the remaining gas
+	return []byte{}, remainingGas, nil
+}
+
+// burnAsset removes the asset given as input along with all of its fields. The caller must own the asset or be
+// approved to transfer it. Write gas is charged for every string slot cleared. Like a transfer, clearing the
+// approval of the asset is reported by an Approval event of the zero address.
+func burnAsset(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, BurnAssetBaseGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	if readOnly {
+		return nil, remainingGas, vmerrs.ErrWriteProtection
+	}
+
+	args, err := unpackAssetInput("burnAsset", input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	assetId, err := ParseAssetId(args[0].(string))
+	if err != nil {
+		return nil, remainingGas, err
+	}
+
+	stateDB := accessibleState.GetStateDB()
+	asset, err := GetStoredAsset(stateDB, assetId)
+	if err != nil {
+		return revertWithReason(remainingGas, fmt.Errorf("%w: %s", err, assetId))
+	}
+	if !isApprovedOrOwner(stateDB, asset, caller) {
+		return revertWithReason(remainingGas, fmt.Errorf("%w: %s", ErrCannotTransferAsset, caller))
+	}
+
+	slots := stringSlots(uint64(len(asset.Name))) + stringSlots(uint64(len(asset.Location))) + assetMetadataSlots(stateDB, assetId)
+	if remainingGas, err = deductGas(remainingGas, slots*AssetWriteGasCostPerSlot); err != nil {
+		return nil, 0, err
+	}
+	if remainingGas, err = emitApprovalCleared(accessibleState, asset, remainingGas); err != nil {
+		return nil, remainingGas, err
+	}
+
+	if err := burnStoredAsset(stateDB, assetId); err != nil {
+		return revertWithReason(remainingGas, err)
+	}
+
+	// Burning is reported as a transfer to the zero address as done by ERC-721.
+	if err := emitAssetEvent(accessibleState, "AssetTransferred", asset.Owner, common.Address{}, assetId.Hex()); err != nil {
+		return nil, remainingGas, err
+	}
+
+	// Return an empty output and the remaining gas
+	return []byte{}, remainingGas, nil
+}
+
+// getApproved returns the address approved to transfer the asset given as input.
+func getApproved(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, GetAssetGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	args, err := unpackAssetInput("getApproved", input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	assetId, err := ParseAssetId(args[0].(string))
+	if err != nil {
+		return nil, remainingGas, err
+	}
+
+	stateDB := accessibleState.GetStateDB()
+	if !assetExists(stateDB, assetId) {
+		return revertWithReason(remainingGas, fmt.Errorf("%w: %s", ErrAssetNotFound, assetId))
+	}
+
+	output, err := packAssetOutput("getApproved", GetAssetApproval(stateDB, assetId))
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	return output, remainingGas, nil
+}
+
+// isApprovedForAll returns whether the owner given as input has approved the operator given as input to manage
+// all of its assets.
+func isApprovedForAll(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, GetAssetGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	args, err := unpackAssetInput("isApprovedForAll", input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	owner := args[0].(common.Address)
+	operator := args[1].(common.Address)
+
+	output, err := packAssetOutput("isApprovedForAll", IsAssetOperatorApproved(accessibleState.GetStateDB(), owner, operator))
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	return output, remainingGas, nil
+}
+
+// clampToUint64 returns [value] as a uint64, saturating at math.MaxUint64.
+func clampToUint64(value *big.Int) uint64 {
+	if !value.IsUint64() {
+		return math.MaxUint64
+	}
+	return value.Uint64()
+}
+
+// getLocationHistory returns a page of the location history of the asset given as input, selected by the offset
+// and limit given as input. Read gas is charged for every storage slot of the returned records before it is read.
+func getLocationHistory(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, GetLocationHistoryBaseGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	args, err := unpackAssetInput("getLocationHistory", input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	assetId, err := ParseAssetId(args[0].(string))
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	offset, limit := clampToUint64(args[1].(*big.Int)), clampToUint64(args[2].(*big.Int))
+
+	stateDB := accessibleState.GetStateDB()
+	start, end := locationHistoryPage(stateDB, assetId, offset, limit)
+	records := make([]locationRecordOutput, 0, end-start)
+	for i := start; i < end; i++ {
+		// Charge for every slot of the record before reading it, starting with the slots that give its fixed size
+		// fields and the length of its location.
+		if remainingGas, err = deductGas(remainingGas, locationRecordFixedSlots*LocationRecordReadGasCostPerSlot); err != nil {
+			return nil, 0, err
+		}
+		if remainingGas, err = deductGas(remainingGas, locationRecordStringSlots(stateDB, assetId, i)*LocationRecordReadGasCostPerSlot); err != nil {
+			return nil, 0, err
+		}
+		records = append(records, toLocationRecordOutput(getLocationRecord(stateDB, assetId, i)))
+	}
+
+	output, err := packAssetOutput("getLocationHistory", records)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	return output, remainingGas, nil
+}
+
+// getLocationHistoryLength returns the number of records in the location history of the asset given as input.
+func getLocationHistoryLength(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, GetAssetGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	args, err := unpackAssetInput("getLocationHistoryLength", input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	assetId, err := ParseAssetId(args[0].(string))
+	if err != nil {
+		return nil, remainingGas, err
+	}
+
+	length := GetLocationHistoryLength(accessibleState.GetStateDB(), assetId)
+	output, err := packAssetOutput("getLocationHistoryLength", new(big.Int).SetUint64(length))
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	return output, remainingGas, nil
+}
+
+// createGetAsset returns an execution function that returns the asset with the id given as input, or reverts if
+// there is no such asset.
+func createGetAsset(_ common.Address) RunStatefulPrecompileFunc {
+	return func(evm PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+		if remainingGas, err = deductGas(suppliedGas, GetAssetGasCost); err != nil {
+			return nil, 0, err
+		}
+
+		args, err := unpackAssetInput("getAsset", input)
+		if err != nil {
+			return nil, remainingGas, err
+		}
+		assetId, err := ParseAssetId(args[0].(string))
+		if err != nil {
+			return nil, remainingGas, err
+		}
+
+		asset, err := getAsset(evm.GetStateDB(), assetId)
+		if err != nil {
+			return revertWithReason(remainingGas, fmt.Errorf("%w: %s", err, assetId))
+		}
+
+		output, err := packAssetOutput("getAsset", toAssetOutput(asset))
+		if err != nil {
+			return nil, remainingGas, err
+		}
+		return output, remainingGas, nil
+	}
+}
+
+// readAssetPage returns at most [limit] assets of [list] starting at [offset] in their ABI representation. Read gas
+// is charged from [suppliedGas] for every storage slot of every returned asset before the slot is read, so the cost
+// of a call scales with the number of records it returns and running out of gas stops the reads it cannot pay for.
+func readAssetPage(stateDB StateDB, list assetList, offset uint64, limit uint64, suppliedGas uint64) ([]assetOutput, uint64, error) {
+	remainingGas := suppliedGas
+	start, end := pageRange(list.length(stateDB), offset, limit)
+	outputs := make([]assetOutput, 0, end-start)
+	for i := start; i < end; i++ {
+		// Charge for every slot of the asset before reading it, starting with the slots that give the lengths of
+		// its string fields.
+		var err error
+		if remainingGas, err = deductGas(remainingGas, assetFixedReadSlots*AssetReadGasCostPerSlot); err != nil {
+			return nil, 0, err
+		}
+		assetId := list.at(stateDB, i)
+		if remainingGas, err = deductGas(remainingGas, assetStringReadSlots(stateDB, assetId)*AssetReadGasCostPerSlot); err != nil {
+			return nil, 0, err
+		}
+		asset, err := GetStoredAsset(stateDB, assetId)
+		if err != nil {
+			return nil, remainingGas, err
+		}
+		outputs = append(outputs, toAssetOutput(asset))
+	}
+	return outputs, remainingGas, nil
+}
+
+// createGetAllAssets returns an execution function that returns every registered asset.
 func createGetAllAssets(_ common.Address) RunStatefulPrecompileFunc {
 	return func(evm PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
 		if remainingGas, err = deductGas(suppliedGas, GetAssetGasCost); err != nil {
 			return nil, 0, err
 		}
 
-		assetDB := evm.GetAssetDB()
-		asset := getAll(assetDB)
+		assets, remainingGas, err := readAssetPage(evm.GetStateDB(), allAssets(), 0, math.MaxUint64, remainingGas)
+		if err != nil {
+			return nil, remainingGas, err
+		}
 
-		return []byte(fmt.Sprintf("%v", asset)), remainingGas, nil
+		output, err := packAssetOutput("getAll", assets)
+		if err != nil {
+			return nil, remainingGas, err
+		}
+		return output, remainingGas, nil
 	}
 }
 
+// createGetAssetByAddress returns an execution function that returns the assets owned by the address given as input.
 func createGetAssetByAddress(_ common.Address) RunStatefulPrecompileFunc {
 	return func(evm PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
 		if remainingGas, err = deductGas(suppliedGas, GetAssetGasCost); err != nil {
 			return nil, 0, err
 		}
 
-		if len(input) != getAssetByAddressInputLen {
-			return nil, remainingGas, fmt.Errorf("invalid input length for getting an asset by address: %d", len(input))
+		args, err := unpackAssetInput("getAssetByAddress", input)
+		if err != nil {
+			return nil, remainingGas, err
+		}
+		owner := args[0].(common.Address)
+
+		assets, remainingGas, err := readAssetPage(evm.GetStateDB(), ownerAssets(owner), 0, math.MaxUint64, remainingGas)
+		if err != nil {
+			return nil, remainingGas, err
+		}
+
+		output, err := packAssetOutput("getAssetByAddress", assets)
+		if err != nil {
+			return nil, remainingGas, err
+		}
+		return output, remainingGas, nil
+	}
+}
+
+// totalAssets returns the number of registered assets.
+func totalAssets(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, GetAssetGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	output, err := packAssetOutput("totalAssets", new(big.Int).SetUint64(GetStoredAssetCount(accessibleState.GetStateDB())))
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	return output, remainingGas, nil
+}
+
+// assetByIndex returns the asset at the index given as input of the list of every registered asset.
+func assetByIndex(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, GetAssetGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	args, err := unpackAssetInput("assetByIndex", input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	index := args[0].(*big.Int)
+
+	stateDB := accessibleState.GetStateDB()
+	if !index.IsUint64() || index.Uint64() >= GetStoredAssetCount(stateDB) {
+		return revertWithReason(remainingGas, fmt.Errorf("%w: %s", ErrAssetIndexOutOfRange, index))
+	}
+	assets, remainingGas, err := readAssetPage(stateDB, allAssets(), index.Uint64(), 1, remainingGas)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+
+	output, err := packAssetOutput("assetByIndex", assets[0])
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	return output, remainingGas, nil
+}
+
+// assetsOfOwner returns a page of the assets owned by the address given as input, selected by the offset and limit
+// given as input.
+func assetsOfOwner(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, GetAssetGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	args, err := unpackAssetInput("assetsOfOwner", input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	owner := args[0].(common.Address)
+	offset, limit := clampToUint64(args[1].(*big.Int)), clampToUint64(args[2].(*big.Int))
+
+	assets, remainingGas, err := readAssetPage(accessibleState.GetStateDB(), ownerAssets(owner), offset, limit, remainingGas)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+
+	output, err := packAssetOutput("assetsOfOwner", assets)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	return output, remainingGas, nil
+}
+
+// balanceOf returns the number of assets owned by the address given as input.
+func balanceOf(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, GetAssetGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	args, err := unpackAssetInput("balanceOf", input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	owner := args[0].(common.Address)
+
+	output, err := packAssetOutput("balanceOf", new(big.Int).SetUint64(GetStoredAssetBalance(accessibleState.GetStateDB(), owner)))
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	return output, remainingGas, nil
+}
+
+// PackRegisterAsset packs the registerAsset signature
+func PackRegisterAsset() []byte {
+	return registerAssetSignature
+}
+
+// PackRegisterAssetWithSalt packs [salt] into the input data to the registerAssetWithSalt function
+func PackRegisterAssetWithSalt(salt common.Hash) []byte {
+	input, err := AssetABI.Pack("registerAssetWithSalt", salt)
+	if err != nil {
+		panic(err)
+	}
+	return input
+}
+
+// PackGetAsset packs [assetId] into the input data to the getAsset function
+func PackGetAsset(assetId common.Hash) []byte {
+	input, err := AssetABI.Pack("getAsset", assetId.Hex())
+	if err != nil {
+		panic(err)
+	}
+	return input
+}
+
+// PackGetAllAssets packs the getAll signature
+func PackGetAllAssets() []byte {
+	return getAllAssetsSignature
+}
+
+// PackGetAssetByAddress packs [owner] into the input data to the getAssetByAddress function
+func PackGetAssetByAddress(owner common.Address) []byte {
+	input, err := AssetABI.Pack("getAssetByAddress", owner)
+	if err != nil {
+		panic(err)
+	}
+	return input
+}
+
+// UnpackAssetIdOutput unpacks the asset id returned by registerAsset and registerAssetWithSalt.
+func UnpackAssetIdOutput(output []byte) (common.Hash, error) {
+	values, err := AssetABI.Methods["registerAsset"].Outputs.Unpack(output)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return ParseAssetId(values[0].(string))
+}
+
+// UnpackAssetOutput unpacks the asset returned by getAsset.
+func UnpackAssetOutput(output []byte) (commontype.Asset, error) {
+	values, err := AssetABI.Methods["getAsset"].Outputs.Unpack(output)
+	if err != nil {
+		return commontype.Asset{}, err
+	}
+	out := *abi.ConvertType(values[0], new(assetOutput)).(*assetOutput)
+	return fromAssetOutput(out)
+}
+
+// UnpackAssetsOutput unpacks the list of assets returned by getAll and getAssetByAddress.
+func UnpackAssetsOutput(output []byte) ([]commontype.Asset, error) {
+	values, err := AssetABI.Methods["getAll"].Outputs.Unpack(output)
+	if err != nil {
+		return nil, err
+	}
+	outs := *abi.ConvertType(values[0], new([]assetOutput)).(*[]assetOutput)
+	assets := make([]commontype.Asset, 0, len(outs))
+	for _, out := range outs {
+		asset, err := fromAssetOutput(out)
+		if err != nil {
+			return nil, err
 		}
+		assets = append(assets, asset)
+	}
+	return assets, nil
+}
+
+// PackUpdateLocation packs [assetId] and [location] into the input data to the updateLocation function
+func PackUpdateLocation(assetId common.Hash, location string) []byte {
+	input, err := AssetABI.Pack("updateLocation", assetId.Hex(), location)
+	if err != nil {
+		panic(err)
+	}
+	return input
+}
+
+// PackUpdateName packs [assetId] and [name] into the input data to the updateName function
+func PackUpdateName(assetId common.Hash, name string) []byte {
+	input, err := AssetABI.Pack("updateName", assetId.Hex(), name)
+	if err != nil {
+		panic(err)
+	}
+	return input
+}
+
+// PackTransferAsset packs [assetId] and [to] into the input data to the transferAsset function
+func PackTransferAsset(assetId common.Hash, to common.Address) []byte {
+	input, err := AssetABI.Pack("transferAsset", assetId.Hex(), to)
+	if err != nil {
+		panic(err)
+	}
+	return input
+}
+
+// PackApproveAsset packs [assetId] and [operator] into the input data to the approve function
+func PackApproveAsset(assetId common.Hash, operator common.Address) []byte {
+	input, err := AssetABI.Pack("approve", assetId.Hex(), operator)
+	if err != nil {
+		panic(err)
+	}
+	return input
+}
+
+// PackSetApprovalForAll packs [operator] and [approved] into the input data to the setApprovalForAll function
+func PackSetApprovalForAll(operator common.Address, approved bool) []byte {
+	input, err := AssetABI.Pack("setApprovalForAll", operator, approved)
+	if err != nil {
+		panic(err)
+	}
+	return input
+}
+
+// PackBurnAsset packs [assetId] into the input data to the burnAsset function
+func PackBurnAsset(assetId common.Hash) []byte {
+	input, err := AssetABI.Pack("burnAsset", assetId.Hex())
+	if err != nil {
+		panic(err)
+	}
+	return input
+}
 
-		assetDB := evm.GetAssetDB()
-		owner := common.BytesToAddress(input)
-		assets := getAssetByAddress(assetDB, owner)
-		assetBytes := []byte(fmt.Sprintf("%v", assets))
+// PackUpdateLocationWithEvidence packs [assetId], [location] and [evidence] into the input data to the updateLocationWithEvidence function
+func PackUpdateLocationWithEvidence(assetId common.Hash, location string, evidence common.Hash) []byte {
+	input, err := AssetABI.Pack("updateLocationWithEvidence", assetId.Hex(), location, evidence)
+	if err != nil {
+		panic(err)
+	}
+	return input
+}
 
-		log.Info("precompile: found assets = ", assets)
-		log.Info("precompile: asset to bytes = ", assetBytes)
+// PackGetLocationHistory packs [assetId], [offset] and [limit] into the input data to the getLocationHistory function
+func PackGetLocationHistory(assetId common.Hash, offset uint64, limit uint64) []byte {
+	input, err := AssetABI.Pack("getLocationHistory", assetId.Hex(), new(big.Int).SetUint64(offset), new(big.Int).SetUint64(limit))
+	if err != nil {
+		panic(err)
+	}
+	return input
+}
 
-		return assetBytes, remainingGas, nil
+// PackGetLocationHistoryLength packs [assetId] into the input data to the getLocationHistoryLength function
+func PackGetLocationHistoryLength(assetId common.Hash) []byte {
+	input, err := AssetABI.Pack("getLocationHistoryLength", assetId.Hex())
+	if err != nil {
+		panic(err)
+	}
+	return input
+}
+
+// UnpackLocationHistoryOutput unpacks the location records returned by getLocationHistory.
+func UnpackLocationHistoryOutput(output []byte) ([]commontype.LocationRecord, error) {
+	values, err := AssetABI.Methods["getLocationHistory"].Outputs.Unpack(output)
+	if err != nil {
+		return nil, err
+	}
+	outs := *abi.ConvertType(values[0], new([]locationRecordOutput)).(*[]locationRecordOutput)
+	records := make([]commontype.LocationRecord, 0, len(outs))
+	for _, out := range outs {
+		records = append(records, commontype.LocationRecord{
+			Location:  out.Location,
+			Timestamp: out.Timestamp.Uint64(),
+			Updater:   out.Updater,
+			Evidence:  out.Evidence,
+		})
+	}
+	return records, nil
+}
+
+// PackSetMetadata packs [assetId], [key] and [value] into the input data to the setMetadata function
+func PackSetMetadata(assetId common.Hash, key string, value string) []byte {
+	input, err := AssetABI.Pack("setMetadata", assetId.Hex(), key, value)
+	if err != nil {
+		panic(err)
+	}
+	return input
+}
+
+// PackGetMetadata packs [assetId] and [key] into the input data to the getMetadata function
+func PackGetMetadata(assetId common.Hash, key string) []byte {
+	input, err := AssetABI.Pack("getMetadata", assetId.Hex(), key)
+	if err != nil {
+		panic(err)
+	}
+	return input
+}
+
+// PackTotalAssets packs the totalAssets signature
+func PackTotalAssets() []byte {
+	return totalAssetsSignature
+}
+
+// PackAssetByIndex packs [index] into the input data to the assetByIndex function
+func PackAssetByIndex(index uint64) []byte {
+	input, err := AssetABI.Pack("assetByIndex", new(big.Int).SetUint64(index))
+	if err != nil {
+		panic(err)
+	}
+	return input
+}
+
+// PackAssetsOfOwner packs [owner], [offset] and [limit] into the input data to the assetsOfOwner function
+func PackAssetsOfOwner(owner common.Address, offset uint64, limit uint64) []byte {
+	input, err := AssetABI.Pack("assetsOfOwner", owner, new(big.Int).SetUint64(offset), new(big.Int).SetUint64(limit))
+	if err != nil {
+		panic(err)
+	}
+	return input
+}
+
+// PackBalanceOf packs [owner] into the input data to the balanceOf function
+func PackBalanceOf(owner common.Address) []byte {
+	input, err := AssetABI.Pack("balanceOf", owner)
+	if err != nil {
+		panic(err)
 	}
+	return input
 }
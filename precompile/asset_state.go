@@ -0,0 +1,645 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package precompile
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ir4tech/webb-evm/commontype"
+)
+
+// Storage layout of the asset precompile. Every record is kept in the storage of the precompile
+// address so that it is journaled with the transaction, committed to the state root and served
+// by snapshots and state sync like any other contract storage.
+//
+// Fixed keys use small constant hashes (as the fee config manager does), while per asset and per
+// owner keys are derived by hashing a one byte prefix with the identifying values.
+const (
+	assetOwnerPrefix byte = iota + 1
+	assetNamePrefix
+	assetLocationPrefix
+	assetByIndexPrefix
+	ownerAssetCountPrefix
+	ownerAssetByIndexPrefix
+	registrationNoncePrefix
+	assetIndexPrefix
+	ownerAssetIndexPrefix
+	assetApprovalPrefix
+	operatorApprovalPrefix
+	locationHistoryLengthPrefix
+	locationRecordPrefix
+	metadataSchemaKeyPrefix
+	metadataSchemaMaxLengthPrefix
+	assetMetadataPrefix
+	assetBurnedPrefix
+	assetMetadataKeyCountPrefix
+	assetMetadataKeyByIndexPrefix
+	assetMetadataKeyIndexPrefix
+)
+
+// Offsets of the fixed size fields of a location record from its key. The location itself is stored as a string
+// at the key of the record, whose data lives at keccak256(key) and therefore never overlaps these slots.
+const (
+	locationRecordTimestampOffset = iota + 1
+	locationRecordUpdaterOffset
+	locationRecordEvidenceOffset
+
+	// locationRecordFixedSlots is the number of slots of a location record excluding the string data
+	locationRecordFixedSlots = 4
+)
+
+var (
+	assetCountKey          = common.Hash{'a', 'c'}
+	metadataSchemaCountKey = common.Hash{'m', 's'}
+
+	defaultAssetName     = "-"
+	defaultAssetLocation = "-"
+
+	ErrAssetNotFound        = errors.New("asset not found")
+	ErrAssetIndexOutOfRange = errors.New("asset index out of range")
+	ErrAssetAlreadyExists   = errors.New("asset already exists")
+	ErrAssetBurned          = errors.New("asset id was burned")
+)
+
+// pageRange returns the range of indices [start, end) of a list of [length] items selected by [offset] and [limit].
+// The range is empty if [offset] is past the end of the list.
+func pageRange(length uint64, offset uint64, limit uint64) (uint64, uint64) {
+	if offset >= length {
+		return length, length
+	}
+	if limit > length-offset {
+		limit = length - offset
+	}
+	return offset, offset + limit
+}
+
+// storeString stores [value] at [key] of [precompileAddr] using the same layout as solidity:
+// [key] holds the length of the string and the data is written to consecutive 32 byte slots
+// starting at keccak256(key). Slots left over from a previously stored longer string are cleared.
+func storeString(stateDB StateDB, precompileAddr common.Address, key common.Hash, value string) {
+	data := []byte(value)
+	oldLen := stateDB.GetState(precompileAddr, key).Big().Uint64()
+	stateDB.SetState(precompileAddr, key, common.BigToHash(big.NewInt(int64(len(data)))))
+
+	dataStart := crypto.Keccak256Hash(key.Bytes()).Big()
+	numSlots := stringSlots(uint64(len(data)))
+	for i := uint64(0); i < numSlots; i++ {
+		var word common.Hash
+		copy(word[:], data[i*common.HashLength:])
+		stateDB.SetState(precompileAddr, stringSlotKey(dataStart, i), word)
+	}
+	for i := numSlots; i < stringSlots(oldLen); i++ {
+		stateDB.SetState(precompileAddr, stringSlotKey(dataStart, i), common.Hash{})
+	}
+}
+
+// loadString returns the string stored at [key] of [precompileAddr] by storeString.
+func loadString(stateDB StateDB, precompileAddr common.Address, key common.Hash) string {
+	length := stateDB.GetState(precompileAddr, key).Big().Uint64()
+	if length == 0 {
+		return ""
+	}
+	dataStart := crypto.Keccak256Hash(key.Bytes()).Big()
+	data := make([]byte, 0, stringSlots(length)*common.HashLength)
+	for i := uint64(0); i < stringSlots(length); i++ {
+		data = append(data, stateDB.GetState(precompileAddr, stringSlotKey(dataStart, i)).Bytes()...)
+	}
+	return string(data[:length])
+}
+
+// stringSlotsTouched returns the number of storage slots written when replacing the string stored at [key] of
+// [precompileAddr] with [value], including the length slot and any slots cleared from the previous value.
+func stringSlotsTouched(stateDB StateDB, precompileAddr common.Address, key common.Hash, value string) uint64 {
+	oldSlots := stringSlots(stateDB.GetState(precompileAddr, key).Big().Uint64())
+	newSlots := stringSlots(uint64(len(value)))
+	if oldSlots > newSlots {
+		return 1 + oldSlots
+	}
+	return 1 + newSlots
+}
+
+// stringSlots returns the number of data slots needed to store a string of [length] bytes.
+func stringSlots(length uint64) uint64 {
+	return (length + common.HashLength - 1) / common.HashLength
+}
+
+// stringSlotKey returns the key of the [i]th data slot of a string whose data starts at [dataStart].
+func stringSlotKey(dataStart *big.Int, i uint64) common.Hash {
+	return common.BigToHash(new(big.Int).Add(dataStart, new(big.Int).SetUint64(i)))
+}
+
+// assetExists returns true if [assetId] has been registered in [stateDB].
+func assetExists(stateDB StateDB, assetId common.Hash) bool {
+	return stateDB.GetState(ContractDeployerAssetAddress, storageKey(assetOwnerPrefix, assetId.Bytes())) != (common.Hash{})
+}
+
+// GetStoredAsset returns the asset with [assetId] from the asset precompile storage in [stateDB].
+func GetStoredAsset(stateDB StateDB, assetId common.Hash) (commontype.Asset, error) {
+	ownerHash := stateDB.GetState(ContractDeployerAssetAddress, storageKey(assetOwnerPrefix, assetId.Bytes()))
+	if ownerHash == (common.Hash{}) {
+		return commontype.Asset{}, ErrAssetNotFound
+	}
+	return commontype.Asset{
+		Id:       assetId,
+		Name:     loadString(stateDB, ContractDeployerAssetAddress, storageKey(assetNamePrefix, assetId.Bytes())),
+		Owner:    common.BytesToAddress(ownerHash.Bytes()),
+		Location: loadString(stateDB, ContractDeployerAssetAddress, storageKey(assetLocationPrefix, assetId.Bytes())),
+	}, nil
+}
+
+// assetList is an enumerable set of asset ids, or of other hashes such as the metadata keys of an asset, kept in the
+// storage of the asset precompile. The ids are stored by index along with a reverse mapping from id to index, so
+// that an id can be removed with a constant number of writes by moving the last id into its place.
+type assetList struct {
+	countKey    common.Hash
+	itemPrefix  byte
+	indexPrefix byte
+	// scope is prepended to the item and index keys to separate lists sharing the same prefixes
+	scope []byte
+}
+
+// allAssets returns the list of every registered asset.
+func allAssets() assetList {
+	return assetList{countKey: assetCountKey, itemPrefix: assetByIndexPrefix, indexPrefix: assetIndexPrefix}
+}
+
+// ownerAssets returns the list of assets owned by [owner].
+func ownerAssets(owner common.Address) assetList {
+	scope := owner.Hash().Bytes()
+	return assetList{
+		countKey:    storageKey(ownerAssetCountPrefix, scope),
+		itemPrefix:  ownerAssetByIndexPrefix,
+		indexPrefix: ownerAssetIndexPrefix,
+		scope:       scope,
+	}
+}
+
+func (l assetList) itemKey(index uint64) common.Hash {
+	if l.scope == nil {
+		return storageKey(l.itemPrefix, indexBytes(index))
+	}
+	return storageKey(l.itemPrefix, l.scope, indexBytes(index))
+}
+
+func (l assetList) indexKey(assetId common.Hash) common.Hash {
+	if l.scope == nil {
+		return storageKey(l.indexPrefix, assetId.Bytes())
+	}
+	return storageKey(l.indexPrefix, l.scope, assetId.Bytes())
+}
+
+// length returns the number of ids in the list.
+func (l assetList) length(stateDB StateDB) uint64 {
+	return stateDB.GetState(ContractDeployerAssetAddress, l.countKey).Big().Uint64()
+}
+
+// at returns the id at [index] of the list. Assumes that [index] is less than the length of the list.
+func (l assetList) at(stateDB StateDB, index uint64) common.Hash {
+	return stateDB.GetState(ContractDeployerAssetAddress, l.itemKey(index))
+}
+
+// add appends [assetId] to the list. Assumes that [assetId] is not in the list.
+func (l assetList) add(stateDB StateDB, assetId common.Hash) {
+	count := l.length(stateDB)
+	stateDB.SetState(ContractDeployerAssetAddress, l.itemKey(count), assetId)
+	stateDB.SetState(ContractDeployerAssetAddress, l.indexKey(assetId), common.BigToHash(new(big.Int).SetUint64(count)))
+	stateDB.SetState(ContractDeployerAssetAddress, l.countKey, common.BigToHash(new(big.Int).SetUint64(count+1)))
+}
+
+// remove removes [assetId] from the list by moving the last id of the list into its place.
+// Assumes that [assetId] is in the list.
+func (l assetList) remove(stateDB StateDB, assetId common.Hash) {
+	count := l.length(stateDB)
+	index := stateDB.GetState(ContractDeployerAssetAddress, l.indexKey(assetId)).Big().Uint64()
+	lastIndex := count - 1
+	if index != lastIndex {
+		lastId := l.at(stateDB, lastIndex)
+		stateDB.SetState(ContractDeployerAssetAddress, l.itemKey(index), lastId)
+		stateDB.SetState(ContractDeployerAssetAddress, l.indexKey(lastId), common.BigToHash(new(big.Int).SetUint64(index)))
+	}
+	stateDB.SetState(ContractDeployerAssetAddress, l.itemKey(lastIndex), common.Hash{})
+	stateDB.SetState(ContractDeployerAssetAddress, l.indexKey(assetId), common.Hash{})
+	stateDB.SetState(ContractDeployerAssetAddress, l.countKey, common.BigToHash(new(big.Int).SetUint64(lastIndex)))
+}
+
+// removeSlots returns the number of storage slots written when removing [assetId] from the list.
+// Assumes that [assetId] is in the list.
+func (l assetList) removeSlots(stateDB StateDB, assetId common.Hash) uint64 {
+	index := stateDB.GetState(ContractDeployerAssetAddress, l.indexKey(assetId)).Big().Uint64()
+	if index != l.length(stateDB)-1 {
+		return 5
+	}
+	return 3
+}
+
+// clear removes every id from the list.
+func (l assetList) clear(stateDB StateDB) {
+	count := l.length(stateDB)
+	for i := uint64(0); i < count; i++ {
+		stateDB.SetState(ContractDeployerAssetAddress, l.indexKey(l.at(stateDB, i)), common.Hash{})
+		stateDB.SetState(ContractDeployerAssetAddress, l.itemKey(i), common.Hash{})
+	}
+	stateDB.SetState(ContractDeployerAssetAddress, l.countKey, common.Hash{})
+}
+
+// assets returns the assets referenced by the ids in the list.
+func (l assetList) assets(stateDB StateDB) []commontype.Asset {
+	count := l.length(stateDB)
+	assets := make([]commontype.Asset, 0, count)
+	for i := uint64(0); i < count; i++ {
+		asset, err := GetStoredAsset(stateDB, l.at(stateDB, i))
+		if err != nil {
+			continue
+		}
+		assets = append(assets, asset)
+	}
+	return assets
+}
+
+// GetStoredAssetCount returns the number of assets registered in [stateDB].
+func GetStoredAssetCount(stateDB StateDB) uint64 {
+	return allAssets().length(stateDB)
+}
+
+// GetStoredAssetBalance returns the number of assets owned by [owner] in [stateDB].
+func GetStoredAssetBalance(stateDB StateDB, owner common.Address) uint64 {
+	return ownerAssets(owner).length(stateDB)
+}
+
+// GetStoredAssetByIndex returns the asset at [index] of the list of every registered asset. The order of the list
+// is deterministic: assets are appended when registered, and burning an asset moves the last asset into its place.
+func GetStoredAssetByIndex(stateDB StateDB, index uint64) (commontype.Asset, error) {
+	if index >= GetStoredAssetCount(stateDB) {
+		return commontype.Asset{}, ErrAssetIndexOutOfRange
+	}
+	return GetStoredAsset(stateDB, allAssets().at(stateDB, index))
+}
+
+// GetStoredAssetsOfOwner returns at most [limit] assets owned by [owner] starting at [offset] of the list of the
+// assets of [owner], which is ordered like the list of every asset.
+func GetStoredAssetsOfOwner(stateDB StateDB, owner common.Address, offset uint64, limit uint64) []commontype.Asset {
+	list := ownerAssets(owner)
+	start, end := pageRange(list.length(stateDB), offset, limit)
+	assets := make([]commontype.Asset, 0, end-start)
+	for i := start; i < end; i++ {
+		if asset, err := GetStoredAsset(stateDB, list.at(stateDB, i)); err == nil {
+			assets = append(assets, asset)
+		}
+	}
+	return assets
+}
+
+// assetFixedReadSlots is the number of storage slots read to load an asset from a list excluding the string data:
+// its entry in the list, its owner and the lengths of both of its string fields.
+const assetFixedReadSlots = 4
+
+// assetStringReadSlots returns the number of storage slots holding the string data of [assetId], found by reading
+// only the lengths of its string fields.
+func assetStringReadSlots(stateDB StateDB, assetId common.Hash) uint64 {
+	nameLength := stateDB.GetState(ContractDeployerAssetAddress, storageKey(assetNamePrefix, assetId.Bytes())).Big().Uint64()
+	locationLength := stateDB.GetState(ContractDeployerAssetAddress, storageKey(assetLocationPrefix, assetId.Bytes())).Big().Uint64()
+	return stringSlots(nameLength) + stringSlots(locationLength)
+}
+
+// GetStoredAssets returns every asset registered in [stateDB].
+func GetStoredAssets(stateDB StateDB) []commontype.Asset {
+	return allAssets().assets(stateDB)
+}
+
+// GetStoredAssetsByOwner returns the assets owned by [owner] in [stateDB].
+func GetStoredAssetsByOwner(stateDB StateDB, owner common.Address) []commontype.Asset {
+	return ownerAssets(owner).assets(stateDB)
+}
+
+// GetAssetApproval returns the address approved to transfer [assetId] or the zero address if there is none.
+func GetAssetApproval(stateDB StateDB, assetId common.Hash) common.Address {
+	return common.BytesToAddress(stateDB.GetState(ContractDeployerAssetAddress, storageKey(assetApprovalPrefix, assetId.Bytes())).Bytes())
+}
+
+// setAssetApproval approves [operator] to transfer [assetId]. The zero address clears the approval.
+func setAssetApproval(stateDB StateDB, assetId common.Hash, operator common.Address) {
+	stateDB.SetState(ContractDeployerAssetAddress, storageKey(assetApprovalPrefix, assetId.Bytes()), operator.Hash())
+}
+
+// IsAssetOperatorApproved returns true if [owner] has approved [operator] to manage all of its assets.
+func IsAssetOperatorApproved(stateDB StateDB, owner common.Address, operator common.Address) bool {
+	return stateDB.GetState(ContractDeployerAssetAddress, storageKey(operatorApprovalPrefix, owner.Hash().Bytes(), operator.Hash().Bytes())) != (common.Hash{})
+}
+
+// setAssetOperatorApproval sets whether [operator] may manage all of the assets of [owner].
+func setAssetOperatorApproval(stateDB StateDB, owner common.Address, operator common.Address, approved bool) {
+	var value common.Hash
+	if approved {
+		value = common.BigToHash(common.Big1)
+	}
+	stateDB.SetState(ContractDeployerAssetAddress, storageKey(operatorApprovalPrefix, owner.Hash().Bytes(), operator.Hash().Bytes()), value)
+}
+
+// GetAssetRegistrationNonce returns the number of assets [caller] has registered without a salt. The next
+// unsalted registration by [caller] receives the id DeriveAssetId(caller, nonce).
+func GetAssetRegistrationNonce(stateDB StateDB, caller common.Address) uint64 {
+	return stateDB.GetState(ContractDeployerAssetAddress, storageKey(registrationNoncePrefix, caller.Hash().Bytes())).Big().Uint64()
+}
+
+// setAssetRegistrationNonce sets the registration nonce of [caller] to [nonce].
+func setAssetRegistrationNonce(stateDB StateDB, caller common.Address, nonce uint64) {
+	stateDB.SetState(ContractDeployerAssetAddress, storageKey(registrationNoncePrefix, caller.Hash().Bytes()), common.BigToHash(new(big.Int).SetUint64(nonce)))
+}
+
+// storeNewAsset writes a newly registered [asset] to [stateDB] and adds it to the global and owner indices.
+// Assumes that [asset.Id] has not been registered before.
+func storeNewAsset(stateDB StateDB, asset commontype.Asset) {
+	idBytes := asset.Id.Bytes()
+	stateDB.SetState(ContractDeployerAssetAddress, storageKey(assetOwnerPrefix, idBytes), asset.Owner.Hash())
+	storeString(stateDB, ContractDeployerAssetAddress, storageKey(assetNamePrefix, idBytes), asset.Name)
+	storeString(stateDB, ContractDeployerAssetAddress, storageKey(assetLocationPrefix, idBytes), asset.Location)
+
+	allAssets().add(stateDB, asset.Id)
+	ownerAssets(asset.Owner).add(stateDB, asset.Id)
+}
+
+// transferStoredAsset changes the owner of [assetId] to [to], moving it between the owner indices and clearing its approval.
+func transferStoredAsset(stateDB StateDB, assetId common.Hash, to common.Address) error {
+	asset, err := GetStoredAsset(stateDB, assetId)
+	if err != nil {
+		return err
+	}
+	setAssetApproval(stateDB, assetId, common.Address{})
+	ownerAssets(asset.Owner).remove(stateDB, assetId)
+	ownerAssets(to).add(stateDB, assetId)
+	stateDB.SetState(ContractDeployerAssetAddress, storageKey(assetOwnerPrefix, assetId.Bytes()), to.Hash())
+	return nil
+}
+
+// isAssetBurned returns true if [assetId] was burned, in which case it cannot be registered again.
+func isAssetBurned(stateDB StateDB, assetId common.Hash) bool {
+	return stateDB.GetState(ContractDeployerAssetAddress, storageKey(assetBurnedPrefix, assetId.Bytes())) != (common.Hash{})
+}
+
+// burnStoredAsset removes [assetId] and all of its fields from [stateDB], except for its location history. The id is
+// marked as burned, so that the history is never inherited by an asset registered under the same id.
+func burnStoredAsset(stateDB StateDB, assetId common.Hash) error {
+	asset, err := GetStoredAsset(stateDB, assetId)
+	if err != nil {
+		return err
+	}
+	setAssetApproval(stateDB, assetId, common.Address{})
+	ownerAssets(asset.Owner).remove(stateDB, assetId)
+	allAssets().remove(stateDB, assetId)
+	storeString(stateDB, ContractDeployerAssetAddress, storageKey(assetNamePrefix, assetId.Bytes()), "")
+	storeString(stateDB, ContractDeployerAssetAddress, storageKey(assetLocationPrefix, assetId.Bytes()), "")
+	clearAssetMetadata(stateDB, assetId)
+	stateDB.SetState(ContractDeployerAssetAddress, storageKey(assetOwnerPrefix, assetId.Bytes()), common.Hash{})
+	stateDB.SetState(ContractDeployerAssetAddress, storageKey(assetBurnedPrefix, assetId.Bytes()), boolToHash(true))
+	return nil
+}
+
+// storeAssetName overwrites the name of [assetId] in [stateDB].
+func storeAssetName(stateDB StateDB, assetId common.Hash, name string) error {
+	if !assetExists(stateDB, assetId) {
+		return ErrAssetNotFound
+	}
+	storeString(stateDB, ContractDeployerAssetAddress, storageKey(assetNamePrefix, assetId.Bytes()), name)
+	return nil
+}
+
+// storeAssetLocation overwrites the location of [assetId] in [stateDB].
+func storeAssetLocation(stateDB StateDB, assetId common.Hash, location string) error {
+	if !assetExists(stateDB, assetId) {
+		return ErrAssetNotFound
+	}
+	storeString(stateDB, ContractDeployerAssetAddress, storageKey(assetLocationPrefix, assetId.Bytes()), location)
+	return nil
+}
+
+// locationRecordKey returns the key of the [index]th location record of [assetId].
+func locationRecordKey(assetId common.Hash, index uint64) common.Hash {
+	return storageKey(locationRecordPrefix, assetId.Bytes(), indexBytes(index))
+}
+
+// locationRecordFieldKey returns the key of the field at [offset] of the location record stored at [recordKey].
+func locationRecordFieldKey(recordKey common.Hash, offset int64) common.Hash {
+	return common.BigToHash(new(big.Int).Add(recordKey.Big(), big.NewInt(offset)))
+}
+
+// GetLocationHistoryLength returns the number of location records of [assetId] in [stateDB].
+func GetLocationHistoryLength(stateDB StateDB, assetId common.Hash) uint64 {
+	return stateDB.GetState(ContractDeployerAssetAddress, storageKey(locationHistoryLengthPrefix, assetId.Bytes())).Big().Uint64()
+}
+
+// locationRecordStringSlots returns the number of storage slots holding the location of the [index]th location
+// record of [assetId], found by reading only the length of the location.
+func locationRecordStringSlots(stateDB StateDB, assetId common.Hash, index uint64) uint64 {
+	return stringSlots(stateDB.GetState(ContractDeployerAssetAddress, locationRecordKey(assetId, index)).Big().Uint64())
+}
+
+// getLocationRecord returns the [index]th location record of [assetId]. Assumes that [index] is less than the
+// length of the history.
+func getLocationRecord(stateDB StateDB, assetId common.Hash, index uint64) commontype.LocationRecord {
+	key := locationRecordKey(assetId, index)
+	return commontype.LocationRecord{
+		Location:  loadString(stateDB, ContractDeployerAssetAddress, key),
+		Timestamp: stateDB.GetState(ContractDeployerAssetAddress, locationRecordFieldKey(key, locationRecordTimestampOffset)).Big().Uint64(),
+		Updater:   common.BytesToAddress(stateDB.GetState(ContractDeployerAssetAddress, locationRecordFieldKey(key, locationRecordUpdaterOffset)).Bytes()),
+		Evidence:  stateDB.GetState(ContractDeployerAssetAddress, locationRecordFieldKey(key, locationRecordEvidenceOffset)),
+	}
+}
+
+// locationHistoryPage returns the range of indices [start, end) of the location history of [assetId] selected by
+// [offset] and [limit].
+func locationHistoryPage(stateDB StateDB, assetId common.Hash, offset uint64, limit uint64) (uint64, uint64) {
+	return pageRange(GetLocationHistoryLength(stateDB, assetId), offset, limit)
+}
+
+// GetStoredLocationHistory returns at most [limit] location records of [assetId] starting at [offset], oldest first.
+// The history is kept after an asset is burned so that its trail remains auditable. Since a burned id cannot be
+// registered again, the history always belongs to a single asset.
+func GetStoredLocationHistory(stateDB StateDB, assetId common.Hash, offset uint64, limit uint64) []commontype.LocationRecord {
+	start, end := locationHistoryPage(stateDB, assetId, offset, limit)
+	records := make([]commontype.LocationRecord, 0, end-start)
+	for i := start; i < end; i++ {
+		records = append(records, getLocationRecord(stateDB, assetId, i))
+	}
+	return records
+}
+
+// locationRecordSlots returns the number of storage slots written when appending a record of [location] to the
+// location history, including the length of the history.
+func locationRecordSlots(location string) uint64 {
+	return 1 + locationRecordFixedSlots + stringSlots(uint64(len(location)))
+}
+
+// appendLocationRecord appends [record] to the location history of [assetId]. Records are never modified or removed.
+func appendLocationRecord(stateDB StateDB, assetId common.Hash, record commontype.LocationRecord) {
+	index := GetLocationHistoryLength(stateDB, assetId)
+	key := locationRecordKey(assetId, index)
+	storeString(stateDB, ContractDeployerAssetAddress, key, record.Location)
+	stateDB.SetState(ContractDeployerAssetAddress, locationRecordFieldKey(key, locationRecordTimestampOffset), common.BigToHash(new(big.Int).SetUint64(record.Timestamp)))
+	stateDB.SetState(ContractDeployerAssetAddress, locationRecordFieldKey(key, locationRecordUpdaterOffset), record.Updater.Hash())
+	stateDB.SetState(ContractDeployerAssetAddress, locationRecordFieldKey(key, locationRecordEvidenceOffset), record.Evidence)
+	stateDB.SetState(ContractDeployerAssetAddress, storageKey(locationHistoryLengthPrefix, assetId.Bytes()), common.BigToHash(new(big.Int).SetUint64(index+1)))
+}
+
+// metadataSchemaMaxLengthKey returns the key holding the maximum length of the values of the metadata [key].
+func metadataSchemaMaxLengthKey(key string) common.Hash {
+	return storageKey(metadataSchemaMaxLengthPrefix, crypto.Keccak256([]byte(key)))
+}
+
+// storeMetadataSchema replaces the schema stored in [stateDB] with [schema]. The keys are stored in sorted order
+// so that they can be enumerated, along with the maximum length of the value of each key. The keys of the
+// previous schema are cleared first, so that keys it no longer declares cannot be set. Values that the new schema
+// no longer allows are kept in the metadata keys of their asset until they are cleared or the asset is burned.
+func storeMetadataSchema(stateDB StateDB, schema map[string]uint64) {
+	oldKeys := getMetadataSchemaKeys(stateDB)
+	for _, key := range oldKeys {
+		stateDB.SetState(ContractDeployerAssetAddress, metadataSchemaMaxLengthKey(key), common.Hash{})
+	}
+	for i := len(schema); i < len(oldKeys); i++ {
+		storeString(stateDB, ContractDeployerAssetAddress, storageKey(metadataSchemaKeyPrefix, indexBytes(uint64(i))), "")
+	}
+
+	keys := make([]string, 0, len(schema))
+	for key := range schema {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for i, key := range keys {
+		storeString(stateDB, ContractDeployerAssetAddress, storageKey(metadataSchemaKeyPrefix, indexBytes(uint64(i))), key)
+		stateDB.SetState(ContractDeployerAssetAddress, metadataSchemaMaxLengthKey(key), common.BigToHash(new(big.Int).SetUint64(schema[key])))
+	}
+	stateDB.SetState(ContractDeployerAssetAddress, metadataSchemaCountKey, common.BigToHash(big.NewInt(int64(len(keys)))))
+}
+
+// getMetadataSchemaKeys returns the metadata keys allowed by the schema stored in [stateDB] in sorted order.
+func getMetadataSchemaKeys(stateDB StateDB) []string {
+	count := stateDB.GetState(ContractDeployerAssetAddress, metadataSchemaCountKey).Big().Uint64()
+	keys := make([]string, 0, count)
+	for i := uint64(0); i < count; i++ {
+		keys = append(keys, loadString(stateDB, ContractDeployerAssetAddress, storageKey(metadataSchemaKeyPrefix, indexBytes(i))))
+	}
+	return keys
+}
+
+// GetMetadataSchema returns the metadata schema stored in [stateDB].
+func GetMetadataSchema(stateDB StateDB) map[string]uint64 {
+	keys := getMetadataSchemaKeys(stateDB)
+	schema := make(map[string]uint64, len(keys))
+	for _, key := range keys {
+		schema[key] = stateDB.GetState(ContractDeployerAssetAddress, metadataSchemaMaxLengthKey(key)).Big().Uint64()
+	}
+	return schema
+}
+
+// checkMetadata returns an error if the schema stored in [stateDB] does not allow [value] to be set for [key].
+// Clearing a value is always allowed, so that values the schema no longer allows can be removed.
+func checkMetadata(stateDB StateDB, key string, value string) error {
+	if value == "" {
+		return nil
+	}
+	schema := AssetMetadataSchema{}
+	if maxLength := stateDB.GetState(ContractDeployerAssetAddress, metadataSchemaMaxLengthKey(key)).Big().Uint64(); maxLength > 0 {
+		schema[key] = maxLength
+	}
+	return schema.check(key, value)
+}
+
+// metadataKeyHash returns the hash identifying the metadata [key] in storage keys and in the metadata keys of assets.
+func metadataKeyHash(key string) common.Hash {
+	return crypto.Keccak256Hash([]byte(key))
+}
+
+// assetMetadataKey returns the key holding the value of the metadata key hashed to [keyHash] of [assetId].
+func assetMetadataKey(assetId common.Hash, keyHash common.Hash) common.Hash {
+	return storageKey(assetMetadataPrefix, assetId.Bytes(), keyHash.Bytes())
+}
+
+// assetMetadataKeys returns the list of the hashes of the metadata keys set on [assetId]. It includes keys dropped
+// from the schema after their value was set, so that every value can be cleared when the asset is burned.
+func assetMetadataKeys(assetId common.Hash) assetList {
+	scope := assetId.Bytes()
+	return assetList{
+		countKey:    storageKey(assetMetadataKeyCountPrefix, scope),
+		itemPrefix:  assetMetadataKeyByIndexPrefix,
+		indexPrefix: assetMetadataKeyIndexPrefix,
+		scope:       scope,
+	}
+}
+
+// GetAssetMetadata returns the value of the metadata [key] of [assetId], or the empty string if it is not set or
+// is no longer allowed by the schema stored in [stateDB].
+func GetAssetMetadata(stateDB StateDB, assetId common.Hash, key string) string {
+	value := loadString(stateDB, ContractDeployerAssetAddress, assetMetadataKey(assetId, metadataKeyHash(key)))
+	if err := checkMetadata(stateDB, key, value); err != nil {
+		return ""
+	}
+	return value
+}
+
+// assetMetadataSlots returns the number of storage slots holding metadata of [assetId], including its list of
+// metadata keys.
+func assetMetadataSlots(stateDB StateDB, assetId common.Hash) uint64 {
+	keys := assetMetadataKeys(assetId)
+	count := keys.length(stateDB)
+	if count == 0 {
+		return 0
+	}
+	slots := uint64(1)
+	for i := uint64(0); i < count; i++ {
+		length := stateDB.GetState(ContractDeployerAssetAddress, assetMetadataKey(assetId, keys.at(stateDB, i))).Big().Uint64()
+		slots += 3 + stringSlots(length) // length, data, and the key and its index in the list of keys
+	}
+	return slots
+}
+
+// assetMetadataSlotsTouched returns the number of storage slots written when setting the metadata [key] of [assetId]
+// to [value], including adding or removing the key from the metadata keys of the asset.
+func assetMetadataSlotsTouched(stateDB StateDB, assetId common.Hash, key string, value string) uint64 {
+	keyHash := metadataKeyHash(key)
+	valueKey := assetMetadataKey(assetId, keyHash)
+	slots := stringSlotsTouched(stateDB, ContractDeployerAssetAddress, valueKey, value)
+	wasSet := stateDB.GetState(ContractDeployerAssetAddress, valueKey) != (common.Hash{})
+	switch {
+	case !wasSet && value != "":
+		slots += 3
+	case wasSet && value == "":
+		slots += assetMetadataKeys(assetId).removeSlots(stateDB, keyHash)
+	}
+	return slots
+}
+
+// clearAssetMetadata clears every metadata value of [assetId] along with its list of metadata keys.
+func clearAssetMetadata(stateDB StateDB, assetId common.Hash) {
+	keys := assetMetadataKeys(assetId)
+	for i, count := uint64(0), keys.length(stateDB); i < count; i++ {
+		storeString(stateDB, ContractDeployerAssetAddress, assetMetadataKey(assetId, keys.at(stateDB, i)), "")
+	}
+	keys.clear(stateDB)
+}
+
+// storeAssetMetadata sets the metadata [key] of [assetId] to [value] after checking it against the metadata schema,
+// keeping track of the keys set on the asset.
+func storeAssetMetadata(stateDB StateDB, assetId common.Hash, key string, value string) error {
+	if !assetExists(stateDB, assetId) {
+		return ErrAssetNotFound
+	}
+	if err := checkMetadata(stateDB, key, value); err != nil {
+		return err
+	}
+	keyHash := metadataKeyHash(key)
+	valueKey := assetMetadataKey(assetId, keyHash)
+	wasSet := stateDB.GetState(ContractDeployerAssetAddress, valueKey) != (common.Hash{})
+	storeString(stateDB, ContractDeployerAssetAddress, valueKey, value)
+	switch {
+	case !wasSet && value != "":
+		assetMetadataKeys(assetId).add(stateDB, keyHash)
+	case wasSet && value == "":
+		assetMetadataKeys(assetId).remove(stateDB, keyHash)
+	}
+	return nil
+}
@@ -20,8 +20,25 @@ type RunStatefulPrecompileFunc func(accessibleState PrecompileAccessibleState, c
 // PrecompileAccessibleState defines the interface exposed to stateful precompile contracts
 type PrecompileAccessibleState interface {
 	GetStateDB() StateDB
-	GetAssetDB() AssetDB
 	GetBlockContext() BlockContext
+	GetChainConfig() ChainConfig
+	// AddLog adds a log with [topics] and [data] emitted by [addr] to the receipt of the current transaction.
+	// The log is reverted along with the rest of the state changes if the call fails.
+	AddLog(addr common.Address, topics []common.Hash, data []byte)
+	// Origin returns the sender of the transaction the precompile is called in.
+	Origin() common.Address
+	// Value returns the value transferred to the precompile by the current call.
+	Value() *big.Int
+	// Call calls [addr] with [input], [gas] and [value] from the address the precompile is executing as, and returns
+	// the output and the gas left over by the call. Like the CALL opcode, the cost of accessing [addr] and of any value
+	// transfer is deducted from [gas]. If the precompile was called in a read only context, the call is static and
+	// transferring value fails.
+	// State changes made by the precompile before the call are visible to the callee, which may call back into the
+	// precompile. The changes made by a failed call are reverted, but the precompile must return an error to revert
+	// its own changes.
+	Call(addr common.Address, input []byte, gas uint64, value *big.Int) (ret []byte, remainingGas uint64, err error)
+	// StaticCall calls [addr] with [input] and [gas] like Call, but disallows any modifications to the state.
+	StaticCall(addr common.Address, input []byte, gas uint64) (ret []byte, remainingGas uint64, err error)
 }
 
 type BlockContext interface {
@@ -33,6 +50,9 @@ type BlockContext interface {
 type ChainConfig interface {
 	// GetFeeConfig returns the original FeeConfig that was set in the genesis.
 	GetFeeConfig() commontype.FeeConfig
+	// IsPrecompileUpgrade returns whether [blockTimestamp] is at or after the network upgrade that changes the
+	// state and gas costs of existing stateful precompiles.
+	IsPrecompileUpgrade(blockTimestamp *big.Int) bool
 }
 
 // StateDB is the interface for accessing EVM state
@@ -41,6 +61,7 @@ type StateDB interface {
 	SetState(common.Address, common.Hash, common.Hash)
 
 	SetCode(common.Address, []byte)
+	GetCodeSize(common.Address) int
 
 	SetNonce(common.Address, uint64)
 	GetNonce(common.Address) uint64
@@ -53,15 +74,6 @@ type StateDB interface {
 	Exist(common.Address) bool
 }
 
-type AssetDB interface {
-	GetAll() []commontype.Asset
-	RegisterAsset(assetId common.Hash, owner common.Address, name string)
-	GetAsset(assetId common.Hash) (commontype.Asset, error)
-	GetAssetByOwner(owner common.Address) []commontype.Asset
-	UpdateLocation(assetId common.Hash, location string) error
-	UpdateName(assetId common.Hash, name string) error
-}
-
 // StatefulPrecompiledContract is the interface for executing a precompiled contract
 type StatefulPrecompiledContract interface {
 	// Run executes the precompiled contract.
@@ -85,6 +97,22 @@ func newStatefulPrecompileFunction(selector []byte, execute RunStatefulPrecompil
 	}
 }
 
+// newUpgradeStatefulPrecompileFunction creates a stateful precompile function that only exists once the PrecompileUpgrade
+// is active. Before the upgrade, calling it fails in the same way as calling an unknown function.
+func newUpgradeStatefulPrecompileFunction(selector []byte, execute RunStatefulPrecompileFunc) *statefulPrecompileFunction {
+	return newStatefulPrecompileFunction(selector, func(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+		if !isPrecompileUpgrade(accessibleState) {
+			return nil, suppliedGas, fmt.Errorf("invalid function selector %#x", selector)
+		}
+		return execute(accessibleState, caller, addr, input, suppliedGas, readOnly)
+	})
+}
+
+// isPrecompileUpgrade returns whether the PrecompileUpgrade is active in the block that [accessibleState] executes in.
+func isPrecompileUpgrade(accessibleState PrecompileAccessibleState) bool {
+	return accessibleState.GetChainConfig().IsPrecompileUpgrade(accessibleState.GetBlockContext().Timestamp())
+}
+
 // statefulPrecompileWithFunctionSelectors implements StatefulPrecompiledContract by using 4 byte function selectors to pass
 // off responsibilities to internal execution functions.
 // Note: because we only ever read from [functions] there no lock is required to make it thread-safe.
@@ -0,0 +1,409 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package precompile
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ir4tech/webb-evm/accounts/abi"
+	"github.com/ir4tech/webb-evm/vmerrs"
+)
+
+// Storage layout of the contract call allow list. The restriction of every function and the callers allowed to
+// call it are kept in the storage of the precompile address, keyed by hashing a one byte prefix with the generation
+// of the restrictions, the address of the contract, the selector of the function and the caller, like the records of
+// the asset precompile. Every reconfiguration of the precompile starts a new generation, which drops the restrictions
+// and allowed callers of the previous generations without having to enumerate them.
+const (
+	contractCallRestrictedPrefix byte = iota + 1
+	contractCallCallerPrefix
+)
+
+var contractCallGenerationKey = common.Hash{'c', 'g'}
+
+// ContractCallAllowListRawABI is the solidity ABI of the contract call allow list as declared in
+// contract-examples/contracts/IContractCallAllowList.sol.
+//
+//go:embed contract_call_allow_list.abi
+var ContractCallAllowListRawABI string
+
+var (
+	_ StatefulPrecompileConfig = &ContractCallAllowListConfig{}
+	// Singleton StatefulPrecompiledContract for restricting calls to functions of contracts to allowed callers.
+	ContractCallAllowListPrecompile StatefulPrecompiledContract = createContractCallAllowListPrecompile(ContractCallAllowListAddress)
+
+	// ContractCallAllowListABI is the parsed ABI of the contract call allow list used to unpack inputs and pack outputs.
+	ContractCallAllowListABI = mustParseABI(ContractCallAllowListRawABI)
+
+	restrictFunctionSignature      = ContractCallAllowListABI.Methods["restrictFunction"].ID
+	unrestrictFunctionSignature    = ContractCallAllowListABI.Methods["unrestrictFunction"].ID
+	setFunctionCallerSignature     = ContractCallAllowListABI.Methods["setFunctionCaller"].ID
+	isFunctionRestrictedSignature  = ContractCallAllowListABI.Methods["isFunctionRestricted"].ID
+	isFunctionCallAllowedSignature = ContractCallAllowListABI.Methods["isFunctionCallAllowed"].ID
+
+	ErrCannotRestrictFunction         = errors.New("non-admin cannot restrict function")
+	ErrCannotSetFunctionCaller        = errors.New("non-admin nor manager cannot set function caller")
+	ErrContractCallNotAllowed         = errors.New("caller is not allowed to call function")
+	ErrInvalidContractCallRestriction = errors.New("invalid contract call restriction")
+)
+
+// ContractCallAllowListConfigKey is the key of the config in the chain config and network upgrades.
+const ContractCallAllowListConfigKey = "contractCallAllowListConfig"
+
+func init() {
+	if err := RegisterModule(Module{
+		ConfigKey: ContractCallAllowListConfigKey,
+		Address:   ContractCallAllowListAddress,
+		NewConfig: func() StatefulPrecompileConfig { return new(ContractCallAllowListConfig) },
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// ContractCallAllowListConfig wraps [AllowListConfig] and uses it to implement the StatefulPrecompileConfig
+// interface while adding in the contract call allow list precompile address.
+// Admins of the allow list may restrict functions, while admins and managers may allow and disallow callers.
+type ContractCallAllowListConfig struct {
+	AllowListConfig
+	// Restrictions are the functions restricted when the precompile activates. A reconfiguration replaces every
+	// restriction and allowed caller, including those set through the precompile, with its own restrictions.
+	Restrictions []ContractCallRestriction `json:"restrictions,omitempty"`
+}
+
+// ContractCallRestriction restricts calls to the function of [Contract] with [Selector] to [Callers].
+type ContractCallRestriction struct {
+	Contract common.Address   `json:"contract"`
+	Selector hexutil.Bytes    `json:"selector"`
+	Callers  []common.Address `json:"callers,omitempty"`
+}
+
+// Address returns the address of the contract call allow list.
+func (c *ContractCallAllowListConfig) Address() common.Address {
+	return ContractCallAllowListAddress
+}
+
+// Verify returns an error if any restriction of [c] has a selector that is not 4 bytes long or restricts the same
+// function as another restriction.
+func (c *ContractCallAllowListConfig) Verify() error {
+	functions := make(map[common.Hash]struct{}, len(c.Restrictions))
+	for _, restriction := range c.Restrictions {
+		if len(restriction.Selector) != selectorLen {
+			return fmt.Errorf("%w: selector %s of %s is not %d bytes", ErrInvalidContractCallRestriction, restriction.Selector, restriction.Contract, selectorLen)
+		}
+		key := contractCallRestrictedKey(common.Hash{}, restriction.Contract, restriction.Selector)
+		if _, ok := functions[key]; ok {
+			return fmt.Errorf("%w: duplicate selector %s of %s", ErrInvalidContractCallRestriction, restriction.Selector, restriction.Contract)
+		}
+		functions[key] = struct{}{}
+	}
+	return c.AllowListConfig.Verify()
+}
+
+// Configure configures [state] with the desired admins and restrictions based on [c]. A reconfiguration starts a
+// new generation of restrictions, clearing the existing restrictions before applying those of [c].
+func (c *ContractCallAllowListConfig) Configure(chainConfig ChainConfig, state StateDB, blockContext BlockContext) {
+	c.AllowListConfig.Configure(chainConfig, state, blockContext, ContractCallAllowListAddress)
+	if isConfigured(state, ContractCallAllowListAddress) {
+		generation := new(big.Int).Add(getContractCallGeneration(state).Big(), common.Big1)
+		state.SetState(ContractCallAllowListAddress, contractCallGenerationKey, common.BigToHash(generation))
+	}
+	for _, restriction := range c.Restrictions {
+		setFunctionRestricted(state, restriction.Contract, restriction.Selector, true)
+		for _, caller := range restriction.Callers {
+			setFunctionCallerAllowed(state, restriction.Contract, restriction.Selector, caller, true)
+		}
+	}
+}
+
+// Disable clears the roles, restrictions and allowed callers of the contract call allow list from [state].
+func (c *ContractCallAllowListConfig) Disable(_ ChainConfig, state StateDB, _ BlockContext) {
+	clearPrecompileState(state, ContractCallAllowListAddress)
+}
+
+// Contract returns the singleton stateful precompiled contract to be used for the contract call allow list.
+func (c *ContractCallAllowListConfig) Contract() StatefulPrecompiledContract {
+	return ContractCallAllowListPrecompile
+}
+
+// GetContractCallAllowListStatus returns the role of [address] for the contract call allow list.
+// [address] has no role if its role has expired by the block [timestamp].
+func GetContractCallAllowListStatus(stateDB StateDB, address common.Address, timestamp *big.Int) AllowListRole {
+	return getAllowListStatus(stateDB, ContractCallAllowListAddress, address, timestamp)
+}
+
+// SetContractCallAllowListStatus sets the permissions of [address] to [role] for the
+// contract call allow list. assumes [role] has already been verified as valid.
+func SetContractCallAllowListStatus(stateDB StateDB, address common.Address, role AllowListRole) {
+	setAllowListRole(stateDB, ContractCallAllowListAddress, address, role)
+}
+
+// IsFunctionRestricted returns true if calls to the function of [contract] with [selector] are restricted in [stateDB].
+func IsFunctionRestricted(stateDB StateDB, contract common.Address, selector []byte) bool {
+	generation := getContractCallGeneration(stateDB)
+	return stateDB.GetState(ContractCallAllowListAddress, contractCallRestrictedKey(generation, contract, selector)) != (common.Hash{})
+}
+
+// IsFunctionCallAllowed returns true if [caller] may call the function of [contract] with [selector] in [stateDB],
+// which is the case if the function is not restricted or [caller] is allowed to call it.
+func IsFunctionCallAllowed(stateDB StateDB, contract common.Address, selector []byte, caller common.Address) bool {
+	if !IsFunctionRestricted(stateDB, contract, selector) {
+		return true
+	}
+	generation := getContractCallGeneration(stateDB)
+	return stateDB.GetState(ContractCallAllowListAddress, contractCallCallerKey(generation, contract, selector, caller)) != (common.Hash{})
+}
+
+// CheckContractCall returns an error if [caller] is not allowed to call [contract] with [input] in [stateDB].
+// Calls with less than a selector of input are never restricted.
+// The storage keys of the contract call allow list read by the check are returned, so that the EVM can charge for
+// reading them.
+func CheckContractCall(stateDB StateDB, caller common.Address, contract common.Address, input []byte) ([]common.Hash, error) {
+	if len(input) < selectorLen {
+		return nil, nil
+	}
+	selector := input[:selectorLen]
+	generation := getContractCallGeneration(stateDB)
+	restrictedKey := contractCallRestrictedKey(generation, contract, selector)
+	keys := []common.Hash{contractCallGenerationKey, restrictedKey}
+	if stateDB.GetState(ContractCallAllowListAddress, restrictedKey) == (common.Hash{}) {
+		return keys, nil
+	}
+	callerKey := contractCallCallerKey(generation, contract, selector, caller)
+	keys = append(keys, callerKey)
+	if stateDB.GetState(ContractCallAllowListAddress, callerKey) == (common.Hash{}) {
+		return keys, fmt.Errorf("%w: %s cannot call %s of %s", ErrContractCallNotAllowed, caller, hexutil.Bytes(selector), contract)
+	}
+	return keys, nil
+}
+
+// getContractCallGeneration returns the generation of the restrictions stored in [stateDB].
+func getContractCallGeneration(stateDB StateDB) common.Hash {
+	return stateDB.GetState(ContractCallAllowListAddress, contractCallGenerationKey)
+}
+
+// contractCallRestrictedKey returns the storage key of the restriction of the function of [contract] with [selector]
+// in [generation].
+func contractCallRestrictedKey(generation common.Hash, contract common.Address, selector []byte) common.Hash {
+	return storageKey(contractCallRestrictedPrefix, generation.Bytes(), contract.Bytes(), selector)
+}
+
+// contractCallCallerKey returns the storage key of whether [caller] may call the function of [contract] with [selector]
+// in [generation].
+func contractCallCallerKey(generation common.Hash, contract common.Address, selector []byte, caller common.Address) common.Hash {
+	return storageKey(contractCallCallerPrefix, generation.Bytes(), contract.Bytes(), selector, caller.Bytes())
+}
+
+func setFunctionRestricted(stateDB StateDB, contract common.Address, selector []byte, restricted bool) {
+	generation := getContractCallGeneration(stateDB)
+	stateDB.SetState(ContractCallAllowListAddress, contractCallRestrictedKey(generation, contract, selector), boolToHash(restricted))
+}
+
+func setFunctionCallerAllowed(stateDB StateDB, contract common.Address, selector []byte, caller common.Address, allowed bool) {
+	generation := getContractCallGeneration(stateDB)
+	stateDB.SetState(ContractCallAllowListAddress, contractCallCallerKey(generation, contract, selector, caller), boolToHash(allowed))
+}
+
+// boolToHash returns the storage representation of [b], which is the empty hash for false so that the slot is
+// deleted from the state.
+func boolToHash(b bool) common.Hash {
+	if b {
+		return common.BigToHash(common.Big1)
+	}
+	return common.Hash{}
+}
+
+// unpackContractCallInput unpacks [input] as the arguments to [method] of the contract call allow list.
+func unpackContractCallInput(method string, input []byte) ([]interface{}, error) {
+	args, err := ContractCallAllowListABI.Methods[method].Inputs.Unpack(input)
+	if err != nil {
+		return nil, fmt.Errorf("invalid input for %s: %w", method, err)
+	}
+	return args, nil
+}
+
+// emitContractCallEvent adds a log of [event] with the indexed [topics] and the non indexed [flag].
+func emitContractCallEvent(accessibleState PrecompileAccessibleState, event string, flag bool, topics ...interface{}) error {
+	abiEvent := ContractCallAllowListABI.Events[event]
+	query := make([][]interface{}, 0, len(topics))
+	for _, topic := range topics {
+		query = append(query, []interface{}{topic})
+	}
+	hashes, err := abi.MakeTopics(query...)
+	if err != nil {
+		return err
+	}
+	data, err := abiEvent.Inputs.NonIndexed().Pack(flag)
+	if err != nil {
+		return err
+	}
+	logTopics := []common.Hash{abiEvent.ID}
+	for _, hash := range hashes {
+		logTopics = append(logTopics, hash[0])
+	}
+	accessibleState.AddLog(ContractCallAllowListAddress, logTopics, data)
+	return nil
+}
+
+// createSetFunctionRestricted returns an execution function that restricts the function given as input if
+// [restricted] is true and lifts its restriction otherwise. The caller must be an admin of the allow list of
+// [precompileAddr].
+func createSetFunctionRestricted(precompileAddr common.Address, method string, restricted bool) RunStatefulPrecompileFunc {
+	return func(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+		if remainingGas, err = deductGas(suppliedGas, RestrictFunctionGasCost); err != nil {
+			return nil, 0, err
+		}
+
+		if readOnly {
+			return nil, remainingGas, vmerrs.ErrWriteProtection
+		}
+
+		args, err := unpackContractCallInput(method, input)
+		if err != nil {
+			return nil, remainingGas, err
+		}
+		target := args[0].(common.Address)
+		selector := args[1].([selectorLen]byte)
+
+		stateDB := accessibleState.GetStateDB()
+		// Verify that the caller is an admin of the allow list and therefore has the right to restrict functions
+		if !getAllowListStatus(stateDB, precompileAddr, caller, accessibleState.GetBlockContext().Timestamp()).IsAdmin() {
+			return revertWithReason(remainingGas, fmt.Errorf("%w: %s", ErrCannotRestrictFunction, caller))
+		}
+
+		setFunctionRestricted(stateDB, target, selector[:], restricted)
+		if err := emitContractCallEvent(accessibleState, "FunctionRestrictionSet", restricted, target, selector); err != nil {
+			return nil, remainingGas, err
+		}
+
+		// Return an empty output and the remaining gas
+		return []byte{}, remainingGas, nil
+	}
+}
+
+// createSetFunctionCaller returns an execution function that allows or disallows the caller given as input to call
+// the function given as input. The caller must be an admin or a manager of the allow list of [precompileAddr].
+func createSetFunctionCaller(precompileAddr common.Address) RunStatefulPrecompileFunc {
+	return func(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+		if remainingGas, err = deductGas(suppliedGas, SetFunctionCallerGasCost); err != nil {
+			return nil, 0, err
+		}
+
+		if readOnly {
+			return nil, remainingGas, vmerrs.ErrWriteProtection
+		}
+
+		args, err := unpackContractCallInput("setFunctionCaller", input)
+		if err != nil {
+			return nil, remainingGas, err
+		}
+		target := args[0].(common.Address)
+		selector := args[1].([selectorLen]byte)
+		functionCaller := args[2].(common.Address)
+		allowed := args[3].(bool)
+
+		stateDB := accessibleState.GetStateDB()
+		// Verify that the caller is an admin or a manager of the allow list and therefore has the right to set callers
+		if role := getAllowListStatus(stateDB, precompileAddr, caller, accessibleState.GetBlockContext().Timestamp()); !role.IsAdmin() && !role.IsManager() {
+			return revertWithReason(remainingGas, fmt.Errorf("%w: %s", ErrCannotSetFunctionCaller, caller))
+		}
+
+		setFunctionCallerAllowed(stateDB, target, selector[:], functionCaller, allowed)
+		if err := emitContractCallEvent(accessibleState, "FunctionCallerSet", allowed, target, selector, functionCaller); err != nil {
+			return nil, remainingGas, err
+		}
+
+		// Return an empty output and the remaining gas
+		return []byte{}, remainingGas, nil
+	}
+}
+
+// isFunctionRestricted returns whether the function given as input is restricted.
+func isFunctionRestricted(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, IsFunctionRestrictedGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	args, err := unpackContractCallInput("isFunctionRestricted", input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	target := args[0].(common.Address)
+	selector := args[1].([selectorLen]byte)
+
+	return boolToHash(IsFunctionRestricted(accessibleState.GetStateDB(), target, selector[:])).Bytes(), remainingGas, nil
+}
+
+// isFunctionCallAllowed returns whether the caller given as input may call the function given as input.
+func isFunctionCallAllowed(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, IsFunctionCallAllowedGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	args, err := unpackContractCallInput("isFunctionCallAllowed", input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	target := args[0].(common.Address)
+	selector := args[1].([selectorLen]byte)
+	functionCaller := args[2].(common.Address)
+
+	return boolToHash(IsFunctionCallAllowed(accessibleState.GetStateDB(), target, selector[:], functionCaller)).Bytes(), remainingGas, nil
+}
+
+// PackRestrictFunction packs [target] and [selector] into the input data to the restrictFunction function
+func PackRestrictFunction(target common.Address, selector [selectorLen]byte) []byte {
+	return mustPackContractCallInput("restrictFunction", target, selector)
+}
+
+// PackUnrestrictFunction packs [target] and [selector] into the input data to the unrestrictFunction function
+func PackUnrestrictFunction(target common.Address, selector [selectorLen]byte) []byte {
+	return mustPackContractCallInput("unrestrictFunction", target, selector)
+}
+
+// PackSetFunctionCaller packs [target], [selector], [caller] and [allowed] into the input data to the
+// setFunctionCaller function
+func PackSetFunctionCaller(target common.Address, selector [selectorLen]byte, caller common.Address, allowed bool) []byte {
+	return mustPackContractCallInput("setFunctionCaller", target, selector, caller, allowed)
+}
+
+// PackIsFunctionRestricted packs [target] and [selector] into the input data to the isFunctionRestricted function
+func PackIsFunctionRestricted(target common.Address, selector [selectorLen]byte) []byte {
+	return mustPackContractCallInput("isFunctionRestricted", target, selector)
+}
+
+// PackIsFunctionCallAllowed packs [target], [selector] and [caller] into the input data to the
+// isFunctionCallAllowed function
+func PackIsFunctionCallAllowed(target common.Address, selector [selectorLen]byte, caller common.Address) []byte {
+	return mustPackContractCallInput("isFunctionCallAllowed", target, selector, caller)
+}
+
+// mustPackContractCallInput packs [args] into the input data to [method], which cannot fail for the statically
+// typed arguments of the Pack functions.
+func mustPackContractCallInput(method string, args ...interface{}) []byte {
+	input, err := ContractCallAllowListABI.Pack(method, args...)
+	if err != nil {
+		panic(err)
+	}
+	return input
+}
+
+// createContractCallAllowListPrecompile returns a StatefulPrecompiledContract with R/W control of an allow list at
+// [precompileAddr] and the restrictions of calls to functions of contracts.
+func createContractCallAllowListPrecompile(precompileAddr common.Address) StatefulPrecompiledContract {
+	functions := createAllowListFunctions(precompileAddr)
+	functions = append(functions,
+		newStatefulPrecompileFunction(restrictFunctionSignature, createSetFunctionRestricted(precompileAddr, "restrictFunction", true)),
+		newStatefulPrecompileFunction(unrestrictFunctionSignature, createSetFunctionRestricted(precompileAddr, "unrestrictFunction", false)),
+		newStatefulPrecompileFunction(setFunctionCallerSignature, createSetFunctionCaller(precompileAddr)),
+		newStatefulPrecompileFunction(isFunctionRestrictedSignature, isFunctionRestricted),
+		newStatefulPrecompileFunction(isFunctionCallAllowedSignature, isFunctionCallAllowed),
+	)
+	// Construct the contract with no fallback function.
+	return newStatefulPrecompileWithFunctionSelectors(nil, functions)
+}
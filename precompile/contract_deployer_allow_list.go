@@ -3,7 +3,11 @@
 
 package precompile
 
-import "github.com/ethereum/go-ethereum/common"
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
 
 var (
 	_ StatefulPrecompileConfig = &ContractDeployerAllowListConfig{}
@@ -11,6 +15,19 @@ var (
 	ContractDeployerAllowListPrecompile StatefulPrecompiledContract = createAllowListPrecompile(ContractDeployerAllowListAddress)
 )
 
+// ContractDeployerAllowListConfigKey is the key of the config in the chain config and network upgrades.
+const ContractDeployerAllowListConfigKey = "contractDeployerAllowListConfig"
+
+func init() {
+	if err := RegisterModule(Module{
+		ConfigKey: ContractDeployerAllowListConfigKey,
+		Address:   ContractDeployerAllowListAddress,
+		NewConfig: func() StatefulPrecompileConfig { return new(ContractDeployerAllowListConfig) },
+	}); err != nil {
+		panic(err)
+	}
+}
+
 // ContractDeployerAllowListConfig wraps [AllowListConfig] and uses it to implement the StatefulPrecompileConfig
 // interface while adding in the contract deployer specific precompile address.
 type ContractDeployerAllowListConfig struct {
@@ -23,8 +40,13 @@ func (c *ContractDeployerAllowListConfig) Address() common.Address {
 }
 
 // Configure configures [state] with the desired admins based on [c].
-func (c *ContractDeployerAllowListConfig) Configure(_ ChainConfig, state StateDB, _ BlockContext) {
-	c.AllowListConfig.Configure(state, ContractDeployerAllowListAddress)
+func (c *ContractDeployerAllowListConfig) Configure(chainConfig ChainConfig, state StateDB, blockContext BlockContext) {
+	c.AllowListConfig.Configure(chainConfig, state, blockContext, ContractDeployerAllowListAddress)
+}
+
+// Disable clears the roles and any other state of the contract deployer allow list from [state].
+func (c *ContractDeployerAllowListConfig) Disable(_ ChainConfig, state StateDB, _ BlockContext) {
+	clearPrecompileState(state, ContractDeployerAllowListAddress)
 }
 
 // Contract returns the singleton stateful precompiled contract to be used for the allow list.
@@ -34,8 +56,9 @@ func (c *ContractDeployerAllowListConfig) Contract() StatefulPrecompiledContract
 
 // GetContractDeployerAllowListStatus returns the role of [address] for the contract deployer
 // allow list.
-func GetContractDeployerAllowListStatus(stateDB StateDB, address common.Address) AllowListRole {
-	return getAllowListStatus(stateDB, ContractDeployerAllowListAddress, address)
+// [address] has no role if its role has expired by the block [timestamp].
+func GetContractDeployerAllowListStatus(stateDB StateDB, address common.Address, timestamp *big.Int) AllowListRole {
+	return getAllowListStatus(stateDB, ContractDeployerAllowListAddress, address, timestamp)
 }
 
 // SetContractDeployerAllowListStatus sets the permissions of [address] to [role] for the
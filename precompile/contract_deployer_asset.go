@@ -1,24 +1,76 @@
 package precompile
 
-import "github.com/ethereum/go-ethereum/common"
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
 
 var (
 	_                               StatefulPrecompileConfig = &ContractDeployerAssetConfig{}
 	ContractDeployerAssetPrecompile                          = createAssetPrecompile(ContractDeployerAssetAddress)
 )
 
+// ContractDeployerAssetConfigKey is the key of the config in the chain config and network upgrades.
+const ContractDeployerAssetConfigKey = "contractDeployerAssetConfig"
+
+func init() {
+	if err := RegisterModule(Module{
+		ConfigKey: ContractDeployerAssetConfigKey,
+		Address:   ContractDeployerAssetAddress,
+		NewConfig: func() StatefulPrecompileConfig { return new(ContractDeployerAssetConfig) },
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// ContractDeployerAssetConfig wraps [AllowListConfig] and [AssetConfig] and uses them to implement the
+// StatefulPrecompileConfig interface while adding in the asset precompile address.
+// Only enabled addresses of the allow list may register assets, and admins may update any asset.
 type ContractDeployerAssetConfig struct {
+	AllowListConfig // Config for the asset allow list
 	AssetConfig
 }
 
+// Address returns the address of the asset precompile.
 func (c *ContractDeployerAssetConfig) Address() common.Address {
 	return ContractDeployerAssetAddress
 }
 
-func (c *ContractDeployerAssetConfig) Configure(_ ChainConfig, state StateDB, _ BlockContext) {
-	c.AssetConfig.Configure(state, ContractDeployerAssetAddress)
+// Timestamp returns the timestamp at which the asset precompile should be enabled
+func (c *ContractDeployerAssetConfig) Timestamp() *big.Int {
+	return c.AllowListConfig.Timestamp()
+}
+
+// Verify checks that the initial assets and metadata schema of [c] are valid.
+func (c *ContractDeployerAssetConfig) Verify() error {
+	return c.AssetConfig.Verify()
 }
 
+// Configure configures [state] with the desired admins, initial assets and metadata schema based on [c].
+func (c *ContractDeployerAssetConfig) Configure(chainConfig ChainConfig, state StateDB, blockContext BlockContext) {
+	c.AllowListConfig.Configure(chainConfig, state, blockContext, ContractDeployerAssetAddress)
+	c.AssetConfig.Configure(state, blockContext, ContractDeployerAssetAddress)
+}
+
+// Disable clears the roles and any other state of the asset precompile from [state].
+func (c *ContractDeployerAssetConfig) Disable(_ ChainConfig, state StateDB, _ BlockContext) {
+	clearPrecompileState(state, ContractDeployerAssetAddress)
+}
+
+// Contract returns the singleton stateful precompiled contract to be used for the asset precompile.
 func (c *ContractDeployerAssetConfig) Contract() StatefulPrecompiledContract {
 	return ContractDeployerAssetPrecompile
 }
+
+// GetContractDeployerAssetStatus returns the role of [address] for the asset precompile allow list.
+// [address] has no role if its role has expired by the block [timestamp].
+func GetContractDeployerAssetStatus(stateDB StateDB, address common.Address, timestamp *big.Int) AllowListRole {
+	return getAllowListStatus(stateDB, ContractDeployerAssetAddress, address, timestamp)
+}
+
+// SetContractDeployerAssetStatus sets the permissions of [address] to [role] for the
+// asset precompile allow list. assumes [role] has already been verified as valid.
+func SetContractDeployerAssetStatus(stateDB StateDB, address common.Address, role AllowListRole) {
+	setAllowListRole(stateDB, ContractDeployerAssetAddress, address, role)
+}
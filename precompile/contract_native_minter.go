@@ -9,6 +9,7 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ir4tech/webb-evm/vmerrs"
 )
 
@@ -17,6 +18,7 @@ const (
 	mintInputAmountSlot
 
 	mintInputLen = common.HashLength + common.HashLength
+	burnInputLen = common.HashLength
 )
 
 var (
@@ -24,14 +26,51 @@ var (
 	// Singleton StatefulPrecompiledContract for minting native assets by permissioned callers.
 	ContractNativeMinterPrecompile StatefulPrecompiledContract = createNativeMinterPrecompile(ContractNativeMinterAddress)
 
-	mintSignature = CalculateFunctionSelector("mintNativeCoin(address,uint256)") // address, amount
-	ErrCannotMint = errors.New("non-enabled cannot mint")
+	mintSignature                  = CalculateFunctionSelector("mintNativeCoin(address,uint256)") // address, amount
+	burnSignature                  = CalculateFunctionSelector("burnNativeCoin(uint256)")         // amount
+	totalNativeCoinMintedSignature = CalculateFunctionSelector("totalNativeCoinMinted()")
+	totalNativeCoinBurnedSignature = CalculateFunctionSelector("totalNativeCoinBurned()")
+	maxNativeCoinSupplySignature   = CalculateFunctionSelector("maxNativeCoinSupply()")
+
+	// Storage keys of the native coin supply counters and the max supply, which use small constant hashes that
+	// cannot collide with the roles of the allow list, which are keyed by address.
+	nativeCoinMintedKey    = common.Hash{'n', 'm'}
+	nativeCoinBurnedKey    = common.Hash{'n', 'b'}
+	nativeCoinMaxSupplyKey = common.Hash{'n', 's'}
+
+	ErrCannotMint              = errors.New("non-enabled cannot mint")
+	ErrMaxSupplyExceeded       = errors.New("minting exceeds max native coin supply")
+	ErrInsufficientBalanceBurn = errors.New("insufficient balance to burn")
+	ErrInvalidMaxSupply        = errors.New("invalid max native coin supply")
 )
 
+// ContractNativeMinterConfigKey is the key of the config in the chain config and network upgrades.
+const ContractNativeMinterConfigKey = "contractNativeMinterConfig"
+
+func init() {
+	if err := RegisterModule(Module{
+		ConfigKey: ContractNativeMinterConfigKey,
+		Address:   ContractNativeMinterAddress,
+		NewConfig: func() StatefulPrecompileConfig { return new(ContractNativeMinterConfig) },
+	}); err != nil {
+		panic(err)
+	}
+}
+
 // ContractNativeMinterConfig wraps [AllowListConfig] and uses it to implement the StatefulPrecompileConfig
 // interface while adding in the ContractNativeMinter specific precompile address.
+//
+// While the native minter is enabled, it keeps count of the native coins minted and burned. Coins minted by the
+// precompile and the genesis allocations of a chain that enables the minter in its genesis are counted as minted,
+// while coins burned by the precompile and the transaction fees sent to the blackhole address are counted as burned.
+// Coins minted or burned while the minter is not enabled are not counted, and burns are only counted up to the
+// coins counted as minted, such that burning coins that were never counted does not make room under the max supply.
+// The counters, the max supply and burning only take effect once the PrecompileUpgrade is active.
 type ContractNativeMinterConfig struct {
 	AllowListConfig
+	// MaxSupply caps the native coins that may be minted, such that the minted coins less the burned coins
+	// never exceed it. A nil or zero MaxSupply does not cap minting.
+	MaxSupply *big.Int `json:"maxSupply,omitempty"`
 }
 
 // Address returns the address of the native minter contract.
@@ -39,9 +78,28 @@ func (c *ContractNativeMinterConfig) Address() common.Address {
 	return ContractNativeMinterAddress
 }
 
-// Configure configures [state] with the desired admins based on [c].
-func (c *ContractNativeMinterConfig) Configure(_ ChainConfig, state StateDB, _ BlockContext) {
-	c.AllowListConfig.Configure(state, ContractNativeMinterAddress)
+// Verify returns an error if the max supply of [c] is negative or exceeds 256 bits.
+func (c *ContractNativeMinterConfig) Verify() error {
+	if c.MaxSupply != nil && (c.MaxSupply.Sign() < 0 || c.MaxSupply.BitLen() > 256) {
+		return fmt.Errorf("%w: %s", ErrInvalidMaxSupply, c.MaxSupply)
+	}
+	return c.AllowListConfig.Verify()
+}
+
+// Configure configures [state] with the desired admins and max supply based on [c].
+func (c *ContractNativeMinterConfig) Configure(chainConfig ChainConfig, state StateDB, blockContext BlockContext) {
+	c.AllowListConfig.Configure(chainConfig, state, blockContext, ContractNativeMinterAddress)
+	// The max supply is always written, such that reconfiguring the precompile without a max supply lifts the cap.
+	maxSupply := c.MaxSupply
+	if maxSupply == nil {
+		maxSupply = common.Big0
+	}
+	state.SetState(ContractNativeMinterAddress, nativeCoinMaxSupplyKey, common.BigToHash(maxSupply))
+}
+
+// Disable clears the roles and any other state of the native minter from [state].
+func (c *ContractNativeMinterConfig) Disable(_ ChainConfig, state StateDB, _ BlockContext) {
+	clearPrecompileState(state, ContractNativeMinterAddress)
 }
 
 // Contract returns the singleton stateful precompiled contract to be used for the native minter.
@@ -50,8 +108,9 @@ func (c *ContractNativeMinterConfig) Contract() StatefulPrecompiledContract {
 }
 
 // GetContractNativeMinterStatus returns the role of [address] for the minter list.
-func GetContractNativeMinterStatus(stateDB StateDB, address common.Address) AllowListRole {
-	return getAllowListStatus(stateDB, ContractNativeMinterAddress, address)
+// [address] has no role if its role has expired by the block [timestamp].
+func GetContractNativeMinterStatus(stateDB StateDB, address common.Address, timestamp *big.Int) AllowListRole {
+	return getAllowListStatus(stateDB, ContractNativeMinterAddress, address, timestamp)
 }
 
 // SetContractNativeMinterStatus sets the permissions of [address] to [role] for the
@@ -60,6 +119,52 @@ func SetContractNativeMinterStatus(stateDB StateDB, address common.Address, role
 	setAllowListRole(stateDB, ContractNativeMinterAddress, address, role)
 }
 
+// GetNativeCoinMinted returns the native coins counted as minted by the native minter in [stateDB].
+func GetNativeCoinMinted(stateDB StateDB) *big.Int {
+	return stateDB.GetState(ContractNativeMinterAddress, nativeCoinMintedKey).Big()
+}
+
+// GetNativeCoinBurned returns the native coins counted as burned by the native minter in [stateDB].
+func GetNativeCoinBurned(stateDB StateDB) *big.Int {
+	return stateDB.GetState(ContractNativeMinterAddress, nativeCoinBurnedKey).Big()
+}
+
+// GetMaxNativeCoinSupply returns the max supply of native coins enforced by the native minter in [stateDB], which
+// is zero if minting is not capped.
+func GetMaxNativeCoinSupply(stateDB StateDB) *big.Int {
+	return stateDB.GetState(ContractNativeMinterAddress, nativeCoinMaxSupplyKey).Big()
+}
+
+// AddNativeCoinMinted counts [amount] of native coins as minted in [stateDB].
+// Assumes that the native minter is enabled.
+func AddNativeCoinMinted(stateDB StateDB, amount *big.Int) {
+	addToCounter(stateDB, nativeCoinMintedKey, amount)
+}
+
+// AddNativeCoinBurned counts [amount] of native coins as burned in [stateDB], up to the coins counted as minted
+// that have not been burned yet, so that the supply counted by the native minter never goes negative.
+// Assumes that the native minter is enabled.
+func AddNativeCoinBurned(stateDB StateDB, amount *big.Int) {
+	supply := new(big.Int).Sub(GetNativeCoinMinted(stateDB), GetNativeCoinBurned(stateDB))
+	if amount.Cmp(supply) > 0 {
+		amount = supply
+	}
+	addToCounter(stateDB, nativeCoinBurnedKey, amount)
+}
+
+// addToCounter adds [amount] to the counter of the native minter at [key], saturating at the largest uint256.
+func addToCounter(stateDB StateDB, key common.Hash, amount *big.Int) {
+	if amount.Sign() == 0 {
+		return
+	}
+	counter := stateDB.GetState(ContractNativeMinterAddress, key).Big()
+	counter.Add(counter, amount)
+	if counter.Cmp(math.MaxBig256) > 0 {
+		counter = math.MaxBig256
+	}
+	stateDB.SetState(ContractNativeMinterAddress, key, common.BigToHash(counter))
+}
+
 // PackMintInput packs [address] and [amount] into the appropriate arguments for minting operation.
 // Assumes that [amount] can be represented by 32 bytes.
 func PackMintInput(address common.Address, amount *big.Int) ([]byte, error) {
@@ -86,6 +191,7 @@ func UnpackMintInput(input []byte) (common.Address, *big.Int, error) {
 
 // mintNativeCoin checks if the caller is permissioned for minting operation.
 // The execution function parses the [input] into native coin amount and receiver address.
+// Once the PrecompileUpgrade is active, minting is capped by the max supply and counted as minted.
 func mintNativeCoin(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
 	if remainingGas, err = deductGas(suppliedGas, MintGasCost); err != nil {
 		return nil, 0, err
@@ -102,28 +208,105 @@ func mintNativeCoin(accessibleState PrecompileAccessibleState, caller common.Add
 
 	stateDB := accessibleState.GetStateDB()
 	// Verify that the caller is in the allow list and therefore has the right to modify it
-	callerStatus := getAllowListStatus(stateDB, ContractNativeMinterAddress, caller)
+	callerStatus := getAllowListStatus(stateDB, ContractNativeMinterAddress, caller, accessibleState.GetBlockContext().Timestamp())
 	if !callerStatus.IsEnabled() {
 		return nil, remainingGas, fmt.Errorf("%w: %s", ErrCannotMint, caller)
 	}
 
+	upgraded := isPrecompileUpgrade(accessibleState)
+	// Verify that minting [amount] keeps the supply within the max supply, if there is one.
+	if maxSupply := GetMaxNativeCoinSupply(stateDB); upgraded && maxSupply.Sign() > 0 {
+		supply := new(big.Int).Sub(GetNativeCoinMinted(stateDB), GetNativeCoinBurned(stateDB))
+		if supply.Add(supply, amount).Cmp(maxSupply) > 0 {
+			return nil, remainingGas, fmt.Errorf("%w: %s", ErrMaxSupplyExceeded, maxSupply)
+		}
+	}
+
 	// if there is no address in the state, create one.
 	if !stateDB.Exist(to) {
 		stateDB.CreateAccount(to)
 	}
 
 	stateDB.AddBalance(to, amount)
+	if upgraded {
+		AddNativeCoinMinted(stateDB, amount)
+	}
 	// Return an empty output and the remaining gas
 	return []byte{}, remainingGas, nil
 }
 
+// PackBurnInput packs [amount] into the appropriate arguments for the burn operation.
+// Assumes that [amount] can be represented by 32 bytes.
+func PackBurnInput(amount *big.Int) []byte {
+	// function selector (4 bytes) + input(hash for amount)
+	res := make([]byte, selectorLen+burnInputLen)
+	packOrderedHashesWithSelector(res, burnSignature, []common.Hash{common.BigToHash(amount)})
+	return res
+}
+
+// UnpackBurnInput attempts to unpack [input] into the amount to burn.
+// assumes that [input] does not include selector (omits first 4 bytes in PackBurnInput)
+func UnpackBurnInput(input []byte) (*big.Int, error) {
+	if len(input) != burnInputLen {
+		return nil, fmt.Errorf("invalid input length for burning: %d", len(input))
+	}
+	return new(big.Int).SetBytes(input), nil
+}
+
+// burnNativeCoin burns the amount of native coins given as [input] from the balance of the caller.
+// Any caller may burn its own native coins.
+func burnNativeCoin(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, BurnGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	if readOnly {
+		return nil, remainingGas, vmerrs.ErrWriteProtection
+	}
+
+	amount, err := UnpackBurnInput(input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+
+	stateDB := accessibleState.GetStateDB()
+	if balance := stateDB.GetBalance(caller); balance.Cmp(amount) < 0 {
+		return nil, remainingGas, fmt.Errorf("%w: %s has %s", ErrInsufficientBalanceBurn, caller, balance)
+	}
+
+	stateDB.SubBalance(caller, amount)
+	AddNativeCoinBurned(stateDB, amount)
+	// Return an empty output and the remaining gas
+	return []byte{}, remainingGas, nil
+}
+
+// createNativeCoinCounterReader returns an execution function that reads the native minter storage at [key].
+func createNativeCoinCounterReader(key common.Hash) RunStatefulPrecompileFunc {
+	return func(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+		if remainingGas, err = deductGas(suppliedGas, ReadNativeCoinSupplyGasCost); err != nil {
+			return nil, 0, err
+		}
+
+		if len(input) != 0 {
+			return nil, remainingGas, fmt.Errorf("invalid input length for reading native coin supply: %d", len(input))
+		}
+
+		return accessibleState.GetStateDB().GetState(ContractNativeMinterAddress, key).Bytes(), remainingGas, nil
+	}
+}
+
 // createNativeMinterPrecompile returns a StatefulPrecompiledContract with R/W control of an allow list at [precompileAddr] and a native coin minter.
+// Burning and reading the supply counters only exist once the PrecompileUpgrade is active.
 func createNativeMinterPrecompile(precompileAddr common.Address) StatefulPrecompiledContract {
 	enabledFuncs := createAllowListFunctions(precompileAddr)
 
 	mintFunc := newStatefulPrecompileFunction(mintSignature, mintNativeCoin)
+	burnFunc := newUpgradeStatefulPrecompileFunction(burnSignature, burnNativeCoin)
+	totalMintedFunc := newUpgradeStatefulPrecompileFunction(totalNativeCoinMintedSignature, createNativeCoinCounterReader(nativeCoinMintedKey))
+	totalBurnedFunc := newUpgradeStatefulPrecompileFunction(totalNativeCoinBurnedSignature, createNativeCoinCounterReader(nativeCoinBurnedKey))
+	maxSupplyFunc := newUpgradeStatefulPrecompileFunction(maxNativeCoinSupplySignature, createNativeCoinCounterReader(nativeCoinMaxSupplyKey))
 
-	enabledFuncs = append(enabledFuncs, mintFunc)
+	enabledFuncs = append(enabledFuncs, mintFunc, burnFunc, totalMintedFunc, totalBurnedFunc, maxSupplyFunc)
 	// Construct the contract with no fallback function.
 	contract := newStatefulPrecompileWithFunctionSelectors(nil, enabledFuncs)
 	return contract
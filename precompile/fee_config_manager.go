@@ -4,11 +4,14 @@
 package precompile
 
 import (
+	_ "embed"
 	"errors"
 	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ir4tech/webb-evm/accounts/abi"
 	"github.com/ir4tech/webb-evm/commontype"
 	"github.com/ir4tech/webb-evm/vmerrs"
 )
@@ -30,27 +33,83 @@ const (
 
 	// [numFeeConfigField] fields in FeeConfig struct
 	feeConfigInputLen = common.HashLength * numFeeConfigField
+	// effective at timestamp followed by the [numFeeConfigField] fields in FeeConfig struct
+	scheduleFeeConfigInputLen = common.HashLength + feeConfigInputLen
 )
 
+// Every fee config stored by the precompile keeps each field at the key of the field followed by the prefix of the
+// fee config, such that the current fee config, which has no prefix, keeps each field at the key of the field.
+const (
+	currentFeeConfigPrefix byte = 0
+	pendingFeeConfigPrefix byte = 'p'
+	minFeeConfigPrefix     byte = 'l'
+	maxFeeConfigPrefix     byte = 'u'
+)
+
+// FeeConfigManagerRawABI is the solidity ABI of the events of the fee config manager as declared in
+// contract-examples/contracts/IFeeManager.sol.
+//
+//go:embed fee_config_manager.abi
+var FeeConfigManagerRawABI string
+
 var (
 	_ StatefulPrecompileConfig = &FeeConfigManagerConfig{}
 
+	// FeeConfigManagerABI is the parsed ABI of the fee config manager used to pack its events.
+	FeeConfigManagerABI = mustParseABI(FeeConfigManagerRawABI)
+
 	// Singleton StatefulPrecompiledContract for setting fee configs by permissioned callers.
 	FeeConfigManagerPrecompile StatefulPrecompiledContract = createFeeConfigManagerPrecompile(FeeConfigManagerAddress)
 
 	setFeeConfigSignature              = CalculateFunctionSelector("setFeeConfig(uint256,uint256,uint256,uint256,uint256,uint256,uint256,uint256)")
 	getFeeConfigSignature              = CalculateFunctionSelector("getFeeConfig()")
 	getFeeConfigLastChangedAtSignature = CalculateFunctionSelector("getFeeConfigLastChangedAt()")
+	scheduleFeeConfigSignature         = CalculateFunctionSelector("scheduleFeeConfig(uint256,uint256,uint256,uint256,uint256,uint256,uint256,uint256,uint256)")
+	cancelPendingFeeConfigSignature    = CalculateFunctionSelector("cancelPendingFeeConfig()")
+	getPendingFeeConfigSignature       = CalculateFunctionSelector("getPendingFeeConfig()")
+
+	feeConfigLastChangedAtKey      = common.Hash{'l', 'c', 'a'}
+	pendingFeeConfigEffectiveAtKey = common.Hash{'p', 'e', 'a'}
+
+	// feeConfigFieldNames are the JSON names of the fields of FeeConfig in the order of their keys.
+	feeConfigFieldNames = [numFeeConfigField]string{
+		"gasLimit",
+		"targetBlockRate",
+		"minBaseFee",
+		"targetGas",
+		"baseFeeChangeDenominator",
+		"minBlockGasCost",
+		"maxBlockGasCost",
+		"blockGasCostStep",
+	}
 
-	feeConfigLastChangedAtKey = common.Hash{'l', 'c', 'a'}
-
-	ErrCannotChangeFee = errors.New("non-enabled cannot change fee config")
+	ErrCannotChangeFee          = errors.New("non-enabled cannot change fee config")
+	ErrFeeConfigOutOfBounds     = errors.New("fee config out of bounds")
+	ErrInvalidFeeConfigBounds   = errors.New("invalid fee config bounds")
+	ErrInvalidFeeConfigSchedule = errors.New("fee config must be scheduled after the current block")
 )
 
+// FeeConfigManagerConfigKey is the key of the config in the chain config and network upgrades.
+const FeeConfigManagerConfigKey = "feeManagerConfig"
+
+func init() {
+	if err := RegisterModule(Module{
+		ConfigKey: FeeConfigManagerConfigKey,
+		Address:   FeeConfigManagerAddress,
+		NewConfig: func() StatefulPrecompileConfig { return new(FeeConfigManagerConfig) },
+	}); err != nil {
+		panic(err)
+	}
+}
+
 // FeeConfigManagerConfig wraps [AllowListConfig] and uses it to implement the StatefulPrecompileConfig
 // interface while adding in the FeeConfigManager specific precompile address.
 type FeeConfigManagerConfig struct {
 	AllowListConfig // Config for the fee config manager allow list
+	// MinFeeConfig and MaxFeeConfig bound each field of the fee configs set or scheduled by the allow list, so that
+	// a compromised key cannot set fees that halt the chain. A nil or zero field of either does not bound the field.
+	MinFeeConfig *commontype.FeeConfig `json:"minFeeConfig,omitempty"`
+	MaxFeeConfig *commontype.FeeConfig `json:"maxFeeConfig,omitempty"`
 }
 
 // Address returns the address of the fee config manager contract.
@@ -58,13 +117,45 @@ func (c *FeeConfigManagerConfig) Address() common.Address {
 	return FeeConfigManagerAddress
 }
 
-// Configure configures [state] with the desired admins based on [c].
+// Verify returns an error if a bound of [c] is negative or exceeds 256 bits, or if the min bound of a field
+// exceeds its max bound.
+func (c *FeeConfigManagerConfig) Verify() error {
+	minFields, maxFields := feeConfigBoundFields(c.MinFeeConfig), feeConfigBoundFields(c.MaxFeeConfig)
+	for i := range feeConfigFieldNames {
+		for _, bound := range []*big.Int{minFields[i], maxFields[i]} {
+			if bound.Sign() < 0 || bound.BitLen() > 256 {
+				return fmt.Errorf("%w: %s bound %s", ErrInvalidFeeConfigBounds, feeConfigFieldNames[i], bound)
+			}
+		}
+		if maxFields[i].Sign() != 0 && minFields[i].Cmp(maxFields[i]) > 0 {
+			return fmt.Errorf("%w: %s min %s exceeds max %s", ErrInvalidFeeConfigBounds, feeConfigFieldNames[i], minFields[i], maxFields[i])
+		}
+	}
+	return c.AllowListConfig.Verify()
+}
+
+// Configure configures [state] with the desired admins and fee config bounds based on [c].
 func (c *FeeConfigManagerConfig) Configure(chainConfig ChainConfig, state StateDB, blockContext BlockContext) {
-	// Store the initial fee config into the state when the fee config manager activates.
-	if err := StoreFeeConfig(state, chainConfig.GetFeeConfig(), blockContext); err != nil {
-		panic(fmt.Sprintf("fee config should have been verified in genesis: %s", err))
+	// Store the initial fee config into the state when the fee config manager activates. A reconfiguration
+	// keeps the fee config set through the precompile.
+	if !isConfigured(state, FeeConfigManagerAddress) {
+		if err := StoreFeeConfig(state, chainConfig.GetFeeConfig(), blockContext); err != nil {
+			panic(fmt.Sprintf("fee config should have been verified in genesis: %s", err))
+		}
 	}
-	c.AllowListConfig.Configure(state, FeeConfigManagerAddress)
+	// Every bound is written, such that reconfiguring the precompile lifts the bounds that are no longer set.
+	minFields, maxFields := feeConfigBoundFields(c.MinFeeConfig), feeConfigBoundFields(c.MaxFeeConfig)
+	for i := range feeConfigFieldNames {
+		field := minFeeConfigFieldKey + i
+		state.SetState(FeeConfigManagerAddress, feeConfigKey(minFeeConfigPrefix, field), common.BigToHash(minFields[i]))
+		state.SetState(FeeConfigManagerAddress, feeConfigKey(maxFeeConfigPrefix, field), common.BigToHash(maxFields[i]))
+	}
+	c.AllowListConfig.Configure(chainConfig, state, blockContext, FeeConfigManagerAddress)
+}
+
+// Disable clears the roles and any other state of the fee config manager from [state].
+func (c *FeeConfigManagerConfig) Disable(_ ChainConfig, state StateDB, _ BlockContext) {
+	clearPrecompileState(state, FeeConfigManagerAddress)
 }
 
 // Contract returns the singleton stateful precompiled contract to be used for the fee manager.
@@ -73,8 +164,9 @@ func (c *FeeConfigManagerConfig) Contract() StatefulPrecompiledContract {
 }
 
 // GetFeeConfigManagerStatus returns the role of [address] for the fee config manager list.
-func GetFeeConfigManagerStatus(stateDB StateDB, address common.Address) AllowListRole {
-	return getAllowListStatus(stateDB, FeeConfigManagerAddress, address)
+// [address] has no role if its role has expired by the block [timestamp].
+func GetFeeConfigManagerStatus(stateDB StateDB, address common.Address, timestamp *big.Int) AllowListRole {
+	return getAllowListStatus(stateDB, FeeConfigManagerAddress, address, timestamp)
 }
 
 // SetFeeConfigManagerStatus sets the permissions of [address] to [role] for the
@@ -93,6 +185,35 @@ func PackGetLastChangedAtInput() []byte {
 	return getFeeConfigLastChangedAtSignature
 }
 
+// PackGetPendingFeeConfigInput packs the getPendingFeeConfig signature
+func PackGetPendingFeeConfigInput() []byte {
+	return getPendingFeeConfigSignature
+}
+
+// PackCancelPendingFeeConfigInput packs the cancelPendingFeeConfig signature
+func PackCancelPendingFeeConfigInput() []byte {
+	return cancelPendingFeeConfigSignature
+}
+
+// PackScheduleFeeConfig packs [effectiveAt] and [feeConfig] with the selector into the appropriate arguments for
+// scheduling fee config operations.
+func PackScheduleFeeConfig(effectiveAt *big.Int, feeConfig commontype.FeeConfig) ([]byte, error) {
+	// function selector (4 bytes) + input(effectiveAt, feeConfig)
+	res := make([]byte, len(scheduleFeeConfigSignature)+scheduleFeeConfigInputLen)
+	copy(res, scheduleFeeConfigSignature)
+	copy(res[len(scheduleFeeConfigSignature):], common.BigToHash(effectiveAt).Bytes())
+	copy(res[len(scheduleFeeConfigSignature)+common.HashLength:], packFeeConfigHelper(feeConfig, false))
+	return res, nil
+}
+
+// PackPendingFeeConfig packs [effectiveAt] and [feeConfig] into the output of the getPendingFeeConfig function.
+func PackPendingFeeConfig(effectiveAt *big.Int, feeConfig commontype.FeeConfig) []byte {
+	res := make([]byte, scheduleFeeConfigInputLen)
+	copy(res, common.BigToHash(effectiveAt).Bytes())
+	copy(res[common.HashLength:], packFeeConfigHelper(feeConfig, false))
+	return res
+}
+
 // PackFeeConfig packs [feeConfig] without the selector into the appropriate arguments for fee config operations.
 func PackFeeConfig(feeConfig commontype.FeeConfig) ([]byte, error) {
 	//  input(feeConfig)
@@ -162,11 +283,97 @@ func UnpackFeeConfigInput(input []byte) (commontype.FeeConfig, error) {
 	return feeConfig, nil
 }
 
+// feeConfigKey returns the storage key of [field] of the fee config stored with [prefix].
+func feeConfigKey(prefix byte, field int) common.Hash {
+	return common.Hash{byte(field), prefix}
+}
+
+// feeConfigBoundFields returns the fields of the bounds [feeConfig] in the order of their keys, where the fields
+// that are not set, and all fields if [feeConfig] is nil, are zero.
+func feeConfigBoundFields(feeConfig *commontype.FeeConfig) [numFeeConfigField]*big.Int {
+	var fields [numFeeConfigField]*big.Int
+	if feeConfig != nil {
+		fields = [numFeeConfigField]*big.Int{
+			feeConfig.GasLimit,
+			new(big.Int).SetUint64(feeConfig.TargetBlockRate),
+			feeConfig.MinBaseFee,
+			feeConfig.TargetGas,
+			feeConfig.BaseFeeChangeDenominator,
+			feeConfig.MinBlockGasCost,
+			feeConfig.MaxBlockGasCost,
+			feeConfig.BlockGasCostStep,
+		}
+	}
+	for i, field := range fields {
+		if field == nil {
+			fields[i] = new(big.Int)
+		}
+	}
+	return fields
+}
+
 // GetStoredFeeConfig returns fee config from contract storage in given state
 func GetStoredFeeConfig(stateDB StateDB) commontype.FeeConfig {
+	return getStoredFeeConfig(stateDB, currentFeeConfigPrefix)
+}
+
+// GetPendingFeeConfig returns the fee config scheduled in [stateDB] and the timestamp it takes effect at, or the
+// empty fee config and nil if no fee config is scheduled.
+func GetPendingFeeConfig(stateDB StateDB) (commontype.FeeConfig, *big.Int) {
+	effectiveAt := stateDB.GetState(FeeConfigManagerAddress, pendingFeeConfigEffectiveAtKey).Big()
+	if effectiveAt.Sign() == 0 {
+		return commontype.EmptyFeeConfig, nil
+	}
+	return getStoredFeeConfig(stateDB, pendingFeeConfigPrefix), effectiveAt
+}
+
+// GetFeeConfigBounds returns the min and max bounds of each field of the fee config in [stateDB], where the fields
+// that are not bounded are zero.
+func GetFeeConfigBounds(stateDB StateDB) (commontype.FeeConfig, commontype.FeeConfig) {
+	return getStoredFeeConfig(stateDB, minFeeConfigPrefix), getStoredFeeConfig(stateDB, maxFeeConfigPrefix)
+}
+
+// ApplyPendingFeeConfig makes the pending fee config in [stateDB] the current one if it takes effect by the
+// timestamp of [blockContext], and records the block of [blockContext] as the block it changed at. Like a fee
+// config set by setFeeConfig in the block, it governs the fees of the blocks built on top of it. A pending fee config
+// that is no longer within the bounds stored in [stateDB] is dropped instead.
+// Returns true if the pending fee config was applied.
+func ApplyPendingFeeConfig(stateDB StateDB, blockContext BlockContext) bool {
+	feeConfig, effectiveAt := GetPendingFeeConfig(stateDB)
+	if effectiveAt == nil || effectiveAt.Cmp(blockContext.Timestamp()) > 0 {
+		return false
+	}
+	// The bounds may have been tightened by a reconfiguration since the fee config was scheduled.
+	if err := checkFeeConfigBounds(stateDB, feeConfig); err != nil {
+		log.Warn("dropping pending fee config", "effectiveAt", effectiveAt, "err", err)
+		stateDB.SetState(FeeConfigManagerAddress, pendingFeeConfigEffectiveAtKey, common.Hash{})
+		return false
+	}
+	storeFeeConfigFields(stateDB, currentFeeConfigPrefix, feeConfig)
+	stateDB.SetState(FeeConfigManagerAddress, feeConfigLastChangedAtKey, common.BigToHash(blockContext.Number()))
+	stateDB.SetState(FeeConfigManagerAddress, pendingFeeConfigEffectiveAtKey, common.Hash{})
+	return true
+}
+
+// checkFeeConfigBounds returns an error if a field of [feeConfig] is outside of the bounds stored in [stateDB].
+func checkFeeConfigBounds(stateDB StateDB, feeConfig commontype.FeeConfig) error {
+	for i, value := range feeConfigBoundFields(&feeConfig) {
+		field := minFeeConfigFieldKey + i
+		if lower := stateDB.GetState(FeeConfigManagerAddress, feeConfigKey(minFeeConfigPrefix, field)).Big(); value.Cmp(lower) < 0 {
+			return fmt.Errorf("%w: %s = %s is less than %s", ErrFeeConfigOutOfBounds, feeConfigFieldNames[i], value, lower)
+		}
+		if upper := stateDB.GetState(FeeConfigManagerAddress, feeConfigKey(maxFeeConfigPrefix, field)).Big(); upper.Sign() != 0 && value.Cmp(upper) > 0 {
+			return fmt.Errorf("%w: %s = %s is greater than %s", ErrFeeConfigOutOfBounds, feeConfigFieldNames[i], value, upper)
+		}
+	}
+	return nil
+}
+
+// getStoredFeeConfig returns the fee config stored with [prefix] in [stateDB].
+func getStoredFeeConfig(stateDB StateDB, prefix byte) commontype.FeeConfig {
 	feeConfig := commontype.FeeConfig{}
 	for i := minFeeConfigFieldKey; i <= numFeeConfigField; i++ {
-		val := stateDB.GetState(FeeConfigManagerAddress, common.Hash{byte(i)})
+		val := stateDB.GetState(FeeConfigManagerAddress, feeConfigKey(prefix, i))
 		switch i {
 		case gasLimitKey:
 			feeConfig.GasLimit = new(big.Int).Set(val.Big())
@@ -191,6 +398,7 @@ func GetStoredFeeConfig(stateDB StateDB) commontype.FeeConfig {
 	return feeConfig
 }
 
+// GetFeeConfigLastChangedAt returns the number of the block the fee config last changed in from [stateDB].
 func GetFeeConfigLastChangedAt(stateDB StateDB) *big.Int {
 	val := stateDB.GetState(FeeConfigManagerAddress, feeConfigLastChangedAtKey)
 	return val.Big()
@@ -203,6 +411,19 @@ func StoreFeeConfig(stateDB StateDB, feeConfig commontype.FeeConfig, blockContex
 		return err
 	}
 
+	storeFeeConfigFields(stateDB, currentFeeConfigPrefix, feeConfig)
+
+	blockNumber := blockContext.Number()
+	if blockNumber == nil {
+		return fmt.Errorf("blockNumber cannot be nil")
+	}
+	stateDB.SetState(FeeConfigManagerAddress, feeConfigLastChangedAtKey, common.BigToHash(blockNumber))
+
+	return nil
+}
+
+// storeFeeConfigFields stores the fields of [feeConfig] with [prefix] to [stateDB].
+func storeFeeConfigFields(stateDB StateDB, prefix byte, feeConfig commontype.FeeConfig) {
 	for i := minFeeConfigFieldKey; i <= numFeeConfigField; i++ {
 		var input common.Hash
 		switch i {
@@ -225,22 +446,19 @@ func StoreFeeConfig(stateDB StateDB, feeConfig commontype.FeeConfig, blockContex
 		default:
 			panic(fmt.Sprintf("unknown fee config key: %d", i))
 		}
-		stateDB.SetState(FeeConfigManagerAddress, common.Hash{byte(i)}, input)
+		stateDB.SetState(FeeConfigManagerAddress, feeConfigKey(prefix, i), input)
 	}
-
-	blockNumber := blockContext.Number()
-	if blockNumber == nil {
-		return fmt.Errorf("blockNumber cannot be nil")
-	}
-	stateDB.SetState(FeeConfigManagerAddress, feeConfigLastChangedAtKey, common.BigToHash(blockNumber))
-
-	return nil
 }
 
 // setFeeConfig checks if the caller has permissions to set the fee config.
 // The execution function parses [input] into FeeConfig structure and sets contract storage accordingly.
 func setFeeConfig(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
-	if remainingGas, err = deductGas(suppliedGas, SetFeeConfigGasCost); err != nil {
+	upgraded := isPrecompileUpgrade(accessibleState)
+	gasCost := uint64(SetFeeConfigGasCost)
+	if !upgraded {
+		gasCost = LegacySetFeeConfigGasCost
+	}
+	if remainingGas, err = deductGas(suppliedGas, gasCost); err != nil {
 		return nil, 0, err
 	}
 
@@ -255,19 +473,133 @@ func setFeeConfig(accessibleState PrecompileAccessibleState, caller common.Addre
 
 	stateDB := accessibleState.GetStateDB()
 	// Verify that the caller is in the allow list and therefore has the right to modify it
-	callerStatus := getAllowListStatus(stateDB, FeeConfigManagerAddress, caller)
+	callerStatus := getAllowListStatus(stateDB, FeeConfigManagerAddress, caller, accessibleState.GetBlockContext().Timestamp())
 	if !callerStatus.IsEnabled() {
 		return nil, remainingGas, fmt.Errorf("%w: %s", ErrCannotChangeFee, caller)
 	}
 
+	// The bounds are only enforced once the PrecompileUpgrade is active, like the gas charged for reading them.
+	if upgraded {
+		if err := checkFeeConfigBounds(stateDB, feeConfig); err != nil {
+			return nil, remainingGas, err
+		}
+	}
+
 	if err := StoreFeeConfig(stateDB, feeConfig, accessibleState.GetBlockContext()); err != nil {
 		return nil, remainingGas, err
 	}
+	if upgraded {
+		if err := emitFeeConfigEvent(accessibleState, "FeeConfigChanged", caller, feeConfigFields(feeConfig)...); err != nil {
+			return nil, remainingGas, err
+		}
+	}
+
+	// Return an empty output and the remaining gas
+	return []byte{}, remainingGas, nil
+}
+
+// scheduleFeeConfig checks if the caller has permissions to set the fee config.
+// The execution function parses [input] into the timestamp the fee config takes effect at and the FeeConfig
+// structure, and stores them as the pending fee config, replacing any fee config scheduled before.
+func scheduleFeeConfig(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, ScheduleFeeConfigGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	if readOnly {
+		return nil, remainingGas, vmerrs.ErrWriteProtection
+	}
+
+	if len(input) != scheduleFeeConfigInputLen {
+		return nil, remainingGas, fmt.Errorf("invalid input length for schedule fee config input: %d", len(input))
+	}
+	effectiveAt := new(big.Int).SetBytes(returnPackedHash(input, 0))
+	feeConfig, err := UnpackFeeConfigInput(input[common.HashLength:])
+	if err != nil {
+		return nil, remainingGas, err
+	}
+
+	stateDB := accessibleState.GetStateDB()
+	blockTimestamp := accessibleState.GetBlockContext().Timestamp()
+	// Verify that the caller is in the allow list and therefore has the right to modify it
+	callerStatus := getAllowListStatus(stateDB, FeeConfigManagerAddress, caller, blockTimestamp)
+	if !callerStatus.IsEnabled() {
+		return nil, remainingGas, fmt.Errorf("%w: %s", ErrCannotChangeFee, caller)
+	}
+
+	if effectiveAt.Cmp(blockTimestamp) <= 0 {
+		return nil, remainingGas, fmt.Errorf("%w: effective at %s, block timestamp %s", ErrInvalidFeeConfigSchedule, effectiveAt, blockTimestamp)
+	}
+	if err := feeConfig.Verify(); err != nil {
+		return nil, remainingGas, err
+	}
+	if err := checkFeeConfigBounds(stateDB, feeConfig); err != nil {
+		return nil, remainingGas, err
+	}
+
+	storeFeeConfigFields(stateDB, pendingFeeConfigPrefix, feeConfig)
+	stateDB.SetState(FeeConfigManagerAddress, pendingFeeConfigEffectiveAtKey, common.BigToHash(effectiveAt))
+	if err := emitFeeConfigEvent(accessibleState, "FeeConfigScheduled", caller, append([]interface{}{effectiveAt}, feeConfigFields(feeConfig)...)...); err != nil {
+		return nil, remainingGas, err
+	}
+
+	// Return an empty output and the remaining gas
+	return []byte{}, remainingGas, nil
+}
+
+// cancelPendingFeeConfig checks if the caller has permissions to set the fee config.
+// The execution function removes the pending fee config, if any, so that it never takes effect.
+func cancelPendingFeeConfig(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, CancelPendingFeeConfigGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	if readOnly {
+		return nil, remainingGas, vmerrs.ErrWriteProtection
+	}
+
+	stateDB := accessibleState.GetStateDB()
+	// Verify that the caller is in the allow list and therefore has the right to modify it
+	callerStatus := getAllowListStatus(stateDB, FeeConfigManagerAddress, caller, accessibleState.GetBlockContext().Timestamp())
+	if !callerStatus.IsEnabled() {
+		return nil, remainingGas, fmt.Errorf("%w: %s", ErrCannotChangeFee, caller)
+	}
+
+	stateDB.SetState(FeeConfigManagerAddress, pendingFeeConfigEffectiveAtKey, common.Hash{})
+	if err := emitFeeConfigEvent(accessibleState, "PendingFeeConfigCancelled", caller); err != nil {
+		return nil, remainingGas, err
+	}
 
 	// Return an empty output and the remaining gas
 	return []byte{}, remainingGas, nil
 }
 
+// emitFeeConfigEvent adds a log of [event] sent by [sender] with the non indexed [data] to the logs of the fee config
+// manager.
+func emitFeeConfigEvent(accessibleState PrecompileAccessibleState, event string, sender common.Address, data ...interface{}) error {
+	abiEvent := FeeConfigManagerABI.Events[event]
+	topics, err := abi.MakeTopics([]interface{}{sender})
+	if err != nil {
+		return err
+	}
+	packed, err := abiEvent.Inputs.NonIndexed().Pack(data...)
+	if err != nil {
+		return err
+	}
+	accessibleState.AddLog(FeeConfigManagerAddress, []common.Hash{abiEvent.ID, topics[0][0]}, packed)
+	return nil
+}
+
+// feeConfigFields returns the fields of [feeConfig] in the order of their keys, as they are packed in events.
+func feeConfigFields(feeConfig commontype.FeeConfig) []interface{} {
+	fields := feeConfigBoundFields(&feeConfig)
+	values := make([]interface{}, 0, len(fields))
+	for _, field := range fields {
+		values = append(values, field)
+	}
+	return values
+}
+
 // getFeeConfig returns the stored fee config as an output.
 // The execution function reads the contract state for the stored fee config and returns the output.
 func getFeeConfig(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
@@ -299,6 +631,36 @@ func getFeeConfigLastChangedAt(accessibleState PrecompileAccessibleState, caller
 	return common.BigToHash(lastChangedAt).Bytes(), remainingGas, err
 }
 
+// getPendingFeeConfig returns the timestamp the pending fee config takes effect at and the pending fee config as an
+// output, which are zero if no fee config is scheduled.
+func getPendingFeeConfig(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, GetPendingFeeConfigGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	feeConfig, effectiveAt := GetPendingFeeConfig(accessibleState.GetStateDB())
+	if effectiveAt == nil {
+		feeConfig = zeroFeeConfig()
+		effectiveAt = new(big.Int)
+	}
+
+	// Return the pending fee config as output and the remaining gas
+	return PackPendingFeeConfig(effectiveAt, feeConfig), remainingGas, nil
+}
+
+// zeroFeeConfig returns a fee config with every field zero, which unlike [commontype.EmptyFeeConfig] can be packed.
+func zeroFeeConfig() commontype.FeeConfig {
+	return commontype.FeeConfig{
+		GasLimit:                 new(big.Int),
+		MinBaseFee:               new(big.Int),
+		TargetGas:                new(big.Int),
+		BaseFeeChangeDenominator: new(big.Int),
+		MinBlockGasCost:          new(big.Int),
+		MaxBlockGasCost:          new(big.Int),
+		BlockGasCostStep:         new(big.Int),
+	}
+}
+
 // createFeeConfigManagerPrecompile returns a StatefulPrecompiledContract
 // with getters and setters for the chain's fee config. Access to the getters/setters
 // is controlled by an allow list for [precompileAddr].
@@ -308,8 +670,12 @@ func createFeeConfigManagerPrecompile(precompileAddr common.Address) StatefulPre
 	setFeeConfigFunc := newStatefulPrecompileFunction(setFeeConfigSignature, setFeeConfig)
 	getFeeConfigFunc := newStatefulPrecompileFunction(getFeeConfigSignature, getFeeConfig)
 	getFeeConfigLastChangedAtFunc := newStatefulPrecompileFunction(getFeeConfigLastChangedAtSignature, getFeeConfigLastChangedAt)
+	scheduleFeeConfigFunc := newUpgradeStatefulPrecompileFunction(scheduleFeeConfigSignature, scheduleFeeConfig)
+	cancelPendingFeeConfigFunc := newUpgradeStatefulPrecompileFunction(cancelPendingFeeConfigSignature, cancelPendingFeeConfig)
+	getPendingFeeConfigFunc := newUpgradeStatefulPrecompileFunction(getPendingFeeConfigSignature, getPendingFeeConfig)
 
-	feeConfigManagerFunctions = append(feeConfigManagerFunctions, setFeeConfigFunc, getFeeConfigFunc, getFeeConfigLastChangedAtFunc)
+	feeConfigManagerFunctions = append(feeConfigManagerFunctions, setFeeConfigFunc, getFeeConfigFunc, getFeeConfigLastChangedAtFunc,
+		scheduleFeeConfigFunc, cancelPendingFeeConfigFunc, getPendingFeeConfigFunc)
 	// Construct the contract with no fallback function.
 	contract := newStatefulPrecompileWithFunctionSelectors(nil, feeConfigManagerFunctions)
 	return contract
@@ -0,0 +1,456 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package precompile
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ir4tech/webb-evm/accounts/abi"
+	"github.com/ir4tech/webb-evm/vmerrs"
+)
+
+// Storage layout of the native asset manager. Balances and total supplies are kept in the storage of the precompile
+// address, keyed by hashing a one byte prefix with the asset id and the account, like the records of the asset
+// precompile.
+const (
+	nativeAssetBalancePrefix byte = iota + 1
+	nativeAssetSupplyPrefix
+)
+
+// NativeAssetRawABI is the solidity ABI of the native asset manager as declared in
+// contract-examples/contracts/INativeAsset.sol.
+//
+//go:embed native_asset.abi
+var NativeAssetRawABI string
+
+var (
+	_ StatefulPrecompileConfig = &NativeAssetManagerConfig{}
+	// Singleton StatefulPrecompiledContract for minting, burning and transferring native assets.
+	NativeAssetManagerPrecompile StatefulPrecompiledContract = createNativeAssetManagerPrecompile(NativeAssetManagerAddress)
+
+	// NativeAssetABI is the parsed ABI of the native asset manager used to unpack inputs and pack outputs.
+	NativeAssetABI = mustParseABI(NativeAssetRawABI)
+
+	mintNativeAssetSignature            = NativeAssetABI.Methods["mintNativeAsset"].ID
+	burnNativeAssetSignature            = NativeAssetABI.Methods["burnNativeAsset"].ID
+	transferNativeAssetSignature        = NativeAssetABI.Methods["transferNativeAsset"].ID
+	transferNativeAssetAndCallSignature = NativeAssetABI.Methods["transferNativeAssetAndCall"].ID
+	nativeAssetBalanceSignature         = NativeAssetABI.Methods["nativeAssetBalance"].ID
+	nativeAssetTotalSupplySignature     = NativeAssetABI.Methods["nativeAssetTotalSupply"].ID
+
+	ErrCannotMintNativeAsset       = errors.New("non-enabled cannot mint native asset")
+	ErrCannotBurnNativeAsset       = errors.New("non-enabled cannot burn native asset")
+	ErrInsufficientNativeAsset     = errors.New("insufficient native asset balance")
+	ErrNativeAssetSupplyOverflow   = errors.New("native asset total supply overflow")
+	ErrInvalidNativeAssetRecipient = errors.New("cannot transfer native asset to the zero address")
+	ErrNativeAssetCallFailed       = errors.New("native asset call failed")
+)
+
+// NativeAssetManagerConfigKey is the key of the config in the chain config and network upgrades.
+const NativeAssetManagerConfigKey = "nativeAssetManagerConfig"
+
+func init() {
+	if err := RegisterModule(Module{
+		ConfigKey: NativeAssetManagerConfigKey,
+		Address:   NativeAssetManagerAddress,
+		NewConfig: func() StatefulPrecompileConfig { return new(NativeAssetManagerConfig) },
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// NativeAssetManagerConfig wraps [AllowListConfig] and uses it to implement the StatefulPrecompileConfig
+// interface while adding in the native asset manager precompile address.
+// Only enabled addresses of the allow list may mint and burn native assets.
+type NativeAssetManagerConfig struct {
+	AllowListConfig
+}
+
+// Address returns the address of the native asset manager.
+func (c *NativeAssetManagerConfig) Address() common.Address {
+	return NativeAssetManagerAddress
+}
+
+// Configure configures [state] with the desired admins based on [c].
+func (c *NativeAssetManagerConfig) Configure(chainConfig ChainConfig, state StateDB, blockContext BlockContext) {
+	c.AllowListConfig.Configure(chainConfig, state, blockContext, NativeAssetManagerAddress)
+}
+
+// Disable clears the roles, balances and total supplies of the native asset manager from [state].
+func (c *NativeAssetManagerConfig) Disable(_ ChainConfig, state StateDB, _ BlockContext) {
+	clearPrecompileState(state, NativeAssetManagerAddress)
+}
+
+// Contract returns the singleton stateful precompiled contract to be used for the native asset manager.
+func (c *NativeAssetManagerConfig) Contract() StatefulPrecompiledContract {
+	return NativeAssetManagerPrecompile
+}
+
+// GetNativeAssetManagerStatus returns the role of [address] for the native asset manager allow list.
+// [address] has no role if its role has expired by the block [timestamp].
+func GetNativeAssetManagerStatus(stateDB StateDB, address common.Address, timestamp *big.Int) AllowListRole {
+	return getAllowListStatus(stateDB, NativeAssetManagerAddress, address, timestamp)
+}
+
+// SetNativeAssetManagerStatus sets the permissions of [address] to [role] for the
+// native asset manager allow list. assumes [role] has already been verified as valid.
+func SetNativeAssetManagerStatus(stateDB StateDB, address common.Address, role AllowListRole) {
+	setAllowListRole(stateDB, NativeAssetManagerAddress, address, role)
+}
+
+// GetNativeAssetBalance returns the balance of [assetId] held by [account] in [stateDB].
+func GetNativeAssetBalance(stateDB StateDB, account common.Address, assetId common.Hash) *big.Int {
+	return stateDB.GetState(NativeAssetManagerAddress, nativeAssetBalanceKey(account, assetId)).Big()
+}
+
+// GetNativeAssetTotalSupply returns the amount of [assetId] held by all accounts in [stateDB].
+func GetNativeAssetTotalSupply(stateDB StateDB, assetId common.Hash) *big.Int {
+	return stateDB.GetState(NativeAssetManagerAddress, storageKey(nativeAssetSupplyPrefix, assetId.Bytes())).Big()
+}
+
+// nativeAssetBalanceKey returns the storage key of the balance of [assetId] held by [account].
+func nativeAssetBalanceKey(account common.Address, assetId common.Hash) common.Hash {
+	return storageKey(nativeAssetBalancePrefix, assetId.Bytes(), account.Bytes())
+}
+
+func setNativeAssetBalance(stateDB StateDB, account common.Address, assetId common.Hash, balance *big.Int) {
+	stateDB.SetState(NativeAssetManagerAddress, nativeAssetBalanceKey(account, assetId), common.BigToHash(balance))
+}
+
+func setNativeAssetTotalSupply(stateDB StateDB, assetId common.Hash, supply *big.Int) {
+	stateDB.SetState(NativeAssetManagerAddress, storageKey(nativeAssetSupplyPrefix, assetId.Bytes()), common.BigToHash(supply))
+}
+
+// transferNativeAssetBalance moves [amount] of [assetId] from [from] to [to] in [stateDB].
+// The total supply bounds every balance, so crediting [to] cannot overflow.
+func transferNativeAssetBalance(stateDB StateDB, from common.Address, to common.Address, assetId common.Hash, amount *big.Int) error {
+	fromBalance := GetNativeAssetBalance(stateDB, from, assetId)
+	if fromBalance.Cmp(amount) < 0 {
+		return fmt.Errorf("%w: %s has %s of %s", ErrInsufficientNativeAsset, from, fromBalance, assetId)
+	}
+	setNativeAssetBalance(stateDB, from, assetId, fromBalance.Sub(fromBalance, amount))
+	toBalance := GetNativeAssetBalance(stateDB, to, assetId)
+	setNativeAssetBalance(stateDB, to, assetId, toBalance.Add(toBalance, amount))
+	return nil
+}
+
+// unpackNativeAssetInput unpacks [input] as the arguments to [method] of the native asset manager.
+func unpackNativeAssetInput(method string, input []byte) ([]interface{}, error) {
+	args, err := NativeAssetABI.Methods[method].Inputs.Unpack(input)
+	if err != nil {
+		return nil, fmt.Errorf("invalid input for %s: %w", method, err)
+	}
+	return args, nil
+}
+
+// emitNativeAssetTransferred adds a NativeAssetTransferred log of [amount] of [assetId] moving from [from] to [to].
+func emitNativeAssetTransferred(accessibleState PrecompileAccessibleState, from common.Address, to common.Address, assetId common.Hash, amount *big.Int) error {
+	event := NativeAssetABI.Events["NativeAssetTransferred"]
+	topics, err := abi.MakeTopics([]interface{}{from}, []interface{}{to}, []interface{}{assetId})
+	if err != nil {
+		return err
+	}
+	data, err := event.Inputs.NonIndexed().Pack(amount)
+	if err != nil {
+		return err
+	}
+	accessibleState.AddLog(NativeAssetManagerAddress, []common.Hash{event.ID, topics[0][0], topics[1][0], topics[2][0]}, data)
+	return nil
+}
+
+// createMintNativeAsset returns an execution function that mints the amount of the asset given as input to the
+// recipient given as input. The caller must be enabled on the allow list of [precompileAddr].
+func createMintNativeAsset(precompileAddr common.Address) RunStatefulPrecompileFunc {
+	return func(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+		if remainingGas, err = deductGas(suppliedGas, MintNativeAssetGasCost); err != nil {
+			return nil, 0, err
+		}
+
+		if readOnly {
+			return nil, remainingGas, vmerrs.ErrWriteProtection
+		}
+
+		args, err := unpackNativeAssetInput("mintNativeAsset", input)
+		if err != nil {
+			return nil, remainingGas, err
+		}
+		to := args[0].(common.Address)
+		assetId := common.Hash(args[1].([common.HashLength]byte))
+		amount := args[2].(*big.Int)
+
+		stateDB := accessibleState.GetStateDB()
+		// Verify that the caller is in the allow list and therefore has the right to mint
+		if !getAllowListStatus(stateDB, precompileAddr, caller, accessibleState.GetBlockContext().Timestamp()).IsEnabled() {
+			return revertWithReason(remainingGas, fmt.Errorf("%w: %s", ErrCannotMintNativeAsset, caller))
+		}
+		if to == (common.Address{}) {
+			return revertWithReason(remainingGas, ErrInvalidNativeAssetRecipient)
+		}
+
+		supply := GetNativeAssetTotalSupply(stateDB, assetId)
+		supply.Add(supply, amount)
+		if supply.Cmp(math.MaxBig256) > 0 {
+			return revertWithReason(remainingGas, fmt.Errorf("%w: %s", ErrNativeAssetSupplyOverflow, assetId))
+		}
+		setNativeAssetTotalSupply(stateDB, assetId, supply)
+		balance := GetNativeAssetBalance(stateDB, to, assetId)
+		setNativeAssetBalance(stateDB, to, assetId, balance.Add(balance, amount))
+
+		if err := emitNativeAssetTransferred(accessibleState, common.Address{}, to, assetId, amount); err != nil {
+			return nil, remainingGas, err
+		}
+
+		// Return an empty output and the remaining gas
+		return []byte{}, remainingGas, nil
+	}
+}
+
+// createBurnNativeAsset returns an execution function that burns the amount of the asset given as input from the
+// balance of the caller. The caller must be enabled on the allow list of [precompileAddr].
+func createBurnNativeAsset(precompileAddr common.Address) RunStatefulPrecompileFunc {
+	return func(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+		if remainingGas, err = deductGas(suppliedGas, BurnNativeAssetGasCost); err != nil {
+			return nil, 0, err
+		}
+
+		if readOnly {
+			return nil, remainingGas, vmerrs.ErrWriteProtection
+		}
+
+		args, err := unpackNativeAssetInput("burnNativeAsset", input)
+		if err != nil {
+			return nil, remainingGas, err
+		}
+		assetId := common.Hash(args[0].([common.HashLength]byte))
+		amount := args[1].(*big.Int)
+
+		stateDB := accessibleState.GetStateDB()
+		// Verify that the caller is in the allow list and therefore has the right to burn
+		if !getAllowListStatus(stateDB, precompileAddr, caller, accessibleState.GetBlockContext().Timestamp()).IsEnabled() {
+			return revertWithReason(remainingGas, fmt.Errorf("%w: %s", ErrCannotBurnNativeAsset, caller))
+		}
+
+		balance := GetNativeAssetBalance(stateDB, caller, assetId)
+		if balance.Cmp(amount) < 0 {
+			return revertWithReason(remainingGas, fmt.Errorf("%w: %s has %s of %s", ErrInsufficientNativeAsset, caller, balance, assetId))
+		}
+		setNativeAssetBalance(stateDB, caller, assetId, balance.Sub(balance, amount))
+		// The total supply is at least the balance of the caller, so it cannot underflow.
+		supply := GetNativeAssetTotalSupply(stateDB, assetId)
+		setNativeAssetTotalSupply(stateDB, assetId, supply.Sub(supply, amount))
+
+		if err := emitNativeAssetTransferred(accessibleState, caller, common.Address{}, assetId, amount); err != nil {
+			return nil, remainingGas, err
+		}
+
+		// Return an empty output and the remaining gas
+		return []byte{}, remainingGas, nil
+	}
+}
+
+// transferNativeAsset transfers the amount of the asset given as input from the balance of the caller to the
+// recipient given as input.
+func transferNativeAsset(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, TransferNativeAssetGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	if readOnly {
+		return nil, remainingGas, vmerrs.ErrWriteProtection
+	}
+
+	args, err := unpackNativeAssetInput("transferNativeAsset", input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	to := args[0].(common.Address)
+	assetId := common.Hash(args[1].([common.HashLength]byte))
+	amount := args[2].(*big.Int)
+
+	if to == (common.Address{}) {
+		return revertWithReason(remainingGas, ErrInvalidNativeAssetRecipient)
+	}
+	if err := transferNativeAssetBalance(accessibleState.GetStateDB(), caller, to, assetId, amount); err != nil {
+		return revertWithReason(remainingGas, err)
+	}
+
+	if err := emitNativeAssetTransferred(accessibleState, caller, to, assetId, amount); err != nil {
+		return nil, remainingGas, err
+	}
+
+	// Return an empty output and the remaining gas
+	return []byte{}, remainingGas, nil
+}
+
+// transferNativeAssetAndCall transfers the amount of the asset given as input from the balance of the caller to the
+// recipient given as input, and then calls the recipient with the data given as input followed by the address of the
+// caller. The value sent to the precompile is forwarded with the call, along with all but one 64th of the remaining
+// gas like the CALL opcode. The transfer reverts if the call fails.
+func transferNativeAssetAndCall(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, TransferNativeAssetAndCallGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	if readOnly {
+		return nil, remainingGas, vmerrs.ErrWriteProtection
+	}
+
+	args, err := unpackNativeAssetInput("transferNativeAssetAndCall", input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	to := args[0].(common.Address)
+	assetId := common.Hash(args[1].([common.HashLength]byte))
+	amount := args[2].(*big.Int)
+	data := args[3].([]byte)
+
+	if to == (common.Address{}) {
+		return revertWithReason(remainingGas, ErrInvalidNativeAssetRecipient)
+	}
+	if err := transferNativeAssetBalance(accessibleState.GetStateDB(), caller, to, assetId, amount); err != nil {
+		return revertWithReason(remainingGas, err)
+	}
+
+	if err := emitNativeAssetTransferred(accessibleState, caller, to, assetId, amount); err != nil {
+		return nil, remainingGas, err
+	}
+
+	callInput := make([]byte, 0, len(data)+common.AddressLength)
+	callInput = append(callInput, data...)
+	callInput = append(callInput, caller.Bytes()...)
+	gas := callGas(remainingGas)
+	callRet, leftOverGas, err := accessibleState.Call(to, callInput, gas, accessibleState.Value())
+	remainingGas = remainingGas - gas + leftOverGas
+	if err != nil {
+		// Bubble up the revert reason of the callee
+		if err == vmerrs.ErrExecutionReverted {
+			return callRet, remainingGas, err
+		}
+		return revertWithReason(remainingGas, fmt.Errorf("%w: %v", ErrNativeAssetCallFailed, err))
+	}
+
+	// Return the output of the call and the remaining gas
+	output, err := NativeAssetABI.Methods["transferNativeAssetAndCall"].Outputs.Pack(callRet)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	return output, remainingGas, nil
+}
+
+// getNativeAssetBalance returns the balance of the asset given as input held by the account given as input.
+func getNativeAssetBalance(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, GetNativeAssetBalanceGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	args, err := unpackNativeAssetInput("nativeAssetBalance", input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	account := args[0].(common.Address)
+	assetId := common.Hash(args[1].([common.HashLength]byte))
+
+	return common.BigToHash(GetNativeAssetBalance(accessibleState.GetStateDB(), account, assetId)).Bytes(), remainingGas, nil
+}
+
+// getNativeAssetTotalSupply returns the total supply of the asset given as input.
+func getNativeAssetTotalSupply(accessibleState PrecompileAccessibleState, caller common.Address, addr common.Address, input []byte, suppliedGas uint64, readOnly bool) (ret []byte, remainingGas uint64, err error) {
+	if remainingGas, err = deductGas(suppliedGas, GetNativeAssetBalanceGasCost); err != nil {
+		return nil, 0, err
+	}
+
+	args, err := unpackNativeAssetInput("nativeAssetTotalSupply", input)
+	if err != nil {
+		return nil, remainingGas, err
+	}
+	assetId := common.Hash(args[0].([common.HashLength]byte))
+
+	return common.BigToHash(GetNativeAssetTotalSupply(accessibleState.GetStateDB(), assetId)).Bytes(), remainingGas, nil
+}
+
+// PackMintNativeAsset packs [to], [assetId] and [amount] into the input data to the mintNativeAsset function
+func PackMintNativeAsset(to common.Address, assetId common.Hash, amount *big.Int) []byte {
+	input, err := NativeAssetABI.Pack("mintNativeAsset", to, assetId, amount)
+	if err != nil {
+		panic(err)
+	}
+	return input
+}
+
+// PackBurnNativeAsset packs [assetId] and [amount] into the input data to the burnNativeAsset function
+func PackBurnNativeAsset(assetId common.Hash, amount *big.Int) []byte {
+	input, err := NativeAssetABI.Pack("burnNativeAsset", assetId, amount)
+	if err != nil {
+		panic(err)
+	}
+	return input
+}
+
+// PackTransferNativeAsset packs [to], [assetId] and [amount] into the input data to the transferNativeAsset function
+func PackTransferNativeAsset(to common.Address, assetId common.Hash, amount *big.Int) []byte {
+	input, err := NativeAssetABI.Pack("transferNativeAsset", to, assetId, amount)
+	if err != nil {
+		panic(err)
+	}
+	return input
+}
+
+// PackTransferNativeAssetAndCall packs [to], [assetId], [amount] and [data] into the input data to the
+// transferNativeAssetAndCall function
+func PackTransferNativeAssetAndCall(to common.Address, assetId common.Hash, amount *big.Int, data []byte) []byte {
+	input, err := NativeAssetABI.Pack("transferNativeAssetAndCall", to, assetId, amount, data)
+	if err != nil {
+		panic(err)
+	}
+	return input
+}
+
+// PackNativeAssetBalance packs [account] and [assetId] into the input data to the nativeAssetBalance function
+func PackNativeAssetBalance(account common.Address, assetId common.Hash) []byte {
+	input, err := NativeAssetABI.Pack("nativeAssetBalance", account, assetId)
+	if err != nil {
+		panic(err)
+	}
+	return input
+}
+
+// PackNativeAssetTotalSupply packs [assetId] into the input data to the nativeAssetTotalSupply function
+func PackNativeAssetTotalSupply(assetId common.Hash) []byte {
+	input, err := NativeAssetABI.Pack("nativeAssetTotalSupply", assetId)
+	if err != nil {
+		panic(err)
+	}
+	return input
+}
+
+// UnpackTransferNativeAssetAndCallOutput unpacks the output of the call made by transferNativeAssetAndCall from [output].
+func UnpackTransferNativeAssetAndCallOutput(output []byte) ([]byte, error) {
+	res, err := NativeAssetABI.Unpack("transferNativeAssetAndCall", output)
+	if err != nil {
+		return nil, err
+	}
+	return res[0].([]byte), nil
+}
+
+// createNativeAssetManagerPrecompile returns a StatefulPrecompiledContract with R/W control of an allow list at
+// [precompileAddr] and a ledger of native assets.
+func createNativeAssetManagerPrecompile(precompileAddr common.Address) StatefulPrecompiledContract {
+	enabledFuncs := createAllowListFunctions(precompileAddr)
+	enabledFuncs = append(enabledFuncs,
+		newStatefulPrecompileFunction(mintNativeAssetSignature, createMintNativeAsset(precompileAddr)),
+		newStatefulPrecompileFunction(burnNativeAssetSignature, createBurnNativeAsset(precompileAddr)),
+		newStatefulPrecompileFunction(transferNativeAssetSignature, transferNativeAsset),
+		newStatefulPrecompileFunction(transferNativeAssetAndCallSignature, transferNativeAssetAndCall),
+		newStatefulPrecompileFunction(nativeAssetBalanceSignature, getNativeAssetBalance),
+		newStatefulPrecompileFunction(nativeAssetTotalSupplySignature, getNativeAssetTotalSupply),
+	)
+	// Construct the contract with no fallback function.
+	contract := newStatefulPrecompileWithFunctionSelectors(nil, enabledFuncs)
+	return contract
+}
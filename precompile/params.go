@@ -10,22 +10,83 @@ const (
 	writeGasCostPerSlot = 20_000
 	readGasCostPerSlot  = 5_000
 
-	ModifyAllowListGasCost = writeGasCostPerSlot
-	ReadAllowListGasCost   = readGasCostPerSlot
+	// Gas costs of emitting logs, matching the LOG opcodes in params/protocol_params.go
+	logGas      = 375
+	logTopicGas = 375
+	logDataGas  = 8
 
-	RegisterAssetGasCost = writeGasCostPerSlot
-	GetAssetGasCost      = readGasCostPerSlot
+	// AllowListEventGasCost is charged for the RoleSet event emitted on every change of the allow list, for the log,
+	// its topics and the expiry in its data.
+	AllowListEventGasCost      = logGas + 4*logTopicGas + common.HashLength*logDataGas
+	ModifyAllowListGasCost     = readGasCostPerSlot*4 + writeGasCostPerSlot*7 + AllowListEventGasCost // the roles and expiries of the caller and the address, and moving the last role holder into a removed one
+	ReadAllowListGasCost       = readGasCostPerSlot * 2                                               // role and expiry
+	ReadAllowListExpiryGasCost = readGasCostPerSlot
+	ReadRoleHolderCountGasCost = readGasCostPerSlot
+	ReadRoleHolderAtGasCost    = readGasCostPerSlot * 4 // count, address, role and expiry
 
-	MintGasCost = 30_000
+	// Gas costs of modifying and reading the allow list before the PrecompileUpgrade, which only read and write the role.
+	LegacyModifyAllowListGasCost = writeGasCostPerSlot
+	LegacyReadAllowListGasCost   = readGasCostPerSlot
 
-	SetFeeConfigGasCost     = writeGasCostPerSlot * (numFeeConfigField + 1) // plus one for setting last changed at
-	GetFeeConfigGasCost     = readGasCostPerSlot * numFeeConfigField
-	GetLastChangedAtGasCost = readGasCostPerSlot
+	// AssetEventGasCost is charged by every asset function that emits an event. The variable length fields in the
+	// event data are already charged per storage slot when they are written, so only the log and its topics are charged.
+	AssetEventGasCost = logGas + 3*logTopicGas
+
+	RegisterAssetGasCost     = writeGasCostPerSlot + AssetEventGasCost
+	GetAssetGasCost          = readGasCostPerSlot
+	AssetReadGasCostPerSlot  = readGasCostPerSlot                     // charged for every slot of every asset returned by a list query
+	UpdateAssetBaseGasCost   = readGasCostPerSlot + AssetEventGasCost // plus AssetWriteGasCostPerSlot for every storage slot written
+	AssetWriteGasCostPerSlot = writeGasCostPerSlot
+	TransferAssetGasCost     = readGasCostPerSlot + writeGasCostPerSlot*10 + AssetEventGasCost // owner, approval and both owner indices, plus AssetEventGasCost if an approval is cleared
+	ApproveAssetGasCost      = readGasCostPerSlot + writeGasCostPerSlot + AssetEventGasCost
+	SetApprovalForAllGasCost = writeGasCostPerSlot + AssetEventGasCost
+	BurnAssetBaseGasCost     = readGasCostPerSlot + writeGasCostPerSlot*13 + AssetEventGasCost // plus AssetWriteGasCostPerSlot for every string slot cleared and AssetEventGasCost if an approval is cleared
+
+	GetLocationHistoryBaseGasCost    = readGasCostPerSlot // plus LocationRecordReadGasCostPerSlot for every slot of the returned records
+	LocationRecordReadGasCostPerSlot = readGasCostPerSlot
+
+	MintGasCost                 = 30_000
+	BurnGasCost                 = 30_000
+	ReadNativeCoinSupplyGasCost = readGasCostPerSlot
+
+	// NativeAssetEventGasCost is charged by every native asset function that emits an event, for the log, its topics
+	// and the amount in its data.
+	NativeAssetEventGasCost           = logGas + 4*logTopicGas + common.HashLength*logDataGas
+	MintNativeAssetGasCost            = readGasCostPerSlot*2 + writeGasCostPerSlot*2 + NativeAssetEventGasCost // balance and total supply
+	BurnNativeAssetGasCost            = readGasCostPerSlot*2 + writeGasCostPerSlot*2 + NativeAssetEventGasCost // balance and total supply
+	TransferNativeAssetGasCost        = readGasCostPerSlot*2 + writeGasCostPerSlot*2 + NativeAssetEventGasCost // both balances
+	TransferNativeAssetAndCallGasCost = TransferNativeAssetGasCost                                             // plus the gas used by the call
+	GetNativeAssetBalanceGasCost      = readGasCostPerSlot
+
+	// ContractCallEventGasCost is charged by every function of the contract call allow list that emits an event,
+	// for the log, its topics and the flag in its data.
+	ContractCallEventGasCost     = logGas + 4*logTopicGas + common.HashLength*logDataGas
+	RestrictFunctionGasCost      = readGasCostPerSlot + writeGasCostPerSlot + ContractCallEventGasCost // generation and restriction
+	SetFunctionCallerGasCost     = readGasCostPerSlot + writeGasCostPerSlot + ContractCallEventGasCost // generation and caller
+	IsFunctionRestrictedGasCost  = readGasCostPerSlot * 2                                              // generation and restriction
+	IsFunctionCallAllowedGasCost = readGasCostPerSlot * 3                                              // generation, restriction and caller
+
+	// FeeConfigEventGasCost is charged by setFeeConfig for its event, for the log, the sender topic and the fields of the
+	// fee config in its data. The event of scheduleFeeConfig also has the effective at in its data.
+	FeeConfigEventGasCost          = logGas + 2*logTopicGas + numFeeConfigField*common.HashLength*logDataGas
+	FeeConfigScheduledEventGasCost = FeeConfigEventGasCost + common.HashLength*logDataGas
+	FeeConfigCancelledEventGasCost = logGas + 2*logTopicGas
+
+	FeeConfigBoundsGasCost        = readGasCostPerSlot * 2 * numFeeConfigField // min and max of every field
+	SetFeeConfigGasCost           = LegacySetFeeConfigGasCost + FeeConfigBoundsGasCost + FeeConfigEventGasCost
+	ScheduleFeeConfigGasCost      = writeGasCostPerSlot*(numFeeConfigField+1) + FeeConfigBoundsGasCost + FeeConfigScheduledEventGasCost // plus one for setting effective at
+	CancelPendingFeeConfigGasCost = writeGasCostPerSlot + FeeConfigCancelledEventGasCost
+	GetFeeConfigGasCost           = readGasCostPerSlot * numFeeConfigField
+	GetPendingFeeConfigGasCost    = readGasCostPerSlot * (numFeeConfigField + 1) // plus one for reading effective at
+	GetLastChangedAtGasCost       = readGasCostPerSlot
+
+	// Gas cost of setting the fee config before the PrecompileUpgrade, which neither reads the bounds nor emits an event.
+	LegacySetFeeConfigGasCost = writeGasCostPerSlot * (numFeeConfigField + 1) // plus one for setting last changed at
 )
 
 // Designated addresses of stateful precompiles
-// Note: it is important that none of these addresses conflict with each other or any other precompiles
-// in core/vm/contracts.go.
+// Note: RegisterModule ensures that none of these addresses conflict with each other and that they fall
+// within the ranges reserved for stateful precompiles, which do not overlap the precompiles in core/vm/contracts.go.
 // We start at 0x0200000000000000000000000000000000000000 and will increment by 1 from here to reduce
 // the risk of conflicts.
 // For forks of subnet-evm, users should start at 0x0300000000000000000000000000000000000000 to ensure
@@ -37,12 +98,6 @@ var (
 	TxAllowListAddress               = common.HexToAddress("0x0200000000000000000000000000000000000002")
 	FeeConfigManagerAddress          = common.HexToAddress("0x0200000000000000000000000000000000000003")
 	ContractDeployerAssetAddress     = common.HexToAddress("0x0300000000000000000000000000000000000000")
-
-	UsedAddresses = []common.Address{
-		ContractDeployerAllowListAddress,
-		ContractNativeMinterAddress,
-		TxAllowListAddress,
-		FeeConfigManagerAddress,
-		ContractDeployerAssetAddress,
-	}
+	NativeAssetManagerAddress        = common.HexToAddress("0x0300000000000000000000000000000000000001")
+	ContractCallAllowListAddress     = common.HexToAddress("0x0300000000000000000000000000000000000002")
 )
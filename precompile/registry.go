@@ -0,0 +1,120 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package precompile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	ErrInvalidModule = errors.New("invalid stateful precompile module")
+
+	// reservedRanges are the address ranges stateful precompiles may be registered in. They do not
+	// overlap the native precompiles in core/vm/contracts.go or the blackhole address.
+	reservedRanges = []AddressRange{
+		{
+			Start: common.HexToAddress("0x0200000000000000000000000000000000000000"),
+			End:   common.HexToAddress("0x02000000000000000000000000000000000000ff"),
+		},
+		{
+			Start: common.HexToAddress("0x0300000000000000000000000000000000000000"),
+			End:   common.HexToAddress("0x03000000000000000000000000000000000000ff"),
+		},
+	}
+
+	// registeredModules is sorted by address so that precompiles are always iterated
+	// in the same order.
+	registeredModules = make([]Module, 0)
+)
+
+// AddressRange represents a continuous range of addresses
+type AddressRange struct {
+	Start common.Address
+	End   common.Address
+}
+
+// Contains returns true iff [addr] is contained within the (inclusive) range
+func (a *AddressRange) Contains(addr common.Address) bool {
+	addrBytes := addr.Bytes()
+	return bytes.Compare(addrBytes, a.Start[:]) >= 0 && bytes.Compare(addrBytes, a.End[:]) <= 0
+}
+
+// Module describes a stateful precompile that can be enabled by the chain config and network upgrades.
+type Module struct {
+	// ConfigKey is the JSON key of the precompile's config in the chain config and in network upgrades.
+	ConfigKey string
+	// Address is the address where the stateful precompile is accessible.
+	Address common.Address
+	// NewConfig returns a new, empty config of the precompile that JSON can be decoded into.
+	NewConfig func() StatefulPrecompileConfig
+}
+
+// RegisterModule registers [module] so that its config is recognized in the chain config and network
+// upgrades. Modules should be registered from an init function. Returns an error if the config key or
+// address is already in use, or if the address is outside of the reserved ranges.
+func RegisterModule(module Module) error {
+	if module.ConfigKey == "" {
+		return fmt.Errorf("%w: missing config key", ErrInvalidModule)
+	}
+	if module.NewConfig == nil {
+		return fmt.Errorf("%w: missing config constructor for %s", ErrInvalidModule, module.ConfigKey)
+	}
+	if !IsReservedAddress(module.Address) {
+		return fmt.Errorf("%w: address %s of %s is not in a reserved range", ErrInvalidModule, module.Address, module.ConfigKey)
+	}
+	for _, registered := range registeredModules {
+		if registered.ConfigKey == module.ConfigKey {
+			return fmt.Errorf("%w: config key %s is already registered", ErrInvalidModule, module.ConfigKey)
+		}
+		if registered.Address == module.Address {
+			return fmt.Errorf("%w: address %s of %s is already registered by %s", ErrInvalidModule, module.Address, module.ConfigKey, registered.ConfigKey)
+		}
+	}
+
+	registeredModules = append(registeredModules, module)
+	sort.Slice(registeredModules, func(i, j int) bool {
+		return bytes.Compare(registeredModules[i].Address[:], registeredModules[j].Address[:]) < 0
+	})
+	return nil
+}
+
+// IsReservedAddress returns true if [addr] is in a range reserved for stateful precompiles.
+func IsReservedAddress(addr common.Address) bool {
+	for _, reservedRange := range reservedRanges {
+		if reservedRange.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetRegisteredModule returns the module registered with [configKey].
+func GetRegisteredModule(configKey string) (Module, bool) {
+	for _, module := range registeredModules {
+		if module.ConfigKey == configKey {
+			return module, true
+		}
+	}
+	return Module{}, false
+}
+
+// GetRegisteredModuleByAddress returns the module registered at [addr].
+func GetRegisteredModuleByAddress(addr common.Address) (Module, bool) {
+	for _, module := range registeredModules {
+		if module.Address == addr {
+			return module, true
+		}
+	}
+	return Module{}, false
+}
+
+// RegisteredModules returns the registered modules sorted by address.
+func RegisteredModules() []Module {
+	return registeredModules
+}
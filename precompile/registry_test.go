@@ -0,0 +1,45 @@
+// (c) 2019-2020, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package precompile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"gotest.tools/assert"
+)
+
+func TestRegisterModule(t *testing.T) {
+	newConfig := func() StatefulPrecompileConfig { return new(TxAllowListConfig) }
+
+	for name, module := range map[string]Module{
+		"duplicate config key":            {ConfigKey: TxAllowListConfigKey, Address: common.HexToAddress("0x02000000000000000000000000000000000000ff"), NewConfig: newConfig},
+		"duplicate address":               {ConfigKey: "duplicateAddressConfig", Address: TxAllowListAddress, NewConfig: newConfig},
+		"address outside reserved ranges": {ConfigKey: "nativeAddressConfig", Address: common.HexToAddress("0x0000000000000000000000000000000000000001"), NewConfig: newConfig},
+		"blackhole address":               {ConfigKey: "blackholeConfig", Address: common.HexToAddress("0x0100000000000000000000000000000000000000"), NewConfig: newConfig},
+		"missing config key":              {Address: common.HexToAddress("0x02000000000000000000000000000000000000ff"), NewConfig: newConfig},
+		"missing config constructor":      {ConfigKey: "noConstructorConfig", Address: common.HexToAddress("0x02000000000000000000000000000000000000ff")},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := RegisterModule(module)
+			assert.Assert(t, errors.Is(err, ErrInvalidModule), "expected ErrInvalidModule, got %v", err)
+		})
+	}
+
+	// The modules of this package are registered in order of their address.
+	modules := RegisteredModules()
+	for i := 1; i < len(modules); i++ {
+		assert.Assert(t, modules[i-1].Address.Hash().Big().Cmp(modules[i].Address.Hash().Big()) < 0)
+	}
+
+	module, ok := GetRegisteredModule(FeeConfigManagerConfigKey)
+	assert.Assert(t, ok)
+	assert.Equal(t, FeeConfigManagerAddress, module.Address)
+	module, ok = GetRegisteredModuleByAddress(ContractDeployerAssetAddress)
+	assert.Assert(t, ok)
+	assert.Equal(t, ContractDeployerAssetConfigKey, module.ConfigKey)
+	_, ok = module.NewConfig().(*ContractDeployerAssetConfig)
+	assert.Assert(t, ok)
+}
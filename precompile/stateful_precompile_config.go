@@ -7,8 +7,6 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
-
-	"github.com/ir4tech/webb-evm/utils"
 )
 
 // StatefulPrecompileConfig defines the interface for a stateful precompile to
@@ -20,6 +18,12 @@ type StatefulPrecompileConfig interface {
 	// 2) n indicates that the precompile should be enabled in the first block with timestamp >= [n].
 	// 3) nil indicates that the precompile is never enabled.
 	Timestamp() *big.Int
+	// IsDisabled returns true if this config disables the precompile at [Timestamp] rather than enabling it.
+	// Disabling configs are only meaningful as network upgrades of a previously enabled precompile.
+	IsDisabled() bool
+	// Verify returns an error if the config is invalid. It is called when the chain config and network
+	// upgrades are loaded, so that Configure does not need to handle invalid input.
+	Verify() error
 	// Configure is called on the first block where the stateful precompile should be enabled.
 	// This allows the stateful precompile to configure its own state via [StateDB] and [BlockContext] as necessary.
 	// This function must be deterministic since it will impact the EVM state. If a change to the
@@ -29,30 +33,50 @@ type StatefulPrecompileConfig interface {
 	// Configure is called on the first block where the stateful precompile should be enabled. This
 	// provides the config the ability to set its initial state and should only modify the state within
 	// its own address space.
+	// Configure is also called when a network upgrade reconfigures an enabled precompile, so state that
+	// is only seeded on activation, rather than derived from the config, must be guarded with [isConfigured].
 	Configure(ChainConfig, StateDB, BlockContext)
+	// Disable is called on the first block where a network upgrade disables the stateful precompile.
+	// It must deterministically remove the code, nonce and storage of the precompile's address, so that
+	// re-enabling the precompile later calls Configure on a clean slate.
+	Disable(ChainConfig, StateDB, BlockContext)
 	// Contract returns a thread-safe singleton that can be used as the StatefulPrecompiledContract when
 	// this config is enabled.
 	Contract() StatefulPrecompiledContract
 }
 
-// CheckConfigure checks if [config] is activated by the transition from block at [parentTimestamp] to [currentTimestamp].
-// If it does, then it calls Configure on [config] to make the necessary state update to enable the StatefulPrecompile.
-// Note: this function is called within genesis to configure the starting state if it [config] specifies that it should be
-// configured at genesis, or happens during block processing to update the state before processing the given block.
-// TODO: add ability to call Configure at different timestamps, so that developers can easily re-configure by updating the
-// stateful precompile config.
-// Assumes that [config] is non-nil.
-func CheckConfigure(chainConfig ChainConfig, parentTimestamp *big.Int, blockContext BlockContext, precompileConfig StatefulPrecompileConfig, state StateDB) {
-	forkTimestamp := precompileConfig.Timestamp()
-	// If the network upgrade goes into effect within this transition, configure the stateful precompile
-	if utils.IsForkTransition(forkTimestamp, parentTimestamp, blockContext.Timestamp()) {
-		// Set the nonce of the precompile's address (as is done when a contract is created) to ensure
-		// that it is marked as non-empty and will not be cleaned up when the statedb is finalized.
-		state.SetNonce(precompileConfig.Address(), 1)
-		// Set the code of the precompile's address to a non-zero length byte slice to ensure that the precompile
-		// can be called from within Solidity contracts. Solidity adds a check before invoking a contract to ensure
-		// that it does not attempt to invoke a non-existent contract.
-		state.SetCode(precompileConfig.Address(), []byte{0x1})
-		precompileConfig.Configure(chainConfig, state, blockContext)
-	}
+// Configure activates [precompileConfig] in [state]: it marks the precompile's address as a non-empty
+// account and calls Configure on [precompileConfig] to set up its initial state.
+// Note: this function is called within genesis to configure the starting state if [precompileConfig] specifies
+// that it should be configured at genesis, or during block processing to update the state before processing
+// the block in which [precompileConfig] goes into effect, either as an activation or a reconfiguration.
+// Assumes that [precompileConfig] is non-nil and does not disable the precompile.
+func Configure(chainConfig ChainConfig, blockContext BlockContext, precompileConfig StatefulPrecompileConfig, state StateDB) {
+	// Configure the precompile before its nonce is set, so that it can tell an activation from a
+	// reconfiguration with isConfigured.
+	precompileConfig.Configure(chainConfig, state, blockContext)
+	// Set the nonce of the precompile's address (as is done when a contract is created) to ensure
+	// that it is marked as non-empty and will not be cleaned up when the statedb is finalized.
+	state.SetNonce(precompileConfig.Address(), 1)
+	// Set the code of the precompile's address to a non-zero length byte slice to ensure that the precompile
+	// can be called from within Solidity contracts. Solidity adds a check before invoking a contract to ensure
+	// that it does not attempt to invoke a non-existent contract.
+	state.SetCode(precompileConfig.Address(), []byte{0x1})
+}
+
+// isConfigured returns true if the precompile at [precompileAddr] is already enabled in [state], that is, if
+// Configure is reconfiguring it rather than activating it. Since Disable resets the nonce, a precompile
+// re-enabled after being disabled is activated again.
+func isConfigured(state StateDB, precompileAddr common.Address) bool {
+	return state.GetNonce(precompileAddr) != 0
+}
+
+// clearPrecompileState resets the account at [precompileAddr] in [state], removing its code, nonce and
+// storage while carrying over its balance. The emptied account is removed from the state when the
+// statedb is finalized unless it holds a balance.
+func clearPrecompileState(state StateDB, precompileAddr common.Address) {
+	state.CreateAccount(precompileAddr)
+	// Replacing the account alone does not mark it as dirty, so explicitly set the nonce to ensure
+	// the new account is written to the state trie when the statedb is finalized.
+	state.SetNonce(precompileAddr, 0)
 }
@@ -5,6 +5,7 @@ package precompile
 
 import (
 	"errors"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
 )
@@ -17,6 +18,19 @@ var (
 	ErrSenderAddressNotAllowListed = errors.New("cannot issue transaction from non-allow listed address")
 )
 
+// TxAllowListConfigKey is the key of the config in the chain config and network upgrades.
+const TxAllowListConfigKey = "txAllowListConfig"
+
+func init() {
+	if err := RegisterModule(Module{
+		ConfigKey: TxAllowListConfigKey,
+		Address:   TxAllowListAddress,
+		NewConfig: func() StatefulPrecompileConfig { return new(TxAllowListConfig) },
+	}); err != nil {
+		panic(err)
+	}
+}
+
 // TxAllowListConfig wraps [AllowListConfig] and uses it to implement the StatefulPrecompileConfig
 // interface while adding in the TxAllowList specific precompile address.
 type TxAllowListConfig struct {
@@ -29,8 +43,13 @@ func (c *TxAllowListConfig) Address() common.Address {
 }
 
 // Configure configures [state] with the desired admins based on [c].
-func (c *TxAllowListConfig) Configure(_ ChainConfig, state StateDB, _ BlockContext) {
-	c.AllowListConfig.Configure(state, TxAllowListAddress)
+func (c *TxAllowListConfig) Configure(chainConfig ChainConfig, state StateDB, blockContext BlockContext) {
+	c.AllowListConfig.Configure(chainConfig, state, blockContext, TxAllowListAddress)
+}
+
+// Disable clears the roles and any other state of the tx allow list from [state].
+func (c *TxAllowListConfig) Disable(_ ChainConfig, state StateDB, _ BlockContext) {
+	clearPrecompileState(state, TxAllowListAddress)
 }
 
 // Contract returns the singleton stateful precompiled contract to be used for the allow list.
@@ -40,8 +59,9 @@ func (c *TxAllowListConfig) Contract() StatefulPrecompiledContract {
 
 // GetTxAllowListStatus returns the role of [address] for the contract deployer
 // allow list.
-func GetTxAllowListStatus(stateDB StateDB, address common.Address) AllowListRole {
-	return getAllowListStatus(stateDB, TxAllowListAddress, address)
+// [address] has no role if its role has expired by the block [timestamp].
+func GetTxAllowListStatus(stateDB StateDB, address common.Address, timestamp *big.Int) AllowListRole {
+	return getAllowListStatus(stateDB, TxAllowListAddress, address, timestamp)
 }
 
 // SetTxAllowListStatus sets the permissions of [address] to [role] for the
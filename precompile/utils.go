@@ -5,6 +5,7 @@ package precompile
 
 import (
 	"fmt"
+	"math/big"
 	"regexp"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -12,7 +13,12 @@ import (
 	"github.com/ir4tech/webb-evm/vmerrs"
 )
 
-var functionSignatureRegex = regexp.MustCompile(`[\w]+\(((([\w]+)?)|((([\w]+),)+([\w]+)))\)`)
+var (
+	functionSignatureRegex = regexp.MustCompile(`[\w]+\(((([\w]+)?)|((([\w]+),)+([\w]+)))\)`)
+
+	// revertSelector is the selector of solidity's Error(string) used to encode revert reasons.
+	revertSelector = CalculateFunctionSelector("Error(string)")
+)
 
 // CalculateFunctionSelector returns the 4 byte function selector that results from [functionSignature]
 // Ex. the function setBalance(addr address, balance uint256) should be passed in as the string:
@@ -33,6 +39,33 @@ func deductGas(suppliedGas uint64, requiredGas uint64) (uint64, error) {
 	return suppliedGas - requiredGas, nil
 }
 
+// callGas returns the gas forwarded by a call made with [availableGas], which is all but one 64th of it as for the
+// CALL opcode since EIP-150.
+func callGas(availableGas uint64) uint64 {
+	return availableGas - availableGas/64
+}
+
+// revertWithReason returns the output and error of a precompile call that reverts with [reason]. The reason is packed
+// as solidity's Error(string) so that it is surfaced to callers and in receipts, and unlike other errors the
+// [remainingGas] is not consumed.
+func revertWithReason(remainingGas uint64, reason error) ([]byte, uint64, error) {
+	return PackRevertReason(reason), remainingGas, vmerrs.ErrExecutionReverted
+}
+
+// PackRevertReason packs [reason] as solidity's Error(string), the output of a call that reverts with [reason].
+func PackRevertReason(reason error) []byte {
+	reasonBytes := []byte(reason.Error())
+	paddedLen := (len(reasonBytes) + common.HashLength - 1) / common.HashLength * common.HashLength
+	ret := make([]byte, selectorLen+2*common.HashLength+paddedLen)
+	copy(ret, revertSelector)
+	packOrderedHashes(ret[selectorLen:selectorLen+2*common.HashLength], []common.Hash{
+		common.BigToHash(big.NewInt(common.HashLength)),
+		common.BigToHash(big.NewInt(int64(len(reasonBytes)))),
+	})
+	copy(ret[selectorLen+2*common.HashLength:], reasonBytes)
+	return ret
+}
+
 // packOrderedHashesWithSelector packs the function selector and ordered list of hashes into [dst]
 // byte slice.
 // assumes that [dst] has sufficient room for [functionSelector] and [hashes].
@@ -67,3 +100,19 @@ func returnPackedHash(packed []byte, index int) []byte {
 	end := start + common.HashLength
 	return packed[start:end]
 }
+
+// storageKey returns the storage key for [prefix] and the concatenation of [parts]. Precompiles derive the keys of
+// their mappings and lists with it, using a distinct [prefix] for each of them.
+func storageKey(prefix byte, parts ...[]byte) common.Hash {
+	data := make([]byte, 0, 1+len(parts)*common.HashLength)
+	data = append(data, prefix)
+	for _, part := range parts {
+		data = append(data, part...)
+	}
+	return crypto.Keccak256Hash(data)
+}
+
+// indexBytes returns the 32 byte big endian encoding of [index] to be used in a storage key.
+func indexBytes(index uint64) []byte {
+	return common.BigToHash(new(big.Int).SetUint64(index)).Bytes()
+}
@@ -0,0 +1,219 @@
+// (c) 2021-2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package statesync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ava-labs/avalanchego/codec"
+	"github.com/ava-labs/avalanchego/version"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ir4tech/webb-evm/core/types"
+	"github.com/ir4tech/webb-evm/peer"
+	"github.com/ir4tech/webb-evm/plugin/evm/message"
+)
+
+const (
+	// maxRetryAttempts is the number of times a request is sent to peers
+	// before the client gives up on it.
+	maxRetryAttempts = 32
+
+	// retryDelay is the time the client waits before retrying a failed request.
+	retryDelay = 100 * time.Millisecond
+)
+
+var (
+	_ Client = &client{}
+
+	errEmptyResponse         = errors.New("empty response")
+	errHashMismatch          = errors.New("hash does not match expected value")
+	errTooManyBlocks         = errors.New("response contains more than requested blocks")
+	errExceededRetryAttempts = errors.New("exceeded maximum retry attempts")
+)
+
+// Client fetches state sync data from peers and verifies it before returning it
+// to the caller. Requests that fail or return data that cannot be verified are
+// retried against other peers.
+type Client interface {
+	// GetLeafs returns the leaves of the trie at request.Root within the range
+	// [request.Start, request.End], after verifying the range proof attached to
+	// the response. The More flag of the returned response is set if the trie
+	// has more leaves to the right of the last returned leaf.
+	GetLeafs(ctx context.Context, request message.LeafsRequest) (message.LeafsResponse, error)
+
+	// GetCode returns the contract code with the given hash.
+	GetCode(ctx context.Context, hash common.Hash) ([]byte, error)
+
+	// GetBlocks returns the block with the given hash and height followed by up
+	// to [parents]-1 of its ancestors, newest first.
+	GetBlocks(ctx context.Context, hash common.Hash, height uint64, parents uint16) ([]*types.Block, error)
+}
+
+// client implements Client over a peer.Client, sending each request to any
+// peer running at least [minVersion].
+type client struct {
+	networkClient peer.Client
+	codec         codec.Manager
+	minVersion    *version.Application
+}
+
+// NewClient returns a Client sending requests through [networkClient] to peers
+// running at least [minVersion]. If [minVersion] is nil, requests are sent to
+// any connected peer.
+func NewClient(networkClient peer.Client, codec codec.Manager, minVersion *version.Application) Client {
+	return &client{
+		networkClient: networkClient,
+		codec:         codec,
+		minVersion:    minVersion,
+	}
+}
+
+func (c *client) GetLeafs(ctx context.Context, request message.LeafsRequest) (message.LeafsResponse, error) {
+	var leafsResponse message.LeafsResponse
+	err := c.get(ctx, request, func(response []byte) error {
+		var err error
+		leafsResponse, err = parseLeafsResponse(c.codec, request, response)
+		return err
+	})
+	return leafsResponse, err
+}
+
+func (c *client) GetCode(ctx context.Context, hash common.Hash) ([]byte, error) {
+	var code []byte
+	err := c.get(ctx, message.NewCodeRequest(hash), func(response []byte) error {
+		var err error
+		code, err = parseCodeResponse(c.codec, hash, response)
+		return err
+	})
+	return code, err
+}
+
+func (c *client) GetBlocks(ctx context.Context, hash common.Hash, height uint64, parents uint16) ([]*types.Block, error) {
+	request := message.BlockRequest{
+		Hash:    hash,
+		Height:  height,
+		Parents: parents,
+	}
+	var blocks []*types.Block
+	err := c.get(ctx, request, func(response []byte) error {
+		var err error
+		blocks, err = parseBlockResponse(c.codec, request, response)
+		return err
+	})
+	return blocks, err
+}
+
+// get sends [request] to any peer matching [c.minVersion] and passes the
+// response to [parseFn], retrying until [parseFn] succeeds, [ctx] is done or
+// [maxRetryAttempts] is exceeded.
+func (c *client) get(ctx context.Context, request message.Request, parseFn func([]byte) error) error {
+	requestBytes, err := message.RequestToBytes(c.codec, request)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; attempt < maxRetryAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(retryDelay):
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		response, nodeID, err := c.networkClient.RequestAny(c.minVersion, requestBytes)
+		if err != nil {
+			log.Debug("state sync request failed, retrying", "request", request, "nodeID", nodeID, "attempt", attempt, "err", err)
+			continue
+		}
+		if err := parseFn(response); err != nil {
+			// An empty response is how a peer declines a request for data it
+			// does not have, which is not held against it.
+			if errors.Is(err, errEmptyResponse) {
+				log.Debug("peer does not have requested state sync data, retrying", "request", request, "nodeID", nodeID, "attempt", attempt)
+				continue
+			}
+			log.Info("could not verify state sync response, retrying", "request", request, "nodeID", nodeID, "attempt", attempt, "err", err)
+			c.networkClient.TrackInvalidResponse(nodeID)
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s", errExceededRetryAttempts, request)
+}
+
+// parseLeafsResponse unmarshals [responseBytes] into a message.LeafsResponse
+// and verifies it against [request] with VerifyLeafsResponse.
+func parseLeafsResponse(codec codec.Manager, request message.LeafsRequest, responseBytes []byte) (message.LeafsResponse, error) {
+	var leafsResponse message.LeafsResponse
+	if len(responseBytes) == 0 {
+		return leafsResponse, errEmptyResponse
+	}
+	if _, err := codec.Unmarshal(responseBytes, &leafsResponse); err != nil {
+		return leafsResponse, err
+	}
+
+	err := VerifyLeafsResponse(request, &leafsResponse)
+	return leafsResponse, err
+}
+
+// parseCodeResponse unmarshals [responseBytes] into a message.CodeResponse and
+// verifies that the returned code hashes to [hash].
+func parseCodeResponse(codec codec.Manager, hash common.Hash, responseBytes []byte) ([]byte, error) {
+	if len(responseBytes) == 0 {
+		return nil, errEmptyResponse
+	}
+	var codeResponse message.CodeResponse
+	if _, err := codec.Unmarshal(responseBytes, &codeResponse); err != nil {
+		return nil, err
+	}
+	if codeHash := crypto.Keccak256Hash(codeResponse.Data); codeHash != hash {
+		return nil, fmt.Errorf("%w: code hash %s, expected %s", errHashMismatch, codeHash, hash)
+	}
+	return codeResponse.Data, nil
+}
+
+// parseBlockResponse unmarshals [responseBytes] into a message.BlockResponse and
+// verifies that the returned blocks are the requested block followed by its
+// ancestors.
+func parseBlockResponse(codec codec.Manager, request message.BlockRequest, responseBytes []byte) ([]*types.Block, error) {
+	if len(responseBytes) == 0 {
+		return nil, errEmptyResponse
+	}
+	var blockResponse message.BlockResponse
+	if _, err := codec.Unmarshal(responseBytes, &blockResponse); err != nil {
+		return nil, err
+	}
+	if len(blockResponse.Blocks) == 0 {
+		return nil, errEmptyResponse
+	}
+	if len(blockResponse.Blocks) > int(request.Parents) {
+		return nil, fmt.Errorf("%w: (%d > %d)", errTooManyBlocks, len(blockResponse.Blocks), request.Parents)
+	}
+
+	blocks := make([]*types.Block, len(blockResponse.Blocks))
+	expectedHash := request.Hash
+	for i, blockBytes := range blockResponse.Blocks {
+		block := new(types.Block)
+		if err := rlp.DecodeBytes(blockBytes, block); err != nil {
+			return nil, err
+		}
+		if block.Hash() != expectedHash {
+			return nil, fmt.Errorf("%w: block hash %s, expected %s", errHashMismatch, block.Hash(), expectedHash)
+		}
+		blocks[i] = block
+		expectedHash = block.ParentHash()
+	}
+	return blocks, nil
+}
@@ -0,0 +1,85 @@
+// (c) 2021-2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package statesync
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/version"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ir4tech/webb-evm/peer"
+	"github.com/ir4tech/webb-evm/plugin/evm/message"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ peer.Client = &testResponsesClient{}
+
+// testResponsesClient answers every request with the next of [responses] and
+// counts the responses reported as invalid.
+type testResponsesClient struct {
+	responses        [][]byte
+	invalidResponses int
+}
+
+func (c *testResponsesClient) RequestAny(_ *version.Application, requestBytes []byte) ([]byte, ids.NodeID, error) {
+	nodeID := ids.GenerateTestNodeID()
+	response, err := c.Request(nodeID, requestBytes)
+	return response, nodeID, err
+}
+
+func (c *testResponsesClient) Request(ids.NodeID, []byte) ([]byte, error) {
+	response := c.responses[0]
+	c.responses = c.responses[1:]
+	return response, nil
+}
+
+func (c *testResponsesClient) Gossip([]byte) error { return nil }
+
+func (c *testResponsesClient) TrackInvalidResponse(ids.NodeID) { c.invalidResponses++ }
+
+func TestParseCodeResponse(t *testing.T) {
+	codec, err := message.BuildCodec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := []byte("some code goes here")
+	responseBytes, err := codec.Marshal(message.Version, message.CodeResponse{Data: code})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := parseCodeResponse(codec, crypto.Keccak256Hash(code), responseBytes)
+	assert.NoError(t, err)
+	assert.Equal(t, code, parsed)
+
+	_, err = parseCodeResponse(codec, crypto.Keccak256Hash([]byte("other code")), responseBytes)
+	assert.ErrorIs(t, err, errHashMismatch)
+}
+
+func TestClientRetriesEmptyResponses(t *testing.T) {
+	codec, err := message.BuildCodec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := []byte("some code goes here")
+	responseBytes, err := codec.Marshal(message.Version, message.CodeResponse{Data: code})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherResponseBytes, err := codec.Marshal(message.Version, message.CodeResponse{Data: []byte("other code")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a peer that does not have the code is retried without being reported,
+	// unlike a peer that answers with the wrong code
+	networkClient := &testResponsesClient{responses: [][]byte{{}, otherResponseBytes, responseBytes}}
+	client := NewClient(networkClient, codec, nil)
+	parsed, err := client.GetCode(context.Background(), crypto.Keccak256Hash(code))
+	assert.NoError(t, err)
+	assert.Equal(t, code, parsed)
+	assert.Equal(t, 1, networkClient.invalidResponses)
+}
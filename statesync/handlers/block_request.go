@@ -39,7 +39,8 @@ func NewBlockRequestHandler(getter func(common.Hash, uint64) *types.Block, codec
 // OnBlockRequest handles incoming message.BlockRequest, returning blocks as requested
 // Never returns error
 // Expects returned errors to be treated as FATAL
-// Returns empty response or subset of requested blocks if ctx expires during fetch
+// Returns nothing or subset of requested blocks if ctx expires during fetch
+// Returns an empty response if the requested block is not found
 // Assumes ctx is active
 func (b *BlockRequestHandler) OnBlockRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, blockRequest message.BlockRequest) ([]byte, error) {
 	startTime := time.Now()
@@ -89,9 +90,12 @@ func (b *BlockRequestHandler) OnBlockRequest(ctx context.Context, nodeID ids.Nod
 	}
 
 	if len(blocks) == 0 {
-		// drop this request
-		log.Debug("no requested blocks found, dropping request", "nodeID", nodeID, "requestID", requestID, "hash", blockRequest.Hash, "parents", blockRequest.Parents)
-		return nil, nil
+		if err := ctx.Err(); err != nil {
+			log.Debug("context err set before any blocks were read, dropping request", "nodeID", nodeID, "requestID", requestID, "hash", blockRequest.Hash, "err", err)
+			return nil, nil
+		}
+		log.Debug("no requested blocks found, sending empty response", "nodeID", nodeID, "requestID", requestID, "hash", blockRequest.Hash, "parents", blockRequest.Parents)
+		return notFoundResponse, nil
 	}
 
 	response := message.BlockResponse{
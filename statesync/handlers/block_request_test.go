@@ -61,9 +61,9 @@ func TestBlockRequestHandler(t *testing.T) {
 		startBlockHash   common.Hash
 		startBlockHeight uint64
 
-		requestedParents  uint16
-		expectedBlocks    int
-		expectNilResponse bool
+		requestedParents    uint16
+		expectedBlocks      int
+		expectEmptyResponse bool
 	}{
 		{
 			name:             "handler_returns_blocks_as_requested",
@@ -84,11 +84,11 @@ func TestBlockRequestHandler(t *testing.T) {
 			expectedBlocks:   1,
 		},
 		{
-			name:              "handler_unknown_block",
-			startBlockHash:    common.BytesToHash([]byte("some block pls k thx bye")),
-			startBlockHeight:  1_000_000,
-			requestedParents:  64,
-			expectNilResponse: true,
+			name:                "handler_unknown_block",
+			startBlockHash:      common.BytesToHash([]byte("some block pls k thx bye")),
+			startBlockHeight:    1_000_000,
+			requestedParents:    64,
+			expectEmptyResponse: true,
 		},
 	}
 	for _, test := range tests {
@@ -109,8 +109,9 @@ func TestBlockRequestHandler(t *testing.T) {
 				t.Fatal("unexpected error during block request", err)
 			}
 
-			if test.expectNilResponse {
-				assert.Nil(t, responseBytes)
+			if test.expectEmptyResponse {
+				assert.NotNil(t, responseBytes)
+				assert.Empty(t, responseBytes)
 				return
 			}
 
@@ -37,7 +37,7 @@ func NewCodeRequestHandler(codeReader ethdb.KeyValueReader, stats stats.HandlerS
 
 // OnCodeRequest handles request to retrieve contract code by its hash in message.CodeRequest
 // Never returns error
-// Returns nothing if code hash is not found
+// Returns an empty response if code hash is not found
 // Expects returned errors to be treated as FATAL
 // Assumes ctx is active
 func (n *CodeRequestHandler) OnCodeRequest(_ context.Context, nodeID ids.NodeID, requestID uint32, codeRequest message.CodeRequest) ([]byte, error) {
@@ -52,8 +52,8 @@ func (n *CodeRequestHandler) OnCodeRequest(_ context.Context, nodeID ids.NodeID,
 	codeData := rawdb.ReadCode(n.codeReader, codeRequest.Hash)
 	if len(codeData) == 0 {
 		n.stats.IncMissingCodeHash()
-		log.Debug("requested code not found, dropping request", "nodeID", nodeID, "requestID", requestID, "hash", codeRequest.Hash)
-		return nil, nil
+		log.Debug("requested code not found, sending empty response", "nodeID", nodeID, "requestID", requestID, "hash", codeRequest.Hash)
+		return notFoundResponse, nil
 	}
 
 	codeResponse := message.CodeResponse{Data: common.CopyBytes(codeData)}
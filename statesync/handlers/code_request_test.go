@@ -48,7 +48,8 @@ func TestCodeRequestHandler(t *testing.T) {
 	// query for missing code entry
 	responseBytes, err = codeRequestHandler.OnCodeRequest(context.Background(), ids.GenerateTestNodeID(), 2, message.CodeRequest{Hash: common.BytesToHash([]byte("some unknown hash"))})
 	assert.NoError(t, err)
-	assert.Nil(t, responseBytes)
+	assert.NotNil(t, responseBytes)
+	assert.Empty(t, responseBytes)
 
 	// assert max size code bytes are handled
 	codeBytes = make([]byte, params.MaxCodeSize)
@@ -10,7 +10,13 @@ import (
 	"github.com/ir4tech/webb-evm/plugin/evm/message"
 )
 
-var _ message.RequestHandler = &syncHandler{}
+var _ message.SyncRequestHandler = &syncHandler{}
+
+// notFoundResponse answers a request for data the node does not have, such as
+// a trie root it never synced or has pruned. Answering rather than dropping the
+// request lets the requester retry with another peer right away, without the
+// request timing out and counting against this node.
+var notFoundResponse = []byte{}
 
 type syncHandler struct {
 	trieLeafsRequestHandler *LeafsRequestHandler
@@ -22,7 +28,7 @@ func NewSyncHandler(
 	trieLeafsRequestHandler *LeafsRequestHandler,
 	blockRequestHandler *BlockRequestHandler,
 	codeRequestHandler *CodeRequestHandler,
-) message.RequestHandler {
+) message.SyncRequestHandler {
 	return &syncHandler{
 		trieLeafsRequestHandler: trieLeafsRequestHandler,
 		blockRequestHandler:     blockRequestHandler,
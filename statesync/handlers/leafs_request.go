@@ -8,6 +8,8 @@ import (
 	"context"
 	"time"
 
+	"github.com/ir4tech/webb-evm/core/state"
+	"github.com/ir4tech/webb-evm/core/state/snapshot"
 	"github.com/ir4tech/webb-evm/core/types"
 
 	"github.com/ava-labs/avalanchego/codec"
@@ -26,19 +28,40 @@ import (
 // in message.LeafsRequest if it is greater than this value
 const maxLeavesLimit = uint16(1024)
 
+// StateCacheProvider provides the state database whose tries leaves are
+// served from when the snapshot does not cover the requested trie.
+// The database may be replaced over time, for example by state sync, so it is
+// fetched for every request.
+type StateCacheProvider interface {
+	StateCache() state.Database
+}
+
+// SnapshotProvider provides the snapshot tree leaves are served from.
+// The tree may be replaced over time, for example by state sync, so it is
+// fetched for every request.
+type SnapshotProvider interface {
+	Snapshots() *snapshot.Tree
+}
+
 // LeafsRequestHandler is a peer.RequestHandler for types.LeafsRequest
 // serving requested trie data
 type LeafsRequestHandler struct {
-	trieDB *trie.Database
-	stats  stats.HandlerStats
-	codec  codec.Manager
+	stateProvider    StateCacheProvider
+	snapshotProvider SnapshotProvider
+	stats            stats.HandlerStats
+	codec            codec.Manager
 }
 
-func NewLeafsRequestHandler(trieDB *trie.Database, syncerStats stats.HandlerStats, codec codec.Manager) *LeafsRequestHandler {
+// NewLeafsRequestHandler returns a LeafsRequestHandler serving leaves from the
+// snapshot tree of [snapshotProvider] when it covers the requested trie, and
+// from the state database of [stateProvider] otherwise. [snapshotProvider] may
+// be nil to always serve leaves from the state database.
+func NewLeafsRequestHandler(stateProvider StateCacheProvider, snapshotProvider SnapshotProvider, syncerStats stats.HandlerStats, codec codec.Manager) *LeafsRequestHandler {
 	return &LeafsRequestHandler{
-		trieDB: trieDB,
-		stats:  syncerStats,
-		codec:  codec,
+		stateProvider:    stateProvider,
+		snapshotProvider: snapshotProvider,
+		stats:            syncerStats,
+		codec:            codec,
 	}
 }
 
@@ -47,9 +70,12 @@ func NewLeafsRequestHandler(trieDB *trie.Database, syncerStats stats.HandlerStat
 // - ctx expired while fetching leafs
 // - number of leaves read is greater than Limit (message.LeafsRequest)
 // Specified Limit in message.LeafsRequest is overridden to maxLeavesLimit if it is greater than maxLeavesLimit
+// Returned message.LeafsResponse carries a range proof of its leaves unless it holds every leaf of the trie
+// Leaves are read from the snapshot if it covers the requested trie, and from the trie otherwise
 // Expects returned errors to be treated as FATAL
 // Never returns errors
-// Returns nothing if NodeType is invalid or requested trie root is not found
+// Returns nothing if the request is invalid
+// Returns an empty response if the requested trie root is not found
 // Assumes ctx is active
 func (lrh *LeafsRequestHandler) OnLeafsRequest(ctx context.Context, nodeID ids.NodeID, requestID uint32, leafsRequest message.LeafsRequest) ([]byte, error) {
 	startTime := time.Now()
@@ -65,11 +91,11 @@ func (lrh *LeafsRequestHandler) OnLeafsRequest(ctx context.Context, nodeID ids.N
 		return nil, nil
 	}
 
-	t, err := trie.New(leafsRequest.Root, lrh.trieDB)
+	t, err := trie.New(leafsRequest.Root, lrh.stateProvider.StateCache().TrieDB())
 	if err != nil {
-		log.Debug("error opening trie when processing request, dropping request", "nodeID", nodeID, "requestID", requestID, "root", leafsRequest.Root, "err", err)
+		log.Debug("error opening trie when processing request, sending empty response", "nodeID", nodeID, "requestID", requestID, "root", leafsRequest.Root, "err", err)
 		lrh.stats.IncMissingRoot()
-		return nil, nil
+		return notFoundResponse, nil
 	}
 
 	// ensure metrics are captured properly on all return paths
@@ -78,61 +104,182 @@ func (lrh *LeafsRequestHandler) OnLeafsRequest(ctx context.Context, nodeID ids.N
 		lrh.stats.UpdateLeafsReturned(leafCount)
 	}()
 
-	// create iterator to iterate the trie
-	// Note that leafsRequest.Start could be an original start point
-	// or leafsResponse.NextKey from partial response to previous request
-	it := trie.NewIterator(t.NodeIterator(leafsRequest.Start))
-
 	// override limit if it is greater than the configured maxLeavesLimit
 	limit := leafsRequest.Limit
 	if limit > maxLeavesLimit {
 		limit = maxLeavesLimit
 	}
 
-	var leafsResponse message.LeafsResponse
-	for it.Next() {
-		// if we're at the end, break this loop
-		if bytes.Compare(it.Key, leafsRequest.End) > 0 {
-			break
+	leafsResponse, fromSnapshot := lrh.readLeafsFromSnapshot(ctx, t, &leafsRequest, limit)
+	if fromSnapshot {
+		lrh.stats.IncSnapshotReadSuccess()
+	} else {
+		lrh.stats.IncTrieRead()
+
+		var exhausted bool
+		leafsResponse, exhausted, err = lrh.readLeafsFromTrie(ctx, t, &leafsRequest, limit)
+		if err != nil {
+			log.Debug("failed to iterate trie, dropping request", "nodeID", nodeID, "requestID", requestID, "request", leafsRequest, "err", err)
+			return nil, nil
+		}
+		if len(leafsResponse.Keys) == 0 && ctx.Err() != nil {
+			log.Debug("context err set before any leafs were iterated", "nodeID", nodeID, "requestID", requestID, "request", leafsRequest, "ctxErr", ctx.Err())
+			return nil, nil
 		}
 
-		// If we've returned enough data or run out of time, set the more flag and exit
-		// this flag will determine where the proof ends
-		if leafCount >= limit || ctx.Err() != nil {
-			if leafCount == 0 {
-				log.Debug("context err set before any leafs were iterated", "nodeID", nodeID, "requestID", requestID, "request", leafsRequest, "ctxErr", ctx.Err())
+		// A response holding every leaf of the trie is proven by the root alone,
+		// otherwise generate the range proof and add it to the response.
+		if !exhausted || !isFirstKey(leafsRequest.Start) {
+			if err := lrh.addProofKeys(t, &leafsRequest, &leafsResponse); err != nil {
+				log.Debug("failed to create valid proof serving leafs request", "nodeID", nodeID, "requestID", requestID, "request", leafsRequest, "err", err)
 				return nil, nil
 			}
-			break
+		}
+	}
+	leafCount = uint16(len(leafsResponse.Keys))
+
+	responseBytes, err := lrh.codec.Marshal(message.Version, leafsResponse)
+	if err != nil {
+		log.Debug("failed to marshal LeafsResponse, dropping request", "nodeID", nodeID, "requestID", requestID, "request", leafsRequest, "err", err)
+		return nil, nil
+	}
+
+	log.Debug("handled leafsRequest", "time", time.Since(startTime), "leafs", leafCount, "proofLen", len(leafsResponse.ProofKeys), "fromSnapshot", fromSnapshot)
+	return responseBytes, nil
+}
+
+// readLeafsFromTrie returns up to [limit] leaves of [t] within the requested
+// range, and whether every leaf to the right of the requested start was
+// returned. Stops early, possibly without any leaves, if [ctx] is done.
+func (lrh *LeafsRequestHandler) readLeafsFromTrie(ctx context.Context, t *trie.Trie, leafsRequest *message.LeafsRequest, limit uint16) (message.LeafsResponse, bool, error) {
+	var leafsResponse message.LeafsResponse
+
+	// create iterator to iterate the trie
+	// Note that leafsRequest.Start could be an original start point
+	// or leafsResponse.NextKey from partial response to previous request
+	it := trie.NewIterator(t.NodeIterator(leafsRequest.Start))
+	for it.Next() {
+		// Stop at the end of the range, or once we've returned enough data or
+		// run out of time. The proof ends at the last returned leaf.
+		if bytes.Compare(it.Key, leafsRequest.End) > 0 || len(leafsResponse.Keys) >= int(limit) || ctx.Err() != nil {
+			return leafsResponse, false, it.Err
 		}
 
 		// collect data to return
-		leafCount++
 		leafsResponse.Keys = append(leafsResponse.Keys, it.Key)
 		leafsResponse.Vals = append(leafsResponse.Vals, it.Value)
 	}
+	return leafsResponse, true, it.Err
+}
 
-	if it.Err != nil {
-		log.Debug("failed to iterate trie, dropping request", "nodeID", nodeID, "requestID", requestID, "request", leafsRequest, "err", it.Err)
-		return nil, nil
+// readLeafsFromSnapshot returns up to [limit] leaves within the requested
+// range read from the snapshot, along with their range proof generated from
+// [t]. Returns false if the snapshot does not cover the requested trie or the
+// leaves read from it do not match [t], in which case the leaves must be read
+// from [t] instead.
+func (lrh *LeafsRequestHandler) readLeafsFromSnapshot(ctx context.Context, t *trie.Trie, leafsRequest *message.LeafsRequest, limit uint16) (message.LeafsResponse, bool) {
+	var leafsResponse message.LeafsResponse
+	if lrh.snapshotProvider == nil {
+		return leafsResponse, false
 	}
-
-	// Generate the proof and add it to the response.
-	if err := lrh.addProofKeys(t, &leafsRequest, &leafsResponse); err != nil {
-		log.Debug("failed to create valid proof serving leafs request", "nodeID", nodeID, "requestID", requestID, "request", leafsRequest, "err", err)
-		return nil, nil
+	snaps := lrh.snapshotProvider.Snapshots()
+	if snaps == nil {
+		return leafsResponse, false
 	}
 
-	responseBytes, err := lrh.codec.Marshal(message.Version, leafsResponse)
+	// Snapshot keys are hashes, so right pad the start of the range to seek
+	// to the first key that is not smaller than it.
+	var seek common.Hash
+	copy(seek[:], leafsRequest.Start)
+
+	var (
+		it      snapshot.Iterator
+		valueFn func() ([]byte, error)
+		err     error
+	)
+	if leafsRequest.Account == (common.Hash{}) {
+		// Snapshot layers are keyed by the root of the account trie they hold,
+		// so an account trie is only covered by the layer at its root.
+		if snaps.Snapshot(leafsRequest.Root) == nil {
+			return leafsResponse, false
+		}
+		var accountIt snapshot.AccountIterator
+		accountIt, err = snaps.AccountIterator(leafsRequest.Root, seek, false)
+		it, valueFn = accountIt, func() ([]byte, error) { return snapshot.FullAccountRLP(accountIt.Account()) }
+	} else {
+		// The state root a storage trie belongs to is not part of the request,
+		// so the storage of the account is read from the disk layer. The proof
+		// check below rejects a disk layer holding a different storage trie.
+		var storageIt snapshot.StorageIterator
+		storageIt, err = snaps.StorageIterator(snaps.DiskRoot(), leafsRequest.Account, seek, false)
+		it, valueFn = storageIt, func() ([]byte, error) { return storageIt.Slot(), nil }
+	}
 	if err != nil {
-		log.Debug("failed to marshal LeafsResponse, dropping request", "nodeID", nodeID, "requestID", requestID, "request", leafsRequest, "err", err)
-		return nil, nil
+		// The snapshot is not available, for example while it is being generated.
+		return leafsResponse, false
 	}
+	defer it.Release()
 
-	log.Debug("handled leafsRequest", "time", time.Since(startTime), "leafs", leafCount, "proofLen", len(leafsResponse.ProofKeys))
-	return responseBytes, nil
+	exhausted := true
+	for it.Next() {
+		key := it.Hash()
+		if bytes.Compare(key[:], leafsRequest.End) > 0 || len(leafsResponse.Keys) >= int(limit) || ctx.Err() != nil {
+			exhausted = false
+			break
+		}
+		value, err := valueFn()
+		if err != nil {
+			lrh.stats.IncSnapshotReadError()
+			return leafsResponse, false
+		}
+		if len(value) == 0 {
+			// Skip entries deleted in the snapshot.
+			continue
+		}
+		leafsResponse.Keys = append(leafsResponse.Keys, common.CopyBytes(key[:]))
+		leafsResponse.Vals = append(leafsResponse.Vals, value)
+	}
+	if it.Error() != nil {
+		lrh.stats.IncSnapshotReadError()
+		return leafsResponse, false
+	}
+	if len(leafsResponse.Keys) == 0 {
+		// Leave empty ranges to the trie, which proves they are empty.
+		return leafsResponse, false
+	}
+
+	// Verify the leaves read from the snapshot against [t], so a stale or
+	// incomplete snapshot is never served.
+	if exhausted && isFirstKey(leafsRequest.Start) {
+		if _, err := trie.VerifyRangeProof(leafsRequest.Root, nil, nil, leafsResponse.Keys, leafsResponse.Vals, nil); err != nil {
+			lrh.stats.IncSnapshotReadError()
+			return leafsResponse, false
+		}
+		return leafsResponse, true
+	}
+	if err := lrh.addProofKeys(t, leafsRequest, &leafsResponse); err != nil {
+		return leafsResponse, false
+	}
+	proof := memorydb.New()
+	defer proof.Close() // Closing the memorydb should never error
+	for i, key := range leafsResponse.ProofKeys {
+		if err := proof.Put(key, leafsResponse.ProofVals[i]); err != nil {
+			return leafsResponse, false
+		}
+	}
+	lastKey := leafsResponse.Keys[len(leafsResponse.Keys)-1]
+	more, err := trie.VerifyRangeProof(leafsRequest.Root, leafsRequest.Start, lastKey, leafsResponse.Keys, leafsResponse.Vals, proof)
+	if err != nil || (exhausted && more) {
+		// A snapshot running out of leaves before the trie is missing some.
+		lrh.stats.IncSnapshotReadError()
+		return leafsResponse, false
+	}
+	return leafsResponse, true
 }
 
+// addProofKeys adds the range proof of the leaves in [leafsResponse] to it:
+// the Merkle proofs of [leafsRequest.Start] and of the last key in
+// [leafsResponse], or of [leafsRequest.End] if the response holds no leaves.
 func (lrh *LeafsRequestHandler) addProofKeys(t *trie.Trie, leafsRequest *message.LeafsRequest, leafsResponse *message.LeafsResponse) error {
 	proof := memorydb.New()
 	defer proof.Close() // Closing the memorydb should never error
@@ -163,3 +310,13 @@ func (lrh *LeafsRequestHandler) addProofKeys(t *trie.Trie, leafsRequest *message
 
 	return proofIt.Error()
 }
+
+// isFirstKey returns true if no trie key is smaller than [key].
+func isFirstKey(key []byte) bool {
+	for _, b := range key {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
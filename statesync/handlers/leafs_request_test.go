@@ -6,12 +6,17 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"math/big"
 	"math/rand"
 	"testing"
 
 	"github.com/ava-labs/avalanchego/ids"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ir4tech/webb-evm/core/rawdb"
+	"github.com/ir4tech/webb-evm/core/state"
+	"github.com/ir4tech/webb-evm/core/state/snapshot"
+	"github.com/ir4tech/webb-evm/core/types"
 	"github.com/ir4tech/webb-evm/ethdb/memorydb"
 	"github.com/ir4tech/webb-evm/plugin/evm/message"
 	"github.com/ir4tech/webb-evm/statesync/handlers/stats"
@@ -26,8 +31,8 @@ func TestLeafsRequestHandler_OnLeafsRequest(t *testing.T) {
 	}
 
 	stats := stats.NewNoopHandlerStats()
-	memdb := memorydb.New()
-	trieDB := trie.NewDatabase(memdb)
+	stateCache := state.NewDatabase(rawdb.NewMemoryDatabase())
+	trieDB := stateCache.TrieDB()
 	tr, err := trie.New(common.Hash{}, trieDB)
 	assert.NoError(t, err)
 
@@ -56,7 +61,7 @@ func TestLeafsRequestHandler_OnLeafsRequest(t *testing.T) {
 		t.Fatal("error committing trieDB", err)
 	}
 
-	leafsHandler := NewLeafsRequestHandler(trieDB, stats, codec)
+	leafsHandler := NewLeafsRequestHandler(&testStateCacheProvider{stateCache: stateCache}, nil, stats, codec)
 
 	tests := map[string]struct {
 		prepareTestFn    func() (context.Context, message.LeafsRequest)
@@ -90,7 +95,7 @@ func TestLeafsRequestHandler_OnLeafsRequest(t *testing.T) {
 				assert.Nil(t, err)
 			},
 		},
-		"missing root dropped": {
+		"missing root answered with empty response": {
 			prepareTestFn: func() (context.Context, message.LeafsRequest) {
 				return context.Background(), message.LeafsRequest{
 					Root:  common.BytesToHash([]byte("something is missing here...")),
@@ -100,7 +105,8 @@ func TestLeafsRequestHandler_OnLeafsRequest(t *testing.T) {
 				}
 			},
 			assertResponseFn: func(t *testing.T, _ message.LeafsRequest, response []byte, err error) {
-				assert.Nil(t, response)
+				assert.NotNil(t, response)
+				assert.Empty(t, response)
 				assert.Nil(t, err)
 			},
 		},
@@ -237,3 +243,138 @@ func TestLeafsRequestHandler_OnLeafsRequest(t *testing.T) {
 		})
 	}
 }
+
+// countingHandlerStats counts the leafs requests served from the snapshot
+// and from the trie.
+type countingHandlerStats struct {
+	stats.HandlerStats
+	snapshotReadSuccess, snapshotReadError, trieRead int
+}
+
+func (c *countingHandlerStats) IncSnapshotReadSuccess() { c.snapshotReadSuccess++ }
+func (c *countingHandlerStats) IncSnapshotReadError()   { c.snapshotReadError++ }
+func (c *countingHandlerStats) IncTrieRead()            { c.trieRead++ }
+
+type testStateCacheProvider struct {
+	stateCache state.Database
+}
+
+func (p *testStateCacheProvider) StateCache() state.Database { return p.stateCache }
+
+type testSnapshotProvider struct {
+	snaps *snapshot.Tree
+}
+
+func (p *testSnapshotProvider) Snapshots() *snapshot.Tree { return p.snaps }
+
+func TestLeafsRequestHandler_Snapshot(t *testing.T) {
+	codec, err := message.BuildCodec()
+	if err != nil {
+		t.Fatal("unexpected error building codec", err)
+	}
+
+	// fill state with accounts, every second of which has storage
+	memdb := rawdb.NewMemoryDatabase()
+	stateDB, err := state.New(common.Hash{}, state.NewDatabase(memdb), nil)
+	assert.NoError(t, err)
+	for i := 0; i < 500; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i + 1)))
+		stateDB.SetBalance(addr, big.NewInt(int64(i+1)))
+		if i%2 == 0 {
+			for j := 0; j < 50; j++ {
+				stateDB.SetState(addr, common.BigToHash(big.NewInt(int64(j))), common.BigToHash(big.NewInt(int64(i+j+1))))
+			}
+		}
+	}
+	root, err := stateDB.Commit(false)
+	assert.NoError(t, err)
+	stateProvider := &testStateCacheProvider{stateCache: stateDB.Database()}
+	trieDB := stateDB.Database().TrieDB()
+	assert.NoError(t, trieDB.Commit(root, false, nil))
+
+	// generate the snapshot of the state
+	snaps, err := snapshot.New(memdb, trieDB, 16, common.Hash{0x01}, root, false, true, false)
+	assert.NoError(t, err)
+
+	storageAccount := crypto.Keccak256Hash(common.BigToAddress(big.NewInt(1)).Bytes())
+	storageRoot := stateDB.StorageTrie(common.BigToAddress(big.NewInt(1))).Hash()
+	assert.NotEqual(t, types.EmptyRootHash, storageRoot)
+
+	tests := map[string]struct {
+		request       message.LeafsRequest
+		fromSnapshot  bool
+		snapshotError bool
+	}{
+		"account trie from snapshot": {
+			request:      message.LeafsRequest{Root: root, Start: bytes.Repeat([]byte{0x00}, common.HashLength), End: bytes.Repeat([]byte{0xff}, common.HashLength), Limit: 100},
+			fromSnapshot: true,
+		},
+		"account trie range from snapshot": {
+			request:      message.LeafsRequest{Root: root, Start: bytes.Repeat([]byte{0x40}, common.HashLength), End: bytes.Repeat([]byte{0x80}, common.HashLength), Limit: 1024},
+			fromSnapshot: true,
+		},
+		"entire storage trie from snapshot": {
+			request:      message.LeafsRequest{Root: storageRoot, Account: storageAccount, Start: bytes.Repeat([]byte{0x00}, common.HashLength), End: bytes.Repeat([]byte{0xff}, common.HashLength), Limit: 1024},
+			fromSnapshot: true,
+		},
+		"storage trie without account from trie": {
+			request: message.LeafsRequest{Root: storageRoot, Start: bytes.Repeat([]byte{0x00}, common.HashLength), End: bytes.Repeat([]byte{0xff}, common.HashLength), Limit: 1024},
+		},
+		"storage trie of wrong account from trie": {
+			request:       message.LeafsRequest{Root: storageRoot, Account: crypto.Keccak256Hash(common.BigToAddress(big.NewInt(3)).Bytes()), Start: bytes.Repeat([]byte{0x00}, common.HashLength), End: bytes.Repeat([]byte{0xff}, common.HashLength), Limit: 1024},
+			snapshotError: true,
+		},
+	}
+	assertResponse := func(t *testing.T, request message.LeafsRequest, fromSnapshot, snapshotError bool) {
+		trieHandler := NewLeafsRequestHandler(stateProvider, nil, stats.NewNoopHandlerStats(), codec)
+		expected, err := trieHandler.OnLeafsRequest(context.Background(), ids.GenerateTestNodeID(), 1, request)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, expected)
+
+		handlerStats := &countingHandlerStats{HandlerStats: stats.NewNoopHandlerStats()}
+		snapshotHandler := NewLeafsRequestHandler(stateProvider, &testSnapshotProvider{snaps: snaps}, handlerStats, codec)
+		response, err := snapshotHandler.OnLeafsRequest(context.Background(), ids.GenerateTestNodeID(), 1, request)
+		assert.NoError(t, err)
+
+		// the response must not depend on where the leaves were read from
+		assert.Equal(t, expected, response)
+		if fromSnapshot {
+			assert.Equal(t, 1, handlerStats.snapshotReadSuccess)
+			assert.Zero(t, handlerStats.trieRead)
+		} else {
+			assert.Zero(t, handlerStats.snapshotReadSuccess)
+			assert.Equal(t, 1, handlerStats.trieRead)
+		}
+		if snapshotError {
+			assert.Equal(t, 1, handlerStats.snapshotReadError)
+		} else {
+			assert.Zero(t, handlerStats.snapshotReadError)
+		}
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assertResponse(t, test.request, test.fromSnapshot, test.snapshotError)
+		})
+	}
+
+	// corrupt an account in the snapshot, it must be served from the trie instead
+	it, err := snaps.AccountIterator(root, common.Hash{}, false)
+	assert.NoError(t, err)
+	assert.True(t, it.Next())
+	rawdb.WriteAccountSnapshot(memdb, it.Hash(), snapshot.SlimAccountRLP(1, big.NewInt(1), types.EmptyRootHash, crypto.Keccak256(nil)))
+	it.Release()
+	assertResponse(t, message.LeafsRequest{Root: root, Start: bytes.Repeat([]byte{0x00}, common.HashLength), End: bytes.Repeat([]byte{0xff}, common.HashLength), Limit: 100}, false, true)
+
+	// the state database is fetched for every request, so that the handler
+	// serves from the database that replaced it, as done at the end of state sync
+	replacedProvider := &testStateCacheProvider{stateCache: state.NewDatabase(rawdb.NewMemoryDatabase())}
+	handler := NewLeafsRequestHandler(replacedProvider, nil, stats.NewNoopHandlerStats(), codec)
+	request := message.LeafsRequest{Root: root, Start: bytes.Repeat([]byte{0x00}, common.HashLength), End: bytes.Repeat([]byte{0xff}, common.HashLength), Limit: 100}
+	response, err := handler.OnLeafsRequest(context.Background(), ids.GenerateTestNodeID(), 1, request)
+	assert.NoError(t, err)
+	assert.Empty(t, response)
+	replacedProvider.stateCache = stateProvider.stateCache
+	response, err = handler.OnLeafsRequest(context.Background(), ids.GenerateTestNodeID(), 1, request)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, response)
+}
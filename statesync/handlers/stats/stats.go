@@ -28,6 +28,9 @@ type HandlerStats interface {
 	UpdateLeafsReturned(numLeafs uint16)
 	UpdateLeafsRequestProcessingTime(duration time.Duration)
 	IncMissingRoot()
+	IncSnapshotReadSuccess()
+	IncSnapshotReadError()
+	IncTrieRead()
 }
 
 type handlerStats struct {
@@ -48,6 +51,9 @@ type handlerStats struct {
 	leafsReturned              metrics.Histogram
 	leafsRequestProcessingTime metrics.Timer
 	missingRoot                metrics.Counter
+	snapshotReadSuccess        metrics.Counter
+	snapshotReadError          metrics.Counter
+	trieRead                   metrics.Counter
 }
 
 func (h *handlerStats) IncBlockRequest() {
@@ -98,6 +104,18 @@ func (h *handlerStats) IncMissingRoot() {
 	h.missingRoot.Inc(1)
 }
 
+func (h *handlerStats) IncSnapshotReadSuccess() {
+	h.snapshotReadSuccess.Inc(1)
+}
+
+func (h *handlerStats) IncSnapshotReadError() {
+	h.snapshotReadError.Inc(1)
+}
+
+func (h *handlerStats) IncTrieRead() {
+	h.trieRead.Inc(1)
+}
+
 func NewHandlerStats() HandlerStats {
 	return &handlerStats{
 		// initialise block request stats
@@ -117,6 +135,9 @@ func NewHandlerStats() HandlerStats {
 		leafsRequestProcessingTime: metrics.GetOrRegisterTimer("leafs_request_processing_time", nil),
 		leafsReturned:              metrics.GetOrRegisterHistogram("leafs_returned", nil, metrics.NewExpDecaySample(1028, 0.015)),
 		missingRoot:                metrics.GetOrRegisterCounter("missing_root", nil),
+		snapshotReadSuccess:        metrics.GetOrRegisterCounter("snapshot_read_success", nil),
+		snapshotReadError:          metrics.GetOrRegisterCounter("snapshot_read_error", nil),
+		trieRead:                   metrics.GetOrRegisterCounter("trie_read", nil),
 	}
 }
 
@@ -140,3 +161,6 @@ func (n *noopHandlerStats) IncLeafsRequest()                               {}
 func (n *noopHandlerStats) UpdateLeafsRequestProcessingTime(time.Duration) {}
 func (n *noopHandlerStats) UpdateLeafsReturned(uint16)                     {}
 func (n *noopHandlerStats) IncMissingRoot()                                {}
+func (n *noopHandlerStats) IncSnapshotReadSuccess()                        {}
+func (n *noopHandlerStats) IncSnapshotReadError()                          {}
+func (n *noopHandlerStats) IncTrieRead()                                   {}
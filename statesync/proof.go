@@ -0,0 +1,83 @@
+// (c) 2021-2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package statesync
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ir4tech/webb-evm/ethdb/memorydb"
+	"github.com/ir4tech/webb-evm/plugin/evm/message"
+	"github.com/ir4tech/webb-evm/trie"
+)
+
+var (
+	errInvalidRangeProof      = errors.New("failed to verify range proof")
+	errTooManyLeaves          = errors.New("response contains more than requested leaves")
+	errLeafOutOfRange         = errors.New("response contains leaf outside of requested range")
+	errLeafsNotSorted         = errors.New("response leaves are not sorted in ascending order")
+	errInvalidLeafsResponse   = errors.New("number of keys does not match number of values")
+	errInvalidProofKeysLength = errors.New("number of proof keys does not match number of proof values")
+)
+
+// VerifyLeafsResponse verifies that [response] holds exactly the leaves of the
+// trie at [request.Root] from [request.Start] up to its last key, so the
+// response can be trusted without trusting the peer that sent it.
+//
+// A response with a range proof may stop before [request.End], in which case
+// the More flag of [response] is set if the trie has leaves to the right of
+// the last returned key. A response without a range proof must contain every
+// leaf of the trie.
+func VerifyLeafsResponse(request message.LeafsRequest, response *message.LeafsResponse) error {
+	if len(response.Keys) != len(response.Vals) {
+		return fmt.Errorf("%w: (%d != %d)", errInvalidLeafsResponse, len(response.Keys), len(response.Vals))
+	}
+	if len(response.ProofKeys) != len(response.ProofVals) {
+		return fmt.Errorf("%w: (%d != %d)", errInvalidProofKeysLength, len(response.ProofKeys), len(response.ProofVals))
+	}
+	if len(response.Keys) > int(request.Limit) {
+		return fmt.Errorf("%w: (%d > %d)", errTooManyLeaves, len(response.Keys), request.Limit)
+	}
+	for i, key := range response.Keys {
+		if bytes.Compare(key, request.Start) < 0 || bytes.Compare(key, request.End) > 0 {
+			return fmt.Errorf("%w: %x", errLeafOutOfRange, key)
+		}
+		if i > 0 && bytes.Compare(response.Keys[i-1], key) >= 0 {
+			return fmt.Errorf("%w: %x after %x", errLeafsNotSorted, key, response.Keys[i-1])
+		}
+	}
+
+	// A response without proof must contain the entire trie, which is verified
+	// by rebuilding the trie from the returned leaves.
+	if len(response.ProofKeys) == 0 {
+		more, err := trie.VerifyRangeProof(request.Root, nil, nil, response.Keys, response.Vals, nil)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errInvalidRangeProof, err)
+		}
+		response.More = more
+		return nil
+	}
+
+	proof := memorydb.New()
+	defer proof.Close() // Closing the memorydb should never error
+	for i, key := range response.ProofKeys {
+		if err := proof.Put(key, response.ProofVals[i]); err != nil {
+			return err
+		}
+	}
+
+	// The proof covers the requested start and the last returned key, or the
+	// requested end if no keys were returned.
+	lastKey := request.End
+	if len(response.Keys) > 0 {
+		lastKey = response.Keys[len(response.Keys)-1]
+	}
+	more, err := trie.VerifyRangeProof(request.Root, request.Start, lastKey, response.Keys, response.Vals, proof)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errInvalidRangeProof, err)
+	}
+	response.More = more
+	return nil
+}
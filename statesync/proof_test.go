@@ -0,0 +1,133 @@
+// (c) 2021-2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package statesync
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ir4tech/webb-evm/core/rawdb"
+	"github.com/ir4tech/webb-evm/core/state"
+	"github.com/ir4tech/webb-evm/plugin/evm/message"
+	"github.com/ir4tech/webb-evm/statesync/handlers"
+	"github.com/ir4tech/webb-evm/statesync/handlers/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyLeafsResponse(t *testing.T) {
+	codec, err := message.BuildCodec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverDB := rawdb.NewMemoryDatabase()
+	root := fillServerState(t, serverDB, 100)
+	leafsHandler := handlers.NewLeafsRequestHandler(&testStateCacheProvider{stateCache: state.NewDatabase(serverDB)}, nil, stats.NewNoopHandlerStats(), codec)
+
+	var (
+		firstKey = make([]byte, common.HashLength)
+		midKey   = bytes.Repeat([]byte{0x80}, common.HashLength)
+	)
+	tests := map[string]struct {
+		request   message.LeafsRequest
+		modifyFn  func(*message.LeafsResponse)
+		expectErr error
+		noProof   bool
+		more      bool
+	}{
+		"entire trie without proof": {
+			request: message.LeafsRequest{Root: root, Start: firstKey, End: endKey, Limit: 1024},
+			noProof: true,
+		},
+		"partial range": {
+			request: message.LeafsRequest{Root: root, Start: firstKey, End: endKey, Limit: 10},
+			more:    true,
+		},
+		"right half of trie": {
+			request: message.LeafsRequest{Root: root, Start: midKey, End: endKey, Limit: 1024},
+		},
+		"left half of trie": {
+			request: message.LeafsRequest{Root: root, Start: firstKey, End: midKey, Limit: 1024},
+			more:    true,
+		},
+		"modified value": {
+			request: message.LeafsRequest{Root: root, Start: firstKey, End: endKey, Limit: 10},
+			modifyFn: func(response *message.LeafsResponse) {
+				response.Vals[3] = []byte{0x01}
+			},
+			expectErr: errInvalidRangeProof,
+		},
+		"dropped leaf": {
+			request: message.LeafsRequest{Root: root, Start: firstKey, End: endKey, Limit: 10},
+			modifyFn: func(response *message.LeafsResponse) {
+				response.Keys = append(response.Keys[:3], response.Keys[4:]...)
+				response.Vals = append(response.Vals[:3], response.Vals[4:]...)
+			},
+			expectErr: errInvalidRangeProof,
+		},
+		"dropped leaf without proof": {
+			request: message.LeafsRequest{Root: root, Start: firstKey, End: endKey, Limit: 1024},
+			modifyFn: func(response *message.LeafsResponse) {
+				response.Keys = response.Keys[:len(response.Keys)-1]
+				response.Vals = response.Vals[:len(response.Vals)-1]
+			},
+			noProof:   true,
+			expectErr: errInvalidRangeProof,
+		},
+		"dropped proof": {
+			request: message.LeafsRequest{Root: root, Start: firstKey, End: endKey, Limit: 10},
+			modifyFn: func(response *message.LeafsResponse) {
+				response.ProofKeys, response.ProofVals = nil, nil
+			},
+			expectErr: errInvalidRangeProof,
+		},
+		"unsorted leaves": {
+			request: message.LeafsRequest{Root: root, Start: firstKey, End: endKey, Limit: 10},
+			modifyFn: func(response *message.LeafsResponse) {
+				response.Keys[1], response.Keys[2] = response.Keys[2], response.Keys[1]
+				response.Vals[1], response.Vals[2] = response.Vals[2], response.Vals[1]
+			},
+			expectErr: errLeafsNotSorted,
+		},
+		"too many leaves": {
+			request: message.LeafsRequest{Root: root, Start: firstKey, End: endKey, Limit: 10},
+			modifyFn: func(response *message.LeafsResponse) {
+				response.Keys = append(response.Keys, response.Keys[0])
+				response.Vals = append(response.Vals, response.Vals[0])
+			},
+			expectErr: errTooManyLeaves,
+		},
+		"leaf before start": {
+			request: message.LeafsRequest{Root: root, Start: midKey, End: endKey, Limit: 10},
+			modifyFn: func(response *message.LeafsResponse) {
+				response.Keys[0] = firstKey
+			},
+			expectErr: errLeafOutOfRange,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			responseBytes, err := leafsHandler.OnLeafsRequest(context.Background(), ids.GenerateTestNodeID(), 1, test.request)
+			assert.NoError(t, err)
+			var response message.LeafsResponse
+			if _, err := codec.Unmarshal(responseBytes, &response); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, test.noProof, len(response.ProofKeys) == 0)
+			if test.modifyFn != nil {
+				test.modifyFn(&response)
+			}
+
+			err = VerifyLeafsResponse(test.request, &response)
+			if test.expectErr != nil {
+				assert.ErrorIs(t, err, test.expectErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.more, response.More)
+		})
+	}
+}
@@ -0,0 +1,280 @@
+// (c) 2021-2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package statesync
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ava-labs/avalanchego/codec"
+	"github.com/ava-labs/avalanchego/database"
+	"github.com/ava-labs/avalanchego/snow/engine/snowman/block"
+
+	commonEng "github.com/ava-labs/avalanchego/snow/engine/common"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ir4tech/webb-evm/core"
+	"github.com/ir4tech/webb-evm/core/rawdb"
+	"github.com/ir4tech/webb-evm/core/types"
+	"github.com/ir4tech/webb-evm/ethdb"
+	"github.com/ir4tech/webb-evm/plugin/evm/message"
+)
+
+const (
+	// parentsToGet is the number of blocks fetched ending at the synced block,
+	// so the BLOCKHASH opcode can be served once the node resumes from it.
+	parentsToGet = 256
+
+	// blocksPerRequest is the number of blocks requested from a peer in a
+	// single message.BlockRequest.
+	blocksPerRequest = uint16(64)
+)
+
+var (
+	_ block.StateSyncableVM = &StateSyncClient{}
+
+	errSyncedRootMismatch = errors.New("synced block root does not match summary")
+
+	// ErrChainReset is wrapped by the error of a state sync that failed after
+	// the chain was reset to the synced block. Earlier failures leave the chain
+	// at its last accepted block, from which it can bootstrap normally.
+	ErrChainReset = errors.New("chain was reset to the synced block")
+)
+
+// StateSyncClientConfig configures a StateSyncClient.
+type StateSyncClientConfig struct {
+	// Enabled is true if the node may state sync instead of bootstrapping
+	// from genesis.
+	Enabled bool
+	// MinBlocks is the minimum number of blocks a summary must be ahead of the
+	// last accepted block for the node to sync to it.
+	MinBlocks uint64
+	// SyncableInterval is the interval of block heights at which summaries
+	// are served. It must be a multiple of the interval state tries are
+	// committed at, so the state of every summary is on disk.
+	SyncableInterval uint64
+	// NumWorkers is the number of storage tries and codes fetched concurrently.
+	NumWorkers int
+
+	Chain   *core.BlockChain
+	ChainDB ethdb.Database
+	Client  Client
+	Codec   codec.Manager
+
+	// SetLastAcceptedBlock is called with the synced block once its state is
+	// on disk, before the engine is notified that state sync is done.
+	SetLastAcceptedBlock func(*types.Block) error
+	ToEngine             chan<- commonEng.Message
+}
+
+// StateSyncClient implements block.StateSyncableVM. It serves summaries of
+// the accepted blocks whose state is on disk to peers and, when the engine
+// accepts a summary, fetches the block, its ancestors and its state from peers
+// before handing off to bootstrapping.
+//
+// The summary being synced is persisted until the sync completes, so an
+// interrupted sync is reported by GetOngoingSyncStateSummary after a restart
+// and resumes where it left off if the engine accepts it again.
+type StateSyncClient struct {
+	config *StateSyncClientConfig
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// syncErr is set by the sync goroutine before it notifies the engine.
+	syncErr error
+}
+
+// NewStateSyncClient returns a StateSyncClient configured by [config].
+func NewStateSyncClient(config *StateSyncClientConfig) *StateSyncClient {
+	return &StateSyncClient{config: config}
+}
+
+// StateSyncEnabled implements the block.StateSyncableVM interface
+func (c *StateSyncClient) StateSyncEnabled() (bool, error) {
+	return c.config.Enabled, nil
+}
+
+// GetOngoingSyncStateSummary implements the block.StateSyncableVM interface
+func (c *StateSyncClient) GetOngoingSyncStateSummary() (block.StateSummary, error) {
+	summaryBytes := rawdb.ReadSyncSummary(c.config.ChainDB)
+	if len(summaryBytes) == 0 {
+		return nil, database.ErrNotFound
+	}
+	return c.ParseStateSummary(summaryBytes)
+}
+
+// ParseStateSummary implements the block.StateSyncableVM interface
+func (c *StateSyncClient) ParseStateSummary(summaryBytes []byte) (block.StateSummary, error) {
+	return ParseSummary(c.config.Codec, summaryBytes, c.acceptSummary)
+}
+
+// GetLastStateSummary implements the block.StateSyncableVM interface
+func (c *StateSyncClient) GetLastStateSummary() (block.StateSummary, error) {
+	if c.config.SyncableInterval == 0 {
+		return nil, database.ErrNotFound
+	}
+	lastHeight := c.config.Chain.LastAcceptedBlock().NumberU64()
+	return c.GetStateSummary(lastHeight - lastHeight%c.config.SyncableInterval)
+}
+
+// GetStateSummary implements the block.StateSyncableVM interface
+func (c *StateSyncClient) GetStateSummary(height uint64) (block.StateSummary, error) {
+	if height == 0 || c.config.SyncableInterval == 0 || height%c.config.SyncableInterval != 0 {
+		return nil, database.ErrNotFound
+	}
+	if height > c.config.Chain.LastAcceptedBlock().NumberU64() {
+		return nil, database.ErrNotFound
+	}
+	summaryBlock := c.config.Chain.GetBlockByNumber(height)
+	if summaryBlock == nil || !c.config.Chain.HasState(summaryBlock.Root()) {
+		return nil, database.ErrNotFound
+	}
+	return NewSummary(c.config.Codec, message.SyncableBlock{
+		BlockNumber: summaryBlock.NumberU64(),
+		BlockRoot:   summaryBlock.Root(),
+		BlockHash:   summaryBlock.Hash(),
+	}, c.acceptSummary)
+}
+
+// acceptSummary starts syncing to [summary] in the background and returns
+// true, or returns false if the node is close enough to [summary] to
+// bootstrap normally.
+func (c *StateSyncClient) acceptSummary(summary *Summary) (bool, error) {
+	ongoingSummary := rawdb.ReadSyncSummary(c.config.ChainDB)
+	isResume := bytes.Equal(ongoingSummary, summary.Bytes())
+	if !isResume {
+		lastAccepted := c.config.Chain.LastAcceptedBlock()
+		if lastAccepted.NumberU64()+c.config.MinBlocks > summary.Height() {
+			log.Info("last accepted block is close to summary, skipping state sync",
+				"lastAccepted", lastAccepted.NumberU64(), "summary", summary, "minBlocks", c.config.MinBlocks)
+			if len(ongoingSummary) != 0 {
+				rawdb.DeleteSyncSummary(c.config.ChainDB)
+			}
+			return false, nil
+		}
+		rawdb.WriteSyncSummary(c.config.ChainDB, summary.Bytes())
+	}
+	log.Info("starting state sync", "summary", summary, "resume", isResume)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer cancel()
+
+		c.syncErr = c.sync(ctx, summary)
+		if ctx.Err() != nil {
+			// Shutting down, the engine is not waiting for the sync anymore.
+			return
+		}
+		if c.syncErr != nil {
+			log.Error("state sync failed", "summary", summary, "err", c.syncErr)
+		}
+		c.config.ToEngine <- commonEng.StateSyncDone
+	}()
+	return true, nil
+}
+
+// sync fetches the block described by [summary], its ancestors and its state,
+// then resets the chain to the synced block.
+func (c *StateSyncClient) sync(ctx context.Context, summary *Summary) error {
+	syncedBlock, err := c.syncBlocks(ctx, summary.BlockHash, summary.BlockNumber)
+	if err != nil {
+		return err
+	}
+	if syncedBlock.Root() != summary.BlockRoot {
+		return fmt.Errorf("%w: block root %s, summary root %s", errSyncedRootMismatch, syncedBlock.Root(), summary.BlockRoot)
+	}
+
+	syncer := NewStateSyncer(&StateSyncerConfig{
+		Client:     c.config.Client,
+		DB:         c.config.ChainDB,
+		Root:       summary.BlockRoot,
+		NumWorkers: c.config.NumWorkers,
+	})
+	if err := syncer.Sync(ctx); err != nil {
+		return err
+	}
+
+	if err := c.config.Chain.ResetState(syncedBlock); err != nil {
+		return fmt.Errorf("%w: failed to reset chain to synced block %s: %s", ErrChainReset, syncedBlock.Hash(), err)
+	}
+	if err := c.config.SetLastAcceptedBlock(syncedBlock); err != nil {
+		return fmt.Errorf("%w: %s", ErrChainReset, err)
+	}
+	rawdb.DeleteSyncSummary(c.config.ChainDB)
+	log.Info("state sync completed, handing off to bootstrapping", "block", syncedBlock.Hash(), "height", syncedBlock.NumberU64())
+	return nil
+}
+
+// syncBlocks writes the block with [hash] and [height] and up to
+// [parentsToGet]-1 of its ancestors to disk, fetching the blocks that are not
+// already on disk from peers. Returns the block with [hash].
+func (c *StateSyncClient) syncBlocks(ctx context.Context, hash common.Hash, height uint64) (*types.Block, error) {
+	var (
+		nextHash   = hash
+		nextHeight = height
+		synced     = 0
+		done       = false
+	)
+	for !done && synced < parentsToGet {
+		blocks := []*types.Block{rawdb.ReadBlock(c.config.ChainDB, nextHash, nextHeight)}
+		if blocks[0] == nil {
+			var err error
+			blocks, err = c.config.Client.GetBlocks(ctx, nextHash, nextHeight, blocksPerRequest)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch block %s at height %d: %w", nextHash, nextHeight, err)
+			}
+			batch := c.config.ChainDB.NewBatch()
+			for _, block := range blocks {
+				rawdb.WriteBlock(batch, block)
+				rawdb.WriteCanonicalHash(batch, block.Hash(), block.NumberU64())
+			}
+			if err := batch.Write(); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, block := range blocks {
+			synced++
+			if block.NumberU64() == 0 {
+				// Reached genesis, there are no more ancestors to fetch.
+				done = true
+				break
+			}
+			if synced == parentsToGet {
+				break
+			}
+			nextHash, nextHeight = block.ParentHash(), block.NumberU64()-1
+		}
+	}
+
+	syncedBlock := rawdb.ReadBlock(c.config.ChainDB, hash, height)
+	if syncedBlock == nil {
+		return nil, fmt.Errorf("synced block %s at height %d not found on disk", hash, height)
+	}
+	log.Info("fetched blocks for state sync", "block", hash, "height", height, "blocks", synced)
+	return syncedBlock, nil
+}
+
+// Error returns the error of the last state sync, or nil if it succeeded.
+// It must only be called after the engine is notified that state sync is done.
+func (c *StateSyncClient) Error() error {
+	return c.syncErr
+}
+
+// Shutdown cancels any state sync in progress and waits for it to stop.
+// The summary being synced is kept on disk so the sync can be resumed.
+func (c *StateSyncClient) Shutdown() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.wg.Wait()
+}
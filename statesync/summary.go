@@ -0,0 +1,68 @@
+// (c) 2021-2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package statesync
+
+import (
+	"github.com/ava-labs/avalanchego/codec"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/snow/engine/snowman/block"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ir4tech/webb-evm/plugin/evm/message"
+)
+
+var _ block.StateSummary = &Summary{}
+
+// Summary wraps a message.SyncableBlock to implement block.StateSummary,
+// calling [acceptImpl] when the engine accepts the summary.
+type Summary struct {
+	message.SyncableBlock
+
+	summaryID  ids.ID
+	bytes      []byte
+	acceptImpl func(*Summary) (bool, error)
+}
+
+// NewSummary returns a Summary for [syncableBlock] that calls [acceptImpl]
+// when accepted.
+func NewSummary(codec codec.Manager, syncableBlock message.SyncableBlock, acceptImpl func(*Summary) (bool, error)) (*Summary, error) {
+	summaryBytes, err := codec.Marshal(message.Version, &syncableBlock)
+	if err != nil {
+		return nil, err
+	}
+	return newSummary(syncableBlock, summaryBytes, acceptImpl)
+}
+
+// ParseSummary parses a Summary out of [summaryBytes] that calls [acceptImpl]
+// when accepted.
+func ParseSummary(codec codec.Manager, summaryBytes []byte, acceptImpl func(*Summary) (bool, error)) (*Summary, error) {
+	var syncableBlock message.SyncableBlock
+	if _, err := codec.Unmarshal(summaryBytes, &syncableBlock); err != nil {
+		return nil, err
+	}
+	return newSummary(syncableBlock, summaryBytes, acceptImpl)
+}
+
+func newSummary(syncableBlock message.SyncableBlock, summaryBytes []byte, acceptImpl func(*Summary) (bool, error)) (*Summary, error) {
+	summaryID, err := ids.ToID(crypto.Keccak256(summaryBytes))
+	if err != nil {
+		return nil, err
+	}
+	return &Summary{
+		SyncableBlock: syncableBlock,
+		summaryID:     summaryID,
+		bytes:         summaryBytes,
+		acceptImpl:    acceptImpl,
+	}, nil
+}
+
+func (s *Summary) Bytes() []byte  { return s.bytes }
+func (s *Summary) Height() uint64 { return s.BlockNumber }
+func (s *Summary) ID() ids.ID     { return s.summaryID }
+
+func (s *Summary) Accept() (bool, error) {
+	if s.acceptImpl == nil {
+		return false, nil
+	}
+	return s.acceptImpl(s)
+}
@@ -0,0 +1,421 @@
+// (c) 2021-2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package statesync
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ir4tech/webb-evm/core/rawdb"
+	"github.com/ir4tech/webb-evm/core/types"
+	"github.com/ir4tech/webb-evm/ethdb"
+	"github.com/ir4tech/webb-evm/plugin/evm/message"
+	"github.com/ir4tech/webb-evm/trie"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// leafsRequestLimit is the maximum number of leaves requested from a peer
+	// in a single message.LeafsRequest.
+	leafsRequestLimit = uint16(1024)
+
+	// defaultNumWorkers is the number of storage tries and contract codes
+	// fetched concurrently if StateSyncerConfig.NumWorkers is not set.
+	defaultNumWorkers = 8
+
+	// logInterval is the minimum time between two progress logs.
+	logInterval = 30 * time.Second
+)
+
+var (
+	emptyCodeHash = crypto.Keccak256Hash(nil)
+
+	// endKey is the largest possible trie key, used as the end of every range.
+	endKey = bytes.Repeat([]byte{0xff}, common.HashLength)
+
+	errRootMismatch = errors.New("synced trie root does not match expected root")
+)
+
+// StateSyncerConfig configures a StateSyncer.
+type StateSyncerConfig struct {
+	Client     Client         // Client used to fetch and verify state from peers
+	DB         ethdb.Database // Database the synced state is written to
+	Root       common.Hash    // Root of the state trie to sync
+	NumWorkers int            // Number of storage tries and contract codes fetched concurrently
+}
+
+// StateSyncer fetches the account trie at a given root along with every
+// storage trie and contract code it references, and writes them to disk.
+//
+// The account trie is fetched in order on a single goroutine while storage
+// tries and codes are fetched in parallel by a pool of workers. Fetched
+// account leaves are persisted so an interrupted sync of the same root resumes
+// where it left off: on restart the account trie is rebuilt from the persisted
+// leaves, and every storage trie and code they reference that is not yet on
+// disk is fetched again.
+type StateSyncer struct {
+	client     Client
+	db         ethdb.Database
+	root       common.Hash
+	numWorkers int
+
+	// storageRoots and codeHashes are only accessed by the goroutine syncing
+	// the account trie and prevent the same task from being queued twice.
+	storageRoots map[common.Hash]struct{}
+	codeHashes   map[common.Hash]struct{}
+
+	// tasks holds the storage tries and codes waiting to be fetched by a worker.
+	tasks chan syncTask
+}
+
+// syncTask is either a storage trie root or a code hash to fetch.
+type syncTask struct {
+	hash   common.Hash
+	isCode bool
+
+	// account is the hash of an account holding the storage trie, which lets
+	// peers serve the storage trie from their snapshot.
+	account common.Hash
+}
+
+// NewStateSyncer returns a StateSyncer configured by [config].
+func NewStateSyncer(config *StateSyncerConfig) *StateSyncer {
+	numWorkers := config.NumWorkers
+	if numWorkers <= 0 {
+		numWorkers = defaultNumWorkers
+	}
+	return &StateSyncer{
+		client:       config.Client,
+		db:           config.DB,
+		root:         config.Root,
+		numWorkers:   numWorkers,
+		storageRoots: make(map[common.Hash]struct{}),
+		codeHashes:   make(map[common.Hash]struct{}),
+		tasks:        make(chan syncTask, numWorkers*int(leafsRequestLimit)),
+	}
+}
+
+// Sync fetches the state at the configured root and blocks until it is fully
+// written to disk, [ctx] is cancelled or a request could not be completed.
+// Sync resumes any previous sync of the same root and discards the progress of
+// a sync of a different root.
+func (s *StateSyncer) Sync(ctx context.Context) error {
+	if syncRoot := rawdb.ReadSyncRoot(s.db); syncRoot != s.root {
+		// A complete account trie without any sync in progress means the state
+		// is already on disk.
+		if s.root == types.EmptyRootHash || rawdb.HasTrieNode(s.db, s.root) {
+			log.Info("state already available, skipping state sync", "root", s.root)
+			return nil
+		}
+		if syncRoot != (common.Hash{}) {
+			log.Info("discarding progress of previous state sync", "previousRoot", syncRoot, "root", s.root)
+		}
+		if err := s.clearProgress(); err != nil {
+			return err
+		}
+		rawdb.WriteSyncRoot(s.db, s.root)
+	}
+
+	startTime := time.Now()
+	log.Info("starting state sync", "root", s.root)
+
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		defer close(s.tasks)
+		return s.syncAccountTrie(egCtx)
+	})
+	for i := 0; i < s.numWorkers; i++ {
+		eg.Go(func() error {
+			return s.work(egCtx)
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+
+	if err := s.clearProgress(); err != nil {
+		return err
+	}
+	log.Info("state sync completed", "root", s.root, "time", time.Since(startTime))
+	return nil
+}
+
+// syncAccountTrie rebuilds the account trie from the leaves persisted by a
+// previous run, fetches the remaining leaves and queues the storage trie and
+// code of each account for the workers.
+func (s *StateSyncer) syncAccountTrie(ctx context.Context) error {
+	batch := s.db.NewBatch()
+	accountTrie := trie.NewStackTrie(batch)
+
+	var (
+		start    = make([]byte, common.HashLength)
+		accounts = 0
+		logTime  = time.Now()
+	)
+
+	// Replay the leaves fetched before a restart.
+	it := rawdb.IterateSyncAccounts(s.db)
+	for it.Next() {
+		hash, ok := rawdb.SyncAccountHash(it.Key())
+		if !ok {
+			continue
+		}
+		if err := s.addAccount(ctx, accountTrie, hash[:], it.Value()); err != nil {
+			it.Release()
+			return err
+		}
+		accounts++
+		start = hash[:]
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				it.Release()
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	err := it.Error()
+	it.Release()
+	if err != nil {
+		return err
+	}
+
+	// Continue after the last replayed leaf, unless it was the last possible key.
+	fetch := true
+	if accounts > 0 {
+		var overflow bool
+		start, overflow = incrementKey(start)
+		fetch = !overflow
+		log.Info("resuming state sync", "root", s.root, "accounts", accounts)
+	}
+
+	for fetch {
+		response, err := s.client.GetLeafs(ctx, message.LeafsRequest{
+			Root:  s.root,
+			Start: start,
+			End:   endKey,
+			Limit: leafsRequestLimit,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to fetch account trie leaves from %x: %w", start, err)
+		}
+		for i, key := range response.Keys {
+			rawdb.WriteSyncAccount(batch, common.BytesToHash(key), response.Vals[i])
+			if err := s.addAccount(ctx, accountTrie, key, response.Vals[i]); err != nil {
+				return err
+			}
+		}
+		// Persist the fetched leaves before requesting more so a restart does
+		// not fetch them again.
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+		accounts += len(response.Keys)
+
+		if time.Since(logTime) > logInterval {
+			log.Info("state sync in progress", "root", s.root, "accounts", accounts, "storageTries", len(s.storageRoots), "codes", len(s.codeHashes))
+			logTime = time.Now()
+		}
+
+		if !response.More || len(response.Keys) == 0 {
+			break
+		}
+		var overflow bool
+		start, overflow = incrementKey(response.Keys[len(response.Keys)-1])
+		fetch = !overflow
+	}
+
+	root, err := accountTrie.Commit()
+	if err != nil {
+		return err
+	}
+	if root != s.root {
+		return fmt.Errorf("%w: account trie root %s, expected %s", errRootMismatch, root, s.root)
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("account trie synced", "root", s.root, "accounts", accounts)
+	return nil
+}
+
+// addAccount adds the account leaf [key, value] to [accountTrie] and queues
+// its storage trie and code if they are not already on disk.
+func (s *StateSyncer) addAccount(ctx context.Context, accountTrie *trie.StackTrie, key []byte, value []byte) error {
+	if err := accountTrie.TryUpdate(key, value); err != nil {
+		return err
+	}
+
+	var account types.StateAccount
+	if err := rlp.DecodeBytes(value, &account); err != nil {
+		return fmt.Errorf("could not decode account %x: %w", key, err)
+	}
+
+	if account.Root != types.EmptyRootHash && account.Root != (common.Hash{}) {
+		if _, ok := s.storageRoots[account.Root]; !ok {
+			s.storageRoots[account.Root] = struct{}{}
+			if !rawdb.HasTrieNode(s.db, account.Root) {
+				if err := s.queue(ctx, syncTask{hash: account.Root, account: common.BytesToHash(key)}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	codeHash := common.BytesToHash(account.CodeHash)
+	if codeHash != emptyCodeHash && codeHash != (common.Hash{}) {
+		if _, ok := s.codeHashes[codeHash]; !ok {
+			s.codeHashes[codeHash] = struct{}{}
+			if !rawdb.HasCodeWithPrefix(s.db, codeHash) {
+				if err := s.queue(ctx, syncTask{hash: codeHash, isCode: true}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// queue adds [task] to the task queue, blocking until the queue has room or
+// [ctx] is done.
+func (s *StateSyncer) queue(ctx context.Context, task syncTask) error {
+	select {
+	case s.tasks <- task:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// work fetches queued storage tries and codes until the queue is closed or
+// [ctx] is done.
+func (s *StateSyncer) work(ctx context.Context) error {
+	for {
+		select {
+		case task, ok := <-s.tasks:
+			if !ok {
+				return nil
+			}
+			var err error
+			if task.isCode {
+				err = s.syncCode(ctx, task.hash)
+			} else {
+				err = s.syncStorageTrie(ctx, task.hash, task.account)
+			}
+			if err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// syncStorageTrie fetches the storage trie with the given root, held by
+// [account], and writes it to disk. The root node is written last, so a
+// storage trie whose root is on disk is complete.
+func (s *StateSyncer) syncStorageTrie(ctx context.Context, root common.Hash, account common.Hash) error {
+	batch := s.db.NewBatch()
+	storageTrie := trie.NewStackTrie(batch)
+
+	start := make([]byte, common.HashLength)
+	for {
+		response, err := s.client.GetLeafs(ctx, message.LeafsRequest{
+			Root:    root,
+			Account: account,
+			Start:   start,
+			End:     endKey,
+			Limit:   leafsRequestLimit,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to fetch storage trie %s leaves from %x: %w", root, start, err)
+		}
+		for i, key := range response.Keys {
+			if err := storageTrie.TryUpdate(key, response.Vals[i]); err != nil {
+				return err
+			}
+		}
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+
+		if !response.More || len(response.Keys) == 0 {
+			break
+		}
+		var overflow bool
+		if start, overflow = incrementKey(response.Keys[len(response.Keys)-1]); overflow {
+			break
+		}
+	}
+
+	syncedRoot, err := storageTrie.Commit()
+	if err != nil {
+		return err
+	}
+	if syncedRoot != root {
+		return fmt.Errorf("%w: storage trie root %s, expected %s", errRootMismatch, syncedRoot, root)
+	}
+	return batch.Write()
+}
+
+// syncCode fetches the contract code with the given hash and writes it to disk.
+func (s *StateSyncer) syncCode(ctx context.Context, codeHash common.Hash) error {
+	code, err := s.client.GetCode(ctx, codeHash)
+	if err != nil {
+		return fmt.Errorf("failed to fetch code %s: %w", codeHash, err)
+	}
+	rawdb.WriteCode(s.db, codeHash, code)
+	return nil
+}
+
+// clearProgress removes the account leaves and root persisted by an
+// in-progress sync.
+func (s *StateSyncer) clearProgress() error {
+	batch := s.db.NewBatch()
+	it := rawdb.IterateSyncAccounts(s.db)
+	defer it.Release()
+
+	for it.Next() {
+		hash, ok := rawdb.SyncAccountHash(it.Key())
+		if !ok {
+			continue
+		}
+		rawdb.DeleteSyncAccount(batch, hash)
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	rawdb.DeleteSyncRoot(batch)
+	return batch.Write()
+}
+
+// incrementKey returns the key following [key] in lexicographical order among
+// keys of the same length, and true if [key] was already the largest such key.
+func incrementKey(key []byte) ([]byte, bool) {
+	next := common.CopyBytes(key)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			return next, false
+		}
+	}
+	return next, true
+}
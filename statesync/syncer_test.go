@@ -0,0 +1,191 @@
+// (c) 2021-2022, Ava Labs, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package statesync
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ava-labs/avalanchego/codec"
+	"github.com/ava-labs/avalanchego/ids"
+	"github.com/ava-labs/avalanchego/version"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ir4tech/webb-evm/core/rawdb"
+	"github.com/ir4tech/webb-evm/core/state"
+	"github.com/ir4tech/webb-evm/core/types"
+	"github.com/ir4tech/webb-evm/ethdb"
+	"github.com/ir4tech/webb-evm/peer"
+	"github.com/ir4tech/webb-evm/plugin/evm/message"
+	"github.com/ir4tech/webb-evm/statesync/handlers"
+	"github.com/ir4tech/webb-evm/statesync/handlers/stats"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ peer.Client = &testNetworkClient{}
+
+// testNetworkClient serves requests from a local [handler]. Once
+// [requestsLeft] requests have been served, it calls [onExhausted] and fails
+// every further request.
+type testNetworkClient struct {
+	codec        codec.Manager
+	handler      message.RequestHandler
+	requestsLeft int64
+	onExhausted  func()
+}
+
+func (c *testNetworkClient) RequestAny(_ *version.Application, requestBytes []byte) ([]byte, ids.NodeID, error) {
+	nodeID := ids.GenerateTestNodeID()
+	response, err := c.Request(nodeID, requestBytes)
+	return response, nodeID, err
+}
+
+func (c *testNetworkClient) Request(nodeID ids.NodeID, requestBytes []byte) ([]byte, error) {
+	if atomic.AddInt64(&c.requestsLeft, -1) < 0 {
+		if c.onExhausted != nil {
+			c.onExhausted()
+		}
+		return nil, errors.New("no peers available")
+	}
+	request, err := message.BytesToRequest(c.codec, requestBytes)
+	if err != nil {
+		return nil, err
+	}
+	return request.Handle(context.Background(), nodeID, 0, c.handler)
+}
+
+func (c *testNetworkClient) Gossip([]byte) error { return nil }
+
+func (c *testNetworkClient) TrackInvalidResponse(ids.NodeID) {}
+
+// fillServerState writes [numAccounts] accounts to [serverDB], every third
+// of which has storage and code, and returns the resulting state root.
+func fillServerState(t *testing.T, serverDB ethdb.Database, numAccounts int) common.Hash {
+	stateDB, err := state.New(common.Hash{}, state.NewDatabase(serverDB), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < numAccounts; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i + 1)))
+		stateDB.SetBalance(addr, big.NewInt(int64(i+1)))
+		stateDB.SetNonce(addr, uint64(i))
+		if i%3 == 0 {
+			stateDB.SetCode(addr, []byte{byte(i), byte(i >> 8), 0x60, 0x00})
+			for j := 0; j < 20; j++ {
+				stateDB.SetState(addr, common.BigToHash(big.NewInt(int64(j))), common.BigToHash(big.NewInt(int64(i*j+1))))
+			}
+		}
+	}
+	root, err := stateDB.Commit(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stateDB.Database().TrieDB().Commit(root, false, nil); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+// testStateCacheProvider serves leaves from a fixed state database.
+type testStateCacheProvider struct {
+	stateCache state.Database
+}
+
+func (p *testStateCacheProvider) StateCache() state.Database { return p.stateCache }
+
+func newTestSyncClient(t *testing.T, serverDB ethdb.Database, requestsLeft int64, onExhausted func()) Client {
+	codec, err := message.BuildCodec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	handlerStats := stats.NewNoopHandlerStats()
+	handler := handlers.NewSyncHandler(
+		handlers.NewLeafsRequestHandler(&testStateCacheProvider{stateCache: state.NewDatabase(serverDB)}, nil, handlerStats, codec),
+		handlers.NewBlockRequestHandler(func(common.Hash, uint64) *types.Block { return nil }, codec, handlerStats),
+		handlers.NewCodeRequestHandler(serverDB, handlerStats, codec),
+	)
+	return NewClient(&testNetworkClient{codec: codec, handler: message.NewRequestHandler(handler, message.NoopTxsPullRequestHandler{}), requestsLeft: requestsLeft, onExhausted: onExhausted}, codec, nil)
+}
+
+// assertStateSynced checks that the [numAccounts] accounts written by
+// fillServerState at [root] in [clientDB] match [serverDB] and that no sync
+// progress is left on disk.
+func assertStateSynced(t *testing.T, serverDB, clientDB ethdb.Database, root common.Hash, numAccounts int) {
+	serverState, err := state.New(root, state.NewDatabase(serverDB), nil)
+	assert.NoError(t, err)
+	clientState, err := state.New(root, state.NewDatabase(clientDB), nil)
+	assert.NoError(t, err)
+
+	for i := 0; i < numAccounts; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i + 1)))
+		assert.Equal(t, serverState.GetBalance(addr), clientState.GetBalance(addr))
+		assert.Equal(t, serverState.GetNonce(addr), clientState.GetNonce(addr))
+		assert.Equal(t, serverState.GetCode(addr), clientState.GetCode(addr))
+		for j := 0; j < 20; j++ {
+			key := common.BigToHash(big.NewInt(int64(j)))
+			assert.Equal(t, serverState.GetState(addr, key), clientState.GetState(addr, key))
+		}
+	}
+
+	assert.Equal(t, common.Hash{}, rawdb.ReadSyncRoot(clientDB))
+	syncAccounts := rawdb.IterateSyncAccounts(clientDB)
+	defer syncAccounts.Release()
+	assert.False(t, syncAccounts.Next())
+}
+
+func TestStateSyncerSync(t *testing.T) {
+	serverDB := rawdb.NewMemoryDatabase()
+	root := fillServerState(t, serverDB, 3000)
+
+	clientDB := rawdb.NewMemoryDatabase()
+	syncer := NewStateSyncer(&StateSyncerConfig{
+		Client:     newTestSyncClient(t, serverDB, math.MaxInt64, nil),
+		DB:         clientDB,
+		Root:       root,
+		NumWorkers: 4,
+	})
+	assert.NoError(t, syncer.Sync(context.Background()))
+	assertStateSynced(t, serverDB, clientDB, root, 3000)
+
+	// Syncing a root that is already on disk does not send any request.
+	assert.NoError(t, NewStateSyncer(&StateSyncerConfig{
+		Client: newTestSyncClient(t, serverDB, 0, func() { t.Fatal("unexpected request") }),
+		DB:     clientDB,
+		Root:   root,
+	}).Sync(context.Background()))
+}
+
+func TestStateSyncerResume(t *testing.T) {
+	serverDB := rawdb.NewMemoryDatabase()
+	root := fillServerState(t, serverDB, 3000)
+	clientDB := rawdb.NewMemoryDatabase()
+
+	// Interrupt the first sync after a few requests, so only part of the
+	// account trie has been fetched.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	syncer := NewStateSyncer(&StateSyncerConfig{
+		Client:     newTestSyncClient(t, serverDB, 3, cancel),
+		DB:         clientDB,
+		Root:       root,
+		NumWorkers: 1,
+	})
+	assert.ErrorIs(t, syncer.Sync(ctx), context.Canceled)
+	assert.Equal(t, root, rawdb.ReadSyncRoot(clientDB))
+
+	syncAccounts := rawdb.IterateSyncAccounts(clientDB)
+	assert.True(t, syncAccounts.Next(), "expected fetched accounts to be persisted")
+	syncAccounts.Release()
+
+	syncer = NewStateSyncer(&StateSyncerConfig{
+		Client: newTestSyncClient(t, serverDB, math.MaxInt64, nil),
+		DB:     clientDB,
+		Root:   root,
+	})
+	assert.NoError(t, syncer.Sync(context.Background()))
+	assertStateSynced(t, serverDB, clientDB, root, 3000)
+}